@@ -0,0 +1,107 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type BalanceComponent struct {
+	abs *database.AccountBalanceStore
+}
+
+func NewBalanceComponent() *BalanceComponent {
+	return &BalanceComponent{
+		abs: database.NewAccountBalanceStore(),
+	}
+}
+
+// GetBalance returns a zero balance for owners which never had an entry
+func (bc *BalanceComponent) GetBalance(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string) (*types.ACCT_BALANCE, error) {
+	if err := checkBalanceOwnerType(ownerType); err != nil {
+		return nil, err
+	}
+	balance, err := bc.abs.FindByOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find balance of %s %s, error: %w", ownerType, ownerID, err)
+	}
+	if balance == nil {
+		return &types.ACCT_BALANCE{OwnerType: ownerType, OwnerID: ownerID}, nil
+	}
+	return balanceResp(balance), nil
+}
+
+func (bc *BalanceComponent) TopUp(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string, req types.ACCT_BALANCE_REQ) (*types.ACCT_BALANCE, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("top-up amount must be positive")
+	}
+	return bc.addEntry(ctx, ownerType, ownerID, types.BalanceEntryTopUp, req)
+}
+
+func (bc *BalanceComponent) Adjust(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string, req types.ACCT_BALANCE_REQ) (*types.ACCT_BALANCE, error) {
+	if req.Reason == "" {
+		return nil, errors.New("reason is required to adjust balance")
+	}
+	return bc.addEntry(ctx, ownerType, ownerID, types.BalanceEntryAdjustment, req)
+}
+
+func (bc *BalanceComponent) addEntry(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string, entryType types.BalanceEntryType, req types.ACCT_BALANCE_REQ) (*types.ACCT_BALANCE, error) {
+	if err := checkBalanceOwnerType(ownerType); err != nil {
+		return nil, err
+	}
+	balance, err := bc.abs.AddEntry(ctx, &database.AccountBalanceEntry{
+		OwnerType: ownerType,
+		OwnerID:   ownerID,
+		EntryType: entryType,
+		Amount:    req.Amount,
+		OpUser:    req.OpUser,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s of %s %s, error: %w", entryType, ownerType, ownerID, err)
+	}
+	return balanceResp(balance), nil
+}
+
+func (bc *BalanceComponent) ListEntries(ctx context.Context, req types.ACCT_BALANCE_ENTRIES_REQ) ([]database.AccountBalanceEntry, int, error) {
+	if err := checkBalanceOwnerType(req.OwnerType); err != nil {
+		return nil, 0, err
+	}
+	return bc.abs.ListEntries(ctx, req.OwnerType, req.OwnerID, req.Per, req.Page)
+}
+
+// ListOverdue lists the balances which have been negative for longer than the grace period
+func (bc *BalanceComponent) ListOverdue(ctx context.Context, grace time.Duration) ([]types.ACCT_BALANCE, error) {
+	balances, err := bc.abs.ListNegativeBefore(ctx, time.Now().Add(-grace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue balances, error: %w", err)
+	}
+	resp := make([]types.ACCT_BALANCE, 0, len(balances))
+	for i := range balances {
+		resp = append(resp, *balanceResp(&balances[i]))
+	}
+	return resp, nil
+}
+
+func checkBalanceOwnerType(ownerType types.BalanceOwnerType) error {
+	if ownerType != types.BalanceOwnerUser && ownerType != types.BalanceOwnerOrg {
+		return fmt.Errorf("invalid balance owner type %s", ownerType)
+	}
+	return nil
+}
+
+func balanceResp(balance *database.AccountBalance) *types.ACCT_BALANCE {
+	resp := &types.ACCT_BALANCE{
+		OwnerType: balance.OwnerType,
+		OwnerID:   balance.OwnerID,
+		Balance:   balance.Balance,
+	}
+	if !balance.NegativeSince.IsZero() {
+		resp.NegativeSince = &balance.NegativeSince
+	}
+	return resp
+}
@@ -0,0 +1,91 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mq"
+)
+
+type DLQComponent struct {
+	sysMQ     *mq.NatsHandler
+	meterComp *MeteringComponent
+}
+
+func NewDLQComponent(sysMQ *mq.NatsHandler) *DLQComponent {
+	return &DLQComponent{
+		sysMQ:     sysMQ,
+		meterComp: NewMeteringComponent(),
+	}
+}
+
+func (dc *DLQComponent) ListMessages(ctx context.Context, fromSeq uint64, limit int) ([]types.ACCT_DLQ_MESSAGE, error) {
+	msgs, err := dc.sysMQ.ListDLQMessages(ctx, fromSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DLQ messages, error: %w", err)
+	}
+	return msgs, nil
+}
+
+// Replay saves the metering events parked in DLQ again, and removes the ones saved from DLQ.
+// Events saved before are skipped by their uuid, so replaying a message twice is safe
+func (dc *DLQComponent) Replay(ctx context.Context, req types.ACCT_DLQ_REPLAY_REQ) (*types.ACCT_DLQ_REPLAY_RESULT, error) {
+	var msgs []types.ACCT_DLQ_MESSAGE
+	if len(req.Sequences) == 0 {
+		limit := req.Limit
+		if limit <= 0 {
+			limit = math.MaxInt
+		}
+		var err error
+		msgs, err = dc.sysMQ.ListDLQMessages(ctx, 0, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list DLQ messages, error: %w", err)
+		}
+	}
+	for _, seq := range req.Sequences {
+		msg, err := dc.sysMQ.GetDLQMessage(ctx, seq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get DLQ message %d, error: %w", seq, err)
+		}
+		msgs = append(msgs, *msg)
+	}
+
+	result := &types.ACCT_DLQ_REPLAY_RESULT{
+		Replayed: []uint64{},
+		Failed:   []types.ACCT_DLQ_REPLAY_FAILURE{},
+	}
+	for _, msg := range msgs {
+		err := dc.replayMessage(ctx, msg)
+		if err != nil {
+			slog.Error("failed to replay DLQ message", slog.Uint64("sequence", msg.Sequence), slog.String("data", msg.Data), slog.Any("error", err))
+			result.Failed = append(result.Failed, types.ACCT_DLQ_REPLAY_FAILURE{
+				Sequence: msg.Sequence,
+				Error:    err.Error(),
+			})
+			continue
+		}
+		result.Replayed = append(result.Replayed, msg.Sequence)
+	}
+	return result, nil
+}
+
+func (dc *DLQComponent) replayMessage(ctx context.Context, msg types.ACCT_DLQ_MESSAGE) error {
+	var event types.METERING_EVENT
+	err := json.Unmarshal([]byte(msg.Data), &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal meter event, error: %w", err)
+	}
+	err = dc.meterComp.SaveMeteringEventRecord(ctx, &event)
+	if err != nil {
+		return err
+	}
+	err = dc.sysMQ.DeleteDLQMessage(ctx, msg.Sequence)
+	if err != nil {
+		return fmt.Errorf("event is saved but failed to delete it from DLQ, error: %w", err)
+	}
+	return nil
+}
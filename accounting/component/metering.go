@@ -3,18 +3,25 @@ package component
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sort"
+	"time"
 
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/types"
 )
 
+const monthLayout = "2006-01"
+
 type MeteringComponent struct {
 	ams *database.AccountMeteringStore
+	aps *database.AccountPriceStore
 }
 
 func NewMeteringComponent() *MeteringComponent {
 	ams := &MeteringComponent{
 		ams: database.NewAccountMeteringStore(),
+		aps: database.NewAccountPriceStore(),
 	}
 	return ams
 }
@@ -32,15 +39,53 @@ func (mc *MeteringComponent) SaveMeteringEventRecord(ctx context.Context, req *t
 		CustomerID:   req.CustomerID,
 		RecordedAt:   req.CreatedAt,
 		Extra:        req.Extra,
-		SkuUnitType:  getUnitString(req.Scene),
+		SkuUnitType:  getUnitString(req.Scene, req.ValueType),
+		OrgName:      req.OrgName,
+	}
+	// the sku of an event is its resource id, a sku may have prices in different units like minutes and tokens
+	price, err := mc.aps.FindEffective(ctx, am.Scene, req.ResourceID, am.SkuUnitType, req.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to find price of metering event, error: %w", err)
 	}
-	err := mc.ams.Create(ctx, am)
+	if price != nil {
+		am.PriceID = price.ID
+		am.SubCentCost = price.SubCentCost(am.Value)
+	} else {
+		slog.Warn("no price for metering event", slog.Any("scene", req.Scene), slog.String("resource_id", req.ResourceID), slog.Any("event_uuid", req.Uuid))
+	}
+	var charge *database.AccountBalanceEntry
+	if am.SubCentCost > 0 {
+		// the amount is the whole cents the store settles from the sub-cent cost
+		charge = &database.AccountBalanceEntry{
+			OwnerType: types.BalanceOwnerUser,
+			OwnerID:   am.UserUUID,
+			EntryType: types.BalanceEntryCharge,
+			Reason:    am.ResourceName,
+		}
+		// usage of resources owned by an organization is billed to the organization
+		if am.OrgName != "" {
+			charge.OwnerType = types.BalanceOwnerOrg
+			charge.OwnerID = am.OrgName
+		}
+	}
+	created, err := mc.ams.Create(ctx, &am, charge)
 	if err != nil {
 		return fmt.Errorf("failed to save metering event record, error: %w", err)
 	}
+	if !created {
+		slog.Info("skip metering event saved before", slog.Any("event_uuid", req.Uuid))
+	}
 	return nil
 }
 
+func (mc *MeteringComponent) ListMeteringByOrgNameAndDate(ctx context.Context, req types.ACCT_STATEMENTS_REQ) ([]database.AccountMetering, int, error) {
+	meters, total, err := mc.ams.ListByOrgNameAndTime(ctx, req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list metering by OrgNameAndDate, error: %w", err)
+	}
+	return meters, total, nil
+}
+
 func (mc *MeteringComponent) ListMeteringByUserIDAndDate(ctx context.Context, req types.ACCT_STATEMENTS_REQ) ([]database.AccountMetering, int, error) {
 	meters, total, err := mc.ams.ListByUserIDAndTime(ctx, req)
 	if err != nil {
@@ -49,7 +94,57 @@ func (mc *MeteringComponent) ListMeteringByUserIDAndDate(ctx context.Context, re
 	return meters, total, nil
 }
 
-func getUnitString(scene int) string {
+// ListMonthlyTotals sums the value and cost of the user by month, scene and unit
+func (mc *MeteringComponent) ListMonthlyTotals(ctx context.Context, req types.ACCT_MONTHLY_REQ) ([]types.ACCT_MONTHLY_TOTAL, error) {
+	start, err := time.Parse(monthLayout, req.StartMonth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start month %s, error: %w", req.StartMonth, err)
+	}
+	end, err := time.Parse(monthLayout, req.EndMonth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end month %s, error: %w", req.EndMonth, err)
+	}
+	var meters []database.AccountMetering
+	if req.OrgName != "" {
+		meters, err = mc.ams.ListByOrgNameAndMonths(ctx, req.OrgName, req.Scene, start, end.AddDate(0, 1, 0))
+	} else {
+		meters, err = mc.ams.ListByUserIDAndMonths(ctx, req.UserUUID, req.Scene, start, end.AddDate(0, 1, 0))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metering by months, error: %w", err)
+	}
+
+	var totals []types.ACCT_MONTHLY_TOTAL
+	index := make(map[string]int)
+	for _, m := range meters {
+		month := m.RecordedAt.Format(monthLayout)
+		key := fmt.Sprintf("%s/%d/%s", month, m.Scene, m.SkuUnitType)
+		i, ok := index[key]
+		if !ok {
+			i = len(totals)
+			index[key] = i
+			totals = append(totals, types.ACCT_MONTHLY_TOTAL{
+				Month:       month,
+				Scene:       m.Scene,
+				SkuUnitType: m.SkuUnitType,
+			})
+		}
+		totals[i].Value += m.Value
+		totals[i].Cost += m.Cost
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Month != totals[j].Month {
+			return totals[i].Month < totals[j].Month
+		}
+		return totals[i].Scene < totals[j].Scene
+	})
+	return totals, nil
+}
+
+func getUnitString(scene, valueType int) string {
+	if valueType == types.TokenNumberType {
+		return types.UnitToken
+	}
 	switch types.SceneType(scene) {
 	case types.SceneModelInference:
 		return types.UnitMinute
@@ -0,0 +1,95 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type PriceComponent struct {
+	aps *database.AccountPriceStore
+}
+
+func NewPriceComponent() *PriceComponent {
+	return &PriceComponent{
+		aps: database.NewAccountPriceStore(),
+	}
+}
+
+func (pc *PriceComponent) CreatePrice(ctx context.Context, req types.ACCT_PRICE_REQ) (*database.AccountPrice, error) {
+	price := &database.AccountPrice{}
+	setPrice(price, req)
+	if err := pc.checkPrice(ctx, price); err != nil {
+		return nil, err
+	}
+	err := pc.aps.Create(ctx, price)
+	if err != nil {
+		return nil, err
+	}
+	return price, nil
+}
+
+func (pc *PriceComponent) UpdatePrice(ctx context.Context, id int64, req types.ACCT_PRICE_REQ) (*database.AccountPrice, error) {
+	price, err := pc.aps.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find price %d, error: %w", id, err)
+	}
+	setPrice(price, req)
+	if err := pc.checkPrice(ctx, price); err != nil {
+		return nil, err
+	}
+	err = pc.aps.Update(ctx, price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update price %d, error: %w", id, err)
+	}
+	return price, nil
+}
+
+func (pc *PriceComponent) DeletePrice(ctx context.Context, id int64) error {
+	err := pc.aps.Delete(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete price %d, error: %w", id, err)
+	}
+	return nil
+}
+
+func (pc *PriceComponent) GetPrice(ctx context.Context, id int64) (*database.AccountPrice, error) {
+	price, err := pc.aps.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find price %d, error: %w", id, err)
+	}
+	return price, nil
+}
+
+func (pc *PriceComponent) ListPrices(ctx context.Context, req types.ACCT_PRICE_QUERY) ([]database.AccountPrice, int, error) {
+	return pc.aps.List(ctx, req)
+}
+
+// checkPrice makes sure at most one price of a sku in each unit is effective at any time
+func (pc *PriceComponent) checkPrice(ctx context.Context, price *database.AccountPrice) error {
+	if !price.EndTime.IsZero() && !price.EndTime.After(price.StartTime) {
+		return errors.New("end time of price must be after start time")
+	}
+	count, err := pc.aps.CountOverlapping(ctx, price)
+	if err != nil {
+		return fmt.Errorf("failed to check overlapping prices, error: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("price of sku '%s' per %s in scene %d overlaps %d existing prices", price.SkuID, price.SkuUnitType, price.Scene, count)
+	}
+	return nil
+}
+
+func setPrice(price *database.AccountPrice, req types.ACCT_PRICE_REQ) {
+	price.SkuType = req.SkuType
+	price.Scene = req.Scene
+	price.SkuID = req.SkuID
+	price.SkuUnit = req.SkuUnit
+	price.SkuUnitType = req.SkuUnitType
+	price.SkuPrice = req.SkuPrice
+	price.StartTime = req.StartTime
+	price.EndTime = req.EndTime
+}
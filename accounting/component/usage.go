@@ -0,0 +1,161 @@
+package component
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const dayLayout = "2006-01-02"
+
+type UsageComponent struct {
+	aus *database.AccountUsageStore
+}
+
+func NewUsageComponent() *UsageComponent {
+	return &UsageComponent{
+		aus: database.NewAccountUsageStore(),
+	}
+}
+
+// ErrInvalidUsageReq wraps the errors of usage requests with invalid dates, bucket or group by
+var ErrInvalidUsageReq = errors.New("invalid usage request")
+
+// ListUsage sums the daily usages by time bucket and the group by dimensions, usages of different units
+// are never summed together
+func (uc *UsageComponent) ListUsage(ctx context.Context, req types.ACCT_USAGE_REQ) ([]types.ACCT_USAGE, error) {
+	if req.Bucket == "" {
+		req.Bucket = types.UsageBucketDay
+	}
+	start, end, err := parseUsageReq(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %w", ErrInvalidUsageReq, err)
+	}
+
+	dailies, err := uc.aus.List(ctx, req, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	var usages []types.ACCT_USAGE
+	index := make(map[types.ACCT_USAGE]int)
+	for _, daily := range dailies {
+		bucket, err := usageBucket(req, daily.Day)
+		if err != nil {
+			return nil, err
+		}
+		key := types.ACCT_USAGE{Bucket: bucket, SkuUnitType: daily.SkuUnitType}
+		for _, groupBy := range req.GroupBy {
+			switch groupBy {
+			case types.UsageGroupByUser:
+				key.UserUUID = daily.UserUUID
+			case types.UsageGroupByOrg:
+				key.OrgName = daily.OrgName
+			case types.UsageGroupByScene:
+				key.Scene = daily.Scene
+			case types.UsageGroupBySku:
+				key.SkuID = daily.SkuID
+			case types.UsageGroupByResource:
+				key.Resource = daily.Resource
+			}
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(usages)
+			index[key] = i
+			usages = append(usages, key)
+		}
+		if key.SkuID != "" {
+			usages[i].ResourceName = daily.ResourceName
+		}
+		usages[i].Value += daily.Value
+		usages[i].Cost += daily.Cost
+		usages[i].EventCount += daily.EventCount
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].Bucket < usages[j].Bucket
+	})
+	return usages, nil
+}
+
+// parseUsageReq validates the request and returns its first and last day
+func parseUsageReq(req types.ACCT_USAGE_REQ) (time.Time, time.Time, error) {
+	start, err := time.Parse(dayLayout, req.StartDate)
+	if err != nil {
+		return start, start, fmt.Errorf("invalid start date %s", req.StartDate)
+	}
+	end, err := time.Parse(dayLayout, req.EndDate)
+	if err != nil {
+		return start, end, fmt.Errorf("invalid end date %s", req.EndDate)
+	}
+	if end.Before(start) {
+		return start, end, fmt.Errorf("end date %s is before start date %s", req.EndDate, req.StartDate)
+	}
+	if !slices.Contains(types.UsageBuckets, req.Bucket) {
+		return start, end, fmt.Errorf("invalid bucket %s, must be one of %v", req.Bucket, types.UsageBuckets)
+	}
+	for _, groupBy := range req.GroupBy {
+		if !slices.Contains(types.UsageGroupBys, groupBy) {
+			return start, end, fmt.Errorf("invalid group by %s, must be one of %v", groupBy, types.UsageGroupBys)
+		}
+	}
+	return start, end, nil
+}
+
+func usageBucket(req types.ACCT_USAGE_REQ, day time.Time) (string, error) {
+	switch req.Bucket {
+	case types.UsageBucketDay:
+		return day.Format(dayLayout), nil
+	case types.UsageBucketWeek:
+		// weeks start on monday
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset).Format(dayLayout), nil
+	case types.UsageBucketMonth:
+		return day.Format(monthLayout), nil
+	case types.UsageBucketTotal:
+		return fmt.Sprintf("%s~%s", req.StartDate, req.EndDate), nil
+	default:
+		return "", fmt.Errorf("invalid bucket %s", req.Bucket)
+	}
+}
+
+// WriteUsageCSV writes the usages as csv with a header line
+func (uc *UsageComponent) WriteUsageCSV(w io.Writer, usages []types.ACCT_USAGE) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"bucket", "user_uuid", "org_name", "scene", "sku_id", "resource_name", "resource", "sku_unit_type", "value", "cost", "event_count"})
+	if err != nil {
+		return err
+	}
+	for _, u := range usages {
+		scene := ""
+		if u.Scene != 0 {
+			scene = strconv.Itoa(int(u.Scene))
+		}
+		err = writer.Write([]string{
+			u.Bucket,
+			u.UserUUID,
+			u.OrgName,
+			scene,
+			u.SkuID,
+			u.ResourceName,
+			u.Resource,
+			u.SkuUnitType,
+			strconv.FormatFloat(u.Value, 'f', -1, 64),
+			strconv.FormatInt(u.Cost, 10),
+			strconv.FormatInt(u.EventCount, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,48 @@
+package component
+
+import (
+	"testing"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestParseUsageReq(t *testing.T) {
+	valid := types.ACCT_USAGE_REQ{StartDate: "2024-06-01", EndDate: "2024-06-30", Bucket: types.UsageBucketWeek, GroupBy: []string{"user", "sku"}}
+	if _, _, err := parseUsageReq(valid); err != nil {
+		t.Fatalf("expect valid request, got %v", err)
+	}
+
+	testData := map[string]types.ACCT_USAGE_REQ{
+		"bad start date":             {StartDate: "2024/06/01", EndDate: "2024-06-30", Bucket: types.UsageBucketDay},
+		"bad end date":               {StartDate: "2024-06-01", EndDate: "", Bucket: types.UsageBucketDay},
+		"end before start":           {StartDate: "2024-06-30", EndDate: "2024-06-01", Bucket: types.UsageBucketDay},
+		"unknown bucket":             {StartDate: "2024-06-01", EndDate: "2024-06-30", Bucket: "hour"},
+		"unknown group by":           {StartDate: "2024-06-01", EndDate: "2024-06-30", Bucket: types.UsageBucketDay, GroupBy: []string{"region"}},
+		"group by is case sensitive": {StartDate: "2024-06-01", EndDate: "2024-06-30", Bucket: types.UsageBucketDay, GroupBy: []string{"User"}},
+	}
+	for name, req := range testData {
+		if _, _, err := parseUsageReq(req); err == nil {
+			t.Errorf("%s: expect an error", name)
+		}
+	}
+}
+
+func TestUsageBucket(t *testing.T) {
+	// a wednesday
+	day := time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)
+	req := types.ACCT_USAGE_REQ{StartDate: "2024-06-01", EndDate: "2024-06-30"}
+	expect := map[types.UsageBucket]string{
+		types.UsageBucketDay:   "2024-06-12",
+		types.UsageBucketWeek:  "2024-06-10",
+		types.UsageBucketMonth: "2024-06",
+		types.UsageBucketTotal: "2024-06-01~2024-06-30",
+	}
+	for bucket, e := range expect {
+		req.Bucket = bucket
+		got, err := usageBucket(req, day)
+		if err != nil || got != e {
+			t.Errorf("%s: expect %s, got %s, %v", bucket, e, got, err)
+		}
+	}
+}
@@ -121,10 +121,15 @@ func (m *Metering) handleMsgWithRetry(msg jetstream.Msg) {
 		if err != nil {
 			tip := fmt.Sprintf("failed to move meter msg to DLQ with %d retries", 5)
 			slog.Error(tip, slog.Any("msg.data", string(msg.Data())), slog.Any("error", err))
+			// the msg is neither saved nor parked, let it be redelivered
+			if err = msg.Nak(); err != nil {
+				slog.Warn("failed to nak meter msg", slog.Any("msg.data", strData), slog.Any("error", err))
+			}
+			return
 		}
 	}
 
-	// ack for handle metering message done
+	// ack only once the event is committed or parked in DLQ, a redelivered event is skipped by its uuid
 	err = msg.Ack()
 	if err != nil {
 		slog.Warn("failed to ack after processing meter msg", slog.Any("msg.data", strData), slog.Any("error", err))
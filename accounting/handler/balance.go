@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+func NewBalanceHandler() (*BalanceHandler, error) {
+	return &BalanceHandler{
+		abc: component.NewBalanceComponent(),
+	}, nil
+}
+
+type BalanceHandler struct {
+	abc *component.BalanceComponent
+}
+
+func (bh *BalanceHandler) BalanceGet(ctx *gin.Context) {
+	ownerType := types.BalanceOwnerType(ctx.Param("owner_type"))
+	ownerID := ctx.Param("owner_id")
+	balance, err := bh.abc.GetBalance(ctx, ownerType, ownerID)
+	if err != nil {
+		slog.Error("fail to get balance", slog.Any("owner_type", ownerType), slog.String("owner_id", ownerID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, balance)
+}
+
+func (bh *BalanceHandler) BalanceTopUp(ctx *gin.Context) {
+	ownerType := types.BalanceOwnerType(ctx.Param("owner_type"))
+	ownerID := ctx.Param("owner_id")
+	var req types.ACCT_BALANCE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	balance, err := bh.abc.TopUp(ctx, ownerType, ownerID, req)
+	if err != nil {
+		slog.Error("fail to top up balance", slog.Any("owner_type", ownerType), slog.String("owner_id", ownerID), slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, balance)
+}
+
+func (bh *BalanceHandler) BalanceAdjust(ctx *gin.Context) {
+	ownerType := types.BalanceOwnerType(ctx.Param("owner_type"))
+	ownerID := ctx.Param("owner_id")
+	var req types.ACCT_BALANCE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	balance, err := bh.abc.Adjust(ctx, ownerType, ownerID, req)
+	if err != nil {
+		slog.Error("fail to adjust balance", slog.Any("owner_type", ownerType), slog.String("owner_id", ownerID), slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, balance)
+}
+
+func (bh *BalanceHandler) BalanceEntriesList(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request pagination format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ACCT_BALANCE_ENTRIES_REQ{
+		OwnerType: types.BalanceOwnerType(ctx.Param("owner_type")),
+		OwnerID:   ctx.Param("owner_id"),
+		Per:       per,
+		Page:      page,
+	}
+	entries, total, err := bh.abc.ListEntries(ctx, req)
+	if err != nil {
+		slog.Error("fail to list balance entries", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  entries,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+func (bh *BalanceHandler) BalancesOverdue(ctx *gin.Context) {
+	graceMinutes, err := strconv.Atoi(ctx.DefaultQuery("grace_minutes", "0"))
+	if err != nil || graceMinutes < 0 {
+		slog.Error("Bad request grace minutes format", "error", err)
+		httpbase.BadRequest(ctx, "Bad request grace minutes format")
+		return
+	}
+	balances, err := bh.abc.ListOverdue(ctx, time.Duration(graceMinutes)*time.Minute)
+	if err != nil {
+		slog.Error("fail to list overdue balances", slog.Int("grace_minutes", graceMinutes), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, balances)
+}
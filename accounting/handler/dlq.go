@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mq"
+)
+
+func NewDLQHandler(sysMQ *mq.NatsHandler) (*DLQHandler, error) {
+	return &DLQHandler{
+		adc: component.NewDLQComponent(sysMQ),
+	}, nil
+}
+
+type DLQHandler struct {
+	adc *component.DLQComponent
+}
+
+func (dh *DLQHandler) DLQMessagesList(ctx *gin.Context) {
+	fromSeq, err := strconv.ParseUint(ctx.DefaultQuery("from", "0"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request from sequence format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		slog.Error("Bad request limit format", "error", err)
+		httpbase.BadRequest(ctx, "Bad request limit format")
+		return
+	}
+	msgs, err := dh.adc.ListMessages(ctx, fromSeq, limit)
+	if err != nil {
+		slog.Error("fail to list DLQ messages", slog.Uint64("from", fromSeq), slog.Int("limit", limit), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, msgs)
+}
+
+func (dh *DLQHandler) DLQMessagesReplay(ctx *gin.Context) {
+	var req types.ACCT_DLQ_REPLAY_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	result, err := dh.adc.Replay(ctx, req)
+	if err != nil {
+		slog.Error("fail to replay DLQ messages", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, result)
+}
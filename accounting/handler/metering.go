@@ -71,3 +71,129 @@ func (mh *MeteringHandler) QueryMeteringStatementByUserID(ctx *gin.Context) {
 	}
 	httpbase.OK(ctx, respData)
 }
+
+func (mh *MeteringHandler) QueryMonthlyTotalsByUserID(ctx *gin.Context) {
+	userID := ctx.Param("id")
+	startMonth := ctx.Query("start_month") // format: '2024-06'
+	endMonth := ctx.Query("end_month")     // format: '2024-09'
+	if len(startMonth) < 1 || len(endMonth) < 1 || len(userID) < 1 {
+		slog.Error("Bad request parameters format")
+		httpbase.BadRequest(ctx, "Bad request parameters format")
+		return
+	}
+	if !utils.ValidateDateTimeFormat(startMonth, "2006-01") || !utils.ValidateDateTimeFormat(endMonth, "2006-01") {
+		slog.Error("Bad request month format")
+		httpbase.BadRequest(ctx, "Bad request month format")
+		return
+	}
+	req := types.ACCT_MONTHLY_REQ{
+		UserUUID:   userID,
+		StartMonth: startMonth,
+		EndMonth:   endMonth,
+	}
+	if ctx.Query("scene") != "" {
+		scene, err := utils.GetSceneFromContext(ctx)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		req.Scene = scene
+	}
+
+	totals, err := mh.amc.ListMonthlyTotals(ctx, req)
+	if err != nil {
+		slog.Error("fail to query monthly totals by user", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, totals)
+}
+
+func (mh *MeteringHandler) QueryMeteringStatementByOrgName(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request pagination format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	scene, err := utils.GetSceneFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request scene format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	orgName := ctx.Param("name")
+	startTime := ctx.Query("start_time") // format: '2024-06-12 08:27:22'
+	endTime := ctx.Query("end_time")     // format: '2024-06-12 17:17:22'
+	if len(startTime) < 1 || len(endTime) < 1 || len(orgName) < 1 {
+		slog.Error("Bad request parameters format")
+		httpbase.BadRequest(ctx, "Bad request parameters format")
+		return
+	}
+	if !utils.ValidateDateTimeFormat(startTime, "2006-01-02 15:04:05") || !utils.ValidateDateTimeFormat(endTime, "2006-01-02 15:04:05") {
+		slog.Error("Bad request datetime format")
+		httpbase.BadRequest(ctx, "Bad request datetime format")
+		return
+	}
+
+	req := types.ACCT_STATEMENTS_REQ{
+		OrgName:      orgName,
+		Scene:        scene,
+		InstanceName: ctx.Query("instance_name"),
+		StartTime:    startTime,
+		EndTime:      endTime,
+		Per:          per,
+		Page:         page,
+	}
+
+	meters, total, err := mh.amc.ListMeteringByOrgNameAndDate(ctx, req)
+	if err != nil {
+		slog.Error("fail to query meters by org", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  meters,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+func (mh *MeteringHandler) QueryMonthlyTotalsByOrgName(ctx *gin.Context) {
+	orgName := ctx.Param("name")
+	startMonth := ctx.Query("start_month") // format: '2024-06'
+	endMonth := ctx.Query("end_month")     // format: '2024-09'
+	if len(startMonth) < 1 || len(endMonth) < 1 || len(orgName) < 1 {
+		slog.Error("Bad request parameters format")
+		httpbase.BadRequest(ctx, "Bad request parameters format")
+		return
+	}
+	if !utils.ValidateDateTimeFormat(startMonth, "2006-01") || !utils.ValidateDateTimeFormat(endMonth, "2006-01") {
+		slog.Error("Bad request month format")
+		httpbase.BadRequest(ctx, "Bad request month format")
+		return
+	}
+	req := types.ACCT_MONTHLY_REQ{
+		OrgName:    orgName,
+		StartMonth: startMonth,
+		EndMonth:   endMonth,
+	}
+	if ctx.Query("scene") != "" {
+		scene, err := utils.GetSceneFromContext(ctx)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		req.Scene = scene
+	}
+
+	totals, err := mh.amc.ListMonthlyTotals(ctx, req)
+	if err != nil {
+		slog.Error("fail to query monthly totals by org", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, totals)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+func NewPriceHandler() (*PriceHandler, error) {
+	return &PriceHandler{
+		apc: component.NewPriceComponent(),
+	}, nil
+}
+
+type PriceHandler struct {
+	apc *component.PriceComponent
+}
+
+func (ph *PriceHandler) PriceCreate(ctx *gin.Context) {
+	var req types.ACCT_PRICE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	price, err := ph.apc.CreatePrice(ctx, req)
+	if err != nil {
+		slog.Error("fail to create price", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, price)
+}
+
+func (ph *PriceHandler) PriceUpdate(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.ACCT_PRICE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	price, err := ph.apc.UpdatePrice(ctx, id, req)
+	if err != nil {
+		slog.Error("fail to update price", slog.Int64("id", id), slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, price)
+}
+
+func (ph *PriceHandler) PriceDelete(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = ph.apc.DeletePrice(ctx, id)
+	if err != nil {
+		slog.Error("fail to delete price", slog.Int64("id", id), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (ph *PriceHandler) PriceGet(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	price, err := ph.apc.GetPrice(ctx, id)
+	if err != nil {
+		slog.Error("fail to get price", slog.Int64("id", id), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, price)
+}
+
+func (ph *PriceHandler) PricesList(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request pagination format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ACCT_PRICE_QUERY{
+		SkuID: ctx.Query("sku_id"),
+		Per:   per,
+		Page:  page,
+	}
+	if scene := ctx.Query("scene"); scene != "" {
+		req.Scene, err = strconv.Atoi(scene)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	prices, total, err := ph.apc.ListPrices(ctx, req)
+	if err != nil {
+		slog.Error("fail to list prices", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  prices,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
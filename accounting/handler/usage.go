@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/accounting/utils"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func NewUsageHandler() (*UsageHandler, error) {
+	return &UsageHandler{
+		auc: component.NewUsageComponent(),
+	}, nil
+}
+
+type UsageHandler struct {
+	auc *component.UsageComponent
+}
+
+// QueryUsage sums usage by time bucket and group by dimensions, responds csv if format is csv
+func (uh *UsageHandler) QueryUsage(ctx *gin.Context) {
+	req := types.ACCT_USAGE_REQ{
+		StartDate: ctx.Query("start_date"), // format: '2024-06-01'
+		EndDate:   ctx.Query("end_date"),   // format: '2024-06-30'
+		Bucket:    types.UsageBucket(ctx.Query("bucket")),
+		UserUUID:  ctx.Query("user_uuid"),
+		OrgName:   ctx.Query("org_name"),
+		SkuID:     ctx.Query("sku_id"),
+	}
+	if !utils.ValidateDateTimeFormat(req.StartDate, "2006-01-02") || !utils.ValidateDateTimeFormat(req.EndDate, "2006-01-02") {
+		slog.Error("Bad request date format")
+		httpbase.BadRequest(ctx, "Bad request date format")
+		return
+	}
+	if groupBy := ctx.Query("group_by"); groupBy != "" {
+		req.GroupBy = strings.Split(groupBy, ",")
+	}
+	if scene := ctx.Query("scene"); scene != "" {
+		var err error
+		req.Scene, err = strconv.Atoi(scene)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+
+	usages, err := uh.auc.ListUsage(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrInvalidUsageReq) {
+			slog.Error("Bad request usage parameters", slog.Any("req", req), slog.Any("error", err))
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		slog.Error("fail to query usage", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	if ctx.Query("format") != "csv" {
+		httpbase.OK(ctx, usages)
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=usage_%s_%s.csv", req.StartDate, req.EndDate))
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Header().Set("Content-Type", "text/csv")
+	if err := uh.auc.WriteUsageCSV(ctx.Writer, usages); err != nil {
+		slog.Error("fail to write usage csv", slog.Any("req", req), slog.Any("error", err))
+	}
+}
@@ -7,9 +7,10 @@ import (
 	"opencsg.com/csghub-server/accounting/handler"
 	"opencsg.com/csghub-server/api/middleware"
 	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/mq"
 )
 
-func NewAccountRouter(config *config.Config) (*gin.Engine, error) {
+func NewAccountRouter(config *config.Config, mqHandler *mq.NatsHandler) (*gin.Engine, error) {
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(middleware.Log())
@@ -21,11 +22,64 @@ func NewAccountRouter(config *config.Config) (*gin.Engine, error) {
 		return nil, fmt.Errorf("error creating multi sync handler:%w", err)
 	}
 
+	priceHandler, err := handler.NewPriceHandler()
+	if err != nil {
+		return nil, fmt.Errorf("error creating price handler:%w", err)
+	}
+
+	balanceHandler, err := handler.NewBalanceHandler()
+	if err != nil {
+		return nil, fmt.Errorf("error creating balance handler:%w", err)
+	}
+
+	dlqHandler, err := handler.NewDLQHandler(mqHandler)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dlq handler:%w", err)
+	}
+
+	usageHandler, err := handler.NewUsageHandler()
+	if err != nil {
+		return nil, fmt.Errorf("error creating usage handler:%w", err)
+	}
+
 	apiGroup := r.Group("/api/v1/accounting")
 
 	meterGroup := apiGroup.Group("/metering")
 	{
 		meterGroup.GET("/:id/statements", meterHandler.QueryMeteringStatementByUserID)
+		meterGroup.GET("/:id/monthly_totals", meterHandler.QueryMonthlyTotalsByUserID)
+	}
+
+	orgMeterGroup := apiGroup.Group("/organizations/:name/metering")
+	{
+		orgMeterGroup.GET("/statements", meterHandler.QueryMeteringStatementByOrgName)
+		orgMeterGroup.GET("/monthly_totals", meterHandler.QueryMonthlyTotalsByOrgName)
+	}
+
+	priceGroup := apiGroup.Group("/prices")
+	{
+		priceGroup.GET("", priceHandler.PricesList)
+		priceGroup.POST("", priceHandler.PriceCreate)
+		priceGroup.GET("/:id", priceHandler.PriceGet)
+		priceGroup.PUT("/:id", priceHandler.PriceUpdate)
+		priceGroup.DELETE("/:id", priceHandler.PriceDelete)
+	}
+
+	balanceGroup := apiGroup.Group("/balances")
+	{
+		balanceGroup.GET("/overdue", balanceHandler.BalancesOverdue)
+		balanceGroup.GET("/:owner_type/:owner_id", balanceHandler.BalanceGet)
+		balanceGroup.GET("/:owner_type/:owner_id/entries", balanceHandler.BalanceEntriesList)
+		balanceGroup.POST("/:owner_type/:owner_id/topup", balanceHandler.BalanceTopUp)
+		balanceGroup.POST("/:owner_type/:owner_id/adjust", balanceHandler.BalanceAdjust)
+	}
+
+	apiGroup.GET("/usage", usageHandler.QueryUsage)
+
+	dlqGroup := apiGroup.Group("/dlq")
+	{
+		dlqGroup.GET("/metering", dlqHandler.DLQMessagesList)
+		dlqGroup.POST("/metering/replay", dlqHandler.DLQMessagesReplay)
 	}
 
 	return r, nil
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -115,3 +117,595 @@ func (ah *AccountingHandler) QueryMeteringStatementByUserID(ctx *gin.Context) {
 	}
 	httpbase.OK(ctx, data)
 }
+
+// QueryMonthlyTotalsByUserID godoc
+// @Security     ApiKey
+// @Summary      Get monthly usage and cost totals of user
+// @Description  Get monthly usage and cost totals of user, cost is in cents
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "user uuid"
+// @Param        scene query int false "scene, all scenes if not set" Enums(10, 11, 12, 20)
+// @Param        start_month query string true "start_month, format: '2024-06'"
+// @Param        end_month query string true "end_month, format: '2024-09'"
+// @Param        current_user query string true "current_user"
+// @Success      200  {object}  types.Response{data=[]types.ACCT_MONTHLY_TOTAL} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/metering/{id}/monthly_totals [get]
+func (ah *AccountingHandler) QueryMonthlyTotalsByUserID(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, errors.New("user not found, please login first"))
+		return
+	}
+	userUUID := ctx.Param("id")
+	startMonth := ctx.Query("start_month") // format: '2024-06'
+	endMonth := ctx.Query("end_month")     // format: '2024-09'
+	if len(startMonth) < 1 || len(endMonth) < 1 || len(userUUID) < 1 {
+		slog.Error("Bad request format")
+		httpbase.BadRequest(ctx, "Bad request format")
+		return
+	}
+	if !validateDateTimeFormat(startMonth, "2006-01") || !validateDateTimeFormat(endMonth, "2006-01") {
+		slog.Error("Bad request month format")
+		httpbase.BadRequest(ctx, "Bad request month format")
+		return
+	}
+	req := types.ACCT_MONTHLY_REQ{
+		CurrentUser: currentUser,
+		UserUUID:    userUUID,
+		StartMonth:  startMonth,
+		EndMonth:    endMonth,
+	}
+	if ctx.Query("scene") != "" {
+		scene, err := getSceneFromContext(ctx)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		req.Scene = scene
+	}
+	data, err := ah.ac.ListMonthlyTotalsByUserID(ctx, req)
+	if err != nil {
+		errTip := "fail to query monthly totals by user"
+		slog.Error(errTip, slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, errors.New(errTip))
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// QueryMeteringStatementByOrgName godoc
+// @Security     ApiKey
+// @Summary      List meterings billed to organization
+// @Description  List meterings billed to organization by start time and end time, only for organization admins
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        name path string true "organization name"
+// @Param 		 scene query int false "scene" Enums(10, 11, 12, 20) default(10)
+// @Param        instance_name query string false "instance name, all instances if not set"
+// @Param        start_time query string true "start_time, format: '2024-06-12 08:27:22'"
+// @Param        end_time query string true "end_time, format: '2024-06-12 17:17:22'"
+// @Param        current_user query string true "current_user"
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "per page" default(1)
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Unauthorized"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/organizations/{name}/metering/statements [get]
+func (ah *AccountingHandler) QueryMeteringStatementByOrgName(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, errors.New("user not found, please login first"))
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	scene, err := getSceneFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request scene format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	orgName := ctx.Param("name")
+	startTime := ctx.Query("start_time") // format: '2024-06-12 08:27:22'
+	endTime := ctx.Query("end_time")     // format: '2024-06-12 17:17:22'
+	if len(startTime) < 1 || len(endTime) < 1 || len(orgName) < 1 {
+		slog.Error("Bad request format")
+		httpbase.BadRequest(ctx, "Bad request format")
+		return
+	}
+	if !validateDateTimeFormat(startTime, "2006-01-02 15:04:05") || !validateDateTimeFormat(endTime, "2006-01-02 15:04:05") {
+		slog.Error("Bad request datetime format")
+		httpbase.BadRequest(ctx, "Bad request datetime format")
+		return
+	}
+	req := types.ACCT_STATEMENTS_REQ{
+		CurrentUser:  currentUser,
+		OrgName:      orgName,
+		Scene:        scene,
+		InstanceName: ctx.Query("instance_name"),
+		StartTime:    startTime,
+		EndTime:      endTime,
+		Per:          per,
+		Page:         page,
+	}
+	data, err := ah.ac.ListMeteringsByOrgNameAndTime(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		errTip := "fail to query meterings by organization"
+		slog.Error(errTip, slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, errors.New(errTip))
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// QueryMonthlyTotalsByOrgName godoc
+// @Security     ApiKey
+// @Summary      Get monthly usage and cost totals of organization
+// @Description  Get monthly usage and cost totals billed to organization, cost is in cents, only for organization admins
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        name path string true "organization name"
+// @Param        scene query int false "scene, all scenes if not set" Enums(10, 11, 12, 20)
+// @Param        start_month query string true "start_month, format: '2024-06'"
+// @Param        end_month query string true "end_month, format: '2024-09'"
+// @Param        current_user query string true "current_user"
+// @Success      200  {object}  types.Response{data=[]types.ACCT_MONTHLY_TOTAL} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Unauthorized"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/organizations/{name}/metering/monthly_totals [get]
+func (ah *AccountingHandler) QueryMonthlyTotalsByOrgName(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, errors.New("user not found, please login first"))
+		return
+	}
+	orgName := ctx.Param("name")
+	startMonth := ctx.Query("start_month") // format: '2024-06'
+	endMonth := ctx.Query("end_month")     // format: '2024-09'
+	if len(startMonth) < 1 || len(endMonth) < 1 || len(orgName) < 1 {
+		slog.Error("Bad request format")
+		httpbase.BadRequest(ctx, "Bad request format")
+		return
+	}
+	if !validateDateTimeFormat(startMonth, "2006-01") || !validateDateTimeFormat(endMonth, "2006-01") {
+		slog.Error("Bad request month format")
+		httpbase.BadRequest(ctx, "Bad request month format")
+		return
+	}
+	req := types.ACCT_MONTHLY_REQ{
+		CurrentUser: currentUser,
+		OrgName:     orgName,
+		StartMonth:  startMonth,
+		EndMonth:    endMonth,
+	}
+	if ctx.Query("scene") != "" {
+		scene, err := getSceneFromContext(ctx)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		req.Scene = scene
+	}
+	data, err := ah.ac.ListMonthlyTotalsByOrgName(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		errTip := "fail to query monthly totals by organization"
+		slog.Error(errTip, slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, errors.New(errTip))
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// PricesList godoc
+// @Security     ApiKey
+// @Summary      List prices
+// @Description  List prices of the pricing catalog
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        scene query int false "scene" Enums(10, 11, 12, 20)
+// @Param        sku_id query string false "sku id"
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "per page" default(1)
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/prices [get]
+func (ah *AccountingHandler) PricesList(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ACCT_PRICE_QUERY{
+		SkuID: ctx.Query("sku_id"),
+		Per:   per,
+		Page:  page,
+	}
+	if ctx.Query("scene") != "" {
+		req.Scene, err = getSceneFromContext(ctx)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	data, err := ah.ac.ListPrices(ctx, req)
+	if err != nil {
+		slog.Error("fail to list prices", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// PriceGet godoc
+// @Security     ApiKey
+// @Summary      Get price
+// @Description  Get price of the pricing catalog by id
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "price id"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/prices/{id} [get]
+func (ah *AccountingHandler) PriceGet(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := ah.ac.GetPrice(ctx, id)
+	if err != nil {
+		slog.Error("fail to get price", slog.Int64("id", id), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// PriceCreate godoc
+// @Security     ApiKey
+// @Summary      Create price
+// @Description  Create price of a sku, or the default price of a scene if sku id is empty
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        body body types.ACCT_PRICE_REQ true "body"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/prices [post]
+func (ah *AccountingHandler) PriceCreate(ctx *gin.Context) {
+	var req types.ACCT_PRICE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := ah.ac.CreatePrice(ctx, req)
+	if err != nil {
+		slog.Error("fail to create price", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// PriceUpdate godoc
+// @Security     ApiKey
+// @Summary      Update price
+// @Description  Update price of the pricing catalog by id
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "price id"
+// @Param        body body types.ACCT_PRICE_REQ true "body"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/prices/{id} [put]
+func (ah *AccountingHandler) PriceUpdate(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.ACCT_PRICE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := ah.ac.UpdatePrice(ctx, id, req)
+	if err != nil {
+		slog.Error("fail to update price", slog.Int64("id", id), slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// PriceDelete godoc
+// @Security     ApiKey
+// @Summary      Delete price
+// @Description  Delete price of the pricing catalog by id
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "price id"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/prices/{id} [delete]
+func (ah *AccountingHandler) PriceDelete(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = ah.ac.DeletePrice(ctx, id)
+	if err != nil {
+		slog.Error("fail to delete price", slog.Int64("id", id), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// BalanceGet godoc
+// @Security     ApiKey
+// @Summary      Get balance
+// @Description  Get balance of user or organization, users can get their own balance and organization admins the balance of the organization
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        owner_type path string true "owner type" Enums(user, organization)
+// @Param        owner_id path string true "user uuid or organization name"
+// @Param        current_user query string true "current_user"
+// @Success      200  {object}  types.Response{data=types.ACCT_BALANCE} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Unauthorized"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/balances/{owner_type}/{owner_id} [get]
+func (ah *AccountingHandler) BalanceGet(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, errors.New("user not found, please login first"))
+		return
+	}
+	ownerType := types.BalanceOwnerType(ctx.Param("owner_type"))
+	ownerID := ctx.Param("owner_id")
+	data, err := ah.ac.GetBalance(ctx, currentUser, ownerType, ownerID)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("fail to get balance", slog.Any("owner_type", ownerType), slog.String("owner_id", ownerID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// BalanceEntriesList godoc
+// @Security     ApiKey
+// @Summary      List balance entries
+// @Description  List top-ups, charges and adjustments of the balance of user or organization
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        owner_type path string true "owner type" Enums(user, organization)
+// @Param        owner_id path string true "user uuid or organization name"
+// @Param        current_user query string true "current_user"
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "per page" default(1)
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Unauthorized"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/balances/{owner_type}/{owner_id}/entries [get]
+func (ah *AccountingHandler) BalanceEntriesList(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, errors.New("user not found, please login first"))
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ACCT_BALANCE_ENTRIES_REQ{
+		CurrentUser: currentUser,
+		OwnerType:   types.BalanceOwnerType(ctx.Param("owner_type")),
+		OwnerID:     ctx.Param("owner_id"),
+		Per:         per,
+		Page:        page,
+	}
+	data, err := ah.ac.ListBalanceEntries(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("fail to list balance entries", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// BalanceTopUp godoc
+// @Security     ApiKey
+// @Summary      Top up balance
+// @Description  Top up balance of user or organization, amount is in cents
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        owner_type path string true "owner type" Enums(user, organization)
+// @Param        owner_id path string true "user uuid or organization name"
+// @Param        body body types.ACCT_BALANCE_REQ true "body"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/balances/{owner_type}/{owner_id}/topup [post]
+func (ah *AccountingHandler) BalanceTopUp(ctx *gin.Context) {
+	ownerType := types.BalanceOwnerType(ctx.Param("owner_type"))
+	ownerID := ctx.Param("owner_id")
+	var req types.ACCT_BALANCE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.OpUser = httpbase.GetCurrentUser(ctx)
+	data, err := ah.ac.TopUpBalance(ctx, ownerType, ownerID, req)
+	if err != nil {
+		slog.Error("fail to top up balance", slog.Any("owner_type", ownerType), slog.String("owner_id", ownerID), slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// BalanceAdjust godoc
+// @Security     ApiKey
+// @Summary      Adjust balance
+// @Description  Adjust balance of user or organization with a positive or negative amount in cents, reason is required
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        owner_type path string true "owner type" Enums(user, organization)
+// @Param        owner_id path string true "user uuid or organization name"
+// @Param        body body types.ACCT_BALANCE_REQ true "body"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/balances/{owner_type}/{owner_id}/adjust [post]
+func (ah *AccountingHandler) BalanceAdjust(ctx *gin.Context) {
+	ownerType := types.BalanceOwnerType(ctx.Param("owner_type"))
+	ownerID := ctx.Param("owner_id")
+	var req types.ACCT_BALANCE_REQ
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.OpUser = httpbase.GetCurrentUser(ctx)
+	data, err := ah.ac.AdjustBalance(ctx, ownerType, ownerID, req)
+	if err != nil {
+		slog.Error("fail to adjust balance", slog.Any("owner_type", ownerType), slog.String("owner_id", ownerID), slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
+
+// UsageList godoc
+// @Security     ApiKey
+// @Summary      Get usage report
+// @Description  Sum the daily usage and cost of the current user, or of the organization for its admins, by time bucket and group by dimensions, cost is in cents
+// @Tags         Accounting
+// @Accept       json
+// @Produce      json
+// @Param        start_date query string true "start_date, format: '2024-06-01'"
+// @Param        end_date query string true "end_date, format: '2024-06-30', included"
+// @Param        bucket query string false "time bucket" Enums(day, week, month, total) default(day)
+// @Param        group_by query string false "comma separated dimensions" Enums(user, org, scene, sku, resource)
+// @Param        org_name query string false "organization name, usage of the current user if not set"
+// @Param        scene query int false "scene, all scenes if not set" Enums(10, 11, 12, 20)
+// @Param        sku_id query string false "sku id, all skus if not set"
+// @Param        current_user query string true "current_user"
+// @Success      200  {object}  types.Response{data=[]types.ACCT_USAGE} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Unauthorized"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /accounting/usage [get]
+func (ah *AccountingHandler) UsageList(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, errors.New("user not found, please login first"))
+		return
+	}
+	req := types.ACCT_USAGE_REQ{
+		CurrentUser: currentUser,
+		StartDate:   ctx.Query("start_date"),
+		EndDate:     ctx.Query("end_date"),
+		Bucket:      types.UsageBucket(ctx.DefaultQuery("bucket", string(types.UsageBucketDay))),
+		OrgName:     ctx.Query("org_name"),
+		SkuID:       ctx.Query("sku_id"),
+	}
+	if !validateDateTimeFormat(req.StartDate, "2006-01-02") || !validateDateTimeFormat(req.EndDate, "2006-01-02") {
+		slog.Error("Bad request date format")
+		httpbase.BadRequest(ctx, "Bad request date format")
+		return
+	}
+	if req.EndDate < req.StartDate {
+		httpbase.BadRequest(ctx, "end date is before start date")
+		return
+	}
+	if !slices.Contains(types.UsageBuckets, req.Bucket) {
+		httpbase.BadRequest(ctx, fmt.Sprintf("invalid bucket %s", req.Bucket))
+		return
+	}
+	if groupBy := ctx.Query("group_by"); groupBy != "" {
+		req.GroupBy = strings.Split(groupBy, ",")
+	}
+	for _, groupBy := range req.GroupBy {
+		if !slices.Contains(types.UsageGroupBys, groupBy) {
+			httpbase.BadRequest(ctx, fmt.Sprintf("invalid group by %s", groupBy))
+			return
+		}
+	}
+	if ctx.Query("scene") != "" {
+		scene, err := getSceneFromContext(ctx)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		req.Scene = scene
+	}
+	data, err := ah.ac.ListUsage(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("fail to list usage", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, data)
+}
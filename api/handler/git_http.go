@@ -140,9 +140,9 @@ func (h *GitHTTPHandler) GitReceivePack(ctx *gin.Context) {
 			return
 		}
 
-		if err == component.ErrForbidden {
+		if errors.Is(err, component.ErrForbidden) {
 			ctx.PureJSON(http.StatusForbidden, gin.H{
-				"error": "You do not have permission to access this repository.",
+				"error": err.Error(),
 			})
 			return
 		}
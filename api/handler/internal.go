@@ -58,6 +58,29 @@ func (h *InternalHandler) SSHAllowed(ctx *gin.Context) {
 		httpbase.BadRequest(ctx, err.Error())
 		return
 	}
+	// operations through the api (protocol web) are checked against the protected branches by the components that
+	// make them: file commits, branch creation, deletion and renaming, and pull request merges
+	if rawReq.Action == "git-receive-pack" && rawReq.Protocol != "web" && rawReq.Changes != "" && rawReq.Changes != "_any" {
+		preReceiveReq := types.PreReceiveReq{
+			Changes:      rawReq.Changes,
+			GlRepository: rawReq.GlRepository,
+			Protocol:     rawReq.Protocol,
+			Env:          rawReq.Env,
+		}
+		if rawReq.KeyID != "" {
+			preReceiveReq.GlID = "key-" + rawReq.KeyID
+		} else {
+			preReceiveReq.GlID = "user-" + rawReq.UserID
+		}
+		if err := h.c.PreReceive(ctx, preReceiveReq); err != nil {
+			slog.Error("push rejected by protected branch rules", slog.String("gl_repository", rawReq.GlRepository), slog.Any("error", err))
+			ctx.PureJSON(http.StatusOK, gin.H{
+				"status":  false,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
 	if rawReq.Protocol == "ssh" {
 		if rawReq.GlRepository != "" {
 			repoPath = rawReq.GlRepository
@@ -104,6 +127,24 @@ func (h *InternalHandler) LfsAuthenticate(ctx *gin.Context) {
 
 // TODO: add logic
 func (h *InternalHandler) PreReceive(ctx *gin.Context) {
+	var req types.PreReceiveReq
+	if err := ctx.ShouldBind(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	// gitaly only sends gl_repository here, the ref changes are checked in the allowed request,
+	// but other hook clients may send the changes of the push
+	if req.Changes != "" {
+		if err := h.c.PreReceive(ctx, req); err != nil {
+			slog.Error("push rejected by protected branch rules", slog.String("gl_repository", req.GlRepository), slog.Any("error", err))
+			ctx.PureJSON(http.StatusOK, gin.H{
+				"reference_counter_increased": false,
+				"message":                     err.Error(),
+			})
+			return
+		}
+	}
 	ctx.PureJSON(http.StatusOK, gin.H{
 		"reference_counter_increased": true,
 	})
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"opencsg.com/csghub-server/api/httpbase"
@@ -136,3 +139,180 @@ func (h *MirrorHandler) Index(ctx *gin.Context) {
 
 	httpbase.OK(ctx, respData)
 }
+
+// GetMirrorDeadLetters godoc
+// @Security     ApiKey
+// @Summary      Get mirror tasks which failed more than their retries
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  types.Response{data=[]types.MirrorDeadLetter} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/dead_letters [get]
+func (h *MirrorHandler) DeadLetters(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	deadLetters, err := h.mc.DeadLetters(ctx, currentUser)
+	if err != nil {
+		slog.Error("failed to get dead-lettered mirror tasks", slog.Any("error", err))
+		mirrorTaskError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, deadLetters)
+}
+
+// RetryMirrorDeadLetter godoc
+// @Security     ApiKey
+// @Summary      Push a dead-lettered mirror task back to its queue
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Param        type path string true "task type" Enums(repo,lfs)
+// @Param        id path int true "mirror id"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/dead_letters/{type}/{id}/retry [post]
+func (h *MirrorHandler) RetryDeadLetter(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	taskType, mirrorID, err := mirrorTaskFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.mc.RetryDeadLetter(ctx, currentUser, taskType, mirrorID)
+	if err != nil {
+		slog.Error("failed to retry dead-lettered mirror task", slog.Any("type", taskType), slog.Int64("mirror_id", mirrorID), slog.Any("error", err))
+		mirrorTaskError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// DropMirrorDeadLetter godoc
+// @Security     ApiKey
+// @Summary      Drop a dead-lettered mirror task
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Param        type path string true "task type" Enums(repo,lfs)
+// @Param        id path int true "mirror id"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/dead_letters/{type}/{id} [delete]
+func (h *MirrorHandler) DropDeadLetter(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	taskType, mirrorID, err := mirrorTaskFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.mc.DropDeadLetter(ctx, currentUser, taskType, mirrorID)
+	if err != nil {
+		slog.Error("failed to drop dead-lettered mirror task", slog.Any("type", taskType), slog.Int64("mirror_id", mirrorID), slog.Any("error", err))
+		mirrorTaskError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// GetMirrorQueueStats godoc
+// @Security     ApiKey
+// @Summary      Get the number of tasks in each priority band of the mirror queues
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  types.Response{data=[]types.MirrorQueueStats} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/queues [get]
+func (h *MirrorHandler) QueueStats(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	stats, err := h.mc.QueueStats(ctx, currentUser)
+	if err != nil {
+		slog.Error("failed to get mirror queue stats", slog.Any("error", err))
+		mirrorTaskError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, stats)
+}
+
+// GetMirrorQueuePosition godoc
+// @Security     ApiKey
+// @Summary      Get the position of the queued task of a mirror
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Param        type path string true "task type" Enums(repo,lfs)
+// @Param        id path int true "mirror id"
+// @Success      200  {object}  types.Response{data=types.MirrorQueuePosition} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/queues/{type}/{id} [get]
+func (h *MirrorHandler) QueuePosition(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	taskType, mirrorID, err := mirrorTaskFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	position, err := h.mc.QueuePosition(ctx, currentUser, taskType, mirrorID)
+	if err != nil {
+		slog.Error("failed to get mirror queue position", slog.Any("type", taskType), slog.Int64("mirror_id", mirrorID), slog.Any("error", err))
+		mirrorTaskError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, position)
+}
+
+func mirrorTaskFromContext(ctx *gin.Context) (types.MirrorTaskType, int64, error) {
+	taskType := types.MirrorTaskType(ctx.Param("type"))
+	if taskType != types.RepoMirrorTask && taskType != types.LfsMirrorTask {
+		return "", 0, fmt.Errorf("invalid mirror task type %s", taskType)
+	}
+	mirrorID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid mirror id %s", ctx.Param("id"))
+	}
+	return taskType, mirrorID, nil
+}
+
+func mirrorTaskError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	default:
+		httpbase.ServerError(ctx, err)
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -212,3 +213,182 @@ func (h *MirrorSourceHandler) Delete(ctx *gin.Context) {
 	}
 	httpbase.OK(ctx, nil)
 }
+
+// CreateMirrorCredential godoc
+// @Security     ApiKey
+// @Summary      Create credential profile of mirror source
+// @Description  the secret is stored encrypted and never returned, the default profile is used by the mirrors of the source without credentials
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "mirror source id"
+// @Param        body body types.CreateMirrorCredentialReq true "body"
+// @Success      200  {object}  types.Response{data=database.MirrorCredential} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/sources/{id}/credentials [post]
+func (h *MirrorSourceHandler) CreateCredential(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	msId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, "invalid mirror source id")
+		return
+	}
+	var req types.CreateMirrorCredentialReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.MirrorSourceID = msId
+	req.CurrentUser = currentUser
+	credential, err := h.c.CreateCredential(ctx, req)
+	if err != nil {
+		slog.Error("Failed to create mirror credential", slog.Int64("mirror_source_id", msId), slog.Any("error", err))
+		mirrorCredentialError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, credential)
+}
+
+// ListMirrorCredentials godoc
+// @Security     ApiKey
+// @Summary      List credential profiles of mirror source
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "mirror source id"
+// @Success      200  {object}  types.Response{data=[]database.MirrorCredential} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/sources/{id}/credentials [get]
+func (h *MirrorSourceHandler) ListCredentials(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	msId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, "invalid mirror source id")
+		return
+	}
+	credentials, err := h.c.ListCredentials(ctx, msId, currentUser)
+	if err != nil {
+		slog.Error("Failed to list mirror credentials", slog.Int64("mirror_source_id", msId), slog.Any("error", err))
+		mirrorCredentialError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, credentials)
+}
+
+// UpdateMirrorCredential godoc
+// @Security     ApiKey
+// @Summary      Update credential profile of mirror source
+// @Description  the secret is kept if it is empty
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "mirror source id"
+// @Param        credential_id path string true "credential id"
+// @Param        body body types.UpdateMirrorCredentialReq true "body"
+// @Success      200  {object}  types.Response{data=database.MirrorCredential} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/sources/{id}/credentials/{credential_id} [put]
+func (h *MirrorSourceHandler) UpdateCredential(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	msId, credentialId, err := mirrorCredentialFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.UpdateMirrorCredentialReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID = credentialId
+	req.MirrorSourceID = msId
+	req.CurrentUser = currentUser
+	credential, err := h.c.UpdateCredential(ctx, req)
+	if err != nil {
+		slog.Error("Failed to update mirror credential", slog.Int64("credential_id", credentialId), slog.Any("error", err))
+		mirrorCredentialError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, credential)
+}
+
+// DeleteMirrorCredential godoc
+// @Security     ApiKey
+// @Summary      Delete credential profile of mirror source
+// @Description  the mirrors using the profile fall back to the default profile of the source
+// @Tags         Mirror
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "mirror source id"
+// @Param        credential_id path string true "credential id"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /mirror/sources/{id}/credentials/{credential_id} [delete]
+func (h *MirrorSourceHandler) DeleteCredential(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	msId, credentialId, err := mirrorCredentialFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.c.DeleteCredential(ctx, msId, credentialId, currentUser)
+	if err != nil {
+		slog.Error("Failed to delete mirror credential", slog.Int64("credential_id", credentialId), slog.Any("error", err))
+		mirrorCredentialError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func mirrorCredentialFromContext(ctx *gin.Context) (int64, int64, error) {
+	msId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid mirror source id %s", ctx.Param("id"))
+	}
+	credentialId, err := strconv.ParseInt(ctx.Param("credential_id"), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid credential id %s", ctx.Param("credential_id"))
+	}
+	return msId, credentialId, nil
+}
+
+func mirrorCredentialError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	default:
+		httpbase.ServerError(ctx, err)
+	}
+}
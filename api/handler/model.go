@@ -501,6 +501,10 @@ func (h *ModelHandler) DeployDedicated(ctx *gin.Context) {
 	}
 	deployID, err := h.c.Deploy(ctx, epReq, req)
 	if err != nil {
+		if errors.Is(err, component.ErrQuotaExceeded) || errors.Is(err, component.ErrInsufficientBalance) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
 		slog.Error("failed to deploy model as inference", slog.String("namespace", namespace),
 			slog.String("name", name), slog.Any("currentUser", currentUser), slog.Any("req", req), slog.Any("error", err))
 		httpbase.ServerError(ctx, err)
@@ -583,6 +587,10 @@ func (h *ModelHandler) FinetuneCreate(ctx *gin.Context) {
 
 	deployID, err := h.c.Deploy(ctx, ftReq, *modelReq)
 	if err != nil {
+		if errors.Is(err, component.ErrQuotaExceeded) || errors.Is(err, component.ErrInsufficientBalance) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
 		slog.Error("failed to deploy model as notebook instance", slog.String("namespace", namespace),
 			slog.String("name", name), slog.Any("error", err))
 		httpbase.ServerError(ctx, err)
@@ -810,6 +818,10 @@ func (h *ModelHandler) DeployStart(ctx *gin.Context) {
 
 	err = h.c.DeployStart(ctx, startReq)
 	if err != nil {
+		if errors.Is(err, component.ErrQuotaExceeded) || errors.Is(err, component.ErrInsufficientBalance) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
 		slog.Error("Failed to start deploy", slog.Any("error", err), slog.Any("repoType", types.ModelRepo), slog.String("namespace", namespace), slog.String("name", name), slog.Any("deployID", id))
 		httpbase.ServerError(ctx, err)
 		return
@@ -977,6 +989,10 @@ func (h *ModelHandler) FinetuneStart(ctx *gin.Context) {
 	}
 	err = h.c.DeployStart(ctx, startReq)
 	if err != nil {
+		if errors.Is(err, component.ErrQuotaExceeded) || errors.Is(err, component.ErrInsufficientBalance) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
 		slog.Error("Failed to start deploy", slog.Any("error", err), slog.Any("repoType", types.ModelRepo), slog.String("namespace", namespace), slog.String("name", name), slog.Any("deployID", id))
 		httpbase.ServerError(ctx, err)
 		return
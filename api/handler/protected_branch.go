@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+// ListProtectedBranches godoc
+// @Security     ApiKey
+// @Summary      List protected branch rules of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response{data=[]database.ProtectedBranch} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/protected_branches [get]
+func (h *RepoHandler) ListProtectedBranches(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ProtectedBranchActReq{
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}
+	rules, err := h.c.ListProtectedBranches(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to list protected branches", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, rules)
+}
+
+// CreateProtectedBranch godoc
+// @Security     ApiKey
+// @Summary      Create a protected branch rule for repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.CreateProtectedBranchReq true "create protected branch request"
+// @Success      200  {object}  types.Response{data=database.ProtectedBranch} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/protected_branches [post]
+func (h *RepoHandler) CreateProtectedBranch(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreateProtectedBranchReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	rule, err := h.c.CreateProtectedBranch(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to create protected branch", slog.String("repo_type", string(req.RepoType)), slog.String("pattern", req.Pattern), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Create protected branch succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.String("pattern", req.Pattern))
+	httpbase.OK(ctx, rule)
+}
+
+// UpdateProtectedBranch godoc
+// @Security     ApiKey
+// @Summary      Update a protected branch rule of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "protected branch rule id"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.UpdateProtectedBranchReq true "update protected branch request"
+// @Success      200  {object}  types.Response{data=database.ProtectedBranch} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/protected_branches/{id} [put]
+func (h *RepoHandler) UpdateProtectedBranch(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.UpdateProtectedBranchReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID = id
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	rule, err := h.c.UpdateProtectedBranch(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to update protected branch", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", id), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Update protected branch succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.Int64("id", id))
+	httpbase.OK(ctx, rule)
+}
+
+// DeleteProtectedBranch godoc
+// @Security     ApiKey
+// @Summary      Delete a protected branch rule of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "protected branch rule id"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/protected_branches/{id} [delete]
+func (h *RepoHandler) DeleteProtectedBranch(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ProtectedBranchActReq{
+		ID:          id,
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: currentUser,
+	}
+	err = h.c.DeleteProtectedBranch(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to delete protected branch", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", id), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Delete protected branch succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.Int64("id", id))
+	httpbase.OK(ctx, nil)
+}
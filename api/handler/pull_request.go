@@ -0,0 +1,426 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+type PullRequestHandler struct {
+	c *component.PullRequestComponent
+}
+
+func NewPullRequestHandler(config *config.Config) (*PullRequestHandler, error) {
+	c, err := component.NewPullRequestComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequestHandler{
+		c: c,
+	}, nil
+}
+
+// CreatePullRequest godoc
+// @Security     ApiKey
+// @Summary      Create a pull request
+// @Description  propose to merge a branch of the repository or of a fork into a branch of the repository, pull requests are not supported when the git server is gitea
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.CreatePullRequestReq true "create pull request request"
+// @Success      200  {object}  types.Response{data=types.PullRequest} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls [post]
+func (h *PullRequestHandler) CreatePullRequest(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreatePullRequestReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	pr, err := h.c.CreatePullRequest(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		if errors.Is(err, component.ErrNotSupported) {
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		slog.Error("Failed to create pull request", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Create pull request succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.Int64("id", pr.ID))
+	httpbase.OK(ctx, pr)
+}
+
+// ListPullRequests godoc
+// @Security     ApiKey
+// @Summary      List pull requests of repository
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Param		 status query string false "filter by status" Enums(open,merged,closed)
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "per page" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.PullRequest,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls [get]
+func (h *PullRequestHandler) ListPullRequests(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ListPullRequestsReq{
+		Status:      ctx.Query("status"),
+		Per:         per,
+		Page:        page,
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}
+	prs, total, err := h.c.ListPullRequests(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to list pull requests", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  prs,
+		"total": total,
+	})
+}
+
+// GetPullRequest godoc
+// @Security     ApiKey
+// @Summary      Get a pull request with its changed files
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "pull request id"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response{data=types.PullRequestDetail} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id} [get]
+func (h *PullRequestHandler) GetPullRequest(ctx *gin.Context) {
+	req, err := h.pullRequestActReq(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	pr, err := h.c.GetPullRequest(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to get pull request", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", req.ID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, pr)
+}
+
+// MergePullRequest godoc
+// @Security     ApiKey
+// @Summary      Merge a pull request into its target branch
+// @Description  merge the source branch head into the target branch, not supported when the git server is gitea
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "pull request id"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.MergePullRequestReq false "merge pull request request"
+// @Success      200  {object}  types.Response{data=types.PullRequest} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/merge [put]
+func (h *PullRequestHandler) MergePullRequest(ctx *gin.Context) {
+	actReq, err := h.pullRequestActReq(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if actReq.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.MergePullRequestReq
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			slog.Error("Bad request format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	req.ID = actReq.ID
+	req.Namespace = actReq.Namespace
+	req.Name = actReq.Name
+	req.RepoType = actReq.RepoType
+	req.CurrentUser = actReq.CurrentUser
+	pr, err := h.c.MergePullRequest(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to merge pull request", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", req.ID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Merge pull request succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", req.Name), slog.Int64("id", req.ID))
+	httpbase.OK(ctx, pr)
+}
+
+// UpdatePullRequest godoc
+// @Security     ApiKey
+// @Summary      Update the title and description of an open pull request and refresh its source branch head
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "pull request id"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.UpdatePullRequestReq false "update pull request request"
+// @Success      200  {object}  types.Response{data=types.PullRequest} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id} [put]
+func (h *PullRequestHandler) UpdatePullRequest(ctx *gin.Context) {
+	actReq, err := h.pullRequestActReq(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if actReq.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.UpdatePullRequestReq
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			slog.Error("Bad request format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	req.ID = actReq.ID
+	req.Namespace = actReq.Namespace
+	req.Name = actReq.Name
+	req.RepoType = actReq.RepoType
+	req.CurrentUser = actReq.CurrentUser
+	pr, err := h.c.UpdatePullRequest(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to update pull request", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", req.ID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, pr)
+}
+
+// ClosePullRequest godoc
+// @Security     ApiKey
+// @Summary      Close a pull request without merging it
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "pull request id"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/close [put]
+func (h *PullRequestHandler) ClosePullRequest(ctx *gin.Context) {
+	req, err := h.pullRequestActReq(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	err = h.c.ClosePullRequest(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to close pull request", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", req.ID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// CreatePullRequestComment godoc
+// @Security     ApiKey
+// @Summary      Create a review comment on a pull request
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "pull request id"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.CreatePullRequestCommentReq true "create comment request"
+// @Success      200  {object}  types.Response{data=types.PullRequestComment} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/comments [post]
+func (h *PullRequestHandler) CreatePullRequestComment(ctx *gin.Context) {
+	actReq, err := h.pullRequestActReq(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if actReq.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.CreatePullRequestCommentReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID = actReq.ID
+	req.Namespace = actReq.Namespace
+	req.Name = actReq.Name
+	req.RepoType = actReq.RepoType
+	req.CurrentUser = actReq.CurrentUser
+	comment, err := h.c.CreatePullRequestComment(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to create pull request comment", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", req.ID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, comment)
+}
+
+// ListPullRequestComments godoc
+// @Security     ApiKey
+// @Summary      List review comments of a pull request
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 id path int true "pull request id"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response{data=[]types.PullRequestComment} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/comments [get]
+func (h *PullRequestHandler) ListPullRequestComments(ctx *gin.Context) {
+	req, err := h.pullRequestActReq(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	comments, err := h.c.ListPullRequestComments(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to list pull request comments", slog.String("repo_type", string(req.RepoType)), slog.Int64("id", req.ID), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, comments)
+}
+
+func (h *PullRequestHandler) pullRequestActReq(ctx *gin.Context) (types.PullRequestActReq, error) {
+	var req types.PullRequestActReq
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		return req, err
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return req, err
+	}
+	req.ID = id
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = httpbase.GetCurrentUser(ctx)
+	return req, nil
+}
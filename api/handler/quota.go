@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewQuotaHandler(config *config.Config) (*QuotaHandler, error) {
+	qc, err := component.NewQuotaComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaHandler{
+		c: qc,
+	}, nil
+}
+
+type QuotaHandler struct {
+	c *component.QuotaComponent
+}
+
+// UserQuota godoc
+// @Security     ApiKey
+// @Summary      Get the compute quota of user with current usage
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        username path string true "username"
+// @Param        current_user query string true "current user name"
+// @Success      200  {object}  types.Response{data=types.Quota} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /user/{username}/quota [get]
+func (h *QuotaHandler) UserQuota(ctx *gin.Context) {
+	username := ctx.Param("username")
+	quota, err := h.c.UserQuota(ctx, username, httpbase.GetCurrentUser(ctx))
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) || errors.Is(err, component.ErrUserNotFound) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to get user quota", slog.String("username", username), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, quota)
+}
+
+// ListQuotas godoc
+// @Security     ApiKey
+// @Summary      List the compute quotas of users and organizations
+// @Tags         Quota
+// @Accept       json
+// @Produce      json
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.Quota,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /quotas [get]
+func (h *QuotaHandler) Index(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	quotas, total, err := h.c.ListQuotas(ctx, per, page)
+	if err != nil {
+		slog.Error("Failed to list quotas", slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  quotas,
+		"total": total,
+	}
+	ctx.JSON(http.StatusOK, respData)
+}
+
+// SetQuota godoc
+// @Security     ApiKey
+// @Summary      Create or update the compute quota of a user or an organization
+// @Tags         Quota
+// @Accept       json
+// @Produce      json
+// @Param        owner_type path string true "quota owner type" Enums(user,organization)
+// @Param        owner_name path string true "username or organization name"
+// @Param        body body types.SetQuotaReq true "quota limits, 0 means no limit"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /quotas/{owner_type}/{owner_name} [put]
+func (h *QuotaHandler) Set(ctx *gin.Context) {
+	var req types.SetQuotaReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.OwnerType = ctx.Param("owner_type")
+	req.OwnerName = ctx.Param("owner_name")
+	err := h.c.SetQuota(ctx, &req)
+	if err != nil {
+		slog.Error("Failed to set quota", slog.String("owner_type", req.OwnerType), slog.String("owner_name", req.OwnerName), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Set quota succeed", slog.String("owner_type", req.OwnerType), slog.String("owner_name", req.OwnerName))
+	httpbase.OK(ctx, nil)
+}
+
+// DeleteQuota godoc
+// @Security     ApiKey
+// @Summary      Delete the compute quota of a user or an organization
+// @Tags         Quota
+// @Accept       json
+// @Produce      json
+// @Param        owner_type path string true "quota owner type" Enums(user,organization)
+// @Param        owner_name path string true "username or organization name"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /quotas/{owner_type}/{owner_name} [delete]
+func (h *QuotaHandler) Delete(ctx *gin.Context) {
+	ownerType := ctx.Param("owner_type")
+	ownerName := ctx.Param("owner_name")
+	err := h.c.DeleteQuota(ctx, ownerType, ownerName)
+	if err != nil {
+		slog.Error("Failed to delete quota", slog.String("owner_type", ownerType), slog.String("owner_name", ownerName), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Delete quota succeed", slog.String("owner_type", ownerType), slog.String("owner_name", ownerName))
+	httpbase.OK(ctx, nil)
+}
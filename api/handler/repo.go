@@ -29,12 +29,14 @@ func NewRepoHandler(config *config.Config) (*RepoHandler, error) {
 		return nil, err
 	}
 	return &RepoHandler{
-		c: uc,
+		c:  uc,
+		sc: component.NewSensitiveComponent(config),
 	}, nil
 }
 
 type RepoHandler struct {
-	c *component.RepoComponent
+	c  *component.RepoComponent
+	sc component.SensitiveChecker
 }
 
 // CreateRepoFile godoc
@@ -83,6 +85,10 @@ func (h *RepoHandler) CreateFile(ctx *gin.Context) {
 
 	resp, err := h.c.CreateFile(ctx, req)
 	if err != nil {
+		if errors.Is(err, component.ErrForbidden) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
 		slog.Error("Failed to create repo file", slog.String("repo_type", string(req.RepoType)), slog.Any("error", err))
 		httpbase.ServerError(ctx, err)
 		return
@@ -91,6 +97,65 @@ func (h *RepoHandler) CreateFile(ctx *gin.Context) {
 	httpbase.OK(ctx, resp)
 }
 
+// CommitRepoFiles godoc
+// @Security     ApiKey
+// @Summary      Create, update, delete or move multiple files in repository as a single commit
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.CommitFilesReq true  "commit files request, contents are base64 encoded"
+// @Success      200  {object}  types.Response{data=types.CommitFilesResp} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      403  {object}  types.APIBadRequest "Pushing to the protected branch is not allowed"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/commits [post]
+func (h *RepoHandler) CommitFiles(ctx *gin.Context) {
+	userName := httpbase.GetCurrentUser(ctx)
+	if userName == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CommitFilesReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.Username = userName
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = userName
+
+	resp, err := h.c.CommitFiles(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		if errors.Is(err, component.ErrForbidden) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
+		slog.Error("Failed to commit repo files", slog.String("repo_type", string(req.RepoType)), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Commit repo files succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", name), slog.String("commit_id", resp.CommitID))
+	httpbase.OK(ctx, resp)
+}
+
 // UpdateRepoFile godoc
 // @Security     ApiKey
 // @Summary      Update existing file in repository
@@ -138,6 +203,10 @@ func (h *RepoHandler) UpdateFile(ctx *gin.Context) {
 
 	resp, err := h.c.UpdateFile(ctx, req)
 	if err != nil {
+		if errors.Is(err, component.ErrForbidden) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
 		slog.Error("Failed to update repo file", slog.String("repo_type", string(req.RepoType)), slog.Any("error", err))
 		httpbase.ServerError(ctx, err)
 		return
@@ -156,6 +225,7 @@ func (h *RepoHandler) UpdateFile(ctx *gin.Context) {
 // @Param		 namespace path string true "repo owner name"
 // @Param		 name path string true "repo name"
 // @Param		 ref query string true "branch or tag"
+// @Param		 path query string false "only list commits changing the file or directory"
 // @Param		 current_user query string false "current user name"
 // @Param        per query int false "per" default(20)
 // @Param        page query int false "per page" default(1)
@@ -182,6 +252,7 @@ func (h *RepoHandler) Commits(ctx *gin.Context) {
 		Namespace:   namespace,
 		Name:        name,
 		Ref:         ref,
+		Path:        ctx.Query("path"),
 		Per:         per,
 		Page:        page,
 		RepoType:    common.RepoTypeFromContext(ctx),
@@ -245,6 +316,53 @@ func (h *RepoHandler) LastCommit(ctx *gin.Context) {
 	httpbase.OK(ctx, commit)
 }
 
+// GetRepoFileBlame godoc
+// @Security     ApiKey
+// @Summary      Get the commit, author and date which last changed each range of lines of a file
+// @Description  on gitea only the last 20 commits of the file are walked, older lines are attributed to the oldest of them, and files over 1MB are rejected
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 file_path path string true "file path"
+// @Param		 ref query string false "branch or tag"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response{data=[]types.BlameRange} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/blame/{file_path} [get]
+func (h *RepoHandler) FileBlame(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	filePath := convertFilePathFromRoute(ctx.Param("file_path"))
+	req := &types.GetFileReq{
+		Namespace:   namespace,
+		Name:        name,
+		Path:        filePath,
+		Ref:         ctx.Query("ref"),
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}
+	ranges, err := h.c.FileBlame(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to get repo file blame", slog.String("repo_type", string(req.RepoType)), slog.String("path", req.Path), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, ranges)
+}
+
 // GetRepoFileContent godoc
 // @Security     ApiKey
 // @Summary      Get the last commit of repository
@@ -432,24 +550,337 @@ func (h *RepoHandler) Branches(ctx *gin.Context) {
 		httpbase.BadRequest(ctx, err.Error())
 		return
 	}
-	currentUser := httpbase.GetCurrentUser(ctx)
-	req := &types.GetBranchesReq{
+	currentUser := httpbase.GetCurrentUser(ctx)
+	req := &types.GetBranchesReq{
+		Namespace:   namespace,
+		Name:        name,
+		Per:         per,
+		Page:        page,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: currentUser,
+	}
+	branches, err := h.c.Branches(ctx, req)
+	if err != nil {
+		slog.Error("Failed to get repo branches", slog.String("repo_type", string(req.RepoType)), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+
+	slog.Info("Get repo branches succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", name))
+	httpbase.OK(ctx, branches)
+}
+
+// CreateRepoBranch godoc
+// @Security     ApiKey
+// @Summary      Create a new branch in repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.CreateBranchReq true "create branch request"
+// @Success      200  {object}  types.Response{data=types.Branch} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/branches [post]
+func (h *RepoHandler) CreateBranch(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreateBranchReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	repoType := common.RepoTypeFromContext(ctx)
+	allow, err := h.c.AllowWriteAccess(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		slog.Error("failed to check user permission", slog.Any("error", err), slog.String("currentUser", currentUser), slog.String("namespace", namespace), slog.String("name", name))
+		httpbase.ServerError(ctx, errors.New("failed to check user permission"))
+		return
+	}
+	if !allow {
+		httpbase.UnauthorizedError(ctx, errors.New("user not allowed to create branch"))
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = repoType
+	req.CurrentUser = currentUser
+	branch, err := h.c.CreateBranch(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrForbidden) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
+		slog.Error("Failed to create repo branch", slog.String("repo_type", string(repoType)), slog.String("branch", req.Branch), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Create repo branch succeed", slog.String("repo_type", string(repoType)), slog.String("name", name), slog.String("branch", req.Branch))
+	httpbase.OK(ctx, branch)
+}
+
+// DeleteRepoBranch godoc
+// @Security     ApiKey
+// @Summary      Delete a branch of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 branch path string true "branch name"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/branches/{branch} [delete]
+func (h *RepoHandler) DeleteBranch(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	branch := convertFilePathFromRoute(ctx.Param("branch"))
+	if branch == "" {
+		httpbase.BadRequest(ctx, "branch name is required")
+		return
+	}
+	repoType := common.RepoTypeFromContext(ctx)
+	allow, err := h.c.AllowWriteAccess(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		slog.Error("failed to check user permission", slog.Any("error", err), slog.String("currentUser", currentUser), slog.String("namespace", namespace), slog.String("name", name))
+		httpbase.ServerError(ctx, errors.New("failed to check user permission"))
+		return
+	}
+	if !allow {
+		httpbase.UnauthorizedError(ctx, errors.New("user not allowed to delete branch"))
+		return
+	}
+	req := &types.DeleteBranchReq{
+		Branch:      branch,
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    repoType,
+		CurrentUser: currentUser,
+	}
+	err = h.c.DeleteBranch(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrForbidden) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
+		slog.Error("Failed to delete repo branch", slog.String("repo_type", string(repoType)), slog.String("branch", branch), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Delete repo branch succeed", slog.String("repo_type", string(repoType)), slog.String("name", name), slog.String("branch", branch))
+	httpbase.OK(ctx, nil)
+}
+
+// RenameRepoBranch godoc
+// @Security     ApiKey
+// @Summary      Rename a branch of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 branch path string true "branch name"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.RenameBranchReq true "rename branch request"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/branches/{branch} [put]
+func (h *RepoHandler) RenameBranch(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	branch := convertFilePathFromRoute(ctx.Param("branch"))
+	if branch == "" {
+		httpbase.BadRequest(ctx, "branch name is required")
+		return
+	}
+	var req types.RenameBranchReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	repoType := common.RepoTypeFromContext(ctx)
+	allow, err := h.c.AllowWriteAccess(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		slog.Error("failed to check user permission", slog.Any("error", err), slog.String("currentUser", currentUser), slog.String("namespace", namespace), slog.String("name", name))
+		httpbase.ServerError(ctx, errors.New("failed to check user permission"))
+		return
+	}
+	if !allow {
+		httpbase.UnauthorizedError(ctx, errors.New("user not allowed to rename branch"))
+		return
+	}
+	req.Branch = branch
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = repoType
+	req.CurrentUser = currentUser
+	err = h.c.RenameBranch(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrForbidden) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
+		slog.Error("Failed to rename repo branch", slog.String("repo_type", string(repoType)), slog.String("branch", branch), slog.String("new_branch", req.NewBranch), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Rename repo branch succeed", slog.String("repo_type", string(repoType)), slog.String("name", name), slog.String("branch", branch), slog.String("new_branch", req.NewBranch))
+	httpbase.OK(ctx, nil)
+}
+
+// CreateRepoGitTag godoc
+// @Security     ApiKey
+// @Summary      Create a git tag in repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.CreateTagReq true "create tag request"
+// @Success      200  {object}  types.Response{data=types.Tag} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/refs/tags [post]
+func (h *RepoHandler) CreateGitTag(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreateTagReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	repoType := common.RepoTypeFromContext(ctx)
+	allow, err := h.c.AllowWriteAccess(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		slog.Error("failed to check user permission", slog.Any("error", err), slog.String("currentUser", currentUser), slog.String("namespace", namespace), slog.String("name", name))
+		httpbase.ServerError(ctx, errors.New("failed to check user permission"))
+		return
+	}
+	if !allow {
+		httpbase.UnauthorizedError(ctx, errors.New("user not allowed to create tag"))
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = repoType
+	req.CurrentUser = currentUser
+	tag, err := h.c.CreateTag(ctx, &req)
+	if err != nil {
+		slog.Error("Failed to create repo git tag", slog.String("repo_type", string(repoType)), slog.String("tag", req.Tag), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Create repo git tag succeed", slog.String("repo_type", string(repoType)), slog.String("name", name), slog.String("tag", req.Tag))
+	httpbase.OK(ctx, tag)
+}
+
+// DeleteRepoGitTag godoc
+// @Security     ApiKey
+// @Summary      Delete a git tag of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 tag path string true "tag name"
+// @Param		 current_user query string false "current user name"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/refs/tags/{tag} [delete]
+func (h *RepoHandler) DeleteGitTag(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	tag := convertFilePathFromRoute(ctx.Param("tag"))
+	if tag == "" {
+		httpbase.BadRequest(ctx, "tag name is required")
+		return
+	}
+	repoType := common.RepoTypeFromContext(ctx)
+	allow, err := h.c.AllowWriteAccess(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		slog.Error("failed to check user permission", slog.Any("error", err), slog.String("currentUser", currentUser), slog.String("namespace", namespace), slog.String("name", name))
+		httpbase.ServerError(ctx, errors.New("failed to check user permission"))
+		return
+	}
+	if !allow {
+		httpbase.UnauthorizedError(ctx, errors.New("user not allowed to delete tag"))
+		return
+	}
+	req := &types.DeleteTagReq{
+		Tag:         tag,
 		Namespace:   namespace,
 		Name:        name,
-		Per:         per,
-		Page:        page,
-		RepoType:    common.RepoTypeFromContext(ctx),
+		RepoType:    repoType,
 		CurrentUser: currentUser,
 	}
-	branches, err := h.c.Branches(ctx, req)
+	err = h.c.DeleteTag(ctx, req)
 	if err != nil {
-		slog.Error("Failed to get repo branches", slog.String("repo_type", string(req.RepoType)), slog.Any("error", err))
+		slog.Error("Failed to delete repo git tag", slog.String("repo_type", string(repoType)), slog.String("tag", tag), slog.Any("error", err))
 		httpbase.ServerError(ctx, err)
 		return
 	}
-
-	slog.Info("Get repo branches succeed", slog.String("repo_type", string(req.RepoType)), slog.String("name", name))
-	httpbase.OK(ctx, branches)
+	slog.Info("Delete repo git tag succeed", slog.String("repo_type", string(repoType)), slog.String("name", name), slog.String("tag", tag))
+	httpbase.OK(ctx, nil)
 }
 
 // GetRepoTags
@@ -706,6 +1137,10 @@ func (h *RepoHandler) UploadFile(ctx *gin.Context) {
 		}
 		err = h.c.UploadFile(ctx, upload)
 		if err != nil {
+			if errors.Is(err, component.ErrForbidden) {
+				httpbase.ForbiddenError(ctx, err)
+				return
+			}
 			slog.Error("Failed to upload repo file", slog.String("repo_type", string(upload.RepoType)), slog.Any("error", err), slog.String("file_path", filePath))
 			httpbase.ServerError(ctx, err)
 			return
@@ -936,6 +1371,66 @@ func (h *RepoHandler) CommitWithDiff(ctx *gin.Context) {
 	httpbase.OK(ctx, commit)
 }
 
+// CompareRefs godoc
+// @Security     ApiKey
+// @Summary      Compare two refs of repository and diff field of response need to be decode with base64
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 basehead path string true "base and head refs separated by three dots, like v1.0...v2.0"
+// @Param		 current_user query string false "current user name"
+// @Param		 per query int false "commits per page, at most 250" default(50)
+// @Param		 page query int false "page of the commits" default(1)
+// @Success      200  {object}  types.Response{data=types.CompareResponse} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/compare/{basehead} [get]
+func (h *RepoHandler) CompareRefs(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	// refs may contain slashes, so the route captures everything after compare/
+	base, head, found := strings.Cut(strings.TrimPrefix(ctx.Param("basehead"), "/"), "...")
+	if !found || base == "" || head == "" {
+		httpbase.BadRequest(ctx, "compare refs must be in the form of base...head")
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := &types.CompareRefsReq{
+		Namespace:   namespace,
+		Name:        name,
+		Base:        base,
+		Head:        head,
+		Per:         per,
+		Page:        page,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}
+	resp, err := h.c.CompareRefs(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to compare repo refs", slog.String("repo_type", string(req.RepoType)), slog.String("base", base), slog.String("head", head), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, resp)
+}
+
 // CreateMirror godoc
 // @Security     ApiKey
 // @Summary      Create mirror for a existing repository
@@ -982,6 +1477,10 @@ func (h *RepoHandler) CreateMirror(ctx *gin.Context) {
 	mirror, err := h.c.CreateMirror(ctx, mirrorReq)
 	if err != nil {
 		slog.Error("Failed to create mirror for", slog.String("repo_type", string(mirrorReq.RepoType)), slog.String("path", fmt.Sprintf("%s/%s", mirrorReq.Namespace, mirrorReq.Name)), "error", err)
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -1113,6 +1612,10 @@ func (h *RepoHandler) UpdateMirror(ctx *gin.Context) {
 	mirror, err := h.c.UpdateMirror(ctx, mirrorReq)
 	if err != nil {
 		slog.Error("Failed to update mirror for", slog.String("repo_type", string(mirrorReq.RepoType)), slog.String("path", fmt.Sprintf("%s/%s", mirrorReq.Namespace, mirrorReq.Name)), "error", err)
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -1658,8 +2161,9 @@ func (h *RepoHandler) DeployStatus(ctx *gin.Context) {
 				ctx.SSEvent("error", err.Error())
 			} else {
 				eventData := &types.ModelStatusEventData{
-					Status:  status,
-					Details: instances,
+					Status:        status,
+					Details:       instances,
+					QueuePosition: h.c.DeployQueuePosition(deployID),
 				}
 				ctx.SSEvent("status", eventData)
 
@@ -1818,6 +2322,216 @@ func (h *RepoHandler) DeployUpdate(ctx *gin.Context) {
 	httpbase.OK(ctx, nil)
 }
 
+// DeployCancel  godoc
+// @Security     ApiKey
+// @Summary      Cancel the running build or deploy task of a deploy
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,spaces" Enums(models,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path string true "deploy id"
+// @Param        current_user query string true "current_user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/run/{id}/cancel [post]
+func (h *RepoHandler) DeployCancel(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("failed to get namespace and name from context", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	repoType := common.RepoTypeFromContext(ctx)
+	allow, err := h.c.AllowAdminAccess(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		slog.Error("failed to check user permission", "error", err, slog.Any("currentUser", currentUser), slog.Any("namespace", namespace), slog.Any("name", name))
+		httpbase.ServerError(ctx, errors.New("failed to check user permission"))
+		return
+	}
+	if !allow {
+		slog.Info("user not allowed to cancel deploy", slog.String("namespace", namespace),
+			slog.String("name", name), slog.Any("username", currentUser))
+		httpbase.UnauthorizedError(ctx, errors.New("user not allowed to cancel deploy"))
+		return
+	}
+
+	deployID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", slog.Any("error", err), slog.Any("id", ctx.Param("id")))
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	cancelReq := types.DeployActReq{
+		RepoType:    repoType,
+		Namespace:   namespace,
+		Name:        name,
+		CurrentUser: currentUser,
+		DeployID:    deployID,
+	}
+	err = h.c.DeployCancel(ctx, cancelReq)
+	if err != nil {
+		if errors.Is(err, component.ErrNoTaskToCancel) {
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		slog.Error("failed to cancel deploy", slog.String("namespace", namespace), slog.String("name", name), slog.Any("username", currentUser), slog.Int64("deploy_id", deployID), slog.Any("error", err))
+		httpbase.ServerError(ctx, fmt.Errorf("failed to cancel deploy, %w", err))
+		return
+	}
+
+	httpbase.OK(ctx, nil)
+}
+
+// DeployKeyCreate  godoc
+// @Security     ApiKey
+// @Summary      Create an api key of the inference endpoint
+// @Description  Create an api key scoped to the inference endpoint, the key is returned only once
+// @Tags         Model
+// @Accept       json
+// @Produce      json
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path string true "deploy id"
+// @Param        current_user query string true "current_user"
+// @Param        body body types.CreateEndpointKeyReq true "name and limits of the key"
+// @Success      200  {object}  types.Response{data=types.EndpointKey} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /models/{namespace}/{name}/run/{id}/keys [post]
+func (h *RepoHandler) DeployKeyCreate(ctx *gin.Context) {
+	actReq, ok := h.deployKeyActReq(ctx)
+	if !ok {
+		return
+	}
+	var req types.CreateEndpointKeyReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	key, err := h.c.DeployKeyCreate(ctx, actReq, req)
+	if err != nil {
+		slog.Error("failed to create endpoint key", slog.Any("req", actReq), slog.String("key_name", req.Name), slog.Any("error", err))
+		h.deployKeyError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, key)
+}
+
+// DeployKeyList  godoc
+// @Security     ApiKey
+// @Summary      List api keys of the inference endpoint
+// @Tags         Model
+// @Accept       json
+// @Produce      json
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path string true "deploy id"
+// @Param        current_user query string true "current_user"
+// @Success      200  {object}  types.Response{data=[]types.EndpointKey} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /models/{namespace}/{name}/run/{id}/keys [get]
+func (h *RepoHandler) DeployKeyList(ctx *gin.Context) {
+	actReq, ok := h.deployKeyActReq(ctx)
+	if !ok {
+		return
+	}
+	keys, err := h.c.DeployKeyList(ctx, actReq)
+	if err != nil {
+		slog.Error("failed to list endpoint keys", slog.Any("req", actReq), slog.Any("error", err))
+		h.deployKeyError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, keys)
+}
+
+// DeployKeyRevoke  godoc
+// @Security     ApiKey
+// @Summary      Revoke an api key of the inference endpoint
+// @Tags         Model
+// @Accept       json
+// @Produce      json
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path string true "deploy id"
+// @Param        key_id path string true "key id"
+// @Param        current_user query string true "current_user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      401  {object}  types.APIUnauthorized "Permission denied"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /models/{namespace}/{name}/run/{id}/keys/{key_id} [delete]
+func (h *RepoHandler) DeployKeyRevoke(ctx *gin.Context) {
+	actReq, ok := h.deployKeyActReq(ctx)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.ParseInt(ctx.Param("key_id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", slog.Any("error", err), slog.Any("key_id", ctx.Param("key_id")))
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.c.DeployKeyRevoke(ctx, actReq, keyID)
+	if err != nil {
+		slog.Error("failed to revoke endpoint key", slog.Any("req", actReq), slog.Int64("key_id", keyID), slog.Any("error", err))
+		h.deployKeyError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// deployKeyActReq builds the request on the deploy of the key routes, it responds the error if the request is invalid
+func (h *RepoHandler) deployKeyActReq(ctx *gin.Context) (types.DeployActReq, bool) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return types.DeployActReq{}, false
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return types.DeployActReq{}, false
+	}
+	deployID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", slog.Any("error", err), slog.Any("id", ctx.Param("id")))
+		httpbase.BadRequest(ctx, err.Error())
+		return types.DeployActReq{}, false
+	}
+	return types.DeployActReq{
+		RepoType:    common.RepoTypeFromContext(ctx),
+		Namespace:   namespace,
+		Name:        name,
+		CurrentUser: currentUser,
+		DeployID:    deployID,
+		DeployType:  types.InferenceType,
+	}, true
+}
+
+func (h *RepoHandler) deployKeyError(ctx *gin.Context, err error) {
+	var pErr *types.PermissionError
+	if errors.As(err, &pErr) {
+		httpbase.UnauthorizedError(ctx, err)
+		return
+	}
+	httpbase.ServerError(ctx, err)
+}
+
 // RuntimeFrameworkListWithType godoc
 // @Security     ApiKey
 // @Summary      List repo runtime framework
@@ -2099,8 +2813,9 @@ func (h *RepoHandler) ServerlessStatus(ctx *gin.Context) {
 				ctx.SSEvent("error", err.Error())
 			} else {
 				eventData := &types.ModelStatusEventData{
-					Status:  status,
-					Details: instances,
+					Status:        status,
+					Details:       instances,
+					QueuePosition: h.c.DeployQueuePosition(deployID),
 				}
 				ctx.SSEvent("status", eventData)
 
@@ -2178,3 +2893,64 @@ func (h *RepoHandler) ServerlessUpdate(ctx *gin.Context) {
 
 	httpbase.OK(ctx, nil)
 }
+
+// ForkRepo godoc
+// @Security     ApiKey
+// @Summary      Fork a repository into a user or organization namespace
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models,dataset,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string false "current user name"
+// @Param        req body types.ForkRepoReq true "fork repo request"
+// @Success      200  {object}  types.Response{data=database.Repository} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/fork [post]
+func (h *RepoHandler) ForkRepo(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.ForkRepoReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	_, err = h.sc.CheckRequest(ctx, &req)
+	if err != nil {
+		slog.Error("failed to check sensitive request", slog.Any("error", err))
+		httpbase.BadRequest(ctx, fmt.Errorf("sensitive check failed: %w", err).Error())
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	repo, err := h.c.ForkRepo(ctx, &req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		if errors.Is(err, component.ErrAlreadyExists) {
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		slog.Error("Failed to fork repo", slog.String("repo_type", string(req.RepoType)), slog.String("path", namespace+"/"+name), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Fork repo succeed", slog.String("repo_type", string(req.RepoType)), slog.String("from", namespace+"/"+name), slog.String("to", repo.Path))
+	httpbase.OK(ctx, repo)
+}
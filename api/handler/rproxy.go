@@ -1,16 +1,20 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"opencsg.com/csghub-server/api/httpbase"
 	"opencsg.com/csghub-server/builder/proxy"
+	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/component"
 )
 
@@ -18,6 +22,8 @@ type RProxyHandler struct {
 	SpaceRootDomain string
 	spaceComp       *component.SpaceComponent
 	repoComp        *component.RepoComponent
+	tokenMeterComp  *component.TokenMeterComponent
+	limitComp       *component.EndpointLimitComponent
 }
 
 func NewRProxyHandler(config *config.Config) (*RProxyHandler, error) {
@@ -29,11 +35,21 @@ func NewRProxyHandler(config *config.Config) (*RProxyHandler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create repo component,%w", err)
 	}
+	tokenMeterComp, err := component.NewTokenMeterComponent(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token meter component,%w", err)
+	}
+	limitComp, err := component.NewEndpointLimitComponent(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create endpoint limit component,%w", err)
+	}
 
 	return &RProxyHandler{
 		SpaceRootDomain: config.Space.InternalRootDomain,
 		spaceComp:       spaceComp,
 		repoComp:        repoComp,
+		tokenMeterComp:  tokenMeterComp,
+		limitComp:       limitComp,
 	}, nil
 }
 
@@ -51,7 +67,21 @@ func (r *RProxyHandler) Proxy(ctx *gin.Context) {
 	username := httpbase.GetCurrentUser(ctx)
 	allow := false
 	err = nil
-	if deploy.SpaceID > 0 {
+	var endpointKey *database.EndpointKey
+	if key, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer "+types.EndpointKeyPrefix); ok && deploy.ModelID > 0 {
+		// call with the api key of the endpoint on behalf of the user created the key
+		endpointKey, err = r.repoComp.FindEndpointKey(ctx, deploy, types.EndpointKeyPrefix+key)
+		if err == nil && endpointKey == nil {
+			httpbase.UnauthorizedError(ctx, errors.New("invalid api key of endpoint"))
+			return
+		}
+		if endpointKey != nil {
+			username = endpointKey.User.Username
+			allow = true
+			// the endpoint key is for the proxy only, never pass it on to the model
+			ctx.Request.Header.Del("Authorization")
+		}
+	} else if deploy.SpaceID > 0 {
 		// user must login to visit space
 		if httpbase.GetAuthType(ctx) != httpbase.AuthTypeJwt {
 			httpbase.UnauthorizedError(ctx, errors.New("user not found in session, please access with jwt token first"))
@@ -71,6 +101,18 @@ func (r *RProxyHandler) Proxy(ctx *gin.Context) {
 		return
 	}
 
+	if endpointKey != nil {
+		err = r.limitComp.Allow(ctx, endpointKey)
+		if errors.Is(err, component.ErrTooManyRequests) {
+			httpbase.TooManyRequestsError(ctx, err)
+			return
+		}
+		if err != nil {
+			// do not block calls if the limits can not be checked
+			slog.Error("failed to check limits of endpoint key", slog.Int64("key_id", endpointKey.ID), slog.Any("error", err))
+		}
+	}
+
 	if allow {
 		apiname := ctx.Param("api")
 		target := fmt.Sprintf("http://%s.%s", appSrvName, r.SpaceRootDomain)
@@ -84,6 +126,13 @@ func (r *RProxyHandler) Proxy(ctx *gin.Context) {
 			contextPath := fmt.Sprintf("/%s/%s", "endpoint", appSrvName)
 			apiname = strings.TrimPrefix(apiname, contextPath)
 		}
+		// callers of shared serverless models pay for the tokens they use
+		meterTokens := deploy.Type == types.ServerlessType && username != ""
+		if meterTokens || endpointKey != nil {
+			rp.OnTokenUsage(func(usage proxy.TokenUsage) {
+				go r.handleTokenUsage(username, deploy, endpointKey, meterTokens, usage)
+			})
+		}
 		rp.ServeHTTP(ctx.Writer, ctx.Request, apiname)
 	} else {
 		slog.Warn("user not allowed to call endpoint api", slog.String("srv_name", appSrvName), slog.Any("user_name", username), slog.Any("deployID", deploy.ID))
@@ -91,6 +140,25 @@ func (r *RProxyHandler) Proxy(ctx *gin.Context) {
 	}
 }
 
+func (r *RProxyHandler) handleTokenUsage(username string, deploy *database.Deploy, endpointKey *database.EndpointKey, meterTokens bool, usage proxy.TokenUsage) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if endpointKey != nil {
+		err := r.limitComp.AddTokens(ctx, endpointKey, usage.TotalTokens)
+		if err != nil {
+			slog.Error("failed to count tokens of endpoint key", slog.Int64("key_id", endpointKey.ID), slog.Any("error", err))
+		}
+	}
+	if !meterTokens {
+		return
+	}
+	err := r.tokenMeterComp.PublishTokenUsage(ctx, username, deploy, usage)
+	if err != nil {
+		slog.Error("failed to publish token usage of endpoint", slog.String("srv_name", deploy.SvcName), slog.String("user_name", username),
+			slog.Any("usage", usage), slog.Any("error", err))
+	}
+}
+
 // get service name based on request
 func (r *RProxyHandler) GetSrvName(ctx *gin.Context) string {
 	URI := ctx.Request.RequestURI
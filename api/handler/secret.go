@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+// ListSecrets godoc
+// @Security     ApiKey
+// @Summary      List secrets of repository, values are never returned
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models or spaces" Enums(models,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string true "current user name"
+// @Success      200  {object}  types.Response{data=[]types.Secret} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secrets [get]
+func (h *RepoHandler) ListSecrets(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	h.listSecrets(ctx, types.SecretActReq{
+		Namespace:   namespace,
+		RepoName:    name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	})
+}
+
+// SetSecret godoc
+// @Security     ApiKey
+// @Summary      Create or rotate a secret of repository, running deploys are redeployed with it
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models or spaces" Enums(models,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 current_user query string true "current user name"
+// @Param        req body types.SetSecretReq true "set secret request"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secrets [put]
+func (h *RepoHandler) SetSecret(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.SetSecretReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.Namespace = namespace
+	req.RepoName = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	h.setSecret(ctx, &req)
+}
+
+// DeleteSecret godoc
+// @Security     ApiKey
+// @Summary      Delete a secret of repository, running deploys are redeployed without it
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 repo_type path string true "models or spaces" Enums(models,spaces)
+// @Param		 namespace path string true "repo owner name"
+// @Param		 name path string true "repo name"
+// @Param		 secret path string true "secret name"
+// @Param		 current_user query string true "current user name"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secrets/{secret} [delete]
+func (h *RepoHandler) DeleteSecret(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	h.deleteSecret(ctx, types.SecretActReq{
+		Name:        ctx.Param("secret"),
+		Namespace:   namespace,
+		RepoName:    name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	})
+}
+
+// ListNamespaceSecrets godoc
+// @Security     ApiKey
+// @Summary      List secrets shared by all repositories of the namespace, values are never returned
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 namespace path string true "user or organization name"
+// @Param		 current_user query string true "current user name"
+// @Success      200  {object}  types.Response{data=[]types.Secret} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /namespaces/{namespace}/secrets [get]
+func (h *RepoHandler) ListNamespaceSecrets(ctx *gin.Context) {
+	h.listSecrets(ctx, types.SecretActReq{
+		Namespace:   ctx.Param("namespace"),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	})
+}
+
+// SetNamespaceSecret godoc
+// @Security     ApiKey
+// @Summary      Create or rotate a secret of the namespace, running deploys of the namespace are redeployed with it
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 namespace path string true "user or organization name"
+// @Param		 current_user query string true "current user name"
+// @Param        req body types.SetSecretReq true "set secret request"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /namespaces/{namespace}/secrets [put]
+func (h *RepoHandler) SetNamespaceSecret(ctx *gin.Context) {
+	var req types.SetSecretReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.Namespace = ctx.Param("namespace")
+	h.setSecret(ctx, &req)
+}
+
+// DeleteNamespaceSecret godoc
+// @Security     ApiKey
+// @Summary      Delete a secret of the namespace, running deploys of the namespace are redeployed without it
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param		 namespace path string true "user or organization name"
+// @Param		 secret path string true "secret name"
+// @Param		 current_user query string true "current user name"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /namespaces/{namespace}/secrets/{secret} [delete]
+func (h *RepoHandler) DeleteNamespaceSecret(ctx *gin.Context) {
+	h.deleteSecret(ctx, types.SecretActReq{
+		Name:        ctx.Param("secret"),
+		Namespace:   ctx.Param("namespace"),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	})
+}
+
+func (h *RepoHandler) listSecrets(ctx *gin.Context, req types.SecretActReq) {
+	secrets, err := h.c.ListSecrets(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to list secrets", slog.String("namespace", req.Namespace), slog.String("repo_name", req.RepoName), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, secrets)
+}
+
+func (h *RepoHandler) setSecret(ctx *gin.Context, req *types.SetSecretReq) {
+	req.CurrentUser = httpbase.GetCurrentUser(ctx)
+	err := h.c.SetSecret(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to set secret", slog.String("namespace", req.Namespace), slog.String("repo_name", req.RepoName), slog.String("secret", req.Name), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Set secret succeed", slog.String("namespace", req.Namespace), slog.String("repo_name", req.RepoName), slog.String("secret", req.Name))
+	httpbase.OK(ctx, nil)
+}
+
+func (h *RepoHandler) deleteSecret(ctx *gin.Context, req types.SecretActReq) {
+	err := h.c.DeleteSecret(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to delete secret", slog.String("namespace", req.Namespace), slog.String("repo_name", req.RepoName), slog.String("secret", req.Name), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	slog.Info("Delete secret succeed", slog.String("namespace", req.Namespace), slog.String("repo_name", req.RepoName), slog.String("secret", req.Name))
+	httpbase.OK(ctx, nil)
+}
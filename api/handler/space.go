@@ -299,6 +299,10 @@ func (h *SpaceHandler) Run(ctx *gin.Context) {
 	}
 	deployID, err := h.c.Deploy(ctx, namespace, name, currentUser)
 	if err != nil {
+		if errors.Is(err, component.ErrQuotaExceeded) || errors.Is(err, component.ErrInsufficientBalance) {
+			httpbase.ForbiddenError(ctx, err)
+			return
+		}
 		slog.Error("failed to deploy space", slog.String("namespace", namespace),
 			slog.String("name", name), slog.Any("error", err))
 		httpbase.ServerError(ctx, errors.New("failed to deploy space"))
@@ -51,6 +51,17 @@ func UnauthorizedError(c *gin.Context, err error) {
 	})
 }
 
+// ForbiddenError responds with a JSON-formatted error message.
+//
+// Example:
+//
+//	ForbiddenError(c, errors.New("protected branch"))
+func ForbiddenError(c *gin.Context, err error) {
+	c.PureJSON(http.StatusForbidden, R{
+		Msg: err.Error(),
+	})
+}
+
 // NotFoundError responds with a JSON-formatted error message.
 //
 // Example:
@@ -62,6 +73,17 @@ func NotFoundError(c *gin.Context, err error) {
 	})
 }
 
+// TooManyRequestsError responds with a JSON-formatted error message.
+//
+// Example:
+//
+//	TooManyRequestsError(c, errors.New("rate limit exceeded"))
+func TooManyRequestsError(c *gin.Context, err error) {
+	c.PureJSON(http.StatusTooManyRequests, R{
+		Msg: err.Error(),
+	})
+}
+
 // R is the response envelope
 type R struct {
 	Code int    `json:"code,omitempty"`
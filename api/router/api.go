@@ -142,6 +142,14 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 	// space routers
 	createSpaceRoutes(config, apiGroup, spaceHandler, repoCommonHandler)
 
+	// secrets shared by the deploys of all repos in a namespace
+	namespaceSecrets := apiGroup.Group("/namespaces/:namespace/secrets")
+	{
+		namespaceSecrets.GET("", repoCommonHandler.ListNamespaceSecrets)
+		namespaceSecrets.PUT("", repoCommonHandler.SetNamespaceSecret)
+		namespaceSecrets.DELETE("/:secret", repoCommonHandler.DeleteNamespaceSecret)
+	}
+
 	spaceResourceHandler, err := handler.NewSpaceResourceHandler(config)
 	if err != nil {
 		return nil, fmt.Errorf("error creating space resource handler:%w", err)
@@ -155,6 +163,18 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 		spaceResource.DELETE("/:id", needAPIKey, spaceResourceHandler.Delete)
 	}
 
+	quotaHandler, err := handler.NewQuotaHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating quota handler:%w", err)
+	}
+
+	quotas := apiGroup.Group("quotas")
+	{
+		quotas.GET("", needAPIKey, quotaHandler.Index)
+		quotas.PUT("/:owner_type/:owner_name", needAPIKey, quotaHandler.Set)
+		quotas.DELETE("/:owner_type/:owner_name", needAPIKey, quotaHandler.Delete)
+	}
+
 	spaceSdkHandler, err := handler.NewSpaceSdkHandler(config)
 	if err != nil {
 		return nil, fmt.Errorf("error creating space sdk handler:%w", err)
@@ -173,7 +193,7 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 		return nil, fmt.Errorf("error creating user proxy handler:%w", err)
 	}
 
-	createUserRoutes(apiGroup, needAPIKey, userProxyHandler, userHandler)
+	createUserRoutes(apiGroup, needAPIKey, userProxyHandler, userHandler, quotaHandler)
 
 	tokenGroup := apiGroup.Group("token")
 	{
@@ -259,8 +279,17 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 		mirror.PUT("/sources/:id", msHandler.Update)
 		mirror.DELETE("/sources/:id", msHandler.Delete)
 		mirror.GET("/sources/:id", msHandler.Get)
+		mirror.GET("/sources/:id/credentials", msHandler.ListCredentials)
+		mirror.POST("/sources/:id/credentials", msHandler.CreateCredential)
+		mirror.PUT("/sources/:id/credentials/:credential_id", msHandler.UpdateCredential)
+		mirror.DELETE("/sources/:id/credentials/:credential_id", msHandler.DeleteCredential)
 		mirror.POST("/repo", mirrorHandler.CreateMirrorRepo)
 		mirror.GET("/repos", mirrorHandler.Repos)
+		mirror.GET("/dead_letters", mirrorHandler.DeadLetters)
+		mirror.POST("/dead_letters/:type/:id/retry", mirrorHandler.RetryDeadLetter)
+		mirror.DELETE("/dead_letters/:type/:id", mirrorHandler.DropDeadLetter)
+		mirror.GET("/queues", mirrorHandler.QueueStats)
+		mirror.GET("/queues/:type/:id", mirrorHandler.QueuePosition)
 
 	}
 
@@ -370,6 +399,12 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 	}
 	createDiscussionRoutes(apiGroup, needAPIKey, discussionHandler)
 
+	pullRequestHandler, err := handler.NewPullRequestHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pull request handler:%w", err)
+	}
+	createPullRequestRoutes(apiGroup, pullRequestHandler)
+
 	return r, nil
 }
 
@@ -385,14 +420,27 @@ func createModelRoutes(config *config.Config, apiGroup *gin.RouterGroup, needAPI
 		modelsGroup.GET("/:namespace/:name/all_files", modelHandler.AllFiles)
 		modelsGroup.GET("/:namespace/:name/relations", modelHandler.Relations)
 		modelsGroup.GET("/:namespace/:name/branches", middleware.RepoType(types.ModelRepo), repoCommonHandler.Branches)
+		modelsGroup.POST("/:namespace/:name/branches", middleware.RepoType(types.ModelRepo), repoCommonHandler.CreateBranch)
+		modelsGroup.PUT("/:namespace/:name/branches/*branch", middleware.RepoType(types.ModelRepo), repoCommonHandler.RenameBranch)
+		modelsGroup.DELETE("/:namespace/:name/branches/*branch", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeleteBranch)
+		modelsGroup.GET("/:namespace/:name/protected_branches", middleware.RepoType(types.ModelRepo), repoCommonHandler.ListProtectedBranches)
+		modelsGroup.POST("/:namespace/:name/protected_branches", middleware.RepoType(types.ModelRepo), repoCommonHandler.CreateProtectedBranch)
+		modelsGroup.PUT("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.ModelRepo), repoCommonHandler.UpdateProtectedBranch)
+		modelsGroup.DELETE("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeleteProtectedBranch)
+		modelsGroup.POST("/:namespace/:name/fork", middleware.RepoType(types.ModelRepo), repoCommonHandler.ForkRepo)
+		modelsGroup.POST("/:namespace/:name/refs/tags", middleware.RepoType(types.ModelRepo), repoCommonHandler.CreateGitTag)
+		modelsGroup.DELETE("/:namespace/:name/refs/tags/*tag", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeleteGitTag)
 		modelsGroup.GET("/:namespace/:name/tags", middleware.RepoType(types.ModelRepo), repoCommonHandler.Tags)
 		// update tags of a certain category
 		modelsGroup.POST("/:namespace/:name/tags/:category", middleware.RepoType(types.ModelRepo), repoCommonHandler.UpdateTags)
 		modelsGroup.GET("/:namespace/:name/last_commit", middleware.RepoType(types.ModelRepo), repoCommonHandler.LastCommit)
 		modelsGroup.GET("/:namespace/:name/commit/:commit_id", middleware.RepoType(types.ModelRepo), repoCommonHandler.CommitWithDiff)
+		modelsGroup.GET("/:namespace/:name/compare/*basehead", middleware.RepoType(types.ModelRepo), repoCommonHandler.CompareRefs)
 		modelsGroup.GET("/:namespace/:name/tree", middleware.RepoType(types.ModelRepo), repoCommonHandler.Tree)
 		modelsGroup.GET("/:namespace/:name/commits", middleware.RepoType(types.ModelRepo), repoCommonHandler.Commits)
+		modelsGroup.POST("/:namespace/:name/commits", middleware.RepoType(types.ModelRepo), repoCommonHandler.CommitFiles)
 		modelsGroup.GET("/:namespace/:name/raw/*file_path", middleware.RepoType(types.ModelRepo), repoCommonHandler.FileRaw)
+		modelsGroup.GET("/:namespace/:name/blame/*file_path", middleware.RepoType(types.ModelRepo), repoCommonHandler.FileBlame)
 		modelsGroup.GET("/:namespace/:name/blob/*file_path", middleware.RepoType(types.ModelRepo), repoCommonHandler.FileInfo)
 		// The DownloadFile method differs from the SDKDownload interface in a few ways
 
@@ -440,6 +488,14 @@ func createModelRoutes(config *config.Config, apiGroup *gin.RouterGroup, needAPI
 		modelsGroup.PUT("/:namespace/:name/run/:id", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeployUpdate)
 		modelsGroup.PUT("/:namespace/:name/run/:id/stop", middleware.RepoType(types.ModelRepo), modelHandler.DeployStop)
 		modelsGroup.PUT("/:namespace/:name/run/:id/start", middleware.RepoType(types.ModelRepo), modelHandler.DeployStart)
+		modelsGroup.POST("/:namespace/:name/run/:id/cancel", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeployCancel)
+		// api keys of the endpoint
+		modelsGroup.GET("/:namespace/:name/run/:id/keys", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeployKeyList)
+		modelsGroup.POST("/:namespace/:name/run/:id/keys", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeployKeyCreate)
+		modelsGroup.DELETE("/:namespace/:name/run/:id/keys/:key_id", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeployKeyRevoke)
+		modelsGroup.GET("/:namespace/:name/secrets", middleware.RepoType(types.ModelRepo), repoCommonHandler.ListSecrets)
+		modelsGroup.PUT("/:namespace/:name/secrets", middleware.RepoType(types.ModelRepo), repoCommonHandler.SetSecret)
+		modelsGroup.DELETE("/:namespace/:name/secrets/:secret", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeleteSecret)
 
 		// runtime framework for both finetune and inference
 		modelsGroup.GET("/runtime_framework", middleware.RepoType(types.ModelRepo), repoCommonHandler.RuntimeFrameworkListWithType)
@@ -476,15 +532,28 @@ func createDatasetRoutes(config *config.Config, apiGroup *gin.RouterGroup, dsHan
 		datasetsGroup.GET("/:namespace/:name/all_files", dsHandler.AllFiles)
 		datasetsGroup.GET("/:namespace/:name/relations", dsHandler.Relations)
 		datasetsGroup.GET("/:namespace/:name/branches", middleware.RepoType(types.DatasetRepo), repoCommonHandler.Branches)
+		datasetsGroup.POST("/:namespace/:name/branches", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CreateBranch)
+		datasetsGroup.PUT("/:namespace/:name/branches/*branch", middleware.RepoType(types.DatasetRepo), repoCommonHandler.RenameBranch)
+		datasetsGroup.DELETE("/:namespace/:name/branches/*branch", middleware.RepoType(types.DatasetRepo), repoCommonHandler.DeleteBranch)
+		datasetsGroup.GET("/:namespace/:name/protected_branches", middleware.RepoType(types.DatasetRepo), repoCommonHandler.ListProtectedBranches)
+		datasetsGroup.POST("/:namespace/:name/protected_branches", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CreateProtectedBranch)
+		datasetsGroup.PUT("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.DatasetRepo), repoCommonHandler.UpdateProtectedBranch)
+		datasetsGroup.DELETE("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.DatasetRepo), repoCommonHandler.DeleteProtectedBranch)
+		datasetsGroup.POST("/:namespace/:name/fork", middleware.RepoType(types.DatasetRepo), repoCommonHandler.ForkRepo)
+		datasetsGroup.POST("/:namespace/:name/refs/tags", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CreateGitTag)
+		datasetsGroup.DELETE("/:namespace/:name/refs/tags/*tag", middleware.RepoType(types.DatasetRepo), repoCommonHandler.DeleteGitTag)
 		datasetsGroup.GET("/:namespace/:name/tags", middleware.RepoType(types.DatasetRepo), repoCommonHandler.Tags)
 		// update tags of a certain category
 		datasetsGroup.POST("/:namespace/:name/tags/:category", middleware.RepoType(types.DatasetRepo), repoCommonHandler.UpdateTags)
 		datasetsGroup.GET("/:namespace/:name/last_commit", middleware.RepoType(types.DatasetRepo), repoCommonHandler.LastCommit)
 		datasetsGroup.GET("/:namespace/:name/commit/:commit_id", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CommitWithDiff)
+		datasetsGroup.GET("/:namespace/:name/compare/*basehead", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CompareRefs)
 		datasetsGroup.GET("/:namespace/:name/tree", middleware.RepoType(types.DatasetRepo), repoCommonHandler.Tree)
 		datasetsGroup.GET("/:namespace/:name/commits", middleware.RepoType(types.DatasetRepo), repoCommonHandler.Commits)
+		datasetsGroup.POST("/:namespace/:name/commits", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CommitFiles)
 		datasetsGroup.POST("/:namespace/:name/raw/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CreateFile)
 		datasetsGroup.GET("/:namespace/:name/raw/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.FileRaw)
+		datasetsGroup.GET("/:namespace/:name/blame/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.FileBlame)
 		datasetsGroup.GET("/:namespace/:name/blob/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.FileInfo)
 		datasetsGroup.GET("/:namespace/:name/download/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.DownloadFile)
 		datasetsGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.ResolveDownload)
@@ -515,15 +584,28 @@ func createCodeRoutes(config *config.Config, apiGroup *gin.RouterGroup, codeHand
 		codesGroup.GET("/:namespace/:name", codeHandler.Show)
 		codesGroup.GET("/:namespace/:name/relations", codeHandler.Relations)
 		codesGroup.GET("/:namespace/:name/branches", middleware.RepoType(types.CodeRepo), repoCommonHandler.Branches)
+		codesGroup.POST("/:namespace/:name/branches", middleware.RepoType(types.CodeRepo), repoCommonHandler.CreateBranch)
+		codesGroup.PUT("/:namespace/:name/branches/*branch", middleware.RepoType(types.CodeRepo), repoCommonHandler.RenameBranch)
+		codesGroup.DELETE("/:namespace/:name/branches/*branch", middleware.RepoType(types.CodeRepo), repoCommonHandler.DeleteBranch)
+		codesGroup.GET("/:namespace/:name/protected_branches", middleware.RepoType(types.CodeRepo), repoCommonHandler.ListProtectedBranches)
+		codesGroup.POST("/:namespace/:name/protected_branches", middleware.RepoType(types.CodeRepo), repoCommonHandler.CreateProtectedBranch)
+		codesGroup.PUT("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.CodeRepo), repoCommonHandler.UpdateProtectedBranch)
+		codesGroup.DELETE("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.CodeRepo), repoCommonHandler.DeleteProtectedBranch)
+		codesGroup.POST("/:namespace/:name/fork", middleware.RepoType(types.CodeRepo), repoCommonHandler.ForkRepo)
+		codesGroup.POST("/:namespace/:name/refs/tags", middleware.RepoType(types.CodeRepo), repoCommonHandler.CreateGitTag)
+		codesGroup.DELETE("/:namespace/:name/refs/tags/*tag", middleware.RepoType(types.CodeRepo), repoCommonHandler.DeleteGitTag)
 		codesGroup.GET("/:namespace/:name/tags", middleware.RepoType(types.CodeRepo), repoCommonHandler.Tags)
 		// update tags of a certain category
 		codesGroup.POST("/:namespace/:name/tags/:category", middleware.RepoType(types.CodeRepo), repoCommonHandler.UpdateTags)
 		codesGroup.GET("/:namespace/:name/last_commit", middleware.RepoType(types.CodeRepo), repoCommonHandler.LastCommit)
 		codesGroup.GET("/:namespace/:name/commit/:commit_id", middleware.RepoType(types.CodeRepo), repoCommonHandler.CommitWithDiff)
+		codesGroup.GET("/:namespace/:name/compare/*basehead", middleware.RepoType(types.CodeRepo), repoCommonHandler.CompareRefs)
 		codesGroup.GET("/:namespace/:name/tree", middleware.RepoType(types.CodeRepo), repoCommonHandler.Tree)
 		codesGroup.GET("/:namespace/:name/commits", middleware.RepoType(types.CodeRepo), repoCommonHandler.Commits)
+		codesGroup.POST("/:namespace/:name/commits", middleware.RepoType(types.CodeRepo), repoCommonHandler.CommitFiles)
 		codesGroup.POST("/:namespace/:name/raw/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.CreateFile)
 		codesGroup.GET("/:namespace/:name/raw/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.FileRaw)
+		codesGroup.GET("/:namespace/:name/blame/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.FileBlame)
 		codesGroup.GET("/:namespace/:name/blob/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.FileInfo)
 		codesGroup.GET("/:namespace/:name/download/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.DownloadFile)
 		codesGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.ResolveDownload)
@@ -568,15 +650,28 @@ func createSpaceRoutes(config *config.Config, apiGroup *gin.RouterGroup, spaceHa
 		spaces.POST("/:namespace/:name/webhook", nil)
 
 		spaces.GET("/:namespace/:name/branches", middleware.RepoType(types.SpaceRepo), repoCommonHandler.Branches)
+		spaces.POST("/:namespace/:name/branches", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CreateBranch)
+		spaces.PUT("/:namespace/:name/branches/*branch", middleware.RepoType(types.SpaceRepo), repoCommonHandler.RenameBranch)
+		spaces.DELETE("/:namespace/:name/branches/*branch", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeleteBranch)
+		spaces.GET("/:namespace/:name/protected_branches", middleware.RepoType(types.SpaceRepo), repoCommonHandler.ListProtectedBranches)
+		spaces.POST("/:namespace/:name/protected_branches", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CreateProtectedBranch)
+		spaces.PUT("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.SpaceRepo), repoCommonHandler.UpdateProtectedBranch)
+		spaces.DELETE("/:namespace/:name/protected_branches/:id", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeleteProtectedBranch)
+		spaces.POST("/:namespace/:name/fork", middleware.RepoType(types.SpaceRepo), repoCommonHandler.ForkRepo)
+		spaces.POST("/:namespace/:name/refs/tags", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CreateGitTag)
+		spaces.DELETE("/:namespace/:name/refs/tags/*tag", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeleteGitTag)
 		spaces.GET("/:namespace/:name/tags", middleware.RepoType(types.SpaceRepo), repoCommonHandler.Tags)
 		// update tags of a certain category
 		spaces.POST("/:namespace/:name/tags/:category", middleware.RepoType(types.SpaceRepo), repoCommonHandler.UpdateTags)
 		spaces.GET("/:namespace/:name/last_commit", middleware.RepoType(types.SpaceRepo), repoCommonHandler.LastCommit)
 		spaces.GET("/:namespace/:name/commit/:commit_id", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CommitWithDiff)
+		spaces.GET("/:namespace/:name/compare/*basehead", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CompareRefs)
 		spaces.GET("/:namespace/:name/tree", middleware.RepoType(types.SpaceRepo), repoCommonHandler.Tree)
 		spaces.GET("/:namespace/:name/commits", middleware.RepoType(types.SpaceRepo), repoCommonHandler.Commits)
+		spaces.POST("/:namespace/:name/commits", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CommitFiles)
 		spaces.POST("/:namespace/:name/raw/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CreateFile)
 		spaces.GET("/:namespace/:name/raw/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.FileRaw)
+		spaces.GET("/:namespace/:name/blame/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.FileBlame)
 		spaces.GET("/:namespace/:name/blob/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.FileInfo)
 		spaces.GET("/:namespace/:name/download/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DownloadFile)
 		spaces.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.ResolveDownload)
@@ -598,10 +693,14 @@ func createSpaceRoutes(config *config.Config, apiGroup *gin.RouterGroup, spaceHa
 		spaces.GET("/:namespace/:name/run/:id", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeployDetail)
 		spaces.GET("/:namespace/:name/run/:id/status", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeployStatus)
 		spaces.GET("/:namespace/:name/run/:id/logs/:instance", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeployInstanceLogs)
+		spaces.POST("/:namespace/:name/run/:id/cancel", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeployCancel)
+		spaces.GET("/:namespace/:name/secrets", middleware.RepoType(types.SpaceRepo), repoCommonHandler.ListSecrets)
+		spaces.PUT("/:namespace/:name/secrets", middleware.RepoType(types.SpaceRepo), repoCommonHandler.SetSecret)
+		spaces.DELETE("/:namespace/:name/secrets/:secret", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeleteSecret)
 	}
 }
 
-func createUserRoutes(apiGroup *gin.RouterGroup, needAPIKey gin.HandlerFunc, userProxyHandler *handler.InternalServiceProxyHandler, userHandler *handler.UserHandler) {
+func createUserRoutes(apiGroup *gin.RouterGroup, needAPIKey gin.HandlerFunc, userProxyHandler *handler.InternalServiceProxyHandler, userHandler *handler.UserHandler, quotaHandler *handler.QuotaHandler) {
 	// depricated
 	{
 		apiGroup.POST("/users", userProxyHandler.ProxyToApi("/api/v1/user"))
@@ -631,6 +730,7 @@ func createUserRoutes(apiGroup *gin.RouterGroup, needAPIKey gin.HandlerFunc, use
 		apiGroup.GET("/user/:username/likes/datasets", userHandler.LikesDatasets)
 		apiGroup.GET("/user/:username/run/:repo_type", userHandler.GetRunDeploys)
 		apiGroup.GET("/user/:username/finetune/instances", userHandler.GetFinetuneInstances)
+		apiGroup.GET("/user/:username/quota", quotaHandler.UserQuota)
 	}
 
 	// User collection
@@ -667,7 +767,29 @@ func createAccountRoutes(apiGroup *gin.RouterGroup, needAPIKey gin.HandlerFunc,
 		meterGroup := accountingGroup.Group("/metering")
 		{
 			meterGroup.GET("/:id/statements", accountingHandler.QueryMeteringStatementByUserID)
+			meterGroup.GET("/:id/monthly_totals", accountingHandler.QueryMonthlyTotalsByUserID)
 		}
+		orgMeterGroup := accountingGroup.Group("/organizations/:name/metering")
+		{
+			orgMeterGroup.GET("/statements", accountingHandler.QueryMeteringStatementByOrgName)
+			orgMeterGroup.GET("/monthly_totals", accountingHandler.QueryMonthlyTotalsByOrgName)
+		}
+		priceGroup := accountingGroup.Group("/prices")
+		{
+			priceGroup.GET("", accountingHandler.PricesList)
+			priceGroup.GET("/:id", accountingHandler.PriceGet)
+			priceGroup.POST("", needAPIKey, accountingHandler.PriceCreate)
+			priceGroup.PUT("/:id", needAPIKey, accountingHandler.PriceUpdate)
+			priceGroup.DELETE("/:id", needAPIKey, accountingHandler.PriceDelete)
+		}
+		balanceGroup := accountingGroup.Group("/balances")
+		{
+			balanceGroup.GET("/:owner_type/:owner_id", accountingHandler.BalanceGet)
+			balanceGroup.GET("/:owner_type/:owner_id/entries", accountingHandler.BalanceEntriesList)
+			balanceGroup.POST("/:owner_type/:owner_id/topup", needAPIKey, accountingHandler.BalanceTopUp)
+			balanceGroup.POST("/:owner_type/:owner_id/adjust", needAPIKey, accountingHandler.BalanceAdjust)
+		}
+		accountingGroup.GET("/usage", accountingHandler.UsageList)
 	}
 }
 
@@ -715,3 +837,25 @@ func createDiscussionRoutes(apiGroup *gin.RouterGroup, needAPIKey gin.HandlerFun
 	apiGroup.PUT("/discussions/:id/comments/:comment_id", discussionHandler.UpdateComment)
 	apiGroup.DELETE("/discussions/:id/comments/:comment_id", discussionHandler.DeleteComment)
 }
+
+func createPullRequestRoutes(apiGroup *gin.RouterGroup, pullRequestHandler *handler.PullRequestHandler) {
+	repoTypes := map[string]types.RepositoryType{
+		"/models":   types.ModelRepo,
+		"/datasets": types.DatasetRepo,
+		"/codes":    types.CodeRepo,
+		"/spaces":   types.SpaceRepo,
+	}
+	for prefix, repoType := range repoTypes {
+		pullsGroup := apiGroup.Group(prefix+"/:namespace/:name/pulls", middleware.RepoType(repoType))
+		{
+			pullsGroup.POST("", pullRequestHandler.CreatePullRequest)
+			pullsGroup.GET("", pullRequestHandler.ListPullRequests)
+			pullsGroup.GET("/:id", pullRequestHandler.GetPullRequest)
+			pullsGroup.PUT("/:id", pullRequestHandler.UpdatePullRequest)
+			pullsGroup.PUT("/:id/merge", pullRequestHandler.MergePullRequest)
+			pullsGroup.PUT("/:id/close", pullRequestHandler.ClosePullRequest)
+			pullsGroup.POST("/:id/comments", pullRequestHandler.CreatePullRequestComment)
+			pullsGroup.GET("/:id/comments", pullRequestHandler.ListPullRequestComments)
+		}
+	}
+}
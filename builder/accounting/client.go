@@ -7,6 +7,9 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
@@ -36,6 +39,112 @@ func (ac *AccountingClient) ListMeteringsByUserIDAndTime(req types.ACCT_STATEMEN
 	return ac.handleResponse(ac.doRequest(http.MethodGet, subUrlPath, nil))
 }
 
+func (ac *AccountingClient) ListMonthlyTotalsByUserID(req types.ACCT_MONTHLY_REQ) (interface{}, error) {
+	subUrlPath := fmt.Sprintf("/metering/%s/monthly_totals?start_month=%s&end_month=%s", req.UserUUID, url.QueryEscape(req.StartMonth), url.QueryEscape(req.EndMonth))
+	if req.Scene > 0 {
+		subUrlPath = fmt.Sprintf("%s&scene=%d", subUrlPath, req.Scene)
+	}
+	return ac.handleResponse(ac.doRequest(http.MethodGet, subUrlPath, nil))
+}
+
+func (ac *AccountingClient) ListMeteringsByOrgNameAndTime(req types.ACCT_STATEMENTS_REQ) (interface{}, error) {
+	subUrlPath := fmt.Sprintf("/organizations/%s/metering/statements?scene=%d&instance_name=%s&start_time=%s&end_time=%s&per=%d&page=%d", url.PathEscape(req.OrgName), req.Scene, url.QueryEscape(req.InstanceName), url.QueryEscape(req.StartTime), url.QueryEscape(req.EndTime), req.Per, req.Page)
+	return ac.handleResponse(ac.doRequest(http.MethodGet, subUrlPath, nil))
+}
+
+func (ac *AccountingClient) ListMonthlyTotalsByOrgName(req types.ACCT_MONTHLY_REQ) (interface{}, error) {
+	subUrlPath := fmt.Sprintf("/organizations/%s/metering/monthly_totals?start_month=%s&end_month=%s", url.PathEscape(req.OrgName), url.QueryEscape(req.StartMonth), url.QueryEscape(req.EndMonth))
+	if req.Scene > 0 {
+		subUrlPath = fmt.Sprintf("%s&scene=%d", subUrlPath, req.Scene)
+	}
+	return ac.handleResponse(ac.doRequest(http.MethodGet, subUrlPath, nil))
+}
+
+func (ac *AccountingClient) ListPrices(req types.ACCT_PRICE_QUERY) (interface{}, error) {
+	subUrlPath := fmt.Sprintf("/prices?sku_id=%s&per=%d&page=%d", url.QueryEscape(req.SkuID), req.Per, req.Page)
+	if req.Scene > 0 {
+		subUrlPath = fmt.Sprintf("%s&scene=%d", subUrlPath, req.Scene)
+	}
+	return ac.handleResponse(ac.doRequest(http.MethodGet, subUrlPath, nil))
+}
+
+func (ac *AccountingClient) GetPrice(id int64) (interface{}, error) {
+	return ac.handleResponse(ac.doRequest(http.MethodGet, fmt.Sprintf("/prices/%d", id), nil))
+}
+
+func (ac *AccountingClient) CreatePrice(req types.ACCT_PRICE_REQ) (interface{}, error) {
+	return ac.handleResponse(ac.doRequest(http.MethodPost, "/prices", req))
+}
+
+func (ac *AccountingClient) UpdatePrice(id int64, req types.ACCT_PRICE_REQ) (interface{}, error) {
+	return ac.handleResponse(ac.doRequest(http.MethodPut, fmt.Sprintf("/prices/%d", id), req))
+}
+
+func (ac *AccountingClient) DeletePrice(id int64) (interface{}, error) {
+	return ac.handleResponse(ac.doRequest(http.MethodDelete, fmt.Sprintf("/prices/%d", id), nil))
+}
+
+func (ac *AccountingClient) GetBalance(ownerType types.BalanceOwnerType, ownerID string) (*types.ACCT_BALANCE, error) {
+	resp, err := ac.doRequest(http.MethodGet, fmt.Sprintf("/balances/%s/%s", ownerType, url.PathEscape(ownerID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var balance types.ACCT_BALANCE
+	if err := ac.decodeResponse(resp, &balance); err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+func (ac *AccountingClient) ListBalanceEntries(req types.ACCT_BALANCE_ENTRIES_REQ) (interface{}, error) {
+	subUrlPath := fmt.Sprintf("/balances/%s/%s/entries?per=%d&page=%d", req.OwnerType, url.PathEscape(req.OwnerID), req.Per, req.Page)
+	return ac.handleResponse(ac.doRequest(http.MethodGet, subUrlPath, nil))
+}
+
+func (ac *AccountingClient) ListUsage(req types.ACCT_USAGE_REQ) (interface{}, error) {
+	query := url.Values{}
+	query.Set("start_date", req.StartDate)
+	query.Set("end_date", req.EndDate)
+	query.Set("bucket", string(req.Bucket))
+	if len(req.GroupBy) > 0 {
+		query.Set("group_by", strings.Join(req.GroupBy, ","))
+	}
+	if req.UserUUID != "" {
+		query.Set("user_uuid", req.UserUUID)
+	}
+	if req.OrgName != "" {
+		query.Set("org_name", req.OrgName)
+	}
+	if req.Scene > 0 {
+		query.Set("scene", strconv.Itoa(req.Scene))
+	}
+	if req.SkuID != "" {
+		query.Set("sku_id", req.SkuID)
+	}
+	return ac.handleResponse(ac.doRequest(http.MethodGet, "/usage?"+query.Encode(), nil))
+}
+
+func (ac *AccountingClient) TopUpBalance(ownerType types.BalanceOwnerType, ownerID string, req types.ACCT_BALANCE_REQ) (interface{}, error) {
+	return ac.handleResponse(ac.doRequest(http.MethodPost, fmt.Sprintf("/balances/%s/%s/topup", ownerType, url.PathEscape(ownerID)), req))
+}
+
+func (ac *AccountingClient) AdjustBalance(ownerType types.BalanceOwnerType, ownerID string, req types.ACCT_BALANCE_REQ) (interface{}, error) {
+	return ac.handleResponse(ac.doRequest(http.MethodPost, fmt.Sprintf("/balances/%s/%s/adjust", ownerType, url.PathEscape(ownerID)), req))
+}
+
+// ListOverdueBalances lists the balances which have been negative for longer than the grace period
+func (ac *AccountingClient) ListOverdueBalances(grace time.Duration) ([]types.ACCT_BALANCE, error) {
+	resp, err := ac.doRequest(http.MethodGet, fmt.Sprintf("/balances/overdue?grace_minutes=%d", int(grace.Minutes())), nil)
+	if err != nil {
+		return nil, err
+	}
+	var balances []types.ACCT_BALANCE
+	if err := ac.decodeResponse(resp, &balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
 // Helper method to execute the actual HTTP request and read the response.
 func (ac *AccountingClient) doRequest(method, subPath string, data interface{}) (*http.Response, error) {
 	urlPath := fmt.Sprintf("%s%s%s", ac.remote, "/api/v1/accounting", subPath)
@@ -90,3 +199,13 @@ func (ac *AccountingClient) handleResponse(response *http.Response, err error) (
 	}
 	return res.Data, nil
 }
+
+// decodeResponse decodes the data of the response into the given value
+func (ac *AccountingClient) decodeResponse(response *http.Response, data interface{}) error {
+	defer response.Body.Close()
+	res := struct {
+		Msg  string      `json:"msg"`
+		Data interface{} `json:"data"`
+	}{Data: data}
+	return json.NewDecoder(response.Body).Decode(&res)
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
-	"math/rand"
 	"path/filepath"
 	"strings"
 
@@ -34,12 +33,17 @@ type Cluster struct {
 type ClusterPool struct {
 	Clusters     []Cluster
 	ClusterStore *database.ClusterInfoStore
+	// Placement chooses the cluster for services which do not pin one
+	Placement PlacementPolicy
+	// node resources of the clusters seen by recent placements
+	nodeCache nodeResourceCache
 }
 
 // NewClusterPool initializes and returns a ClusterPool by reading kubeconfig files from $HOME/.kube directory
 func NewClusterPool() (*ClusterPool, error) {
 	pool := &ClusterPool{}
 	pool.ClusterStore = database.NewClusterInfoStore()
+	pool.Placement = &LeastLoadedPolicy{}
 
 	home := homedir.HomeDir()
 	kubeconfigFolderPath := filepath.Join(home, ".kube")
@@ -83,20 +87,6 @@ func NewClusterPool() (*ClusterPool, error) {
 	return pool, nil
 }
 
-// SelectCluster selects the most appropriate cluster to deploy the service to
-func (p *ClusterPool) GetCluster() (*Cluster, error) {
-	if len(p.Clusters) == 0 {
-		return nil, fmt.Errorf("no available clusters")
-	}
-	// Randomly choose a cluster to deploy the service to
-	// to do: The cluster should be selected based on criteria such as availability, performance, load, etc.
-	randomIndex := rand.Intn(len(p.Clusters))
-
-	// Select a cluster using the random index
-	selectedCluster := p.Clusters[randomIndex]
-	return &selectedCluster, nil
-}
-
 // GetClusterByID retrieves a cluster from the pool given its unique ID
 func (p *ClusterPool) GetClusterByID(ctx context.Context, id string) (*Cluster, error) {
 	cfId := "config"
@@ -117,12 +107,16 @@ func (p *ClusterPool) GetClusterByID(ctx context.Context, id string) (*Cluster,
 
 // getNodeResources retrieves all node cpu and gpu info
 func GetNodeResources(clientset *kubernetes.Clientset, config *config.Config) (map[string]types.NodeResourceInfo, error) {
-	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	return getNodeResources(context.TODO(), clientset, config)
+}
+
+func getNodeResources(ctx context.Context, clientset *kubernetes.Clientset, config *config.Config) (map[string]types.NodeResourceInfo, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	pods, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +154,7 @@ func GetNodeResources(clientset *kubernetes.Clientset, config *config.Config) (m
 			AvailableMem:     totalMem,
 			TotalMem:         totalMem,
 			XPUCapacityLabel: xpuCapacityLabel,
+			Labels:           node.Labels,
 		}
 	}
 	for _, pod := range pods.Items {
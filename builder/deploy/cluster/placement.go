@@ -0,0 +1,307 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const (
+	PlacementLeastLoaded = "least_loaded"
+	PlacementBinPacking  = "bin_packing"
+	PlacementRandom      = "random"
+)
+
+// PlacementRequest describes the service to place
+type PlacementRequest struct {
+	Hardware types.HardWare
+	// clusters in the zone are preferred when they have enough resources
+	Zone string
+	// only nodes with all the labels can run the service
+	NodeLabels map[string]string
+}
+
+// ClusterCandidate is an enabled cluster with the nodes able to run the service
+type ClusterCandidate struct {
+	Cluster Cluster
+	Info    database.ClusterInfo
+	// nodes matching the label constraints
+	Nodes []types.NodeResourceInfo
+	// ratio of free resources on the matching nodes, between 0 and 1
+	FreeRatio float64
+}
+
+// PlacementDecision is the chosen cluster and why it was chosen
+type PlacementDecision struct {
+	Cluster   *Cluster
+	ClusterID string
+	Reason    string
+}
+
+// PlacementPolicy picks one of the clusters that have enough resources for a service
+type PlacementPolicy interface {
+	Name() string
+	Choose(candidates []ClusterCandidate) *ClusterCandidate
+}
+
+// NewPlacementPolicy returns the policy by name, least loaded is used for unknown names
+func NewPlacementPolicy(name string) PlacementPolicy {
+	switch name {
+	case PlacementBinPacking:
+		return &BinPackingPolicy{}
+	case PlacementRandom:
+		return &RandomPolicy{}
+	case PlacementLeastLoaded, "":
+	default:
+		slog.Warn("unknown cluster placement policy, fallback to least loaded", slog.String("policy", name))
+	}
+	return &LeastLoadedPolicy{}
+}
+
+// LeastLoadedPolicy spreads services to the cluster with the most free resources
+type LeastLoadedPolicy struct{}
+
+func (p *LeastLoadedPolicy) Name() string { return PlacementLeastLoaded }
+
+func (p *LeastLoadedPolicy) Choose(candidates []ClusterCandidate) *ClusterCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].FreeRatio > candidates[j].FreeRatio
+	})
+	return &candidates[0]
+}
+
+// BinPackingPolicy fills up the busiest cluster which still fits, keeping other clusters free for large services
+type BinPackingPolicy struct{}
+
+func (p *BinPackingPolicy) Name() string { return PlacementBinPacking }
+
+func (p *BinPackingPolicy) Choose(candidates []ClusterCandidate) *ClusterCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].FreeRatio < candidates[j].FreeRatio
+	})
+	return &candidates[0]
+}
+
+// RandomPolicy picks any cluster which fits
+type RandomPolicy struct{}
+
+func (p *RandomPolicy) Name() string { return PlacementRandom }
+
+func (p *RandomPolicy) Choose(candidates []ClusterCandidate) *ClusterCandidate {
+	return &candidates[rand.Intn(len(candidates))]
+}
+
+// placementFetchTimeout bounds how long a placement waits for the node resources of one cluster
+const placementFetchTimeout = 10 * time.Second
+
+// PlaceService chooses a cluster with enough free resources for the service using the placement policy of the pool
+func (p *ClusterPool) PlaceService(ctx context.Context, cfg *config.Config, req PlacementRequest) (*PlacementDecision, error) {
+	if len(p.Clusters) == 0 {
+		return nil, fmt.Errorf("no available clusters")
+	}
+	policy := p.Placement
+	if policy == nil {
+		policy = &LeastLoadedPolicy{}
+	}
+
+	var enabled []ClusterCandidate
+	for _, c := range p.Clusters {
+		info, err := p.ClusterStore.ByClusterConfig(ctx, c.ID)
+		if err != nil || !info.Enable {
+			continue
+		}
+		candidate := ClusterCandidate{Cluster: c, Info: info}
+		candidate.Cluster.StorageClass = info.StorageClass
+		enabled = append(enabled, candidate)
+	}
+
+	ttl := time.Duration(cfg.Space.PlacementCacheSeconds) * time.Second
+	nodes := p.nodeCache.load(ctx, enabled, ttl, func(ctx context.Context, c Cluster) (map[string]types.NodeResourceInfo, error) {
+		return getNodeResources(ctx, c.Client, cfg)
+	})
+	decision, err := choosePlacement(policy, enabled, nodes, req, len(p.Clusters))
+	if err != nil {
+		return nil, err
+	}
+	// the chosen cluster has less room now, load it again for the next placement
+	p.nodeCache.invalidate(decision.Cluster.ID)
+	return decision, nil
+}
+
+// choosePlacement keeps the clusters with a node fitting the request and lets the policy pick one of them,
+// nodes holds the node resources by cluster id, clusters missing from it are skipped
+func choosePlacement(policy PlacementPolicy, enabled []ClusterCandidate, nodes map[string]map[string]types.NodeResourceInfo, req PlacementRequest, total int) (*PlacementDecision, error) {
+	var candidates []ClusterCandidate
+	for _, candidate := range enabled {
+		clusterNodes, ok := nodes[candidate.Cluster.ID]
+		if !ok {
+			continue
+		}
+		fits := false
+		for _, node := range clusterNodes {
+			if !matchLabels(node.Labels, req.NodeLabels) {
+				continue
+			}
+			candidate.Nodes = append(candidate.Nodes, node)
+			if common.NodeHasResource(node, &req.Hardware) {
+				fits = true
+			}
+		}
+		if !fits {
+			continue
+		}
+		candidate.FreeRatio = freeRatio(candidate.Nodes, &req.Hardware)
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no cluster has enough resources for the service")
+	}
+
+	fitCount := len(candidates)
+	zoneNote := ""
+	if req.Zone != "" {
+		var inZone []ClusterCandidate
+		for _, candidate := range candidates {
+			if candidate.Info.Zone == req.Zone {
+				inZone = append(inZone, candidate)
+			}
+		}
+		if len(inZone) > 0 {
+			candidates = inZone
+			zoneNote = fmt.Sprintf(", preferred zone %s", req.Zone)
+		} else {
+			zoneNote = fmt.Sprintf(", no cluster fits in preferred zone %s", req.Zone)
+		}
+	}
+
+	chosen := policy.Choose(candidates)
+	reason := fmt.Sprintf("%s policy: %d of %d clusters fit%s, chose zone %s with %.0f%% resources free",
+		policy.Name(), fitCount, total, zoneNote, chosen.Info.Zone, chosen.FreeRatio*100)
+	return &PlacementDecision{
+		Cluster:   &chosen.Cluster,
+		ClusterID: chosen.Info.ClusterID,
+		Reason:    reason,
+	}, nil
+}
+
+type nodeResourceEntry struct {
+	nodes     map[string]types.NodeResourceInfo
+	fetchedAt time.Time
+}
+
+// nodeResourceCache keeps the node resources of each cluster for a short time, so placing many
+// services does not list all nodes and pods of every cluster each time
+type nodeResourceCache struct {
+	mu      sync.Mutex
+	entries map[string]nodeResourceEntry
+}
+
+type nodeResourceFetcher func(ctx context.Context, c Cluster) (map[string]types.NodeResourceInfo, error)
+
+// load returns the node resources by cluster id, expired clusters are fetched in parallel and
+// clusters failing to respond in time are left out
+func (nc *nodeResourceCache) load(ctx context.Context, candidates []ClusterCandidate, ttl time.Duration, fetch nodeResourceFetcher) map[string]map[string]types.NodeResourceInfo {
+	result := make(map[string]map[string]types.NodeResourceInfo, len(candidates))
+	var expired []Cluster
+	now := time.Now()
+	nc.mu.Lock()
+	for _, candidate := range candidates {
+		entry, ok := nc.entries[candidate.Cluster.ID]
+		if ok && now.Sub(entry.fetchedAt) < ttl {
+			result[candidate.Cluster.ID] = entry.nodes
+			continue
+		}
+		expired = append(expired, candidate.Cluster)
+	}
+	nc.mu.Unlock()
+
+	var wg sync.WaitGroup
+	fetched := make([]map[string]types.NodeResourceInfo, len(expired))
+	for i, c := range expired {
+		wg.Add(1)
+		go func(i int, c Cluster) {
+			defer wg.Done()
+			fetchCtx, cancel := context.WithTimeout(ctx, placementFetchTimeout)
+			defer cancel()
+			nodes, err := fetch(fetchCtx, c)
+			if err != nil {
+				slog.Warn("skip cluster failed to get node resources for placement", slog.String("cluster_config", c.ID), slog.Any("error", err))
+				return
+			}
+			fetched[i] = nodes
+		}(i, c)
+	}
+	wg.Wait()
+
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if nc.entries == nil {
+		nc.entries = make(map[string]nodeResourceEntry)
+	}
+	for i, c := range expired {
+		if fetched[i] == nil {
+			continue
+		}
+		nc.entries[c.ID] = nodeResourceEntry{nodes: fetched[i], fetchedAt: now}
+		result[c.ID] = fetched[i]
+	}
+	return result
+}
+
+func (nc *nodeResourceCache) invalidate(clusterID string) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	delete(nc.entries, clusterID)
+}
+
+func matchLabels(nodeLabels, required map[string]string) bool {
+	for k, v := range required {
+		if nodeLabels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// freeRatio averages the free ratio of memory, cpu and, for gpu services, xpu over the nodes
+func freeRatio(nodes []types.NodeResourceInfo, hardware *types.HardWare) float64 {
+	var (
+		totalMem, availMem float64
+		totalCPU, availCPU float64
+		totalXPU, availXPU float64
+	)
+	for _, node := range nodes {
+		totalMem += float64(node.TotalMem)
+		availMem += float64(node.AvailableMem)
+		totalCPU += node.TotalCPU
+		availCPU += node.AvailableCPU
+		totalXPU += float64(node.TotalXPU)
+		availXPU += float64(node.AvailableXPU)
+	}
+	ratios := []float64{ratio(availMem, totalMem), ratio(availCPU, totalCPU)}
+	if gpu, _ := strconv.Atoi(hardware.Gpu.Num); gpu > 0 {
+		ratios = append(ratios, ratio(availXPU, totalXPU))
+	}
+	var sum float64
+	for _, r := range ratios {
+		sum += r
+	}
+	return sum / float64(len(ratios))
+}
+
+func ratio(avail, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return max(avail, 0) / total
+}
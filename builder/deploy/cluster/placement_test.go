@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func placementCandidate(id, zone string) ClusterCandidate {
+	return ClusterCandidate{
+		Cluster: Cluster{ID: id},
+		Info:    database.ClusterInfo{ClusterID: id + "-uuid", ClusterConfig: id, Zone: zone, Enable: true},
+	}
+}
+
+func placementNode(availMem, totalMem float32, labels map[string]string) types.NodeResourceInfo {
+	return types.NodeResourceInfo{
+		TotalMem:     totalMem,
+		AvailableMem: availMem,
+		TotalCPU:     8,
+		AvailableCPU: 8 * float64(availMem/totalMem),
+		Labels:       labels,
+	}
+}
+
+func placementNodes() map[string]map[string]types.NodeResourceInfo {
+	return map[string]map[string]types.NodeResourceInfo{
+		"busy": {"n1": placementNode(8, 64, map[string]string{"pool": "gpu"})},
+		"idle": {"n1": placementNode(56, 64, map[string]string{"pool": "cpu"})},
+		"full": {"n1": placementNode(1, 64, nil)},
+	}
+}
+
+func TestChoosePlacement_policies(t *testing.T) {
+	enabled := []ClusterCandidate{placementCandidate("busy", "a"), placementCandidate("idle", "b"), placementCandidate("full", "a")}
+	req := PlacementRequest{Hardware: types.HardWare{Memory: "4Gi"}}
+
+	decision, err := choosePlacement(&LeastLoadedPolicy{}, enabled, placementNodes(), req, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.ClusterID != "idle-uuid" {
+		t.Errorf("least loaded should choose idle, got %s", decision.ClusterID)
+	}
+	if !strings.Contains(decision.Reason, "2 of 3 clusters fit") {
+		t.Errorf("unexpected reason %s", decision.Reason)
+	}
+
+	decision, err = choosePlacement(&BinPackingPolicy{}, enabled, placementNodes(), req, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.ClusterID != "busy-uuid" {
+		t.Errorf("bin packing should choose busy, got %s", decision.ClusterID)
+	}
+}
+
+func TestChoosePlacement_constraints(t *testing.T) {
+	enabled := []ClusterCandidate{placementCandidate("busy", "a"), placementCandidate("idle", "b"), placementCandidate("full", "a")}
+
+	decision, err := choosePlacement(&LeastLoadedPolicy{}, enabled, placementNodes(), PlacementRequest{
+		Hardware: types.HardWare{Memory: "4Gi"},
+		Zone:     "a",
+	}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.ClusterID != "busy-uuid" || !strings.Contains(decision.Reason, "preferred zone a") {
+		t.Errorf("clusters in the preferred zone should win, got %s: %s", decision.ClusterID, decision.Reason)
+	}
+
+	decision, err = choosePlacement(&LeastLoadedPolicy{}, enabled, placementNodes(), PlacementRequest{
+		Hardware: types.HardWare{Memory: "4Gi"},
+		Zone:     "c",
+	}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.ClusterID != "idle-uuid" || !strings.Contains(decision.Reason, "no cluster fits in preferred zone c") {
+		t.Errorf("a missing zone should fall back to all clusters, got %s: %s", decision.ClusterID, decision.Reason)
+	}
+
+	decision, err = choosePlacement(&LeastLoadedPolicy{}, enabled, placementNodes(), PlacementRequest{
+		Hardware:   types.HardWare{Memory: "4Gi"},
+		NodeLabels: map[string]string{"pool": "gpu"},
+	}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.ClusterID != "busy-uuid" {
+		t.Errorf("only nodes with the labels should count, got %s", decision.ClusterID)
+	}
+
+	_, err = choosePlacement(&LeastLoadedPolicy{}, enabled, placementNodes(), PlacementRequest{
+		Hardware: types.HardWare{Memory: "128Gi"},
+	}, 3)
+	if err == nil {
+		t.Error("expect no cluster to fit")
+	}
+
+	// clusters without node resources are skipped
+	_, err = choosePlacement(&LeastLoadedPolicy{}, enabled, nil, PlacementRequest{
+		Hardware: types.HardWare{Memory: "4Gi"},
+	}, 3)
+	if err == nil {
+		t.Error("expect no cluster without node resources")
+	}
+}
+
+func TestFreeRatio(t *testing.T) {
+	nodes := []types.NodeResourceInfo{
+		{TotalMem: 10, AvailableMem: 5, TotalCPU: 10, AvailableCPU: 10, TotalXPU: 4, AvailableXPU: 0},
+	}
+	if r := freeRatio(nodes, &types.HardWare{}); r != 0.75 {
+		t.Errorf("expect cpu and memory ratio 0.75, got %f", r)
+	}
+	gpu := &types.HardWare{}
+	gpu.Gpu.Num = "1"
+	if r := freeRatio(nodes, gpu); r != 0.5 {
+		t.Errorf("expect cpu, memory and xpu ratio 0.5, got %f", r)
+	}
+	if r := ratio(-1, 10); r != 0 {
+		t.Errorf("overcommitted resources should count as 0, got %f", r)
+	}
+}
+
+func TestNodeResourceCache(t *testing.T) {
+	var cache nodeResourceCache
+	var calls atomic.Int32
+	fetch := func(ctx context.Context, c Cluster) (map[string]types.NodeResourceInfo, error) {
+		calls.Add(1)
+		if c.ID == "broken" {
+			return nil, errors.New("unreachable")
+		}
+		return map[string]types.NodeResourceInfo{"n1": {NodeName: c.ID}}, nil
+	}
+	candidates := []ClusterCandidate{placementCandidate("a", ""), placementCandidate("b", ""), placementCandidate("broken", "")}
+
+	nodes := cache.load(context.Background(), candidates, time.Minute, fetch)
+	if len(nodes) != 2 || calls.Load() != 3 {
+		t.Fatalf("expect 2 clusters from 3 fetches, got %d from %d", len(nodes), calls.Load())
+	}
+
+	// cached clusters are not fetched again, failed ones are retried
+	nodes = cache.load(context.Background(), candidates, time.Minute, fetch)
+	if len(nodes) != 2 || calls.Load() != 4 {
+		t.Errorf("expect only the failed cluster to be fetched again, got %d fetches", calls.Load())
+	}
+
+	cache.invalidate("a")
+	cache.load(context.Background(), candidates[:1], time.Minute, fetch)
+	if calls.Load() != 5 {
+		t.Errorf("expect an invalidated cluster to be fetched again, got %d fetches", calls.Load())
+	}
+
+	cache.load(context.Background(), candidates[1:2], 0, fetch)
+	if calls.Load() != 6 {
+		t.Errorf("expect an expired cluster to be fetched again, got %d fetches", calls.Load())
+	}
+}
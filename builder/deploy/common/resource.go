@@ -0,0 +1,35 @@
+package common
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+// NodeHasResource reports whether the available resources of a node meet the hardware requirements
+func NodeHasResource(node types.NodeResourceInfo, hardware *types.HardWare) bool {
+	mem, err := strconv.Atoi(strings.Replace(hardware.Memory, "Gi", "", -1))
+	if err != nil {
+		slog.Error("failed to parse hardware memory ", slog.Any("error", err))
+		return false
+	}
+	if float32(mem) > node.AvailableMem {
+		return false
+	}
+	if hardware.Gpu.Num == "" {
+		return true
+	}
+	gpu, err := strconv.Atoi(hardware.Gpu.Num)
+	if err != nil {
+		slog.Error("failed to parse hardware gpu ", slog.Any("error", err))
+		return false
+	}
+	cpu, err := strconv.Atoi(hardware.Cpu.Num)
+	if err != nil {
+		slog.Error("failed to parse hardware cpu ", slog.Any("error", err))
+		return false
+	}
+	return gpu <= int(node.AvailableXPU) && hardware.Gpu.Type == node.XPUModel && cpu <= int(node.AvailableCPU)
+}
@@ -18,4 +18,5 @@ const (
 	Sleeping     = 25
 	Stopped      = 26
 	Deleted      = 27 // end user trigger delete action for deploy
+	Cancelled    = 28 // end user cancel the build or deploy task
 )
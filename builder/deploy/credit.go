@@ -0,0 +1,139 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/deploy/scheduler"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// ErrNoTaskToCancel is returned by Cancel when the deploy has no unfinished task
+var ErrNoTaskToCancel = scheduler.ErrNoTaskToCancel
+
+// splitGitPath returns the namespace and name of the repo of a git path like `models_ns/name`
+func splitGitPath(gitPath string) (namespace, name string) {
+	_, path, _ := strings.Cut(gitPath, "_")
+	namespace, name, _ = strings.Cut(path, "/")
+	return namespace, name
+}
+
+// billingOwner returns the balance a deploy is billed to, deploys of repos under an organization
+// are billed to the organization, others to the user who deployed them
+func (d *deployer) billingOwner(ctx context.Context, userUUID, gitPath string) (types.BalanceOwnerType, string, error) {
+	namespace, _ := splitGitPath(gitPath)
+	ns, err := d.namespaceStore.FindByPath(ctx, namespace)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find namespace %s, %w", namespace, err)
+	}
+	if ns.NamespaceType == database.OrgNamespace {
+		return types.BalanceOwnerOrg, namespace, nil
+	}
+	return types.BalanceOwnerUser, userUUID, nil
+}
+
+// checkBalance rejects billable deploys, the ones with a sku, whose billing owner has no positive balance,
+// it does nothing if credit enforcement is disabled
+func (d *deployer) checkBalance(ctx context.Context, userUUID, gitPath, sku string) error {
+	if d.acctClient == nil || sku == "" {
+		return nil
+	}
+	ownerType, ownerID, err := d.billingOwner(ctx, userUUID, gitPath)
+	if err != nil {
+		return err
+	}
+	balance, err := d.acctClient.GetBalance(ownerType, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to get balance of %s %s, %w", ownerType, ownerID, err)
+	}
+	if balance.Balance <= 0 {
+		return fmt.Errorf("%w, the balance of %s %s is %d cents, please top up first", ErrInsufficientBalance, ownerType, ownerID, balance.Balance)
+	}
+	return nil
+}
+
+// startCreditEnforcement periodically stops the billable deploys of users and organizations whose balance
+// has been negative for longer than the grace period
+func (d *deployer) startCreditEnforcement() {
+	for {
+		d.stopOverdueDeploys()
+		time.Sleep(time.Minute)
+	}
+}
+
+func (d *deployer) stopOverdueDeploys() {
+	balances, err := d.acctClient.ListOverdueBalances(d.creditGracePeriod)
+	if err != nil {
+		slog.Error("failed to list overdue balances", slog.Any("error", err))
+		return
+	}
+	for _, balance := range balances {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		d.stopDeploysOfOwner(ctx, balance)
+		cancel()
+	}
+}
+
+func (d *deployer) stopDeploysOfOwner(ctx context.Context, balance types.ACCT_BALANCE) {
+	var (
+		deploys []database.Deploy
+		err     error
+	)
+	switch balance.OwnerType {
+	case types.BalanceOwnerUser:
+		deploys, err = d.store.ListActiveDeploysByUserUUID(ctx, balance.OwnerID)
+	case types.BalanceOwnerOrg:
+		deploys, err = d.store.ListActiveDeploysByGitPathLike(ctx, "%"+balance.OwnerID+"/%")
+	default:
+		return
+	}
+	if err != nil {
+		slog.Error("failed to list active deploys of overdue balance", slog.Any("owner_type", balance.OwnerType),
+			slog.String("owner_id", balance.OwnerID), slog.Any("error", err))
+		return
+	}
+	for i := range deploys {
+		deploy := &deploys[i]
+		if deploy.SKU == "" {
+			continue
+		}
+		ownerType, ownerID, err := d.billingOwner(ctx, deploy.UserUUID, deploy.GitPath)
+		if err != nil {
+			slog.Error("failed to get billing owner of deploy", slog.Int64("deploy_id", deploy.ID), slog.Any("error", err))
+			continue
+		}
+		// deploys of the user under organizations are billed to the organizations
+		if ownerType != balance.OwnerType || ownerID != balance.OwnerID {
+			continue
+		}
+		namespace, name := splitGitPath(deploy.GitPath)
+		err = d.Stop(ctx, types.DeployRepo{
+			DeployID:  deploy.ID,
+			SpaceID:   deploy.SpaceID,
+			ModelID:   deploy.ModelID,
+			Namespace: namespace,
+			Name:      name,
+			SvcName:   deploy.SvcName,
+			ClusterID: deploy.ClusterID,
+		})
+		if err != nil {
+			// errors are logged by Stop, try again in the next round
+			continue
+		}
+		deploy.Status = common.Stopped
+		if err := d.store.UpdateDeploy(ctx, deploy); err != nil {
+			slog.Error("failed to update status of deploy stopped for negative balance", slog.Int64("deploy_id", deploy.ID), slog.Any("error", err))
+			continue
+		}
+		slog.Info("stopped deploy for negative balance", slog.Int64("deploy_id", deploy.ID), slog.Any("owner_type", balance.OwnerType),
+			slog.String("owner_id", balance.OwnerID), slog.Int64("balance", balance.Balance), slog.Any("negative_since", balance.NegativeSince))
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/google/uuid"
+	"opencsg.com/csghub-server/builder/accounting"
 	"opencsg.com/csghub-server/builder/deploy/common"
 	"opencsg.com/csghub-server/builder/deploy/imagebuilder"
 	"opencsg.com/csghub-server/builder/deploy/imagerunner"
@@ -38,7 +39,10 @@ type Deployer interface {
 	UpdateCluster(ctx context.Context, data types.ClusterRequest) (*types.UpdateClusterResponse, error)
 	UpdateDeploy(ctx context.Context, dur *types.DeployUpdateReq, deploy *database.Deploy) error
 	StartDeploy(ctx context.Context, deploy *database.Deploy) error
+	RestartDeploy(ctx context.Context, deploy *database.Deploy) error
 	CheckResourceAvailable(ctx context.Context, clusterId string, hardWare *types.HardWare) (bool, error)
+	QueuePosition(deployID int64) int
+	Cancel(ctx context.Context, dr types.DeployRepo) error
 }
 
 var _ Deployer = (*deployer)(nil)
@@ -56,6 +60,10 @@ type deployer struct {
 	sfNode             *snowflake.Node
 	eventPub           *event.EventPublisher
 	rtfm               *database.RuntimeFrameworksStore
+	namespaceStore     *database.NamespaceStore
+	// prepaid balance is enforced for billable deploys if set
+	acctClient        *accounting.AccountingClient
+	creditGracePeriod time.Duration
 }
 
 func newDeployer(s scheduler.Scheduler, ib imagebuilder.Builder, ir imagerunner.Runner) (*deployer, error) {
@@ -76,6 +84,7 @@ func newDeployer(s scheduler.Scheduler, ib imagebuilder.Builder, ir imagerunner.
 		sfNode:             node,
 		eventPub:           &event.DefaultEventPublisher,
 		rtfm:               database.NewRuntimeFrameworksStore(),
+		namespaceStore:     database.NewNamespaceStore(),
 	}
 
 	go d.refreshStatus()
@@ -125,6 +134,7 @@ func (d *deployer) serverlessDeploy(ctx context.Context, dr types.DeployRepo) (*
 	}
 	deploy.UserUUID = dr.UserUUID
 	deploy.SKU = dr.SKU
+	deploy.Priority = dr.Priority
 	// dr.ImageID is not null for nginx space, otherwise it's ""
 	deploy.ImageID = dr.ImageID
 	slog.Info("do deployer.serverlessDeploy", slog.Any("dr", dr), slog.Any("deploy", deploy))
@@ -165,6 +175,8 @@ func (d *deployer) dedicatedDeploy(ctx context.Context, dr types.DeployRepo) (*d
 		Type:             dr.Type,
 		UserUUID:         dr.UserUUID,
 		SKU:              dr.SKU,
+		Autoscaling:      dr.Autoscaling,
+		Priority:         dr.Priority,
 	}
 	err := d.store.CreateDeploy(ctx, deploy)
 	return deploy, err
@@ -195,6 +207,9 @@ func (d *deployer) buildDeploy(ctx context.Context, dr types.DeployRepo) (*datab
 }
 
 func (d *deployer) Deploy(ctx context.Context, dr types.DeployRepo) (int64, error) {
+	if err := d.checkBalance(ctx, dr.UserUUID, dr.GitPath, dr.SKU); err != nil {
+		return -1, err
+	}
 	deploy, err := d.buildDeploy(ctx, dr)
 	slog.Info("do deployer.Deploy", slog.Any("dr", dr), slog.Any("deploy", deploy))
 	if err != nil || deploy == nil {
@@ -216,11 +231,13 @@ func (d *deployer) Deploy(ctx context.Context, dr types.DeployRepo) (int64, erro
 		TaskType: 0,
 		Status:   bldTaskStatus,
 		Message:  bldTaskMsg,
+		Priority: deploy.Priority,
 	}
 	d.store.CreateDeployTask(ctx, buildTask)
 	runTask := &database.DeployTask{
 		DeployID: deploy.ID,
 		TaskType: 1,
+		Priority: deploy.Priority,
 	}
 	d.store.CreateDeployTask(ctx, runTask)
 
@@ -229,6 +246,15 @@ func (d *deployer) Deploy(ctx context.Context, dr types.DeployRepo) (int64, erro
 	return deploy.ID, nil
 }
 
+// QueuePosition returns the position of a waiting deploy in the scheduler queue, 0 if it is not waiting
+// or the scheduler does not report positions
+func (d *deployer) QueuePosition(deployID int64) int {
+	if qr, ok := d.s.(scheduler.QueueReporter); ok {
+		return qr.QueuePosition(deployID)
+	}
+	return 0
+}
+
 func (d *deployer) refreshStatus() {
 	for {
 		ctxTimeout, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -252,6 +278,10 @@ func (d *deployer) Status(ctx context.Context, dr types.DeployRepo, needDetails
 		return "", common.Stopped, nil, fmt.Errorf("can't get deploy, %w", err)
 	}
 	svcName := deploy.SvcName
+	// the runner may still report the service of a cancelled deploy until it is stopped
+	if deploy.Status == common.Cancelled {
+		return svcName, common.Cancelled, nil, nil
+	}
 	// srvName := common.UniqueSpaceAppName(dr.Namespace, dr.Name, dr.SpaceID)
 	rstatus, found := d.runnerStatuscache[svcName]
 	if !found {
@@ -342,6 +372,28 @@ func (d *deployer) Stop(ctx context.Context, dr types.DeployRepo) error {
 	return err
 }
 
+// Cancel stops the unfinished build or deploy task of the deploy, and aborts the image build or the service it started
+func (d *deployer) Cancel(ctx context.Context, dr types.DeployRepo) error {
+	deploy, err := d.store.GetDeployByID(ctx, dr.DeployID)
+	if err != nil {
+		return fmt.Errorf("can't get deploy, %w", err)
+	}
+	err = d.s.Cancel(ctx, dr.DeployID)
+	if err != nil {
+		return fmt.Errorf("fail to cancel deploy tasks, %w", err)
+	}
+
+	// the image builder can not abort a build, once the build task is cancelled the scheduler
+	// stops waiting for it and the image is never run. A service may already be started by the run task
+	if deploy.Status == common.Deploying || deploy.Status == common.Startup {
+		err = d.Stop(ctx, dr)
+		if err != nil {
+			return fmt.Errorf("fail to stop service of cancelled deploy, %w", err)
+		}
+	}
+	return nil
+}
+
 func (d *deployer) Purge(ctx context.Context, dr types.DeployRepo) error {
 	targetID := dr.SpaceID // support space only one instance
 	if dr.SpaceID == 0 {
@@ -579,6 +631,9 @@ func (d *deployer) UpdateDeploy(ctx context.Context, dur *types.DeployUpdateReq,
 	if dur.ClusterID != nil {
 		deploy.ClusterID = *dur.ClusterID
 	}
+	if dur.Autoscaling != nil {
+		deploy.Autoscaling = dur.Autoscaling
+	}
 
 	// update deploy table
 	err = d.store.UpdateDeploy(ctx, deploy)
@@ -590,6 +645,17 @@ func (d *deployer) UpdateDeploy(ctx context.Context, dur *types.DeployUpdateReq,
 }
 
 func (d *deployer) StartDeploy(ctx context.Context, deploy *database.Deploy) error {
+	err := d.checkBalance(ctx, deploy.UserUUID, deploy.GitPath, deploy.SKU)
+	if err != nil {
+		return err
+	}
+	return d.RestartDeploy(ctx, deploy)
+}
+
+// RestartDeploy runs a deploy again without checking the balance, for active deploys which are
+// restarted by the system like to pick up changed secrets. Deploys of overdue balances are still
+// stopped by the credit enforcement
+func (d *deployer) RestartDeploy(ctx context.Context, deploy *database.Deploy) error {
 	deploy.Status = common.Pending
 	// update deploy table
 	err := d.store.UpdateDeploy(ctx, deploy)
@@ -601,6 +667,7 @@ func (d *deployer) StartDeploy(ctx context.Context, deploy *database.Deploy) err
 	runTask := &database.DeployTask{
 		DeployID: deploy.ID,
 		TaskType: 1,
+		Priority: deploy.Priority,
 	}
 	d.store.CreateDeployTask(ctx, runTask)
 
@@ -674,6 +741,20 @@ func (d *deployer) startAccountingRequestMeter(resMap map[string]string, svcRes
 		CreatedAt:    time.Now(),
 		Extra:        "",
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// the usage is always metered, it is billed to the user if the owner of the deploy can not be found
+	deploy, err := d.store.GetDeployByID(ctx, svcRes.DeployID)
+	if err != nil {
+		slog.Error("failed to get deploy for metering, bill the user", slog.Any("svcRes", svcRes), slog.Any("error", err))
+	} else {
+		ownerType, ownerID, err := d.billingOwner(ctx, svcRes.UserID, deploy.GitPath)
+		if err != nil {
+			slog.Error("failed to get billing owner of deploy for metering, bill the user", slog.Any("svcRes", svcRes), slog.Any("error", err))
+		} else if ownerType == types.BalanceOwnerOrg {
+			event.OrgName = ownerID
+		}
+	}
 	str, err := json.Marshal(event)
 	if err != nil {
 		slog.Error("error marshal metering event", slog.Any("event", event), slog.Any("error", err))
@@ -726,31 +807,9 @@ func (d *deployer) CheckResourceAvailable(ctx context.Context, clusterId string,
 }
 
 func CheckResource(clusterResources *types.ClusterRes, hardware *types.HardWare) bool {
-	mem, err := strconv.Atoi(strings.Replace(hardware.Memory, "Gi", "", -1))
-	if err != nil {
-		slog.Error("failed to parse hardware memory ", slog.Any("error", err))
-		return false
-	}
 	for _, node := range clusterResources.Resources {
-		if float32(mem) <= node.AvailableMem {
-			if hardware.Gpu.Num != "" {
-				gpu, err := strconv.Atoi(hardware.Gpu.Num)
-				if err != nil {
-					slog.Error("failed to parse hardware gpu ", slog.Any("error", err))
-					return false
-				}
-				cpu, err := strconv.Atoi(hardware.Cpu.Num)
-				if err != nil {
-					slog.Error("failed to parse hardware cpu ", slog.Any("error", err))
-					return false
-
-				}
-				if gpu <= int(node.AvailableXPU) && hardware.Gpu.Type == node.XPUModel && cpu <= int(node.AvailableCPU) {
-					return true
-				}
-			} else {
-				return true
-			}
+		if common.NodeHasResource(node, hardware) {
+			return true
 		}
 	}
 	return false
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"time"
 
+	"opencsg.com/csghub-server/builder/accounting"
 	"opencsg.com/csghub-server/builder/deploy/imagebuilder"
 	"opencsg.com/csghub-server/builder/deploy/imagerunner"
 	"opencsg.com/csghub-server/builder/deploy/scheduler"
 )
 
+const schedulerPriority = "priority"
+
 var (
-	fifoScheduler   scheduler.Scheduler
+	deployScheduler scheduler.Scheduler
 	defaultDeployer Deployer
 )
 
@@ -25,13 +28,29 @@ func Init(c DeployConfig) error {
 		panic(fmt.Errorf("failed to create image runner:%w", err))
 	}
 
-	fifoScheduler = scheduler.NewFIFOScheduler(ib, ir, c.SpaceDeployTimeoutInMin, c.ModelDeployTimeoutInMin, c.ModelDownloadEndpoint, c.PublicRootDomain)
-	deployer, err := newDeployer(fifoScheduler, ib, ir)
+	if c.Scheduler == schedulerPriority {
+		deployScheduler = scheduler.NewPriorityScheduler(ib, ir, c.SpaceDeployTimeoutInMin, c.ModelDeployTimeoutInMin, c.ModelDownloadEndpoint, c.PublicRootDomain,
+			scheduler.PriorityConfig{
+				MaxRunning:       c.SchedulerMaxRunning,
+				MaxRunningOfUser: c.SchedulerMaxRunningOfUser,
+				MaxRunningOfOrg:  c.SchedulerMaxRunningOfOrg,
+				BuildTimeout:     time.Duration(c.BuildTaskTimeoutInMin) * time.Minute,
+				DeployTimeout:    time.Duration(c.DeployTaskTimeoutInMin) * time.Minute,
+			})
+	} else {
+		deployScheduler = scheduler.NewFIFOScheduler(ib, ir, c.SpaceDeployTimeoutInMin, c.ModelDeployTimeoutInMin, c.ModelDownloadEndpoint, c.PublicRootDomain)
+	}
+	deployer, err := newDeployer(deployScheduler, ib, ir)
 	if err != nil {
 		return fmt.Errorf("failed to create deployer:%w", err)
 	}
 
 	deployer.internalRootDomain = c.InternalRootDomain
+	if c.AcctClient != nil {
+		deployer.acctClient = c.AcctClient
+		deployer.creditGracePeriod = time.Duration(c.CreditGracePeriodInMin) * time.Minute
+		go deployer.startCreditEnforcement()
+	}
 	defaultDeployer = deployer
 	return nil
 }
@@ -49,4 +68,14 @@ type DeployConfig struct {
 	ModelDeployTimeoutInMin int
 	ModelDownloadEndpoint   string
 	PublicRootDomain        string
+	// fifo or priority
+	Scheduler                 string
+	SchedulerMaxRunning       int
+	SchedulerMaxRunningOfUser int
+	SchedulerMaxRunningOfOrg  int
+	BuildTaskTimeoutInMin     int
+	DeployTaskTimeoutInMin    int
+	// enforces prepaid balance of billable deploys if set
+	AcctClient             *accounting.AccountingClient
+	CreditGracePeriodInMin int
 }
@@ -101,6 +101,9 @@ func (t *BuilderRunner) Run(ctx context.Context) error {
 
 	// keep checking build status
 	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("build task stopped: %w", err)
+		}
 		fields := strings.Split(t.repo.Path, "/")
 		req := &imagebuilder.StatusRequest{
 			OrgName:   fields[0],
@@ -113,13 +116,13 @@ func (t *BuilderRunner) Run(ctx context.Context) error {
 			// return -1, fmt.Errorf("failed to call builder status api,%w", err)
 			slog.Error("failed to call builder status api", slog.Any("error", err), slog.Any("task", t))
 			// wait before next check
-			time.Sleep(10 * time.Second)
+			sleep(ctx, 10*time.Second)
 			continue
 		}
 		switch {
 		case resp.Inprogress():
 			// wait before next check
-			time.Sleep(10 * time.Second)
+			sleep(ctx, 10*time.Second)
 			continue
 		case resp.Success():
 			slog.Info("image build succeeded", slog.String("repo_name", t.repo.Name), slog.Any("deplopy_task_id", t.task.ID))
@@ -140,9 +143,7 @@ func (t *BuilderRunner) buildInProgress() {
 	t.task.Message = "build in progress"
 	// change to buidling status
 	t.task.Deploy.Status = common.Building
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.deployStore.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.deployStore, []string{"status"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Building`", "error", err)
 	}
 }
@@ -153,9 +154,7 @@ func (t *BuilderRunner) buildSuccess(resp imagebuilder.StatusResponse) {
 	// change to buidling status
 	t.task.Deploy.Status = common.BuildSuccess
 	t.task.Deploy.ImageID = resp.ImageID
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.deployStore.UpdateInTx(ctx, []string{"status", "image_id"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.deployStore, []string{"status", "image_id"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `BuildSuccess`", "error", err)
 	}
 }
@@ -165,9 +164,7 @@ func (t *BuilderRunner) buildFailed() {
 	t.task.Message = "build failed"
 	// change to buidling status
 	t.task.Deploy.Status = common.BuildFailed
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.deployStore.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.deployStore, []string{"status"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `BuildFailed`", "error", err)
 	}
 }
@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+// ErrNoTaskToCancel is returned by Cancel when all tasks of the deploy are finished
+var ErrNoTaskToCancel = errors.New("no unfinished task to cancel")
+
+// cancelCheckInterval is how often a running task checks if it was cancelled by another instance
+const cancelCheckInterval = 5 * time.Second
+
+// taskContext creates the context of a running task which can be cancelled by Cancel of any instance
+func (rs *FIFOScheduler) taskContext(taskID int64, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// sleep tasks are never cancelled
+	if taskID == 0 {
+		return ctx, cancel
+	}
+	rs.cancelLock.Lock()
+	rs.cancels[taskID] = cancel
+	rs.cancelLock.Unlock()
+	go rs.watchCancelled(ctx, taskID, cancel)
+	return ctx, func() {
+		rs.cancelLock.Lock()
+		delete(rs.cancels, taskID)
+		rs.cancelLock.Unlock()
+		cancel()
+	}
+}
+
+// watchCancelled stops the task once it is cancelled in the db, Cancel only stops the tasks run by its own instance
+func (rs *FIFOScheduler) watchCancelled(ctx context.Context, taskID int64, cancel context.CancelFunc) {
+	ticker := time.NewTicker(cancelCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if rs.taskCancelled(ctx, taskID) {
+			slog.Info("stop deploy task cancelled by another instance", slog.Int64("deploy_task_id", taskID))
+			cancel()
+			return
+		}
+	}
+}
+
+// taskCancelled checks if the task was cancelled before its context was created
+func (rs *FIFOScheduler) taskCancelled(ctx context.Context, taskID int64) bool {
+	if taskID == 0 {
+		return false
+	}
+	t, err := rs.store.GetDeployTask(ctx, taskID)
+	if err != nil {
+		return false
+	}
+	return t.Status == cancelled
+}
+
+// Cancel marks the unfinished tasks of the deploy cancelled, and stops the context of the running one,
+// the runner returns soon after and its slot is given to the next task
+func (rs *FIFOScheduler) Cancel(ctx context.Context, deployID int64) error {
+	tasks, err := rs.cancelDeployTasks(ctx, deployID)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("deploy %d: %w", deployID, ErrNoTaskToCancel)
+	}
+
+	rs.cancelLock.Lock()
+	defer rs.cancelLock.Unlock()
+	for _, t := range tasks {
+		if cancel, ok := rs.cancels[t.ID]; ok {
+			slog.Info("cancel running deploy task", slog.Int64("deploy_id", deployID), slog.Int64("deploy_task_id", t.ID))
+			cancel()
+		}
+	}
+	return nil
+}
+
+// cancelDeployOfTask is called once the runner of a cancelled task returned, as the runner may
+// have updated the task status after it was cancelled
+func (rs *FIFOScheduler) cancelDeployOfTask(deployTaskID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	t, err := rs.store.GetDeployTask(ctx, deployTaskID)
+	if err != nil {
+		slog.Error("failed to get cancelled deploy task", slog.Int64("deploy_task_id", deployTaskID), slog.Any("error", err))
+		return
+	}
+	if _, err := rs.cancelDeployTasks(ctx, t.DeployID); err != nil {
+		slog.Error("failed to cancel deploy tasks", slog.Int64("deploy_id", t.DeployID), slog.Any("error", err))
+	}
+}
+
+func (rs *FIFOScheduler) cancelDeployTasks(ctx context.Context, deployID int64) ([]*database.DeployTask, error) {
+	dps, err := rs.store.GetDeployTasksOfDeploy(ctx, deployID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks of deploy %d, %w", deployID, err)
+	}
+
+	var unfinished []*database.DeployTask
+	for _, dp := range dps {
+		if !taskUnfinished(dp) {
+			continue
+		}
+		dp.Status = cancelled
+		dp.Message = "cancelled by user"
+		unfinished = append(unfinished, dp)
+	}
+	if len(unfinished) == 0 {
+		return nil, nil
+	}
+
+	deploy := &database.Deploy{ID: deployID, Status: common.Cancelled}
+	if err := rs.store.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, deploy, unfinished...); err != nil {
+		return nil, fmt.Errorf("failed to update deploy status to `Cancelled`, %w", err)
+	}
+	return unfinished, nil
+}
+
+// taskUnfinished matches the tasks loaded by the schedulers
+func taskUnfinished(t *database.DeployTask) bool {
+	if t.TaskType == 0 {
+		return t.Status == buildPending || t.Status == buildInProgress
+	}
+	return t.Status == deployPending || t.Status == deploying || t.Status == deployStartUp
+}
+
+// updateTaskStatus saves the status of a running task and its deploy, a task cancelled meanwhile, by this
+// or another instance, keeps its cancelled status
+func updateTaskStatus(store *database.DeployTaskStore, deployColumns []string, task *database.DeployTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	updated, err := store.UpdateTaskUnlessStatusInTx(ctx, deployColumns, []string{"status", "message"}, task.Deploy, task, cancelled)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		slog.Info("skip status update of cancelled deploy task", slog.Int64("deploy_task_id", task.ID), slog.Int("status", task.Status))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+func TestUpdateTaskStatus_keepsCancelled(t *testing.T) {
+	ctx := context.Background()
+	dbConfig := database.DBConfig{Dialect: database.DialectSQLite, DSN: "file:deploy_cancel?mode=memory&cache=shared"}
+	database.InitDB(dbConfig)
+	db, err := database.NewDB(ctx, dbConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []any{(*database.Deploy)(nil), (*database.DeployTask)(nil)} {
+		if _, err := db.Core.NewCreateTable().Model(model).IfNotExists().Exec(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deploy := &database.Deploy{Status: common.Building}
+	if _, err := db.Core.NewInsert().Model(deploy).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	running := &database.DeployTask{DeployID: deploy.ID, Status: buildInProgress}
+	cancelledTask := &database.DeployTask{DeployID: deploy.ID, Status: cancelled}
+	for _, task := range []*database.DeployTask{running, cancelledTask} {
+		if _, err := db.Core.NewInsert().Model(task).Exec(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := database.NewDeployTaskStore()
+
+	running.Status = buildSucceed
+	running.Deploy = &database.Deploy{ID: deploy.ID, Status: common.BuildSuccess}
+	if err := updateTaskStatus(store, []string{"status"}, running); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := store.GetDeployTask(ctx, running.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != buildSucceed || saved.Deploy.Status != common.BuildSuccess {
+		t.Errorf("expect the status of a running task to be saved, got task %d and deploy %d", saved.Status, saved.Deploy.Status)
+	}
+
+	// the runner of another instance does not know the task was cancelled
+	cancelledTask.Status = buildFailed
+	cancelledTask.Deploy = &database.Deploy{ID: deploy.ID, Status: common.BuildFailed}
+	if err := updateTaskStatus(store, []string{"status"}, cancelledTask); err != nil {
+		t.Fatal(err)
+	}
+	saved, err = store.GetDeployTask(ctx, cancelledTask.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != cancelled || saved.Deploy.Status != common.BuildSuccess {
+		t.Errorf("expect a cancelled task and its deploy to be kept, got task %d and deploy %d", saved.Status, saved.Deploy.Status)
+	}
+}
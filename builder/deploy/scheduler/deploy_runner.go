@@ -14,6 +14,7 @@ import (
 	"opencsg.com/csghub-server/builder/deploy/imagerunner"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/types"
+	utilcommon "opencsg.com/csghub-server/common/utils/common"
 )
 
 type DeployTimeout struct {
@@ -32,9 +33,12 @@ type DeployRunner struct {
 	deployTimeout         *DeployTimeout
 	modelDownloadEndpoint string
 	publicDomain          string
+	secretStore           *database.SecretStore
+	// key to decrypt the repo and namespace secrets
+	secretKey string
 }
 
-func NewDeployRunner(ir imagerunner.Runner, r *RepoInfo, t *database.DeployTask, dto *DeployTimeout, mdep, publicDomain string) Runner {
+func NewDeployRunner(ir imagerunner.Runner, r *RepoInfo, t *database.DeployTask, dto *DeployTimeout, mdep, publicDomain, secretKey string) Runner {
 	return &DeployRunner{
 		repo:                  r,
 		task:                  t,
@@ -45,6 +49,8 @@ func NewDeployRunner(ir imagerunner.Runner, r *RepoInfo, t *database.DeployTask,
 		tokenStore:            database.NewAccessTokenStore(),
 		modelDownloadEndpoint: mdep,
 		publicDomain:          publicDomain,
+		secretStore:           database.NewSecretStore(),
+		secretKey:             secretKey,
 	}
 }
 
@@ -54,22 +60,30 @@ func (t *DeployRunner) Run(ctx context.Context) error {
 
 	// keep checking deploy status
 	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("deploy task stopped: %w", err)
+		}
 		if t.task.Status == deployPending {
 			req, err := t.makeDeployRequest()
 			if err != nil {
 				return fmt.Errorf("fail to make deploy request: %w", err)
 			}
 			if req.ImageID == "" {
-				time.Sleep(5 * time.Second)
+				sleep(ctx, 5*time.Second)
 				continue
 			}
-			slog.Debug("After build deploy request", slog.Any("req", req))
+			slog.Debug("After build deploy request", slog.Int64("deploy_id", req.DeployID), slog.String("image_id", req.ImageID), slog.String("cluster_id", req.ClusterID))
 			resp, err := t.ir.Run(ctx, req)
 			if err != nil {
 				// TODO:return retryable error
 				return fmt.Errorf("call image runner failed: %w", err)
 			}
 
+			// record the cluster chosen by the runner, later status and stop requests go to it
+			if t.task.Deploy.ClusterID == "" && resp.ClusterID != "" {
+				t.task.Deploy.ClusterID = resp.ClusterID
+				t.task.Deploy.PlacementReason = resp.PlacementReason
+			}
 			t.deployInProgress(resp.Message)
 			// record time of create knative service
 			t.deployStartTime = time.Now()
@@ -96,7 +110,7 @@ func (t *DeployRunner) Run(ctx context.Context) error {
 			// return -1, fmt.Errorf("failed to call builder status api,%w", err)
 			slog.Error("failed to call runner status api", slog.Any("error", err), slog.Any("task", t.task))
 			// wait before next check
-			time.Sleep(10 * time.Second)
+			sleep(ctx, 10*time.Second)
 			continue
 		}
 
@@ -118,7 +132,7 @@ func (t *DeployRunner) Run(ctx context.Context) error {
 			}
 			t.deployInProgress("")
 			// wait before next check
-			time.Sleep(10 * time.Second)
+			sleep(ctx, 10*time.Second)
 		case common.DeployFailed:
 			slog.Error("image deploy failed", slog.String("repo_name", t.repo.Name), slog.Any("deplopy_task_id", t.task.ID), slog.Any("resp", resp))
 			t.deployFailed(resp.Message)
@@ -128,7 +142,7 @@ func (t *DeployRunner) Run(ctx context.Context) error {
 			slog.Info("image deploy success", slog.String("repo_name", t.repo.Name), slog.Any("deplopy_task_id", t.task.ID))
 			t.deploySuccess()
 			// wait before next check
-			time.Sleep(10 * time.Second)
+			sleep(ctx, 10*time.Second)
 
 		case common.Running:
 			slog.Info("image running", slog.String("repo_name", t.repo.Name), slog.Any("deplopy_task_id", t.task.ID))
@@ -158,9 +172,7 @@ func (t *DeployRunner) deployInProgress(svcName string) {
 	if len(svcName) > 0 {
 		t.task.Deploy.SvcName = svcName
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.store.UpdateInTx(ctx, []string{"status", "svc_name"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.store, []string{"status", "svc_name", "cluster_id", "placement_reason"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Deploying`", "error", err)
 	}
 }
@@ -170,9 +182,7 @@ func (t *DeployRunner) deploySuccess() {
 	t.task.Message = "deploy succeeded, wati for startup"
 	// change to buidling status
 	t.task.Deploy.Status = common.Startup
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.store.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.store, []string{"status"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Startup`", "error", err)
 	}
 }
@@ -182,9 +192,7 @@ func (t *DeployRunner) deployFailed(msg string) {
 	t.task.Message = msg
 	// change to buidling status
 	t.task.Deploy.Status = common.DeployFailed
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.store.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.store, []string{"status"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `DeployFailed`", "error", err)
 	}
 }
@@ -195,9 +203,7 @@ func (t *DeployRunner) running(endpoint string) {
 	// change to buidling status
 	t.task.Deploy.Status = common.Running
 	t.task.Deploy.Endpoint = endpoint
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.store.UpdateInTx(ctx, []string{"status", "endpoint"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.store, []string{"status", "endpoint"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Running`", "error", err)
 	}
 }
@@ -207,9 +213,7 @@ func (t *DeployRunner) runtimeError(msg string) {
 	t.task.Message = msg
 	// change to buidling status
 	t.task.Deploy.Status = common.RunTimeError
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := t.store.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
+	if err := updateTaskStatus(t.store, []string{"status"}, t.task); err != nil {
 		slog.Error("failed to change deploy status to `RunTimeError`", "error", err)
 	}
 }
@@ -291,6 +295,11 @@ func (t *DeployRunner) makeDeployRequest() (*types.RunRequest, error) {
 
 	}
 
+	secrets, err := t.loadSecrets(deploy, fields[0])
+	if err != nil {
+		return nil, err
+	}
+
 	targetID := deploy.SpaceID
 	// deployID is unique for space and model
 	if deploy.SpaceID == 0 && deploy.ModelID > 0 {
@@ -306,6 +315,7 @@ func (t *DeployRunner) makeDeployRequest() (*types.RunRequest, error) {
 		Annotation:  annoMap,
 		Hardware:    hardware,
 		Env:         envMap,
+		Secrets:     secrets,
 		GitPath:     deploy.GitPath,
 		GitRef:      deploy.GitBranch,
 		ImageID:     deploy.ImageID,
@@ -318,6 +328,7 @@ func (t *DeployRunner) makeDeployRequest() (*types.RunRequest, error) {
 		DeployType:  deploy.Type,
 		UserID:      deploy.UserUUID,
 		Sku:         deploy.SKU,
+		Autoscaling: deploy.Autoscaling,
 	}, nil
 }
 
@@ -328,10 +339,11 @@ func (t *DeployRunner) cancelDeploy(ctx context.Context, orgName, repoName strin
 		targetID = t.task.Deploy.ID
 	}
 	stopReq := &types.StopRequest{
-		ID:       targetID,
-		OrgName:  orgName,
-		RepoName: repoName,
-		SvcName:  t.task.Deploy.SvcName,
+		ID:        targetID,
+		OrgName:   orgName,
+		RepoName:  repoName,
+		SvcName:   t.task.Deploy.SvcName,
+		ClusterID: t.task.Deploy.ClusterID,
 	}
 	_, err := t.ir.Stop(ctx, stopReq)
 	if err != nil {
@@ -348,3 +360,33 @@ func (t *DeployRunner) getHttpCloneURLWithToken(httpCloneUrl, token string) stri
 	}
 	return httpCloneUrl
 }
+
+// loadSecrets decrypts the namespace and repo secrets of the deploy, repo secrets override
+// namespace secrets with the same name
+func (t *DeployRunner) loadSecrets(deploy *database.Deploy, namespace string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	nsSecrets, err := t.secretStore.FindByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get namespace secrets: %w", err)
+	}
+	repoSecrets, err := t.secretStore.FindByRepoID(ctx, deploy.RepoID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get repo secrets: %w", err)
+	}
+
+	secrets := make(map[string]string)
+	for _, secret := range append(nsSecrets, repoSecrets...) {
+		// secrets saved before the name was reserved must not replace the env of the deploy
+		if types.IsReservedSecretName(secret.Name) {
+			slog.Warn("skip secret with reserved name", slog.String("name", secret.Name), slog.Int64("deploy_id", deploy.ID))
+			continue
+		}
+		value, err := utilcommon.DecryptString(t.secretKey, secret.Value)
+		if err != nil {
+			return nil, fmt.Errorf("fail to decrypt secret %s: %w", secret.Name, err)
+		}
+		secrets[secret.Name] = value
+	}
+	return secrets, nil
+}
@@ -0,0 +1,252 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"opencsg.com/csghub-server/builder/deploy/imagebuilder"
+	"opencsg.com/csghub-server/builder/deploy/imagerunner"
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+// QueueReporter is implemented by schedulers which know the position of a waiting deploy
+type QueueReporter interface {
+	// QueuePosition returns the 1-based position of the deploy in the queue, 0 if it is not waiting
+	QueuePosition(deployID int64) int
+}
+
+type PriorityConfig struct {
+	// max running tasks in total, of one user and of one namespace, 0 means no limit
+	MaxRunning       int
+	MaxRunningOfUser int
+	MaxRunningOfOrg  int
+	BuildTimeout     time.Duration
+	DeployTimeout    time.Duration
+}
+
+// a PriorityScheduler runs tasks with higher priority first, and shares the running slots
+// fairly between users of the same priority, so one user can not starve the others.
+// Several instances can schedule the same tasks, a task is only run by the instance holding its claim in the db
+type PriorityScheduler struct {
+	*FIFOScheduler
+	opts PriorityConfig
+	// identifies this instance in the task claims
+	instanceID string
+
+	lock sync.Mutex
+	// tasks run by this instance by deploy id, a deploy runs one task at a time
+	running map[int64]*database.DeployTask
+	// queue position by deploy id of the waiting deploys
+	positions map[int64]int
+	wakeup    chan struct{}
+}
+
+var (
+	_ Scheduler     = (*PriorityScheduler)(nil)
+	_ QueueReporter = (*PriorityScheduler)(nil)
+)
+
+func NewPriorityScheduler(ib imagebuilder.Builder, ir imagerunner.Runner, sdt, mdt int, mdep, prd string, pc PriorityConfig) Scheduler {
+	if pc.BuildTimeout <= 0 {
+		pc.BuildTimeout = 30 * time.Minute
+	}
+	if pc.DeployTimeout <= 0 {
+		pc.DeployTimeout = 30 * time.Minute
+	}
+	return &PriorityScheduler{
+		FIFOScheduler: newFIFOScheduler(ib, ir, sdt, mdt, mdep, prd),
+		opts:          pc,
+		instanceID:    uuid.NewString(),
+		running:       make(map[int64]*database.DeployTask),
+		positions:     make(map[int64]int),
+		wakeup:        make(chan struct{}, 1),
+	}
+}
+
+// Run schedules waiting tasks when a task is queued or finished, and periodically for tasks created by other instances
+func (rs *PriorityScheduler) Run() error {
+	slog.Info("PriorityScheduler run started")
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		rs.schedule()
+		select {
+		case <-rs.wakeup:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (rs *PriorityScheduler) Queue(deployTaskID int64) error {
+	select {
+	case rs.wakeup <- struct{}{}:
+	default:
+		// a schedule is already pending
+	}
+	return nil
+}
+
+func (rs *PriorityScheduler) QueuePosition(deployID int64) int {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	return rs.positions[deployID]
+}
+
+func (rs *PriorityScheduler) schedule() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tasks, err := rs.store.GetNewTasks(ctx)
+	if err != nil {
+		slog.Error("PriorityScheduler cannot get new tasks by db error", slog.Any("error", err))
+		return
+	}
+
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	now := time.Now()
+	// tasks claimed by any instance count as running, so the limits hold across instances
+	var running int
+	userRunning := make(map[int64]int)
+	orgRunning := make(map[string]int)
+	// only the first unfinished task of a deploy can run, the run task waits for the build task
+	var waiting []*database.DeployTask
+	seen := make(map[int64]bool)
+	for _, t := range tasks {
+		if seen[t.DeployID] || t.Deploy == nil {
+			continue
+		}
+		seen[t.DeployID] = true
+		if _, ok := rs.running[t.DeployID]; ok || rs.claimed(t, now) {
+			running++
+			userRunning[t.Deploy.UserID]++
+			orgRunning[deployNamespace(t.Deploy)]++
+			continue
+		}
+		waiting = append(waiting, t)
+	}
+
+	// fair share: within the same priority, the n-th waiting task of a user goes after
+	// the (n-1)-th waiting tasks of all other users, counting the tasks already running
+	rank := make(map[int64]int, len(waiting))
+	userWaiting := make(map[int64]int)
+	for _, t := range waiting {
+		rank[t.ID] = userRunning[t.Deploy.UserID] + userWaiting[t.Deploy.UserID]
+		userWaiting[t.Deploy.UserID]++
+	}
+	sort.SliceStable(waiting, func(i, j int) bool {
+		if waiting[i].Priority != waiting[j].Priority {
+			return waiting[i].Priority > waiting[j].Priority
+		}
+		return rank[waiting[i].ID] < rank[waiting[j].ID]
+	})
+
+	positions := make(map[int64]int)
+	for _, t := range waiting {
+		org := deployNamespace(t.Deploy)
+		if rs.canRun(running, userRunning[t.Deploy.UserID], orgRunning[org]) {
+			ok, err := rs.store.ClaimTask(ctx, t.ID, rs.instanceID, rs.lease(t))
+			if err != nil {
+				slog.Error("PriorityScheduler failed to claim task", slog.Any("error", err), slog.Int64("deploy_task_id", t.ID))
+				positions[t.DeployID] = len(positions) + 1
+				continue
+			}
+			running++
+			userRunning[t.Deploy.UserID]++
+			orgRunning[org]++
+			// another instance claimed the task since it was loaded
+			if !ok {
+				continue
+			}
+			rs.running[t.DeployID] = t
+			go rs.runTask(t)
+			continue
+		}
+		positions[t.DeployID] = len(positions) + 1
+	}
+	rs.positions = positions
+}
+
+func (rs *PriorityScheduler) canRun(running, userRunning, orgRunning int) bool {
+	if rs.opts.MaxRunning > 0 && running >= rs.opts.MaxRunning {
+		return false
+	}
+	if rs.opts.MaxRunningOfUser > 0 && userRunning >= rs.opts.MaxRunningOfUser {
+		return false
+	}
+	if rs.opts.MaxRunningOfOrg > 0 && orgRunning >= rs.opts.MaxRunningOfOrg {
+		return false
+	}
+	return true
+}
+
+// lease is how long a claim of the task is valid, a task never runs longer than its timeout,
+// so the claim of an instance which died while running it can be taken over afterwards
+func (rs *PriorityScheduler) lease(t *database.DeployTask) time.Duration {
+	if t.TaskType == 0 {
+		return rs.opts.BuildTimeout + time.Minute
+	}
+	return rs.opts.DeployTimeout + time.Minute
+}
+
+func (rs *PriorityScheduler) claimed(t *database.DeployTask, now time.Time) bool {
+	return !t.ClaimedAt.IsZero() && now.Sub(t.ClaimedAt) < rs.lease(t)
+}
+
+func (rs *PriorityScheduler) runTask(deployTask *database.DeployTask) {
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := rs.store.ReleaseTask(ctx, deployTask.ID, rs.instanceID); err != nil {
+			// the claim expires with its lease
+			slog.Error("failed to release claim of task", slog.Any("error", err), slog.Int64("deploy_task_id", deployTask.ID))
+		}
+		rs.lock.Lock()
+		delete(rs.running, deployTask.DeployID)
+		rs.lock.Unlock()
+		rs.Queue(deployTask.ID)
+	}()
+
+	timeout := rs.opts.DeployTimeout
+	if deployTask.TaskType == 0 {
+		timeout = rs.opts.BuildTimeout
+	}
+	ctx, cancel := rs.taskContext(deployTask.ID, timeout)
+	defer cancel()
+	if rs.taskCancelled(ctx, deployTask.ID) {
+		return
+	}
+
+	t, err := rs.newRunner(ctx, deployTask)
+	if err != nil {
+		slog.Error("failed to create runner of task", slog.Any("error", err), slog.Int64("deploy_task_id", deployTask.ID))
+		return
+	}
+	slog.Info("run next task", slog.Any("task", t.WatchID()), slog.Int("priority", deployTask.Priority))
+	if err := t.Run(ctx); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			rs.cancelDeployOfTask(t.WatchID())
+			return
+		}
+		slog.Error("failed to run task", slog.Any("error", err), slog.Any("task", t.WatchID()))
+		rs.failDeployFollowingTasks(t.WatchID(), err.Error())
+		return
+	}
+	// the run task can never start once the build failed
+	if deployTask.TaskType == 0 && deployTask.Status == buildFailed {
+		rs.failDeployFollowingTasks(deployTask.ID, "build failed")
+	}
+}
+
+// deployNamespace returns the owner namespace of the deployed repo, git path is like `spaces_ns/name`
+func deployNamespace(deploy *database.Deploy) string {
+	_, path, _ := strings.Cut(deploy.GitPath, "_")
+	namespace, _, _ := strings.Cut(path, "/")
+	return namespace
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+func TestPriorityScheduler_claimed(t *testing.T) {
+	rs := &PriorityScheduler{opts: PriorityConfig{BuildTimeout: 10 * time.Minute, DeployTimeout: 30 * time.Minute}}
+	now := time.Now()
+
+	testData := []struct {
+		name   string
+		task   *database.DeployTask
+		expect bool
+	}{
+		{"not claimed", &database.DeployTask{}, false},
+		{"fresh build claim", &database.DeployTask{TaskType: 0, ClaimedAt: now.Add(-5 * time.Minute)}, true},
+		{"expired build claim", &database.DeployTask{TaskType: 0, ClaimedAt: now.Add(-20 * time.Minute)}, false},
+		{"deploy claim within deploy timeout", &database.DeployTask{TaskType: 1, ClaimedAt: now.Add(-20 * time.Minute)}, true},
+	}
+	for _, data := range testData {
+		if got := rs.claimed(data.task, now); got != data.expect {
+			t.Errorf("%s: expect %v, got %v", data.name, data.expect, got)
+		}
+	}
+}
+
+func TestPriorityScheduler_canRun(t *testing.T) {
+	rs := &PriorityScheduler{opts: PriorityConfig{MaxRunning: 10, MaxRunningOfUser: 2, MaxRunningOfOrg: 3}}
+	if !rs.canRun(9, 1, 2) {
+		t.Error("expect a task under all limits to run")
+	}
+	if rs.canRun(10, 0, 0) || rs.canRun(0, 2, 0) || rs.canRun(0, 0, 3) {
+		t.Error("expect a task over any limit to wait")
+	}
+	if !(&PriorityScheduler{}).canRun(100, 100, 100) {
+		t.Error("expect no limit when limits are 0")
+	}
+}
+
+func TestDeployNamespace(t *testing.T) {
+	if ns := deployNamespace(&database.Deploy{GitPath: "spaces_opencsg/demo"}); ns != "opencsg" {
+		t.Errorf("expect namespace opencsg, got %s", ns)
+	}
+}
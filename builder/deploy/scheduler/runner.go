@@ -28,6 +28,14 @@ func (t *sleepTask) Run(ctx context.Context) error {
 }
 func (t *sleepTask) WatchID() int64 { return 0 }
 
+// sleep pauses a runner, it returns early when the task is cancelled or timeout
+func sleep(ctx context.Context, du time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(du):
+	}
+}
+
 const cancelled = -1
 
 const (
@@ -19,6 +19,8 @@ import (
 type Scheduler interface {
 	Run() error
 	Queue(deployTaskID int64) error
+	// Cancel stops the running task of the deploy and cancels all its unfinished tasks
+	Cancel(ctx context.Context, deployID int64) error
 }
 
 // a Scheduler will run tasks in their arrival order
@@ -41,9 +43,17 @@ type FIFOScheduler struct {
 	modelDownloadEndpoint   string
 	PublicRootDomain        string
 	config                  *config.Config
+
+	// cancel funcs of the running tasks by task id
+	cancelLock *sync.Mutex
+	cancels    map[int64]context.CancelFunc
 }
 
 func NewFIFOScheduler(ib imagebuilder.Builder, ir imagerunner.Runner, sdt, mdt int, mdep, prd string) Scheduler {
+	return newFIFOScheduler(ib, ir, sdt, mdt, mdep, prd)
+}
+
+func newFIFOScheduler(ib imagebuilder.Builder, ir imagerunner.Runner, sdt, mdt int, mdep, prd string) *FIFOScheduler {
 	s := &FIFOScheduler{}
 	// TODO:allow config
 	s.timeout = 30 * time.Minute
@@ -58,6 +68,8 @@ func NewFIFOScheduler(ib imagebuilder.Builder, ir imagerunner.Runner, sdt, mdt i
 	s.ib = ib
 	s.ir = ir
 	s.nextLock = &sync.Mutex{}
+	s.cancelLock = &sync.Mutex{}
+	s.cancels = make(map[int64]context.CancelFunc)
 	s.spaceDeployTimeoutInMin = sdt
 	s.modelDeployTimeoutInMin = mdt
 	s.modelDownloadEndpoint = mdep
@@ -84,12 +96,20 @@ func (rs *FIFOScheduler) Run() error {
 	for t := range rs.tasks {
 		go func(t Runner) {
 			slog.Debug("dequeue a task to run", slog.Any("task", t.WatchID()))
-			ctx, cancel := context.WithTimeout(context.Background(), rs.timeout)
+			ctx, cancel := rs.taskContext(t.WatchID(), rs.timeout)
 			defer cancel()
+			if rs.taskCancelled(ctx, t.WatchID()) {
+				rs.next()
+				return
+			}
 
 			if err := t.Run(ctx); err != nil {
-				slog.Error("failed to run task", slog.Any("error", err), slog.Any("task", t.WatchID()))
-				rs.failDeployFollowingTasks(t.WatchID(), err.Error())
+				if errors.Is(ctx.Err(), context.Canceled) {
+					rs.cancelDeployOfTask(t.WatchID())
+				} else {
+					slog.Error("failed to run task", slog.Any("error", err), slog.Any("task", t.WatchID()))
+					rs.failDeployFollowingTasks(t.WatchID(), err.Error())
+				}
 			}
 
 			rs.next()
@@ -141,8 +161,29 @@ func (rs *FIFOScheduler) next() (Runner, error) {
 		return t, nil
 	}
 
-	var repo RepoInfo
+	t, err = rs.newRunner(ctx, deployTask)
+	if err != nil {
+		t = &sleepTask{
+			du: 5 * time.Second,
+		}
+		rs.last = deployTask
+		rs.tasks <- t
+		return t, nil
+	}
+
+	rs.last = deployTask
+	rs.tasks <- t
+	slog.Info("enqueue next task", slog.Any("task", t.WatchID()))
+	return t, err
+}
 
+// newRunner loads the repo of the deploy task and creates the runner for it,
+// the task is cancelled if its repo does not exist any more
+func (rs *FIFOScheduler) newRunner(ctx context.Context, deployTask *database.DeployTask) (Runner, error) {
+	var (
+		repo RepoInfo
+		err  error
+	)
 	if deployTask.Deploy.SpaceID > 0 {
 		// handle space
 		var s *database.Space
@@ -185,31 +226,21 @@ func (rs *FIFOScheduler) next() (Runner, error) {
 			deployTask.Message = "repo not found"
 			rs.store.UpdateDeployTask(ctx, deployTask)
 		}
-		t = &sleepTask{
-			du: 5 * time.Second,
-		}
-		rs.last = deployTask
-		rs.tasks <- t
-		return t, nil
+		return nil, err
 	}
 	// for build task
 	if deployTask.TaskType == 0 {
-		t = NewBuidRunner(rs.ib, &repo, deployTask)
-	} else {
-		t = NewDeployRunner(rs.ir, &repo, deployTask,
-			&DeployTimeout{
-				deploySpaceTimeoutInMin: rs.spaceDeployTimeoutInMin,
-				deployModelTimeoutInMin: rs.modelDeployTimeoutInMin,
-			},
-			rs.modelDownloadEndpoint,
-			rs.PublicRootDomain,
-		)
+		return NewBuidRunner(rs.ib, &repo, deployTask), nil
 	}
-
-	rs.last = deployTask
-	rs.tasks <- t
-	slog.Info("enqueue next task", slog.Any("task", t.WatchID()))
-	return t, err
+	return NewDeployRunner(rs.ir, &repo, deployTask,
+		&DeployTimeout{
+			deploySpaceTimeoutInMin: rs.spaceDeployTimeoutInMin,
+			deployModelTimeoutInMin: rs.modelDeployTimeoutInMin,
+		},
+		rs.modelDownloadEndpoint,
+		rs.PublicRootDomain,
+		rs.config.Secret.EncryptionKey,
+	), nil
 }
 
 func (rs *FIFOScheduler) failDeployFollowingTasks(deploytaskID int64, reason string) {
@@ -33,6 +33,15 @@ func InitEventPublisher(cfg *config.Config) error {
 
 // Publish a message to the specified subject
 func (ec *EventPublisher) PublishMeteringEvent(message []byte) error {
+	return ec.publishWithRetry(ec.Connector.PublishMeterDurationData, message)
+}
+
+// PublishTokenMeteringEvent publishes a metering event of token usage
+func (ec *EventPublisher) PublishTokenMeteringEvent(message []byte) error {
+	return ec.publishWithRetry(ec.Connector.PublishMeterTokenData, message)
+}
+
+func (ec *EventPublisher) publishWithRetry(publish func(data []byte) error, message []byte) error {
 	var err error
 	for i := 0; i < 3; i++ {
 		err = ec.Connector.VerifyMeteringStream()
@@ -40,7 +49,7 @@ func (ec *EventPublisher) PublishMeteringEvent(message []byte) error {
 			time.Sleep(2 * time.Second)
 			continue
 		}
-		err = ec.Connector.PublishMeterDurationData(message)
+		err = publish(message)
 		if err == nil {
 			break
 		}
@@ -49,3 +49,101 @@ func (c *Client) GetRepoBranches(ctx context.Context, req gitserver.GetBranchesR
 
 	return branches, nil
 }
+
+func (c *Client) CreateBranch(ctx context.Context, req gitserver.CreateBranchReq) (*types.Branch, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	branchReq := &gitalypb.UserCreateBranchRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+			GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+		},
+		BranchName: []byte(req.Branch),
+		StartPoint: []byte(req.StartPoint),
+		User: &gitalypb.User{
+			GlId:       fmt.Sprintf("user-%d", req.UserID),
+			Name:       []byte(req.Username),
+			GlUsername: req.Username,
+			Email:      []byte(req.Email),
+		},
+	}
+	resp, err := c.operationClient.UserCreateBranch(ctx, branchReq)
+	if err != nil {
+		return nil, err
+	}
+	branch := &types.Branch{Name: req.Branch}
+	if resp.Branch != nil && resp.Branch.TargetCommit != nil {
+		branch.Message = string(resp.Branch.TargetCommit.Subject)
+		branch.Commit = types.RepoBranchCommit{
+			ID: resp.Branch.TargetCommit.Id,
+		}
+	}
+	return branch, nil
+}
+
+func (c *Client) DeleteBranch(ctx context.Context, req gitserver.DeleteBranchReq) error {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	branchReq := &gitalypb.UserDeleteBranchRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+			GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+		},
+		BranchName: []byte(req.Branch),
+		User: &gitalypb.User{
+			GlId:       fmt.Sprintf("user-%d", req.UserID),
+			Name:       []byte(req.Username),
+			GlUsername: req.Username,
+			Email:      []byte(req.Email),
+		},
+	}
+	_, err := c.operationClient.UserDeleteBranch(ctx, branchReq)
+	return err
+}
+
+// RenameBranch creates the new branch from the old one and then deletes the old branch, gitaly has no rename
+// operation. The new branch is deleted again if the old one can not be deleted, so a failed rename leaves no copy
+func (c *Client) RenameBranch(ctx context.Context, req gitserver.RenameBranchReq) error {
+	_, err := c.CreateBranch(ctx, gitserver.CreateBranchReq{
+		Namespace:  req.Namespace,
+		Name:       req.Name,
+		RepoType:   req.RepoType,
+		Branch:     req.NewBranch,
+		StartPoint: req.Branch,
+		UserID:     req.UserID,
+		Username:   req.Username,
+		Email:      req.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s from %s, error: %w", req.NewBranch, req.Branch, err)
+	}
+	err = c.DeleteBranch(ctx, gitserver.DeleteBranchReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Branch:    req.Branch,
+		UserID:    req.UserID,
+		Username:  req.Username,
+		Email:     req.Email,
+	})
+	if err != nil {
+		rollbackErr := c.DeleteBranch(ctx, gitserver.DeleteBranchReq{
+			Namespace: req.Namespace,
+			Name:      req.Name,
+			RepoType:  req.RepoType,
+			Branch:    req.NewBranch,
+			UserID:    req.UserID,
+			Username:  req.Username,
+			Email:     req.Email,
+		})
+		if rollbackErr != nil {
+			return fmt.Errorf("failed to delete old branch %s, error: %w, and failed to delete new branch %s, error: %v", req.Branch, err, req.NewBranch, rollbackErr)
+		}
+		return fmt.Errorf("failed to delete old branch %s, error: %w", req.Branch, err)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
@@ -30,6 +32,9 @@ func (c *Client) GetRepoCommits(ctx context.Context, req gitserver.GetRepoCommit
 		Limit:    int32(req.Per),
 		Offset:   int32(req.Per * (req.Page - 1)),
 	}
+	if req.Path != "" {
+		commitsReq.Paths = [][]byte{[]byte(req.Path)}
+	}
 	stream, err := c.commitClient.FindCommits(ctx, commitsReq)
 	if err != nil {
 		return nil, nil, err
@@ -66,6 +71,7 @@ func (c *Client) GetRepoCommits(ctx context.Context, req gitserver.GetRepoCommit
 			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
 		},
 		Revision: []byte(req.Ref),
+		Path:     []byte(req.Path),
 	}
 	count, err := c.commitClient.CountCommits(ctx, countCommitsReq)
 	if err != nil {
@@ -96,7 +102,8 @@ func (c *Client) GetRepoLastCommit(ctx context.Context, req gitserver.GetRepoLas
 	if err != nil {
 		return nil, err
 	}
-	if resp != nil {
+	// commit is nil when the ref does not exist
+	if resp != nil && resp.Commit != nil {
 		commit = types.Commit{
 			ID:             string(resp.Commit.Id),
 			CommitterName:  string(resp.Commit.Committer.Name),
@@ -338,3 +345,153 @@ func (c *Client) GetDiffBetweenTwoCommits(ctx context.Context, req gitserver.Get
 	}
 	return callback, nil
 }
+
+func (c *Client) CommitIsAncestor(ctx context.Context, req gitserver.CommitIsAncestorReq) (bool, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	ancestorReq := &gitalypb.CommitIsAncestorRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:                   c.config.GitalyServer.Storge,
+			RelativePath:                  BuildRelativePath(repoType, req.Namespace, req.Name),
+			GitObjectDirectory:            req.GitObjectDirectory,
+			GitAlternateObjectDirectories: req.GitAlternateObjectDirectories,
+		},
+		AncestorId: req.AncestorID,
+		ChildId:    req.ChildID,
+	}
+	resp, err := c.commitClient.CommitIsAncestor(ctx, ancestorReq)
+	if err != nil {
+		return false, err
+	}
+	return resp.Value, nil
+}
+
+func (c *Client) GetRepoFileBlame(ctx context.Context, req gitserver.GetRepoInfoByPathReq) ([]types.BlameRange, error) {
+	var data []byte
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	blameReq := &gitalypb.RawBlameRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+		},
+		Revision: []byte(req.Ref),
+		Path:     []byte(req.Path),
+	}
+	stream, err := c.commitClient.RawBlame(ctx, blameReq)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		data = append(data, resp.Data...)
+	}
+	return parseBlamePorcelain(data), nil
+}
+
+// parseBlamePorcelain merges the output of `git blame --porcelain` into ranges of lines
+// changed by the same commit, commit details are only printed at the first line of each commit
+func parseBlamePorcelain(data []byte) []types.BlameRange {
+	var (
+		ranges    []types.BlameRange
+		commits   = make(map[string]*types.Commit)
+		current   *types.Commit
+		finalLine int
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "\t") {
+			if current == nil {
+				continue
+			}
+			last := len(ranges) - 1
+			if last >= 0 && ranges[last].Commit.ID == current.ID && ranges[last].EndLine == finalLine-1 {
+				ranges[last].EndLine = finalLine
+				ranges[last].Lines = append(ranges[last].Lines, line[1:])
+			} else {
+				ranges = append(ranges, types.BlameRange{
+					Commit:    *current,
+					StartLine: finalLine,
+					EndLine:   finalLine,
+					Lines:     []string{line[1:]},
+				})
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		// header line: <commit id> <original line> <final line> [<lines in group>]
+		if len(key) == 40 || len(key) == 64 {
+			if fields := strings.Fields(value); len(fields) >= 2 {
+				if n, err := strconv.Atoi(fields[1]); err == nil {
+					finalLine = n
+					if commits[key] == nil {
+						commits[key] = &types.Commit{ID: key}
+					}
+					current = commits[key]
+					continue
+				}
+			}
+		}
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			current.AuthorName = value
+		case "author-mail":
+			current.AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			current.AuthoredDate = blameTime(value)
+		case "author-tz":
+			current.AuthoredDate = blameTimeInZone(current.AuthoredDate, value)
+		case "committer":
+			current.CommitterName = value
+		case "committer-mail":
+			current.CommitterEmail = strings.Trim(value, "<>")
+		case "committer-time":
+			current.CommitterDate = blameTime(value)
+			current.CreatedAt = current.CommitterDate
+		case "committer-tz":
+			current.CommitterDate = blameTimeInZone(current.CommitterDate, value)
+			current.CreatedAt = current.CommitterDate
+		case "summary":
+			current.Message = value
+		}
+	}
+	return ranges
+}
+
+func blameTime(unix string) string {
+	sec, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return ""
+	}
+	return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+}
+
+// blameTimeInZone converts a RFC3339 time to the time zone given like +0800
+func blameTimeInZone(date, tz string) string {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil || len(tz) != 5 {
+		return date
+	}
+	hours, errH := strconv.Atoi(tz[1:3])
+	minutes, errM := strconv.Atoi(tz[3:5])
+	if errH != nil || errM != nil {
+		return date
+	}
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return t.In(time.FixedZone(tz, offset)).Format(time.RFC3339)
+}
@@ -0,0 +1,88 @@
+package gitaly
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	blameCommitA = "1111111111111111111111111111111111111111"
+	blameCommitB = "2222222222222222222222222222222222222222"
+)
+
+func Test_parseBlamePorcelain(t *testing.T) {
+	data := blameCommitA + " 1 1 2\n" +
+		"author Alice\n" +
+		"author-mail <alice@example.com>\n" +
+		"author-time 1700000000\n" +
+		"author-tz +0800\n" +
+		"committer Bob\n" +
+		"committer-mail <bob@example.com>\n" +
+		"committer-time 1700000100\n" +
+		"committer-tz -0130\n" +
+		"summary first commit\n" +
+		"filename README.md\n" +
+		"\tline one\n" +
+		blameCommitA + " 2 2\n" +
+		"\tline two\n" +
+		blameCommitB + " 3 3 1\n" +
+		"author Carol\n" +
+		"summary second commit\n" +
+		"filename README.md\n" +
+		"\tline three\n" +
+		blameCommitA + " 3 4 1\n" +
+		"\tline four\n"
+
+	ranges := parseBlamePorcelain([]byte(data))
+	if len(ranges) != 3 {
+		t.Fatalf("expect 3 ranges, got %d: %+v", len(ranges), ranges)
+	}
+
+	first := ranges[0]
+	if first.Commit.ID != blameCommitA || first.StartLine != 1 || first.EndLine != 2 ||
+		!reflect.DeepEqual(first.Lines, []string{"line one", "line two"}) {
+		t.Errorf("consecutive lines of a commit should be merged, got %+v", first)
+	}
+	if first.Commit.AuthorName != "Alice" || first.Commit.AuthorEmail != "alice@example.com" ||
+		first.Commit.CommitterName != "Bob" || first.Commit.CommitterEmail != "bob@example.com" ||
+		first.Commit.Message != "first commit" {
+		t.Errorf("unexpected commit details %+v", first.Commit)
+	}
+	if first.Commit.AuthoredDate != "2023-11-15T06:13:20+08:00" {
+		t.Errorf("unexpected authored date %s", first.Commit.AuthoredDate)
+	}
+	if first.Commit.CommitterDate != "2023-11-14T20:45:00-01:30" || first.Commit.CreatedAt != first.Commit.CommitterDate {
+		t.Errorf("unexpected committer date %s, created at %s", first.Commit.CommitterDate, first.Commit.CreatedAt)
+	}
+
+	second := ranges[1]
+	if second.Commit.ID != blameCommitB || second.StartLine != 3 || second.EndLine != 3 || second.Commit.AuthorName != "Carol" {
+		t.Errorf("unexpected second range %+v", second)
+	}
+
+	// a repeated commit only prints its header line, details come from the first occurrence
+	third := ranges[2]
+	if third.Commit.ID != blameCommitA || third.StartLine != 4 || third.EndLine != 4 ||
+		third.Commit.AuthorName != "Alice" || !reflect.DeepEqual(third.Lines, []string{"line four"}) {
+		t.Errorf("unexpected third range %+v", third)
+	}
+}
+
+func Test_parseBlamePorcelain_empty(t *testing.T) {
+	if ranges := parseBlamePorcelain(nil); len(ranges) != 0 {
+		t.Errorf("expect no ranges, got %+v", ranges)
+	}
+}
+
+func Test_blameTimeInZone(t *testing.T) {
+	testData := map[string]string{
+		"+0000": "2023-11-14T22:13:20Z",
+		"+0530": "2023-11-15T03:43:20+05:30",
+		"bad":   "2023-11-14T22:13:20Z",
+	}
+	for tz, expect := range testData {
+		if got := blameTimeInZone("2023-11-14T22:13:20Z", tz); got != expect {
+			t.Errorf("tz %s: expect %s, got %s", tz, expect, got)
+		}
+	}
+}
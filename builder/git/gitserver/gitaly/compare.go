@@ -0,0 +1,305 @@
+package gitaly
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const zeroBlobID = "0000000000000000000000000000000000000000"
+
+// CompareRefs gives each gitaly call its own timeout, so large compares are not cut by the time spent on the
+// calls before them
+func (c *Client) CompareRefs(ctx context.Context, req gitserver.CompareRefsReq) (*types.CompareResponse, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	repository := &gitalypb.Repository{
+		StorageName:  c.config.GitalyServer.Storge,
+		RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+	}
+
+	baseID, err := c.resolveCommitID(ctx, repository, req.Base)
+	if err != nil {
+		return nil, err
+	}
+	headID, err := c.resolveCommitID(ctx, repository, req.Head)
+	if err != nil {
+		return nil, err
+	}
+	mergeBaseID, err := c.findMergeBase(ctx, repository, baseID, headID)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.CompareResponse{
+		BaseCommitID:      baseID,
+		HeadCommitID:      headID,
+		MergeBaseCommitID: mergeBaseID,
+	}
+
+	result.Commits, result.TotalCommits, err = c.listCommitsBetween(ctx, repository, baseID, headID, req.Per, req.Page)
+	if err != nil {
+		return nil, err
+	}
+	result.Files, result.Stats, err = c.diffStats(ctx, repository, mergeBaseID, headID)
+	if err != nil {
+		return nil, err
+	}
+	result.LFSFiles, err = c.lfsPointerChanges(ctx, repository, mergeBaseID, headID)
+	if err != nil {
+		return nil, err
+	}
+	result.Diff, result.DiffTruncated, err = c.rawDiff(ctx, repository, mergeBaseID, headID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) resolveCommitID(ctx context.Context, repository *gitalypb.Repository, ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	resp, err := c.commitClient.FindCommit(ctx, &gitalypb.FindCommitRequest{
+		Repository: repository,
+		Revision:   []byte(ref),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Commit == nil {
+		return "", fmt.Errorf("ref '%s' not found", ref)
+	}
+	return resp.Commit.Id, nil
+}
+
+// findMergeBase returns base itself for unrelated histories which have no merge base
+func (c *Client) findMergeBase(ctx context.Context, repository *gitalypb.Repository, base, head string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	resp, err := c.repoClient.FindMergeBase(ctx, &gitalypb.FindMergeBaseRequest{
+		Repository: repository,
+		Revisions:  [][]byte{[]byte(base), []byte(head)},
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Base == "" {
+		return base, nil
+	}
+	return resp.Base, nil
+}
+
+// listCommitsBetween returns a page of the commits reachable from head but not from base newest first, and
+// the total number of them
+func (c *Client) listCommitsBetween(ctx context.Context, repository *gitalypb.Repository, base, head string, per, page int) ([]types.Commit, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	revision := []byte(base + ".." + head)
+	var commits []types.Commit
+	stream, err := c.commitClient.FindCommits(ctx, &gitalypb.FindCommitsRequest{
+		Repository: repository,
+		Revision:   revision,
+		Limit:      int32(per),
+		Offset:     int32(per * (page - 1)),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, 0, err
+		}
+		for _, commit := range resp.Commits {
+			commits = append(commits, types.Commit{
+				ID:             commit.Id,
+				CommitterName:  string(commit.Committer.Name),
+				CommitterEmail: string(commit.Committer.Email),
+				CommitterDate:  commit.Committer.Date.AsTime().Format(time.RFC3339),
+				CreatedAt:      commit.Committer.Date.AsTime().Format(time.RFC3339),
+				Message:        string(commit.Subject),
+				AuthorName:     string(commit.Author.Name),
+				AuthorEmail:    string(commit.Author.Email),
+				AuthoredDate:   commit.Author.Date.AsTime().Format(time.RFC3339),
+			})
+		}
+	}
+	count, err := c.commitClient.CountCommits(ctx, &gitalypb.CountCommitsRequest{
+		Repository: repository,
+		Revision:   revision,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return commits, int(count.Count), nil
+}
+
+func (c *Client) diffStats(ctx context.Context, repository *gitalypb.Repository, left, right string) ([]types.CompareFile, *types.CommitStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	var (
+		files                []types.CompareFile
+		additions, deletions int
+	)
+	statsStream, err := c.diffClient.DiffStats(ctx, &gitalypb.DiffStatsRequest{
+		Repository:    repository,
+		LeftCommitId:  left,
+		RightCommitId: right,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		data, err := statsStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, err
+		}
+		for _, stat := range data.Stats {
+			file := types.CompareFile{
+				Path:      string(stat.Path),
+				Additions: int(stat.Additions),
+				Deletions: int(stat.Deletions),
+			}
+			if len(stat.OldPath) > 0 && string(stat.OldPath) != file.Path {
+				file.OldPath = string(stat.OldPath)
+			}
+			files = append(files, file)
+			additions += file.Additions
+			deletions += file.Deletions
+		}
+	}
+	return files, &types.CommitStats{
+		Additions: additions,
+		Deletions: deletions,
+		Total:     additions + deletions,
+	}, nil
+}
+
+// rawDiff reads the unified diff up to gitserver.MaxCompareDiffSize, truncated tells if it was cut
+func (c *Client) rawDiff(ctx context.Context, repository *gitalypb.Repository, left, right string) (diff []byte, truncated bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	diffStream, err := c.diffClient.RawDiff(ctx, &gitalypb.RawDiffRequest{
+		Repository:    repository,
+		LeftCommitId:  left,
+		RightCommitId: right,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	for {
+		data, err := diffStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, false, err
+		}
+		if len(diff)+len(data.Data) > gitserver.MaxCompareDiffSize {
+			return append(diff, data.Data[:gitserver.MaxCompareDiffSize-len(diff)]...), true, nil
+		}
+		diff = append(diff, data.Data...)
+	}
+	return diff, false, nil
+}
+
+// lfsPointerChanges finds the changed paths whose old or new blob is an LFS pointer
+func (c *Client) lfsPointerChanges(ctx context.Context, repository *gitalypb.Repository, left, right string) ([]types.LFSPointerChange, error) {
+	var (
+		changedPaths []*gitalypb.ChangedPaths
+		blobIDs      []string
+		changes      []types.LFSPointerChange
+	)
+	pathsCtx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	pathsStream, err := c.diffClient.FindChangedPaths(pathsCtx, &gitalypb.FindChangedPathsRequest{
+		Repository: repository,
+		Requests: []*gitalypb.FindChangedPathsRequest_Request{
+			{
+				Type: &gitalypb.FindChangedPathsRequest_Request_TreeRequest_{
+					TreeRequest: &gitalypb.FindChangedPathsRequest_Request_TreeRequest{
+						LeftTreeRevision:  left,
+						RightTreeRevision: right,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		data, err := pathsStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		for _, path := range data.Paths {
+			changedPaths = append(changedPaths, path)
+			if path.OldBlobId != "" && path.OldBlobId != zeroBlobID {
+				blobIDs = append(blobIDs, path.OldBlobId)
+			}
+			if path.NewBlobId != "" && path.NewBlobId != zeroBlobID {
+				blobIDs = append(blobIDs, path.NewBlobId)
+			}
+		}
+	}
+	if len(blobIDs) == 0 {
+		return nil, nil
+	}
+
+	// only blobs which are valid LFS pointers are returned
+	pointers := make(map[string]types.Pointer)
+	pointersCtx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	pointersStream, err := c.blobClient.GetLFSPointers(pointersCtx, &gitalypb.GetLFSPointersRequest{
+		Repository: repository,
+		BlobIds:    blobIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		data, err := pointersStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		for _, pointer := range data.LfsPointers {
+			p, err := ReadPointerFromBuffer(pointer.Data)
+			if err != nil {
+				continue
+			}
+			pointers[pointer.Oid] = p
+		}
+	}
+
+	for _, path := range changedPaths {
+		oldPointer, oldOK := pointers[path.OldBlobId]
+		newPointer, newOK := pointers[path.NewBlobId]
+		if !oldOK && !newOK {
+			continue
+		}
+		changes = append(changes, types.LFSPointerChange{
+			Path:    string(path.Path),
+			OldOid:  oldPointer.Oid,
+			OldSize: oldPointer.Size,
+			NewOid:  newPointer.Oid,
+			NewSize: newPointer.Size,
+		})
+	}
+	return changes, nil
+}
@@ -292,6 +292,113 @@ func (c *Client) UpdateRepoFile(req *types.UpdateFileReq) (err error) {
 	return err
 }
 
+// content larger than the grpc message limit has to be sent in chunks
+const commitFilesContentChunkSize = 1024 * 1024
+
+var commitFilesActionTypes = map[string]gitalypb.UserCommitFilesActionHeader_ActionType{
+	types.CommitActionCreate: gitalypb.UserCommitFilesActionHeader_CREATE,
+	types.CommitActionUpdate: gitalypb.UserCommitFilesActionHeader_UPDATE,
+	types.CommitActionDelete: gitalypb.UserCommitFilesActionHeader_DELETE,
+	types.CommitActionMove:   gitalypb.UserCommitFilesActionHeader_MOVE,
+}
+
+func (c *Client) CommitFiles(ctx context.Context, req gitserver.CommitFilesReq) (string, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	if timeout := c.config.GitalyServer.CommitFilesTimeoutInSec; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+	userCommitFilesClient, err := c.operationClient.UserCommitFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	repository := &gitalypb.Repository{
+		StorageName:  c.config.GitalyServer.Storge,
+		RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+		GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+	}
+	err = userCommitFilesClient.Send(&gitalypb.UserCommitFilesRequest{
+		UserCommitFilesRequestPayload: &gitalypb.UserCommitFilesRequest_Header{
+			Header: &gitalypb.UserCommitFilesRequestHeader{
+				Repository: repository,
+				User: &gitalypb.User{
+					GlId:       fmt.Sprintf("user-%d", req.UserID),
+					Name:       []byte(req.Username),
+					GlUsername: req.Username,
+					Email:      []byte(req.Email),
+				},
+				BranchName:        []byte(req.Branch),
+				CommitMessage:     []byte(req.Message),
+				CommitAuthorName:  []byte(req.Username),
+				CommitAuthorEmail: []byte(req.Email),
+				Timestamp:         timestamppb.New(time.Now()),
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, action := range req.Actions {
+		actionType, ok := commitFilesActionTypes[action.Action]
+		if !ok {
+			return "", fmt.Errorf("unknown commit action %s", action.Action)
+		}
+		header := &gitalypb.UserCommitFilesActionHeader{
+			Action:        actionType,
+			FilePath:      []byte(action.Path),
+			PreviousPath:  []byte(action.PreviousPath),
+			Base64Content: true,
+			InferContent:  action.Action == types.CommitActionMove && action.Content == "",
+		}
+		err = userCommitFilesClient.Send(&gitalypb.UserCommitFilesRequest{
+			UserCommitFilesRequestPayload: &gitalypb.UserCommitFilesRequest_Action{
+				Action: &gitalypb.UserCommitFilesAction{
+					UserCommitFilesActionPayload: &gitalypb.UserCommitFilesAction_Header{
+						Header: header,
+					},
+				},
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		if action.Action == types.CommitActionDelete || header.InferContent {
+			continue
+		}
+		content := []byte(action.Content)
+		for len(content) > 0 {
+			n := min(len(content), commitFilesContentChunkSize)
+			err = userCommitFilesClient.Send(&gitalypb.UserCommitFilesRequest{
+				UserCommitFilesRequestPayload: &gitalypb.UserCommitFilesRequest_Action{
+					Action: &gitalypb.UserCommitFilesAction{
+						UserCommitFilesActionPayload: &gitalypb.UserCommitFilesAction_Content{
+							Content: content[:n],
+						},
+					},
+				},
+			})
+			if err != nil {
+				return "", err
+			}
+			content = content[n:]
+		}
+	}
+
+	resp, err := userCommitFilesClient.CloseAndRecv()
+	if err != nil {
+		return "", err
+	}
+	if resp.IndexError != "" {
+		return "", errors.New(resp.IndexError)
+	}
+	if resp.PreReceiveError != "" {
+		return "", errors.New(resp.PreReceiveError)
+	}
+	return resp.BranchUpdate.GetCommitId(), nil
+}
+
 func (c *Client) GetRepoFileTree(ctx context.Context, req gitserver.GetRepoInfoByPathReq) ([]*types.File, error) {
 	var files []*types.File
 	repoType := fmt.Sprintf("%ss", string(req.RepoType))
@@ -504,3 +611,43 @@ func (c *Client) GetRepoAllLfsPointers(ctx context.Context, req gitserver.GetRep
 	}
 	return pointers, nil
 }
+
+func (c *Client) GetRepoLfsPointersByRevisions(ctx context.Context, req gitserver.GetRepoLfsPointersByRevisionsReq) ([]*types.LFSPointer, error) {
+	var pointers []*types.LFSPointer
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	pointersReq := &gitalypb.ListLFSPointersRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+		},
+		Revisions: req.Revisions,
+	}
+
+	pointersStream, err := c.blobClient.ListLFSPointers(ctx, pointersReq)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		pointersResp, err := pointersStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		for _, pointer := range pointersResp.GetLfsPointers() {
+			p, _ := ReadPointerFromBuffer(pointer.Data)
+			pointers = append(pointers, &types.LFSPointer{
+				Oid:      pointer.Oid,
+				Size:     pointer.Size,
+				FileOid:  string(p.Oid),
+				FileSize: p.Size,
+				Data:     string(pointer.Data),
+			})
+		}
+	}
+	return pointers, nil
+}
@@ -0,0 +1,120 @@
+package gitaly
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gitalypb "gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"google.golang.org/grpc"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type fakeCommitFilesStream struct {
+	grpc.ClientStream
+	requests []*gitalypb.UserCommitFilesRequest
+	resp     *gitalypb.UserCommitFilesResponse
+}
+
+func (s *fakeCommitFilesStream) Send(req *gitalypb.UserCommitFilesRequest) error {
+	s.requests = append(s.requests, req)
+	return nil
+}
+
+func (s *fakeCommitFilesStream) CloseAndRecv() (*gitalypb.UserCommitFilesResponse, error) {
+	return s.resp, nil
+}
+
+type fakeOperationClient struct {
+	gitalypb.OperationServiceClient
+	stream *fakeCommitFilesStream
+}
+
+func (c *fakeOperationClient) UserCommitFiles(ctx context.Context, opts ...grpc.CallOption) (gitalypb.OperationService_UserCommitFilesClient, error) {
+	return c.stream, nil
+}
+
+func newCommitFilesClient(resp *gitalypb.UserCommitFilesResponse) (*Client, *fakeCommitFilesStream) {
+	stream := &fakeCommitFilesStream{resp: resp}
+	cfg := &config.Config{}
+	cfg.GitalyServer.Storge = "default"
+	return &Client{config: cfg, operationClient: &fakeOperationClient{stream: stream}}, stream
+}
+
+func TestCommitFiles(t *testing.T) {
+	client, stream := newCommitFilesClient(&gitalypb.UserCommitFilesResponse{
+		BranchUpdate: &gitalypb.OperationBranchUpdate{CommitId: "abc"},
+	})
+	large := strings.Repeat("a", commitFilesContentChunkSize+1)
+
+	commitID, err := client.CommitFiles(context.Background(), gitserver.CommitFilesReq{
+		Namespace: "ns",
+		Name:      "repo",
+		RepoType:  types.ModelRepo,
+		Branch:    "main",
+		Message:   "update files",
+		UserID:    7,
+		Username:  "alice",
+		Email:     "alice@example.com",
+		Actions: []types.CommitAction{
+			{Action: types.CommitActionCreate, Path: "a.txt", Content: large},
+			{Action: types.CommitActionDelete, Path: "b.txt"},
+			{Action: types.CommitActionMove, Path: "d.txt", PreviousPath: "c.txt"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitID != "abc" {
+		t.Errorf("expect commit id abc, got %s", commitID)
+	}
+
+	// header, create header with two content chunks, delete header, move header
+	if len(stream.requests) != 6 {
+		t.Fatalf("expect 6 requests, got %d", len(stream.requests))
+	}
+	header := stream.requests[0].GetHeader()
+	if header == nil || string(header.BranchName) != "main" || header.User.GlId != "user-7" ||
+		header.Repository.RelativePath != BuildRelativePath("models", "ns", "repo") {
+		t.Errorf("unexpected commit header %+v", header)
+	}
+
+	create := stream.requests[1].GetAction().GetHeader()
+	if create.Action != gitalypb.UserCommitFilesActionHeader_CREATE || string(create.FilePath) != "a.txt" || !create.Base64Content {
+		t.Errorf("unexpected create header %+v", create)
+	}
+	chunks := len(stream.requests[2].GetAction().GetContent()) + len(stream.requests[3].GetAction().GetContent())
+	if len(stream.requests[2].GetAction().GetContent()) != commitFilesContentChunkSize || chunks != len(large) {
+		t.Errorf("content should be sent in chunks of %d bytes", commitFilesContentChunkSize)
+	}
+
+	if del := stream.requests[4].GetAction().GetHeader(); del.Action != gitalypb.UserCommitFilesActionHeader_DELETE {
+		t.Errorf("unexpected delete header %+v", del)
+	}
+	move := stream.requests[5].GetAction().GetHeader()
+	if move.Action != gitalypb.UserCommitFilesActionHeader_MOVE || string(move.PreviousPath) != "c.txt" || !move.InferContent {
+		t.Errorf("a move without content should infer it, got %+v", move)
+	}
+}
+
+func TestCommitFiles_errors(t *testing.T) {
+	client, _ := newCommitFilesClient(&gitalypb.UserCommitFilesResponse{PreReceiveError: "branch is protected"})
+	_, err := client.CommitFiles(context.Background(), gitserver.CommitFilesReq{
+		RepoType: types.ModelRepo,
+		Actions:  []types.CommitAction{{Action: types.CommitActionDelete, Path: "a.txt"}},
+	})
+	if err == nil || err.Error() != "branch is protected" {
+		t.Errorf("expect the pre receive error, got %v", err)
+	}
+
+	client, _ = newCommitFilesClient(&gitalypb.UserCommitFilesResponse{})
+	_, err = client.CommitFiles(context.Background(), gitserver.CommitFilesReq{
+		RepoType: types.ModelRepo,
+		Actions:  []types.CommitAction{{Action: "chmod", Path: "a.txt"}},
+	})
+	if err == nil {
+		t.Error("expect an unknown action to be rejected")
+	}
+}
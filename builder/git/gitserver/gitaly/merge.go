@@ -0,0 +1,130 @@
+package gitaly
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+)
+
+func (c *Client) FetchSourceBranch(ctx context.Context, req gitserver.FetchSourceBranchReq) error {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	// gitaly needs to know how to reach the storage of the source repository
+	ctx, err := c.injectGitalyServers(ctx)
+	if err != nil {
+		return err
+	}
+	fetchReq := &gitalypb.FetchSourceBranchRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+			GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+		},
+		SourceRepository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.SourceNamespace, req.SourceName),
+			GlRepository: filepath.Join(repoType, req.SourceNamespace, req.SourceName),
+		},
+		SourceBranch: []byte(req.SourceBranch),
+		TargetRef:    []byte(req.TargetRef),
+	}
+	resp, err := c.repoClient.FetchSourceBranch(ctx, fetchReq)
+	if err != nil {
+		return err
+	}
+	if !resp.Result {
+		return fmt.Errorf("branch %s not found in %s/%s", req.SourceBranch, req.SourceNamespace, req.SourceName)
+	}
+	return nil
+}
+
+func (c *Client) GetMergeBase(ctx context.Context, req gitserver.GetMergeBaseReq) (string, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	mergeBaseReq := &gitalypb.FindMergeBaseRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+		},
+	}
+	for _, revision := range req.Revisions {
+		mergeBaseReq.Revisions = append(mergeBaseReq.Revisions, []byte(revision))
+	}
+	resp, err := c.repoClient.FindMergeBase(ctx, mergeBaseReq)
+	if err != nil {
+		return "", err
+	}
+	return resp.Base, nil
+}
+
+func (c *Client) MergeBranch(ctx context.Context, req gitserver.MergeBranchReq) (string, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	stream, err := c.operationClient.UserMergeBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	// the first request creates the merge commit, the second one applies it to the branch
+	err = stream.Send(&gitalypb.UserMergeBranchRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+			GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+		},
+		User: &gitalypb.User{
+			GlId:       fmt.Sprintf("user-%d", req.UserID),
+			Name:       []byte(req.Username),
+			GlUsername: req.Username,
+			Email:      []byte(req.Email),
+		},
+		CommitId:  req.CommitID,
+		Branch:    []byte(req.Branch),
+		Message:   []byte(req.Message),
+		Timestamp: timestamppb.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := stream.Recv(); err != nil {
+		return "", err
+	}
+	if err := stream.Send(&gitalypb.UserMergeBranchRequest{Apply: true}); err != nil {
+		return "", err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return "", err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return "", err
+	}
+	if resp.BranchUpdate == nil {
+		return "", errors.New("merge commit was not applied to the branch")
+	}
+	return resp.BranchUpdate.CommitId, nil
+}
+
+func (c *Client) injectGitalyServers(ctx context.Context) (context.Context, error) {
+	servers := map[string]map[string]string{
+		c.config.GitalyServer.Storge: {
+			"address": c.config.GitalyServer.Address,
+			"token":   c.config.GitalyServer.Token,
+		},
+	}
+	data, err := json.Marshal(servers)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, "gitaly-servers", base64.StdEncoding.EncodeToString(data)), nil
+}
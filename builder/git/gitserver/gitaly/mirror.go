@@ -33,7 +33,7 @@ func (c *Client) CreateMirrorRepo(ctx context.Context, req gitserver.CreateMirro
 		if !resp.Exists {
 			return 0, fmt.Errorf("invalid clone url")
 		}
-		authorHeader = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", req.Username, req.AccessToken)))
+		authorHeader = basicAuthHeader(req.Username, req.AccessToken)
 	}
 
 	gitalyReq := &gitalypb.CreateRepositoryFromURLRequest{
@@ -59,7 +59,7 @@ func (c *Client) CreateMirrorRepo(ctx context.Context, req gitserver.CreateMirro
 }
 
 func (c *Client) CreateMirrorForExistsRepo(ctx context.Context, req gitserver.CreateMirrorRepoReq) error {
-	var authorHeader string
+	authorHeader := basicAuthHeader(req.Username, req.AccessToken)
 	repoType := fmt.Sprintf("%ss", string(req.RepoType))
 	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
 	defer cancel()
@@ -91,12 +91,20 @@ func (c *Client) CreateMirrorForExistsRepo(ctx context.Context, req gitserver.Cr
 	return nil
 }
 
+// basicAuthHeader returns the Authorization header gitaly sends to the remote, empty for public remotes
+func basicAuthHeader(username, accessToken string) string {
+	if accessToken == "" {
+		return ""
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, accessToken)))
+}
+
 func (c *Client) GetMirrorTaskInfo(ctx context.Context, taskId int64) (*gitserver.MirrorTaskInfo, error) {
 	return nil, nil
 }
 
 func (c *Client) MirrorSync(ctx context.Context, req gitserver.MirrorSyncReq) error {
-	var authorHeader string
+	authorHeader := basicAuthHeader(req.Username, req.AccessToken)
 	repoType := fmt.Sprintf("%ss", string(req.RepoType))
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,6 +14,9 @@ import (
 
 const timeoutTime = 10 * time.Second
 
+// forking copies all objects of the source repository
+const forkTimeoutTime = 10 * time.Minute
+
 func (c *Client) CreateRepo(ctx context.Context, req gitserver.CreateRepoReq) (*gitserver.CreateRepoResp, error) {
 	repoType := fmt.Sprintf("%ss", string(req.RepoType))
 	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
@@ -78,6 +82,58 @@ func (c *Client) DeleteRepo(ctx context.Context, req gitserver.DeleteRepoReq) er
 	return nil
 }
 
+func (c *Client) ForkRepo(ctx context.Context, req gitserver.ForkRepoReq) (*gitserver.CreateRepoResp, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, forkTimeoutTime)
+	defer cancel()
+	// gitaly clones the source repository through the gitaly server of its storage
+	ctx, err := c.injectGitalyServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gitalyReq := &gitalypb.CreateForkRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.TargetNamespace, req.TargetName),
+			GlRepository: filepath.Join(repoType, req.TargetNamespace, req.TargetName),
+		},
+		SourceRepository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+			GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+		},
+	}
+	_, err = c.repoClient.CreateFork(ctx, gitalyReq)
+	if err != nil {
+		return nil, err
+	}
+
+	sshCloneURL, err := url.JoinPath(c.config.APIServer.SSHDomain, repoType, req.TargetNamespace, req.TargetName)
+	if err != nil {
+		return nil, err
+	}
+	httpCloneURL, err := url.JoinPath(c.config.APIServer.PublicDomain, repoType, req.TargetNamespace, req.TargetName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitserver.CreateRepoResp{
+		Username:      req.Username,
+		Namespace:     req.TargetNamespace,
+		Name:          req.TargetName,
+		Nickname:      req.Nickname,
+		Description:   req.Description,
+		License:       req.License,
+		DefaultBranch: req.DefaultBranch,
+		RepoType:      req.RepoType,
+		GitPath:       strings.TrimSuffix(BuildRelativePath(repoType, req.TargetNamespace, req.TargetName), ".git"),
+		SshCloneURL:   sshCloneURL + ".git",
+		HttpCloneURL:  httpCloneURL + ".git",
+		Private:       req.Private,
+	}, nil
+}
+
 func (c *Client) GetRepo(ctx context.Context, req gitserver.GetRepoReq) (*gitserver.CreateRepoResp, error) {
 	repoType := fmt.Sprintf("%ss", string(req.RepoType))
 	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
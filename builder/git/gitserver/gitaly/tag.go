@@ -2,7 +2,12 @@ package gitaly
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"time"
 
+	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/common/types"
 )
@@ -10,3 +15,59 @@ import (
 func (c *Client) GetRepoTags(ctx context.Context, req gitserver.GetRepoTagsReq) (tags []*types.Tag, err error) {
 	return
 }
+
+func (c *Client) CreateTag(ctx context.Context, req gitserver.CreateTagReq) (*types.Tag, error) {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	tagReq := &gitalypb.UserCreateTagRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+			GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+		},
+		TagName:        []byte(req.Tag),
+		TargetRevision: []byte(req.Target),
+		Message:        []byte(req.Message),
+		User: &gitalypb.User{
+			GlId:       fmt.Sprintf("user-%d", req.UserID),
+			Name:       []byte(req.Username),
+			GlUsername: req.Username,
+			Email:      []byte(req.Email),
+		},
+		Timestamp: timestamppb.New(time.Now()),
+	}
+	resp, err := c.operationClient.UserCreateTag(ctx, tagReq)
+	if err != nil {
+		return nil, err
+	}
+	tag := &types.Tag{Name: req.Tag, Message: req.Message}
+	if resp.Tag != nil && resp.Tag.TargetCommit != nil {
+		tag.Commit = types.DatasetTagCommit{
+			ID: resp.Tag.TargetCommit.Id,
+		}
+	}
+	return tag, nil
+}
+
+func (c *Client) DeleteTag(ctx context.Context, req gitserver.DeleteTagReq) error {
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	tagReq := &gitalypb.UserDeleteTagRequest{
+		Repository: &gitalypb.Repository{
+			StorageName:  c.config.GitalyServer.Storge,
+			RelativePath: BuildRelativePath(repoType, req.Namespace, req.Name),
+			GlRepository: filepath.Join(repoType, req.Namespace, req.Name),
+		},
+		TagName: []byte(req.Tag),
+		User: &gitalypb.User{
+			GlId:       fmt.Sprintf("user-%d", req.UserID),
+			Name:       []byte(req.Username),
+			GlUsername: req.Username,
+			Email:      []byte(req.Email),
+		},
+	}
+	_, err := c.operationClient.UserDeleteTag(ctx, tagReq)
+	return err
+}
@@ -0,0 +1,219 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/OpenCSGs/gitea-go-sdk/gitea"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+const (
+	// maxBlameCommits bounds how far back the history of a file is walked, lines older than
+	// that are attributed to the oldest commit that was loaded
+	maxBlameCommits     = 20
+	blameCommitsPerPage = 20
+	// maxBlameDiffCells bounds the line matching table between two versions of a file, changed
+	// regions larger than that are attributed to the newer commit as a whole
+	maxBlameDiffCells = 1 << 20
+	// maxBlameFileSize bounds each version of the file, every version is downloaded in full
+	maxBlameFileSize = 1 << 20
+)
+
+// GetRepoFileBlame builds blame on the client side, gitea has no blame api. It walks the commits
+// touching the file from newest to oldest and attributes each line to the commit that introduced it
+func (c *Client) GetRepoFileBlame(ctx context.Context, req gitserver.GetRepoInfoByPathReq) ([]types.BlameRange, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	commits, err := c.listBlameCommits(namespace, req.Name, req.Ref, req.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits of file %s, error: %w", req.Path, err)
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("file %s not found at ref %s", req.Path, req.Ref)
+	}
+
+	versions := make([][]string, len(commits))
+	for i, commit := range commits {
+		versions[i], err = c.blameFileLines(namespace, req.Name, commit.ID, req.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file %s at commit %s, error: %w", req.Path, commit.ID, err)
+		}
+	}
+	return blameRanges(commits, versions), nil
+}
+
+func (c *Client) listBlameCommits(namespace, name, ref, path string) ([]types.Commit, error) {
+	var commits []types.Commit
+	for page := 1; len(commits) < maxBlameCommits; page++ {
+		giteaCommits, _, err := c.giteaClient.ListRepoCommits(namespace, name, gitea.ListCommitOptions{
+			ListOptions: gitea.ListOptions{
+				PageSize: blameCommitsPerPage,
+				Page:     page,
+			},
+			SpeedUpOtions: gitea.SpeedUpOtions{
+				DisableStat:         true,
+				DisableVerification: true,
+				DisableFiles:        true,
+			},
+			SHA:  ref,
+			Path: path,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, giteaCommit := range giteaCommits {
+			if len(commits) == maxBlameCommits {
+				break
+			}
+			summary, _, _ := strings.Cut(giteaCommit.RepoCommit.Message, "\n")
+			commits = append(commits, types.Commit{
+				ID:             giteaCommit.SHA,
+				CommitterName:  giteaCommit.RepoCommit.Committer.Name,
+				CommitterEmail: giteaCommit.RepoCommit.Committer.Email,
+				CommitterDate:  giteaCommit.RepoCommit.Committer.Date,
+				CreatedAt:      giteaCommit.CommitMeta.Created.String(),
+				Message:        summary,
+				AuthorName:     giteaCommit.RepoCommit.Author.Name,
+				AuthorEmail:    giteaCommit.RepoCommit.Author.Email,
+				AuthoredDate:   giteaCommit.RepoCommit.Author.Date,
+			})
+		}
+		if len(giteaCommits) < blameCommitsPerPage {
+			break
+		}
+	}
+	return commits, nil
+}
+
+// blameFileLines returns the lines of the file at the commit, a file missing at that commit
+// (deleted by it) has no lines
+func (c *Client) blameFileLines(namespace, name, commitID, path string) ([]string, error) {
+	data, response, err := c.giteaClient.GetFile(namespace, name, commitID, path)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(data) > maxBlameFileSize {
+		return nil, fmt.Errorf("blame of files larger than %d bytes is not supported by gitea", maxBlameFileSize)
+	}
+	return splitBlameLines(string(data)), nil
+}
+
+func splitBlameLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// blameRanges attributes the lines of versions[0] to commits, versions[i] is the file at commits[i]
+// and commits are ordered from newest to oldest
+func blameRanges(commits []types.Commit, versions [][]string) []types.BlameRange {
+	final := versions[0]
+	owners := make([]int, len(final))
+	// positions[i] is the line in the version being compared that final line i currently maps to
+	positions := make([]int, len(final))
+	pending := make([]int, len(final))
+	for i := range final {
+		positions[i] = i
+		pending[i] = i
+	}
+	for i := range commits {
+		if len(pending) == 0 {
+			break
+		}
+		if i == len(commits)-1 {
+			for _, line := range pending {
+				owners[line] = i
+			}
+			break
+		}
+		matched := matchLines(versions[i], versions[i+1])
+		next := pending[:0]
+		for _, line := range pending {
+			if older := matched[positions[line]]; older >= 0 {
+				positions[line] = older
+				next = append(next, line)
+			} else {
+				owners[line] = i
+			}
+		}
+		pending = next
+	}
+
+	var ranges []types.BlameRange
+	for i, line := range final {
+		last := len(ranges) - 1
+		if last >= 0 && ranges[last].Commit.ID == commits[owners[i]].ID {
+			ranges[last].EndLine = i + 1
+			ranges[last].Lines = append(ranges[last].Lines, line)
+			continue
+		}
+		ranges = append(ranges, types.BlameRange{
+			Commit:    commits[owners[i]],
+			StartLine: i + 1,
+			EndLine:   i + 1,
+			Lines:     []string{line},
+		})
+	}
+	return ranges
+}
+
+// matchLines maps each line of newer to the line of older it is kept from, or -1 for lines added
+// by newer, using the longest common subsequence of the two versions
+func matchLines(newer, older []string) []int {
+	matched := make([]int, len(newer))
+	for i := range matched {
+		matched[i] = -1
+	}
+	prefix := 0
+	for prefix < len(newer) && prefix < len(older) && newer[prefix] == older[prefix] {
+		matched[prefix] = prefix
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(newer)-prefix && suffix < len(older)-prefix &&
+		newer[len(newer)-1-suffix] == older[len(older)-1-suffix] {
+		matched[len(newer)-1-suffix] = len(older) - 1 - suffix
+		suffix++
+	}
+
+	a := newer[prefix : len(newer)-suffix]
+	b := older[prefix : len(older)-suffix]
+	if len(a) == 0 || len(b) == 0 || (len(a)+1)*(len(b)+1) > maxBlameDiffCells {
+		return matched
+	}
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matched[prefix+i] = prefix + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matched
+}
@@ -0,0 +1,77 @@
+package gitea
+
+import (
+	"reflect"
+	"testing"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+func Test_matchLines(t *testing.T) {
+	testData := []struct {
+		name   string
+		newer  []string
+		older  []string
+		expect []int
+	}{
+		{"identical", []string{"a", "b"}, []string{"a", "b"}, []int{0, 1}},
+		{"appended", []string{"a", "b", "c"}, []string{"a", "b"}, []int{0, 1, -1}},
+		{"inserted", []string{"a", "x", "b"}, []string{"a", "b"}, []int{0, -1, 1}},
+		{"replaced", []string{"a", "y", "c"}, []string{"a", "b", "c"}, []int{0, -1, 2}},
+		{"removed", []string{"a", "c"}, []string{"a", "b", "c"}, []int{0, 2}},
+		{"moved", []string{"b", "x", "a"}, []string{"a", "b"}, []int{-1, -1, 0}},
+		{"new file", []string{"a"}, nil, []int{-1}},
+	}
+
+	for _, data := range testData {
+		if got := matchLines(data.newer, data.older); !reflect.DeepEqual(got, data.expect) {
+			t.Errorf("%s: expect %v, got %v", data.name, data.expect, got)
+		}
+	}
+}
+
+func Test_blameRanges(t *testing.T) {
+	commits := []types.Commit{{ID: "c3"}, {ID: "c2"}, {ID: "c1"}}
+	versions := [][]string{
+		{"a", "x", "b", "c", "d"},
+		{"a", "b", "c", "d"},
+		{"a", "b"},
+	}
+
+	ranges := blameRanges(commits, versions)
+	expect := []struct {
+		id         string
+		start, end int
+		lines      []string
+	}{
+		{"c1", 1, 1, []string{"a"}},
+		{"c3", 2, 2, []string{"x"}},
+		{"c1", 3, 3, []string{"b"}},
+		{"c2", 4, 5, []string{"c", "d"}},
+	}
+	if len(ranges) != len(expect) {
+		t.Fatalf("expect %d ranges, got %d: %+v", len(expect), len(ranges), ranges)
+	}
+	for i, e := range expect {
+		r := ranges[i]
+		if r.Commit.ID != e.id || r.StartLine != e.start || r.EndLine != e.end || !reflect.DeepEqual(r.Lines, e.lines) {
+			t.Errorf("range %d: expect %+v, got %+v", i, e, r)
+		}
+	}
+}
+
+func Test_blameRanges_oldestLoadedCommitOwnsRest(t *testing.T) {
+	ranges := blameRanges([]types.Commit{{ID: "c1"}}, [][]string{{"a", "b"}})
+	if len(ranges) != 1 || ranges[0].Commit.ID != "c1" || ranges[0].EndLine != 2 {
+		t.Errorf("expect one range of c1 over 2 lines, got %+v", ranges)
+	}
+}
+
+func Test_splitBlameLines(t *testing.T) {
+	if lines := splitBlameLines(""); lines != nil {
+		t.Errorf("expect no lines for empty file, got %v", lines)
+	}
+	if lines := splitBlameLines("a\nb\n"); !reflect.DeepEqual(lines, []string{"a", "b"}) {
+		t.Errorf("expect trailing newline to be dropped, got %v", lines)
+	}
+}
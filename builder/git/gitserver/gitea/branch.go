@@ -2,6 +2,7 @@ package gitea
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/OpenCSGs/gitea-go-sdk/gitea"
 	"opencsg.com/csghub-server/builder/git/gitserver"
@@ -33,3 +34,72 @@ func (c *Client) GetRepoBranches(ctx context.Context, req gitserver.GetBranchesR
 	}
 	return branches, err
 }
+
+func (c *Client) CreateBranch(ctx context.Context, req gitserver.CreateBranchReq) (*types.Branch, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	giteaBranch, _, err := c.giteaClient.CreateBranch(
+		namespace,
+		req.Name,
+		gitea.CreateBranchOption{
+			BranchName:    req.Branch,
+			OldBranchName: req.StartPoint,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	branch := &types.Branch{Name: giteaBranch.Name}
+	if giteaBranch.Commit != nil {
+		branch.Message = giteaBranch.Commit.Message
+		branch.Commit = types.RepoBranchCommit{
+			ID: giteaBranch.Commit.ID,
+		}
+	}
+	return branch, nil
+}
+
+func (c *Client) DeleteBranch(ctx context.Context, req gitserver.DeleteBranchReq) error {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	deleted, _, err := c.giteaClient.DeleteRepoBranch(namespace, req.Name, req.Branch)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("branch %s was not deleted", req.Branch)
+	}
+	return nil
+}
+
+// RenameBranch creates the new branch from the old one and then deletes the old branch, the new branch is deleted
+// again if the old one can not be deleted
+func (c *Client) RenameBranch(ctx context.Context, req gitserver.RenameBranchReq) error {
+	_, err := c.CreateBranch(ctx, gitserver.CreateBranchReq{
+		Namespace:  req.Namespace,
+		Name:       req.Name,
+		RepoType:   req.RepoType,
+		Branch:     req.NewBranch,
+		StartPoint: req.Branch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s from %s, error: %w", req.NewBranch, req.Branch, err)
+	}
+	err = c.DeleteBranch(ctx, gitserver.DeleteBranchReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Branch:    req.Branch,
+	})
+	if err != nil {
+		rollbackErr := c.DeleteBranch(ctx, gitserver.DeleteBranchReq{
+			Namespace: req.Namespace,
+			Name:      req.Name,
+			RepoType:  req.RepoType,
+			Branch:    req.NewBranch,
+		})
+		if rollbackErr != nil {
+			return fmt.Errorf("failed to delete old branch %s, error: %w, and failed to delete new branch %s, error: %v", req.Branch, err, req.NewBranch, rollbackErr)
+		}
+		return fmt.Errorf("failed to delete old branch %s, error: %w", req.Branch, err)
+	}
+	return nil
+}
@@ -28,7 +28,8 @@ func (c *Client) GetRepoCommits(ctx context.Context, req gitserver.GetRepoCommit
 				DisableVerification: true,
 				DisableFiles:        true,
 			},
-			SHA: req.Ref,
+			SHA:  req.Ref,
+			Path: req.Path,
 		},
 	)
 
@@ -168,3 +169,20 @@ func (c *Client) GetSingleCommit(ctx context.Context, req gitserver.GetRepoLastC
 func (c *Client) GetDiffBetweenTwoCommits(ctx context.Context, req gitserver.GetDiffBetweenTwoCommitsReq) (*types.GiteaCallbackPushReq, error) {
 	return nil, nil
 }
+
+func (c *Client) CommitIsAncestor(ctx context.Context, req gitserver.CommitIsAncestorReq) (bool, error) {
+	// gitea enforces its own branch protection when receiving pushes
+	return false, fmt.Errorf("commit ancestry check is not supported by gitea")
+}
+
+func (c *Client) FetchSourceBranch(ctx context.Context, req gitserver.FetchSourceBranchReq) error {
+	return fmt.Errorf("fetching source branch is not supported by gitea")
+}
+
+func (c *Client) GetMergeBase(ctx context.Context, req gitserver.GetMergeBaseReq) (string, error) {
+	return "", fmt.Errorf("merge base is not supported by gitea")
+}
+
+func (c *Client) MergeBranch(ctx context.Context, req gitserver.MergeBranchReq) (string, error) {
+	return "", fmt.Errorf("merging branches is not supported by gitea")
+}
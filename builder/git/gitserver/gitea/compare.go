@@ -0,0 +1,230 @@
+package gitea
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/OpenCSGs/gitea-go-sdk/gitea"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+// rawClient requests the gitea endpoints the sdk does not cover
+var rawClient = &http.Client{Timeout: 30 * time.Second}
+
+// maxCompareCommitsSize caps the json of the compare api
+const maxCompareCommitsSize = 10 << 20
+
+type compareResponse struct {
+	TotalCommits int             `json:"total_commits"`
+	Commits      []*gitea.Commit `json:"commits"`
+}
+
+// CompareRefs uses the compare api of gitea for the commit list, and the unified diff of the
+// compare page for per-file stats and LFS pointer changes, as the sdk supports neither of them
+func (c *Client) CompareRefs(ctx context.Context, req gitserver.CompareRefsReq) (*types.CompareResponse, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	baseHead := fmt.Sprintf("%s...%s", req.Base, req.Head)
+
+	baseID, err := c.resolveCommitID(namespace, req.Name, req.Base)
+	if err != nil {
+		return nil, err
+	}
+	headID, err := c.resolveCommitID(namespace, req.Name, req.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	var compare compareResponse
+	body, truncated, err := c.getRaw(ctx, fmt.Sprintf("%s/api/v1/repos/%s/%s/compare/%s", c.config.GitServer.Host, namespace, req.Name, baseHead), maxCompareCommitsSize)
+	if err != nil {
+		slog.Error("Fail to compare refs", slog.Any("user", namespace), slog.Any("repo", req.Name), slog.Any("basehead", baseHead), slog.Any("error", err))
+		return nil, err
+	}
+	if truncated {
+		return nil, fmt.Errorf("too many commits between %s and %s", req.Base, req.Head)
+	}
+	if err = json.Unmarshal(body, &compare); err != nil {
+		return nil, fmt.Errorf("failed to decode compare response, error: %w", err)
+	}
+	diff, truncated, err := c.getRaw(ctx, fmt.Sprintf("%s/%s/%s/compare/%s.diff", c.config.GitServer.Host, namespace, req.Name, baseHead), gitserver.MaxCompareDiffSize)
+	if err != nil {
+		slog.Error("Fail to get compare diff", slog.Any("user", namespace), slog.Any("repo", req.Name), slog.Any("basehead", baseHead), slog.Any("error", err))
+		return nil, err
+	}
+
+	result := &types.CompareResponse{
+		BaseCommitID:  baseID,
+		HeadCommitID:  headID,
+		Diff:          diff,
+		DiffTruncated: truncated,
+	}
+	// gitea compares from the merge base but does not return it. The first parent of the oldest commit missing
+	// from base is the merge base of linear histories; if head has no such commits it is an ancestor of base
+	result.MergeBaseCommitID = headID
+	if n := len(compare.Commits); n > 0 {
+		result.MergeBaseCommitID = baseID
+		if oldest := compare.Commits[0]; len(oldest.Parents) > 0 {
+			result.MergeBaseCommitID = oldest.Parents[0].SHA
+		}
+	}
+	for _, giteaCommit := range compare.Commits {
+		result.Commits = append(result.Commits, types.Commit{
+			ID:             giteaCommit.SHA,
+			CommitterName:  giteaCommit.RepoCommit.Committer.Name,
+			CommitterEmail: giteaCommit.RepoCommit.Committer.Email,
+			CommitterDate:  giteaCommit.RepoCommit.Committer.Date,
+			CreatedAt:      giteaCommit.CommitMeta.Created.String(),
+			Message:        giteaCommit.RepoCommit.Message,
+			AuthorName:     giteaCommit.RepoCommit.Author.Name,
+			AuthorEmail:    giteaCommit.RepoCommit.Author.Email,
+			AuthoredDate:   giteaCommit.RepoCommit.Author.Date,
+		})
+	}
+	// gitea lists commits oldest first
+	for i, j := 0, len(result.Commits)-1; i < j; i, j = i+1, j-1 {
+		result.Commits[i], result.Commits[j] = result.Commits[j], result.Commits[i]
+	}
+	// the compare api returns all commits, page them here
+	result.TotalCommits = len(result.Commits)
+	result.Commits = pageCommits(result.Commits, req.Per, req.Page)
+
+	var additions, deletions int
+	for _, file := range parseUnifiedDiff(diff) {
+		result.Files = append(result.Files, file.CompareFile)
+		additions += file.Additions
+		deletions += file.Deletions
+
+		oldPointer, oldErr := ReadPointerFromBuffer([]byte(file.oldContent))
+		newPointer, newErr := ReadPointerFromBuffer([]byte(file.newContent))
+		if oldErr != nil && newErr != nil {
+			continue
+		}
+		result.LFSFiles = append(result.LFSFiles, types.LFSPointerChange{
+			Path:    file.Path,
+			OldOid:  oldPointer.Oid,
+			OldSize: oldPointer.Size,
+			NewOid:  newPointer.Oid,
+			NewSize: newPointer.Size,
+		})
+	}
+	result.Stats = &types.CommitStats{
+		Additions: additions,
+		Deletions: deletions,
+		Total:     additions + deletions,
+	}
+	return result, nil
+}
+
+// pageCommits returns the commits of the page, pages start from 1
+func pageCommits(commits []types.Commit, per, page int) []types.Commit {
+	start := per * (page - 1)
+	if per <= 0 || page <= 0 || start >= len(commits) {
+		return nil
+	}
+	return commits[start:min(start+per, len(commits))]
+}
+
+// getRaw reads at most limit bytes of the response, truncated tells if the body is longer
+func (c *Client) getRaw(ctx context.Context, url string, limit int64) (body []byte, truncated bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Authorization", "Basic "+encodeCredentials(c.config.GitServer.Username, c.config.GitServer.Password))
+	resp, err := rawClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("unexpected status code %d, body: %s", resp.StatusCode, string(body))
+	}
+	if int64(len(body)) > limit {
+		return body[:limit], true, nil
+	}
+	return body, false, nil
+}
+
+func (c *Client) resolveCommitID(namespace, name, ref string) (string, error) {
+	commit, _, err := c.giteaClient.GetSingleCommit(namespace, name, ref, gitea.SpeedUpOtions{
+		DisableStat:         true,
+		DisableVerification: true,
+		DisableFiles:        true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to find commit of ref '%s', error: %w", ref, err)
+	}
+	return commit.SHA, nil
+}
+
+type diffFile struct {
+	types.CompareFile
+	// file contents visible in the hunks, enough to read an LFS pointer which is always fully shown
+	oldContent string
+	newContent string
+}
+
+func parseUnifiedDiff(diff []byte) []diffFile {
+	var (
+		files   []diffFile
+		current *diffFile
+		inHunk  bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, diffFile{})
+			current = &files[len(files)-1]
+			inHunk = false
+			// diff --git a/<old> b/<new>, used as is when no ---/+++ header follows
+			if idx := strings.Index(line, " b/"); idx > 0 {
+				current.Path = line[idx+3:]
+			}
+		case current == nil:
+		case !inHunk && strings.HasPrefix(line, "rename from "):
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case !inHunk && strings.HasPrefix(line, "rename to "):
+			current.Path = strings.TrimPrefix(line, "rename to ")
+		case !inHunk && strings.HasPrefix(line, "+++ b/"):
+			current.Path = strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(line, "+"):
+			current.Additions++
+			current.newContent = appendPointerLine(current.newContent, line[1:])
+		case strings.HasPrefix(line, "-"):
+			current.Deletions++
+			current.oldContent = appendPointerLine(current.oldContent, line[1:])
+		case strings.HasPrefix(line, " "):
+			current.oldContent = appendPointerLine(current.oldContent, line[1:])
+			current.newContent = appendPointerLine(current.newContent, line[1:])
+		}
+	}
+	return files
+}
+
+// appendPointerLine stops collecting content once it is too large to be an LFS pointer
+func appendPointerLine(content, line string) string {
+	if len(content)+len(line) >= blobSizeCutoff {
+		return content
+	}
+	return content + line + "\n"
+}
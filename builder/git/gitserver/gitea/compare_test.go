@@ -0,0 +1,35 @@
+package gitea
+
+import (
+	"testing"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestPageCommits(t *testing.T) {
+	var commits []types.Commit
+	for _, id := range []string{"e", "d", "c", "b", "a"} {
+		commits = append(commits, types.Commit{ID: id})
+	}
+	testData := []struct {
+		per, page int
+		expect    string
+	}{
+		{2, 1, "ed"},
+		{2, 2, "cb"},
+		{2, 3, "a"},
+		{2, 4, ""},
+		{10, 1, "edcba"},
+		{0, 1, ""},
+		{2, 0, ""},
+	}
+	for _, data := range testData {
+		var ids string
+		for _, commit := range pageCommits(commits, data.per, data.page) {
+			ids += commit.ID
+		}
+		if ids != data.expect {
+			t.Errorf("per %d page %d: expect commits %q, got %q", data.per, data.page, data.expect, ids)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"strings"
@@ -175,6 +176,65 @@ func (c *Client) UpdateRepoFile(req *types.UpdateFileReq) (err error) {
 	return
 }
 
+func (c *Client) CommitFiles(ctx context.Context, req gitserver.CommitFilesReq) (string, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	var files []*gitea.ChangeFileOperation
+	for _, action := range req.Actions {
+		file := &gitea.ChangeFileOperation{
+			Operation:     action.Action,
+			Path:          action.Path,
+			ContentBase64: action.Content,
+		}
+		// gitea moves a file by updating it with a source path
+		if action.Action == types.CommitActionMove {
+			file.Operation = types.CommitActionUpdate
+			file.FromPath = action.PreviousPath
+		}
+		if file.Operation != types.CommitActionCreate {
+			// update and delete require the sha of the existing file
+			existingPath := action.Path
+			if file.FromPath != "" {
+				existingPath = file.FromPath
+			}
+			existing, _, err := c.giteaClient.GetContents(namespace, req.Name, req.Branch, existingPath)
+			if err != nil {
+				return "", fmt.Errorf("failed to get file %s, error: %w", existingPath, err)
+			}
+			file.SHA = existing.SHA
+			if file.FromPath != "" && file.ContentBase64 == "" && existing.Content != nil {
+				file.ContentBase64 = *existing.Content
+			}
+		}
+		files = append(files, file)
+	}
+	resp, _, err := c.giteaClient.ModifyMultipleFiles(namespace, req.Name, gitea.ChangeFilesOptions{
+		FileOptions: gitea.FileOptions{
+			Message:    req.Message,
+			BranchName: req.Branch,
+			Author: gitea.Identity{
+				Name:  req.Username,
+				Email: req.Email,
+			},
+			Committer: gitea.Identity{
+				Name:  req.Username,
+				Email: req.Email,
+			},
+			Dates: gitea.CommitDateOptions{
+				Author:    time.Now(),
+				Committer: time.Now(),
+			},
+		},
+		Files: files,
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Commit == nil {
+		return "", nil
+	}
+	return resp.Commit.SHA, nil
+}
+
 func (c *Client) getFileContents(owner, repo, ref, path string) (*types.File, error) {
 	var content string
 	/* Example file content from gitea
@@ -243,3 +303,7 @@ func (c *Client) GetRepoAllFiles(ctx context.Context, req gitserver.GetRepoAllFi
 func (c *Client) GetRepoAllLfsPointers(ctx context.Context, req gitserver.GetRepoAllFilesReq) ([]*types.LFSPointer, error) {
 	return nil, nil
 }
+
+func (c *Client) GetRepoLfsPointersByRevisions(ctx context.Context, req gitserver.GetRepoLfsPointersByRevisionsReq) ([]*types.LFSPointer, error) {
+	return nil, fmt.Errorf("listing LFS pointers by revisions is not supported by gitea")
+}
@@ -53,6 +53,49 @@ func (c *Client) CreateRepo(ctx context.Context, req gitserver.CreateRepoReq) (*
 	return resp, nil
 }
 
+func (c *Client) ForkRepo(ctx context.Context, req gitserver.ForkRepoReq) (*gitserver.CreateRepoResp, error) {
+	targetOrg := common.WithPrefix(req.TargetNamespace, repoPrefixByType(req.RepoType))
+	giteaRepo, _, err := c.giteaClient.CreateFork(
+		common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType)),
+		req.Name,
+		gitea.CreateForkOption{
+			Organization: &targetOrg,
+			Name:         &req.TargetName,
+		},
+	)
+	if err != nil {
+		slog.Error("fail to call gitea to fork repository", slog.Any("req", req), slog.String("error", err.Error()))
+		return nil, err
+	}
+	// gitea forks keep the visibility of the source repo, set the requested one afterwards
+	if giteaRepo.Private != req.Private {
+		giteaRepo, _, err = c.giteaClient.EditRepo(targetOrg, req.TargetName, gitea.EditRepoOption{
+			Private: gitea.OptionalBool(req.Private),
+		})
+		if err != nil {
+			slog.Error("fail to call gitea to set visibility of forked repository", slog.Any("req", req), slog.String("error", err.Error()))
+			return nil, err
+		}
+	}
+
+	resp := &gitserver.CreateRepoResp{
+		Username:      req.Username,
+		Namespace:     req.TargetNamespace,
+		Name:          req.TargetName,
+		Nickname:      req.Nickname,
+		Description:   req.Description,
+		License:       req.License,
+		DefaultBranch: giteaRepo.DefaultBranch,
+		RepoType:      req.RepoType,
+		GitPath:       giteaRepo.FullName,
+		SshCloneURL:   giteaRepo.SSHURL,
+		HttpCloneURL:  common.PortalCloneUrl(giteaRepo.CloneURL, req.RepoType, c.config.GitServer.URL, c.config.Frontend.URL),
+		Private:       req.Private,
+	}
+
+	return resp, nil
+}
+
 func (c *Client) UpdateRepo(ctx context.Context, req gitserver.UpdateRepoReq) (*gitserver.CreateRepoResp, error) {
 	giteaRepo, _, err := c.giteaClient.EditRepo(
 		common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType)),
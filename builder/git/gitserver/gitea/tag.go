@@ -36,3 +36,35 @@ func (c *Client) GetRepoTags(ctx context.Context, req gitserver.GetRepoTagsReq)
 	}
 	return
 }
+
+func (c *Client) CreateTag(ctx context.Context, req gitserver.CreateTagReq) (*types.Tag, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	giteaTag, _, err := c.giteaClient.CreateTag(
+		namespace,
+		req.Name,
+		gitea.CreateTagOption{
+			TagName: req.Tag,
+			Message: req.Message,
+			Target:  req.Target,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	tag := &types.Tag{
+		Name:    giteaTag.Name,
+		Message: giteaTag.Message,
+	}
+	if giteaTag.Commit != nil {
+		tag.Commit = types.DatasetTagCommit{
+			ID: giteaTag.Commit.SHA,
+		}
+	}
+	return tag, nil
+}
+
+func (c *Client) DeleteTag(ctx context.Context, req gitserver.DeleteTagReq) error {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	_, err := c.giteaClient.DeleteTag(namespace, req.Name, req.Tag)
+	return err
+}
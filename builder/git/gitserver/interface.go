@@ -25,21 +25,44 @@ type GitServer interface {
 	CreateRepo(ctx context.Context, req CreateRepoReq) (*CreateRepoResp, error)
 	UpdateRepo(ctx context.Context, req UpdateRepoReq) (*CreateRepoResp, error)
 	DeleteRepo(ctx context.Context, req DeleteRepoReq) error
+	// ForkRepo creates a copy of a repository with all its refs in the target namespace
+	ForkRepo(ctx context.Context, req ForkRepoReq) (*CreateRepoResp, error)
 	GetRepoBranches(ctx context.Context, req GetBranchesReq) ([]types.Branch, error)
+	CreateBranch(ctx context.Context, req CreateBranchReq) (*types.Branch, error)
+	DeleteBranch(ctx context.Context, req DeleteBranchReq) error
+	// RenameBranch points a new branch at the head of the old one, then removes the old branch
+	RenameBranch(ctx context.Context, req RenameBranchReq) error
+	CreateTag(ctx context.Context, req CreateTagReq) (*types.Tag, error)
+	DeleteTag(ctx context.Context, req DeleteTagReq) error
 	GetRepoCommits(ctx context.Context, req GetRepoCommitsReq) ([]types.Commit, *types.RepoPageOpts, error)
 	GetRepoLastCommit(ctx context.Context, req GetRepoLastCommitReq) (*types.Commit, error)
 	GetSingleCommit(ctx context.Context, req GetRepoLastCommitReq) (*types.CommitResponse, error)
 	GetCommitDiff(ctx context.Context, req GetRepoLastCommitReq) ([]byte, error)
 	GetRepoFileTree(ctx context.Context, req GetRepoInfoByPathReq) ([]*types.File, error)
+	// GetRepoFileBlame returns the commit that last changed each range of lines of a file
+	GetRepoFileBlame(ctx context.Context, req GetRepoInfoByPathReq) ([]types.BlameRange, error)
 	GetRepoFileRaw(ctx context.Context, req GetRepoInfoByPathReq) (string, error)
 	GetRepoFileReader(ctx context.Context, req GetRepoInfoByPathReq) (io.ReadCloser, int64, error)
 	GetRepoLfsFileRaw(ctx context.Context, req GetRepoInfoByPathReq) (io.ReadCloser, error)
 	GetRepoFileContents(ctx context.Context, req GetRepoInfoByPathReq) (*types.File, error)
 	CreateRepoFile(req *types.CreateFileReq) (err error)
 	UpdateRepoFile(req *types.UpdateFileReq) (err error)
+	// CommitFiles applies all the actions to Branch in a single commit and returns the commit id
+	CommitFiles(ctx context.Context, req CommitFilesReq) (string, error)
 	GetRepoAllFiles(ctx context.Context, req GetRepoAllFilesReq) ([]*types.File, error)
 	GetRepoAllLfsPointers(ctx context.Context, req GetRepoAllFilesReq) ([]*types.LFSPointer, error)
+	// GetRepoLfsPointersByRevisions returns the LFS pointers reachable from the revisions, revisions prefixed with ^ are excluded
+	GetRepoLfsPointersByRevisions(ctx context.Context, req GetRepoLfsPointersByRevisionsReq) ([]*types.LFSPointer, error)
 	GetDiffBetweenTwoCommits(ctx context.Context, req GetDiffBetweenTwoCommitsReq) (*types.GiteaCallbackPushReq, error)
+	// CommitIsAncestor reports whether AncestorID is reachable from ChildID
+	CommitIsAncestor(ctx context.Context, req CommitIsAncestorReq) (bool, error)
+	// FetchSourceBranch copies a branch of another repository into TargetRef of the repository
+	FetchSourceBranch(ctx context.Context, req FetchSourceBranchReq) error
+	GetMergeBase(ctx context.Context, req GetMergeBaseReq) (string, error)
+	// MergeBranch merges CommitID into Branch and returns the merge commit id
+	MergeBranch(ctx context.Context, req MergeBranchReq) (string, error)
+	// CompareRefs returns the changes Head introduces since its merge base with Base
+	CompareRefs(ctx context.Context, req CompareRefsReq) (*types.CompareResponse, error)
 
 	CreateSSHKey(*types.CreateSSHKeyRequest) (*database.SSHKey, error)
 	// ListSSHKeys(string, int, int) ([]*database.SSHKey, error)
@@ -101,6 +101,8 @@ type GetRepoCommitsReq struct {
 	Page      int                  `json:"page"`
 	Ref       string               `json:"ref"`
 	RepoType  types.RepositoryType `json:"repo_type"`
+	// only list commits touching this path when set
+	Path string `json:"path"`
 }
 type GetRepoLastCommitReq struct {
 	Namespace string               `json:"namespace"`
@@ -141,6 +143,14 @@ type GetRepoAllFilesReq struct {
 	RepoType  types.RepositoryType `json:"repo_type"`
 }
 
+type GetRepoLfsPointersByRevisionsReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	// revisions like `refs/heads/main` and `^<commit id>`, as taken by git rev-list
+	Revisions []string `json:"revisions"`
+}
+
 type GetRepoTagsReq = GetBranchesReq
 
 const (
@@ -205,3 +215,148 @@ type UploadPackReq struct {
 }
 
 type ReceivePackReq = UploadPackReq
+
+type CreateBranchReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Branch    string               `json:"branch"`
+	// branch, tag or commit id the new branch points to
+	StartPoint string `json:"start_point"`
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+}
+
+type DeleteBranchReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Branch    string               `json:"branch"`
+	UserID    int64                `json:"user_id"`
+	Username  string               `json:"username"`
+	Email     string               `json:"email"`
+}
+
+type RenameBranchReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Branch    string               `json:"branch"`
+	NewBranch string               `json:"new_branch"`
+	UserID    int64                `json:"user_id"`
+	Username  string               `json:"username"`
+	Email     string               `json:"email"`
+}
+
+type CreateTagReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Tag       string               `json:"tag"`
+	// branch or commit id the tag points to
+	Target string `json:"target"`
+	// create an annotated tag if message is not empty
+	Message  string `json:"message"`
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type DeleteTagReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Tag       string               `json:"tag"`
+	UserID    int64                `json:"user_id"`
+	Username  string               `json:"username"`
+	Email     string               `json:"email"`
+}
+
+type CommitIsAncestorReq struct {
+	Namespace  string               `json:"namespace"`
+	Name       string               `json:"name"`
+	RepoType   types.RepositoryType `json:"repo_type"`
+	AncestorID string               `json:"ancestor_id"`
+	ChildID    string               `json:"child_id"`
+	// quarantine object directories of a push in progress, relative to the repository
+	GitObjectDirectory            string   `json:"git_object_directory"`
+	GitAlternateObjectDirectories []string `json:"git_alternate_object_directories"`
+}
+
+type FetchSourceBranchReq struct {
+	Namespace       string               `json:"namespace"`
+	Name            string               `json:"name"`
+	RepoType        types.RepositoryType `json:"repo_type"`
+	SourceNamespace string               `json:"source_namespace"`
+	SourceName      string               `json:"source_name"`
+	SourceBranch    string               `json:"source_branch"`
+	// ref created in the target repository, like refs/pull/1/head
+	TargetRef string `json:"target_ref"`
+}
+
+type GetMergeBaseReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Revisions []string             `json:"revisions"`
+}
+
+// MaxCompareDiffSize caps the raw diff CompareRefs returns, larger diffs are truncated
+const MaxCompareDiffSize = 10 << 20
+
+// MaxCompareCommitsPerPage caps the commits CompareRefs returns in one page
+const MaxCompareCommitsPerPage = 250
+
+type CompareRefsReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Base      string               `json:"base"`
+	Head      string               `json:"head"`
+	// page of the commits between the refs
+	Per  int `json:"per"`
+	Page int `json:"page"`
+}
+
+type CommitFilesReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Branch    string               `json:"branch"`
+	Message   string               `json:"message"`
+	UserID    int64                `json:"user_id"`
+	Username  string               `json:"username"`
+	Email     string               `json:"email"`
+	// contents of the actions are base64 encoded
+	Actions []types.CommitAction `json:"actions"`
+}
+
+type MergeBranchReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	// commit merged into the branch
+	CommitID string `json:"commit_id"`
+	Branch   string `json:"branch"`
+	Message  string `json:"message"`
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type ForkRepoReq struct {
+	// repository to fork
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	// the fork to create
+	TargetNamespace string `json:"target_namespace"`
+	TargetName      string `json:"target_name"`
+	Username        string `json:"username"`
+	Nickname        string `json:"nickname"`
+	Description     string `json:"description"`
+	License         string `json:"license"`
+	DefaultBranch   string `json:"default_branch"`
+	Private         bool   `json:"private"`
+}
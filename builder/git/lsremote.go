@@ -0,0 +1,93 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LsRemote lists the branches and tags of a remote repository like `git ls-remote --heads --tags`, through the
+// smart http protocol, without fetching any objects. The result maps ref names to the object ids
+func LsRemote(ctx context.Context, cloneURL, username, accessToken string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	infoURL := strings.TrimSuffix(cloneURL, "/") + "/info/refs?service=git-upload-pack"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, infoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ls-remote request: %w", err)
+	}
+	req.Header.Set("User-Agent", "git/2.43.0")
+	if accessToken != "" {
+		req.SetBasicAuth(username, accessToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote refs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list remote refs: %s", resp.Status)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-git-upload-pack-advertisement") {
+		return nil, fmt.Errorf("remote %s does not support smart http protocol", cloneURL)
+	}
+	return readRefAdvertisement(resp.Body)
+}
+
+// readRefAdvertisement reads the pkt-lines of a ref advertisement, the first line announces the service and
+// the first ref carries the capabilities after a NUL byte
+func readRefAdvertisement(r io.Reader) (map[string]string, error) {
+	refs := make(map[string]string)
+	br := bufio.NewReader(r)
+	for {
+		line, flush, err := readPktLine(br)
+		if err == io.EOF {
+			return refs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if flush || strings.HasPrefix(line, "# service=") {
+			continue
+		}
+		line, _, _ = strings.Cut(strings.TrimSuffix(line, "\n"), "\x00")
+		oid, ref, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid ref advertisement line: %s", line)
+		}
+		// peeled tags point to the tagged commits, the tag object id is enough to detect a change
+		if strings.HasSuffix(ref, "^{}") {
+			continue
+		}
+		if strings.HasPrefix(ref, "refs/heads/") || strings.HasPrefix(ref, "refs/tags/") {
+			refs[ref] = oid
+		}
+	}
+}
+
+func readPktLine(r *bufio.Reader) (line string, flush bool, err error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return "", false, err
+	}
+	n, err := strconv.ParseUint(string(lenBuf[:]), 16, 16)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid pkt-line length %q", lenBuf)
+	}
+	if n == 0 {
+		return "", true, nil
+	}
+	if n < 4 {
+		return "", false, fmt.Errorf("invalid pkt-line length %d", n)
+	}
+	data := make([]byte, n-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", false, fmt.Errorf("failed to read pkt-line: %w", err)
+	}
+	return string(data), false, nil
+}
@@ -9,6 +9,8 @@ import (
 type ReverseProxy struct {
 	target *url.URL
 	proxy  *httputil.ReverseProxy
+	// called with the token usage of successful OpenAI-compatible responses if set
+	onTokenUsage func(TokenUsage)
 }
 
 func NewReverseProxy(target string) (*ReverseProxy, error) {
@@ -21,6 +23,11 @@ func NewReverseProxy(target string) (*ReverseProxy, error) {
 	}, nil
 }
 
+// OnTokenUsage sets the handler of the token usage reported in the responses of the target
+func (rp *ReverseProxy) OnTokenUsage(fn func(TokenUsage)) {
+	rp.onTokenUsage = fn
+}
+
 func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request, api string) {
 	proxy := httputil.NewSingleHostReverseProxy(rp.target)
 	proxy.Director = func(req *http.Request) {
@@ -31,6 +38,10 @@ func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request, api st
 			// change url to given api
 			req.URL.Path = api
 		}
+		if rp.onTokenUsage != nil {
+			// let the transport ask for gzip and decompress it, so the usage can be parsed
+			req.Header.Del("Accept-Encoding")
+		}
 
 		// debug only
 		// {
@@ -47,7 +58,15 @@ func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request, api st
 		r.Header.Del("Access-Control-Allow-Headers")
 		r.Header.Del("Access-Control-Allow-Methods")
 		r.Header.Del("Access-Control-Allow-Origin")
+		if rp.onTokenUsage != nil && r.StatusCode == http.StatusOK {
+			if ur := newUsageReader(r, rp.onTokenUsage); ur != nil {
+				r.Body = ur
+			}
+		}
 		return nil
 	}
+	if rp.onTokenUsage != nil {
+		includeStreamUsage(r)
+	}
 	proxy.ServeHTTP(w, r)
 }
@@ -0,0 +1,221 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const (
+	// maxUsageBodySize limits the response body buffered to parse the usage of a non streamed completion,
+	// and the request body buffered to ask for the usage of a streamed completion
+	maxUsageBodySize = 16 << 20
+	// maxUsageLineSize limits the line of server sent events buffered to parse the usage of a stream
+	maxUsageLineSize = 1 << 20
+)
+
+// TokenUsage is the usage reported by OpenAI-compatible completion APIs
+type TokenUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+type usageBody struct {
+	Usage *TokenUsage `json:"usage"`
+}
+
+// usageReader passes the response body through and parses the token usage of it,
+// the usage handler is called once the body is closed if the body reports a usage
+type usageReader struct {
+	io.ReadCloser
+	// server sent events, the usage is in the last chunk with a usage
+	stream bool
+	// the whole body of a normal response, or the current line of a stream
+	buf bytes.Buffer
+	// max size of buf, the body or line is skipped once it is larger
+	limit    int
+	overflow bool
+	usage    *TokenUsage
+	onUsage  func(TokenUsage)
+	closed   bool
+}
+
+// newUsageReader returns nil if the usage of the response can not be parsed, which is the case for
+// compressed responses and responses which are neither json nor server sent events
+func newUsageReader(resp *http.Response, onUsage func(TokenUsage)) *usageReader {
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return nil
+	}
+	ur := &usageReader{
+		ReadCloser: resp.Body,
+		onUsage:    onUsage,
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream":
+		ur.stream = true
+		ur.limit = maxUsageLineSize
+	case "application/json":
+		if resp.ContentLength > maxUsageBodySize {
+			return nil
+		}
+		ur.limit = maxUsageBodySize
+	default:
+		return nil
+	}
+	return ur
+}
+
+func (ur *usageReader) Read(p []byte) (int, error) {
+	n, err := ur.ReadCloser.Read(p)
+	if n > 0 {
+		ur.consume(p[:n])
+	}
+	return n, err
+}
+
+func (ur *usageReader) consume(data []byte) {
+	if !ur.stream {
+		ur.buffer(data)
+		return
+	}
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			ur.buffer(data)
+			return
+		}
+		ur.buffer(data[:i])
+		if !ur.overflow {
+			ur.parseEvent(ur.buf.Bytes())
+		}
+		ur.buf.Reset()
+		ur.overflow = false
+		data = data[i+1:]
+	}
+}
+
+// buffer appends the data to the buffer unless that grows it over the limit, the rest of an
+// oversized body or line is dropped
+func (ur *usageReader) buffer(data []byte) {
+	if ur.overflow {
+		return
+	}
+	if ur.buf.Len()+len(data) > ur.limit {
+		ur.overflow = true
+		ur.buf.Reset()
+		return
+	}
+	ur.buf.Write(data)
+}
+
+// parseEvent parses a line of server sent events like `data: {"usage": {...}}`
+func (ur *usageReader) parseEvent(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	// most chunks carry no usage, skip them without decoding
+	if !bytes.Contains(data, []byte(`"usage"`)) {
+		return
+	}
+	ur.parseUsage(data)
+}
+
+func (ur *usageReader) parseUsage(data []byte) {
+	var body usageBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		return
+	}
+	if body.Usage != nil {
+		ur.usage = body.Usage
+	}
+}
+
+func (ur *usageReader) Close() error {
+	err := ur.ReadCloser.Close()
+	if ur.closed {
+		return err
+	}
+	ur.closed = true
+	if !ur.overflow {
+		if ur.stream {
+			ur.parseEvent(ur.buf.Bytes())
+		} else {
+			ur.parseUsage(ur.buf.Bytes())
+		}
+	}
+	if ur.usage == nil {
+		return err
+	}
+	usage := *ur.usage
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	if usage.TotalTokens > 0 {
+		ur.onUsage(usage)
+	}
+	return err
+}
+
+// includeStreamUsage asks for the usage of a streamed completion request, OpenAI-compatible servers
+// only report it with stream_options.include_usage, in an extra last chunk without choices.
+// Other requests are passed on unchanged
+func includeStreamUsage(r *http.Request) {
+	if r.Method != http.MethodPost || r.Body == nil || r.Body == http.NoBody || r.ContentLength > maxUsageBodySize {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return
+	}
+	body := r.Body
+	data, err := io.ReadAll(io.LimitReader(body, maxUsageBodySize+1))
+	if err != nil || len(data) > maxUsageBodySize {
+		// pass on what was read and the rest of the body
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), body), body}
+		return
+	}
+	body.Close()
+	if patched, ok := withStreamUsage(data); ok {
+		data = patched
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+}
+
+// withStreamUsage sets stream_options.include_usage of the request body if it streams
+func withStreamUsage(data []byte) ([]byte, bool) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, false
+	}
+	var stream bool
+	if err := json.Unmarshal(req["stream"], &stream); err != nil || !stream {
+		return nil, false
+	}
+	options := make(map[string]json.RawMessage)
+	if raw, ok := req["stream_options"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &options); err != nil {
+			return nil, false
+		}
+	}
+	options["include_usage"] = json.RawMessage("true")
+	raw, err := json.Marshal(options)
+	if err != nil {
+		return nil, false
+	}
+	req["stream_options"] = raw
+	patched, err := json.Marshal(req)
+	if err != nil {
+		return nil, false
+	}
+	return patched, true
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func readUsage(t *testing.T, contentType, encoding, body string) (*TokenUsage, bool) {
+	t.Helper()
+	resp := &http.Response{
+		Header:        http.Header{},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: -1,
+	}
+	resp.Header.Set("Content-Type", contentType)
+	if encoding != "" {
+		resp.Header.Set("Content-Encoding", encoding)
+	}
+	var usage *TokenUsage
+	ur := newUsageReader(resp, func(u TokenUsage) { usage = &u })
+	if ur == nil {
+		return nil, false
+	}
+	data, err := io.ReadAll(ur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != body {
+		t.Errorf("expect the body to pass through unchanged, got %q", data)
+	}
+	ur.Close()
+	return usage, true
+}
+
+func TestUsageReader(t *testing.T) {
+	chunk := `data: {"choices":[{"delta":{"content":"hi"}}]}` + "\n\n"
+	testData := []struct {
+		name        string
+		contentType string
+		encoding    string
+		body        string
+		parsed      bool
+		expect      *TokenUsage
+	}{
+		{"json", "application/json; charset=utf-8", "", `{"usage":{"prompt_tokens":3,"completion_tokens":4}}`, true, &TokenUsage{3, 4, 7}},
+		{"json without usage", "application/json", "", `{"choices":[]}`, true, nil},
+		{"stream", "text/event-stream", "", chunk + `data: {"choices":[],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}` + "\n\ndata: [DONE]\n\n", true, &TokenUsage{1, 2, 3}},
+		{"stream without trailing newline", "text/event-stream", "", chunk + `data: {"usage":{"total_tokens":5}}`, true, &TokenUsage{0, 0, 5}},
+		{"oversized line is skipped", "text/event-stream", "", "data: " + strings.Repeat("x", maxUsageLineSize) + `"usage"` + "\n" + `data: {"usage":{"total_tokens":2}}` + "\n", true, &TokenUsage{0, 0, 2}},
+		{"not json", "text/plain", "", `{"usage":{"total_tokens":5}}`, false, nil},
+		{"gzip", "application/json", "gzip", `{"usage":{"total_tokens":5}}`, false, nil},
+	}
+	for _, data := range testData {
+		usage, parsed := readUsage(t, data.contentType, data.encoding, data.body)
+		if parsed != data.parsed {
+			t.Errorf("%s: expect parsed %v, got %v", data.name, data.parsed, parsed)
+			continue
+		}
+		if (usage == nil) != (data.expect == nil) || (usage != nil && *usage != *data.expect) {
+			t.Errorf("%s: expect usage %+v, got %+v", data.name, data.expect, usage)
+		}
+	}
+}
+
+func TestUsageReader_oversizedBody(t *testing.T) {
+	body := `{"usage":{"total_tokens":5},"pad":"` + strings.Repeat("x", maxUsageBodySize) + `"}`
+	usage, parsed := readUsage(t, "application/json", "", body)
+	if !parsed || usage != nil {
+		t.Errorf("expect an oversized body to pass through without usage, got %+v", usage)
+	}
+
+	resp := &http.Response{Header: http.Header{}, Body: http.NoBody, ContentLength: maxUsageBodySize + 1}
+	resp.Header.Set("Content-Type", "application/json")
+	if newUsageReader(resp, func(TokenUsage) {}) != nil {
+		t.Error("expect a body known to be oversized not to be parsed")
+	}
+}
+
+func TestWithStreamUsage(t *testing.T) {
+	testData := []struct {
+		name    string
+		body    string
+		patched bool
+	}{
+		{"stream", `{"model":"m","stream":true}`, true},
+		{"stream with options", `{"stream":true,"stream_options":{"include_usage":false,"other":1}}`, true},
+		{"null options", `{"stream":true,"stream_options":null}`, true},
+		{"not streamed", `{"stream":false}`, false},
+		{"no stream field", `{"model":"m"}`, false},
+		{"invalid json", `{"stream":`, false},
+	}
+	for _, data := range testData {
+		patched, ok := withStreamUsage([]byte(data.body))
+		if ok != data.patched {
+			t.Errorf("%s: expect patched %v, got %v", data.name, data.patched, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		var req struct {
+			Stream        bool                   `json:"stream"`
+			StreamOptions map[string]interface{} `json:"stream_options"`
+		}
+		if err := json.Unmarshal(patched, &req); err != nil {
+			t.Fatal(err)
+		}
+		if !req.Stream || req.StreamOptions["include_usage"] != true {
+			t.Errorf("%s: expect include_usage to be set, got %s", data.name, patched)
+		}
+		if strings.Contains(data.body, "other") && req.StreamOptions["other"] != float64(1) {
+			t.Errorf("%s: expect other stream options to be kept, got %s", data.name, patched)
+		}
+	}
+}
+
+func TestReverseProxy_tokenUsage(t *testing.T) {
+	var upstreamBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		upstreamBody = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(`{"usage":{"total_tokens":9}}`))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte(`{"usage":{"total_tokens":9}}`))
+		gw.Close()
+	}))
+	defer upstream.Close()
+
+	rp, err := NewReverseProxy(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usage TokenUsage
+	rp.OnTokenUsage(func(u TokenUsage) { usage = u })
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"stream":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	rp.ServeHTTP(w, req, "")
+
+	if !strings.Contains(upstreamBody, `"include_usage":true`) {
+		t.Errorf("expect the stream request to ask for usage, got %s", upstreamBody)
+	}
+	if usage.TotalTokens != 9 {
+		t.Errorf("expect the usage of a gzip response to be parsed, got %+v", usage)
+	}
+	if w.Body.String() != `{"usage":{"total_tokens":9}}` {
+		t.Errorf("unexpected response body %s", w.Body.String())
+	}
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -60,3 +61,70 @@ func (c *Cache) ZAdd(ctx context.Context, key string, z redis.Z) error {
 func (c *Cache) BZPopMax(ctx context.Context, key string) (*redis.ZWithKey, error) {
 	return c.core.BZPopMax(ctx, time.Second*10, key).Result()
 }
+
+// ZRangeByScore returns the members with scores up to max, in ascending order of score
+func (c *Cache) ZRangeByScore(ctx context.Context, key string, max float64) ([]string, error) {
+	return c.core.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatFloat(max, 'f', -1, 64),
+	}).Result()
+}
+
+// ZRem removes the member and returns if it was in the sorted set
+func (c *Cache) ZRem(ctx context.Context, key string, member string) (bool, error) {
+	n, err := c.core.ZRem(ctx, key, member).Result()
+	return n > 0, err
+}
+
+func (c *Cache) HSet(ctx context.Context, key, field string, value interface{}) error {
+	return c.core.HSet(ctx, key, field, value).Err()
+}
+
+// HGet returns redis.Nil if the field does not exist
+func (c *Cache) HGet(ctx context.Context, key, field string) (string, error) {
+	return c.core.HGet(ctx, key, field).Result()
+}
+
+func (c *Cache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.core.HGetAll(ctx, key).Result()
+}
+
+func (c *Cache) HExists(ctx context.Context, key, field string) (bool, error) {
+	return c.core.HExists(ctx, key, field).Result()
+}
+
+// HDel removes the field and returns if it existed
+func (c *Cache) HDel(ctx context.Context, key, field string) (bool, error) {
+	n, err := c.core.HDel(ctx, key, field).Result()
+	return n > 0, err
+}
+
+// ZPopMin pops the member with the lowest score, returns redis.Nil if the sorted set is empty
+func (c *Cache) ZPopMin(ctx context.Context, key string) (redis.Z, error) {
+	zs, err := c.core.ZPopMin(ctx, key).Result()
+	if err != nil {
+		return redis.Z{}, err
+	}
+	if len(zs) == 0 {
+		return redis.Z{}, redis.Nil
+	}
+	return zs[0], nil
+}
+
+func (c *Cache) ZCard(ctx context.Context, key string) (int64, error) {
+	return c.core.ZCard(ctx, key).Result()
+}
+
+// ZRangeWithScores returns all the members with their scores, in ascending order of score
+func (c *Cache) ZRangeWithScores(ctx context.Context, key string) ([]redis.Z, error) {
+	return c.core.ZRangeWithScores(ctx, key, 0, -1).Result()
+}
+
+func (c *Cache) HLen(ctx context.Context, key string) (int64, error) {
+	return c.core.HLen(ctx, key).Result()
+}
+
+// RunScript runs the lua script, returns redis.Nil if the script returns false or nil
+func (c *Cache) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, c.core, keys, args...).Result()
+}
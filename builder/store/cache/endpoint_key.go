@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const endpointKeyPrefix = "endpoint-key-"
+
+// EndpointKeyCache counts the requests and tokens of endpoint keys in fixed one minute windows
+type EndpointKeyCache struct {
+	cache *Cache
+}
+
+func NewEndpointKeyCache(cache *Cache) *EndpointKeyCache {
+	return &EndpointKeyCache{
+		cache: cache,
+	}
+}
+
+func endpointKeyCounter(keyID int64, kind string) string {
+	return fmt.Sprintf("%s%d-%s-%d", endpointKeyPrefix, keyID, kind, time.Now().Unix()/60)
+}
+
+func (c *EndpointKeyCache) incr(ctx context.Context, key string, value int64) (int64, error) {
+	pipe := c.cache.core.TxPipeline()
+	count := pipe.IncrBy(ctx, key, value)
+	// keep the counter a bit longer than its window in case of clock skew between servers
+	pipe.Expire(ctx, key, 2*time.Minute)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// IncrRequests counts a request of the key and returns the requests of the key in the current minute
+func (c *EndpointKeyCache) IncrRequests(ctx context.Context, keyID int64) (int64, error) {
+	return c.incr(ctx, endpointKeyCounter(keyID, "requests"), 1)
+}
+
+// AddTokens adds the tokens used by a request of the key to the current minute
+func (c *EndpointKeyCache) AddTokens(ctx context.Context, keyID int64, tokens int64) error {
+	_, err := c.incr(ctx, endpointKeyCounter(keyID, "tokens"), tokens)
+	return err
+}
+
+// Tokens returns the tokens used by the key in the current minute
+func (c *EndpointKeyCache) Tokens(ctx context.Context, keyID int64) (int64, error) {
+	tokens, err := c.cache.core.Get(ctx, endpointKeyCounter(keyID, "tokens")).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return tokens, err
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type AccountBalanceStore struct {
+	db *DB
+}
+
+func NewAccountBalanceStore() *AccountBalanceStore {
+	return &AccountBalanceStore{
+		db: defaultDB,
+	}
+}
+
+type AccountBalance struct {
+	ID        int64                  `bun:",pk,autoincrement" json:"id"`
+	OwnerType types.BalanceOwnerType `bun:",notnull,unique:idx_account_balances_owner" json:"owner_type"`
+	// user uuid or organization name
+	OwnerID string `bun:",notnull,unique:idx_account_balances_owner" json:"owner_id"`
+	// balance in cents
+	Balance int64 `bun:",notnull,default:0" json:"balance"`
+	// sub-cents of metering costs not charged yet, always less than a cent
+	SubCentRemainder int64 `bun:",notnull,default:0" json:"-"`
+	// time the balance went negative, running deploys are stopped once it is older than the grace period
+	NegativeSince time.Time `bun:",nullzero" json:"negative_since"`
+	times
+}
+
+// AccountBalanceEntry is one change of a balance, the ledger of a balance is the list of its entries
+type AccountBalanceEntry struct {
+	ID        int64                  `bun:",pk,autoincrement" json:"id"`
+	OwnerType types.BalanceOwnerType `bun:",notnull" json:"owner_type"`
+	OwnerID   string                 `bun:",notnull" json:"owner_id"`
+	EntryType types.BalanceEntryType `bun:",notnull" json:"entry_type"`
+	// amount in cents, positive for credits and negative for debits
+	Amount       int64 `bun:",notnull" json:"amount"`
+	BalanceAfter int64 `bun:",notnull" json:"balance_after"`
+	// the metering record a charge is derived from, 0 for other entries
+	MeteringID int64     `bun:",notnull,default:0" json:"metering_id"`
+	OpUser     string    `json:"op_user"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `bun:",notnull,default:current_timestamp" json:"created_at"`
+}
+
+// FindByOwner returns nil if the owner has no balance yet
+func (s *AccountBalanceStore) FindByOwner(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string) (*AccountBalance, error) {
+	var balance AccountBalance
+	err := s.db.Core.NewSelect().Model(&balance).
+		Where("owner_type = ? and owner_id = ?", ownerType, ownerID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// AddEntry applies the amount of the entry to the balance of its owner and saves the entry in the ledger
+func (s *AccountBalanceStore) AddEntry(ctx context.Context, entry *AccountBalanceEntry) (*AccountBalance, error) {
+	var balance *AccountBalance
+	err := s.db.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		balance, err = addBalanceEntry(ctx, tx, entry)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func addBalanceEntry(ctx context.Context, tx bun.Tx, entry *AccountBalanceEntry) (*AccountBalance, error) {
+	err := initBalance(ctx, tx, entry.OwnerType, entry.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	// update in one statement so concurrent entries never lose an amount
+	err = assertAffectedOneRow(tx.NewUpdate().
+		Model((*AccountBalance)(nil)).
+		Set("balance = balance + ?", entry.Amount).
+		Set("negative_since = CASE WHEN balance + ? >= 0 THEN NULL WHEN negative_since IS NULL THEN ? ELSE negative_since END", entry.Amount, now).
+		Set("updated_at = ?", now).
+		Where("owner_type = ? and owner_id = ?", entry.OwnerType, entry.OwnerID).
+		Exec(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update balance, error: %w", err)
+	}
+	var balance AccountBalance
+	err = tx.NewSelect().Model(&balance).
+		Where("owner_type = ? and owner_id = ?", entry.OwnerType, entry.OwnerID).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance, error: %w", err)
+	}
+	entry.BalanceAfter = balance.Balance
+	err = assertAffectedOneRow(tx.NewInsert().Model(entry).Exec(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save balance entry, error: %w", err)
+	}
+	return &balance, nil
+}
+
+func initBalance(ctx context.Context, tx bun.Tx, ownerType types.BalanceOwnerType, ownerID string) error {
+	_, err := tx.NewInsert().
+		Model(&AccountBalance{OwnerType: ownerType, OwnerID: ownerID}).
+		On("CONFLICT (owner_type, owner_id) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to init balance, error: %w", err)
+	}
+	return nil
+}
+
+// settleSubCents adds the sub-cents to the remainder of the balance, and takes the whole cents out of it.
+// It returns the cents to charge, the remainder is kept for the next metering of the owner
+func settleSubCents(ctx context.Context, tx bun.Tx, ownerType types.BalanceOwnerType, ownerID string, subCents int64) (int64, error) {
+	if err := initBalance(ctx, tx, ownerType, ownerID); err != nil {
+		return 0, err
+	}
+	// the update locks the balance until the transaction ends, so the remainder is never charged twice
+	var remainder int64
+	err := tx.NewUpdate().
+		Model((*AccountBalance)(nil)).
+		Set("sub_cent_remainder = sub_cent_remainder + ?", subCents).
+		Where("owner_type = ? and owner_id = ?", ownerType, ownerID).
+		Returning("sub_cent_remainder").
+		Scan(ctx, &remainder)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add sub-cents to balance, error: %w", err)
+	}
+	cents := remainder / SubCentsPerCent
+	if cents == 0 {
+		return 0, nil
+	}
+	err = assertAffectedOneRow(tx.NewUpdate().
+		Model((*AccountBalance)(nil)).
+		Set("sub_cent_remainder = sub_cent_remainder - ?", cents*SubCentsPerCent).
+		Where("owner_type = ? and owner_id = ?", ownerType, ownerID).
+		Exec(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to take cents from sub-cent remainder, error: %w", err)
+	}
+	return cents, nil
+}
+
+func (s *AccountBalanceStore) ListEntries(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string, per, page int) ([]AccountBalanceEntry, int, error) {
+	var entries []AccountBalanceEntry
+	q := s.db.Core.NewSelect().Model(&entries).
+		Where("owner_type = ? and owner_id = ?", ownerType, ownerID)
+	count, err := q.Count(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count balance entries, error: %w", err)
+	}
+	_, err = q.Order("id DESC").Limit(per).Offset((page-1)*per).Exec(ctx, &entries)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list balance entries, error: %w", err)
+	}
+	return entries, count, nil
+}
+
+// ListNegativeBefore lists the balances which went negative before the time and are still negative
+func (s *AccountBalanceStore) ListNegativeBefore(ctx context.Context, before time.Time) ([]AccountBalance, error) {
+	var balances []AccountBalance
+	err := s.db.Core.NewSelect().Model(&balances).
+		Where("negative_since is not null and negative_since <= ?", before).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
@@ -2,10 +2,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/uptrace/bun"
 	"opencsg.com/csghub-server/common/types"
 	commonTypes "opencsg.com/csghub-server/common/types"
 )
@@ -35,14 +38,70 @@ type AccountMetering struct {
 	Extra        string          `json:"extra"`
 	CreatedAt    time.Time       `bun:",notnull,default:current_timestamp" json:"created_at"`
 	SkuUnitType  string          `json:"sku_unit_type"`
+	// price applied to the event, 0 if the sku has no price at the time of the event
+	PriceID int64 `bun:",notnull,default:0" json:"price_id"`
+	// cost of the event in sub-cents, see SubCentsPerCent
+	SubCentCost int64 `bun:",notnull,default:0" json:"sub_cent_cost"`
+	// cents charged for the event, the sub-cent costs of the owner are charged once they add up to a cent
+	Cost int64 `bun:",notnull,default:0" json:"cost"`
+	// organization the usage is billed to, empty if it is billed to the user
+	OrgName string `bun:",notnull,default:''" json:"org_name"`
 }
 
-func (am *AccountMeteringStore) Create(ctx context.Context, input AccountMetering) error {
-	res, err := am.db.Core.NewInsert().Model(&input).Exec(ctx, &input)
-	if err := assertAffectedOneRow(res, err); err != nil {
-		return fmt.Errorf("failed to save metering event, error: %w", err)
+// Create saves the metering record, adds it to the daily usage and applies its charge to the balance in one
+// transaction, the charge can be nil. The amount of the charge is the whole cents of the sub-cent cost of the
+// metering plus the remainder carried on the balance, nothing is charged while they add up to less than a cent.
+// It returns false without saving or charging anything if the event was saved before, so redelivered events are
+// never charged twice
+func (am *AccountMeteringStore) Create(ctx context.Context, input *AccountMetering, charge *AccountBalanceEntry) (bool, error) {
+	created := false
+	err := am.db.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewInsert().Model(input).
+			On("CONFLICT (event_uuid) DO NOTHING").
+			Exec(ctx, input)
+		// nothing is returned if the event was saved before
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save metering event, error: %w", err)
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to save metering event, error: %w", err)
+		}
+		if rows == 0 {
+			return nil
+		}
+		created = true
+		if charge != nil {
+			cents, err := settleSubCents(ctx, tx, charge.OwnerType, charge.OwnerID, input.SubCentCost)
+			if err != nil {
+				return err
+			}
+			input.Cost = cents
+		}
+		if input.Cost > 0 {
+			_, err = tx.NewUpdate().Model(input).Column("cost").WherePK().Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to save cost of metering event, error: %w", err)
+			}
+		}
+		if err := addUsage(ctx, tx, input); err != nil {
+			return err
+		}
+		if input.Cost == 0 {
+			return nil
+		}
+		charge.MeteringID = input.ID
+		charge.Amount = -input.Cost
+		_, err = addBalanceEntry(ctx, tx, charge)
+		return err
+	})
+	if err != nil {
+		return false, err
 	}
-	return nil
+	return created, nil
 }
 
 func (am *AccountMeteringStore) ListByUserIDAndTime(ctx context.Context, req commonTypes.ACCT_STATEMENTS_REQ) ([]AccountMetering, int, error) {
@@ -60,3 +119,50 @@ func (am *AccountMeteringStore) ListByUserIDAndTime(ctx context.Context, req com
 	}
 	return accountMeters, count, nil
 }
+
+// ListByUserIDAndMonths returns the meterings of the user recorded in [start, end) for the monthly totals,
+// all scenes are included if scene is 0
+func (am *AccountMeteringStore) ListByUserIDAndMonths(ctx context.Context, userUUID string, scene int, start, end time.Time) ([]AccountMetering, error) {
+	return am.listByMonths(ctx, "user_uuid = ?", userUUID, scene, start, end)
+}
+
+// ListByOrgNameAndMonths is ListByUserIDAndMonths for the usage billed to the organization
+func (am *AccountMeteringStore) ListByOrgNameAndMonths(ctx context.Context, orgName string, scene int, start, end time.Time) ([]AccountMetering, error) {
+	return am.listByMonths(ctx, "org_name = ?", orgName, scene, start, end)
+}
+
+func (am *AccountMeteringStore) listByMonths(ctx context.Context, ownerQuery string, owner string, scene int, start, end time.Time) ([]AccountMetering, error) {
+	var accountMeters []AccountMetering
+	q := am.db.Operator.Core.NewSelect().Model(&accountMeters).
+		Column("scene", "sku_unit_type", "value", "cost", "recorded_at").
+		Where(ownerQuery, owner).
+		Where("recorded_at >= ? and recorded_at < ?", start, end)
+	if scene > 0 {
+		q = q.Where("scene = ?", scene)
+	}
+	err := q.Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list meters of months, error: %w", err)
+	}
+	return accountMeters, nil
+}
+
+// ListByOrgNameAndTime lists the meterings billed to the organization, of all instances if the instance name is empty
+func (am *AccountMeteringStore) ListByOrgNameAndTime(ctx context.Context, req commonTypes.ACCT_STATEMENTS_REQ) ([]AccountMetering, int, error) {
+	var accountMeters []AccountMetering
+	q := am.db.Operator.Core.NewSelect().Model(&accountMeters).Where("org_name = ? and scene = ? and recorded_at >= ? and recorded_at <= ?", req.OrgName, req.Scene, req.StartTime, req.EndTime)
+	if req.InstanceName != "" {
+		q = q.Where("customer_id = ?", req.InstanceName)
+	}
+
+	count, err := q.Count(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to counting recorders, error: %w", err)
+	}
+
+	_, err = q.Order("id DESC").Limit(req.Per).Offset((req.Page-1)*req.Per).Exec(ctx, &accountMeters)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list all meters, error: %w", err)
+	}
+	return accountMeters, count, nil
+}
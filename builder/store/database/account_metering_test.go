@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// initAccountingDB creates the accounting tables in an in-memory sqlite database and makes it the default database
+func initAccountingDB(t *testing.T, name string) *DB {
+	ctx := context.Background()
+	dbConfig := DBConfig{Dialect: DialectSQLite, DSN: "file:" + name + "?mode=memory&cache=shared"}
+	InitDB(dbConfig)
+	db, err := NewDB(ctx, dbConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []any{(*AccountMetering)(nil), (*AccountUsageDaily)(nil), (*AccountBalance)(nil), (*AccountBalanceEntry)(nil)} {
+		if _, err := db.Core.NewCreateTable().Model(model).IfNotExists().Exec(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = db.Core.NewCreateIndex().Model((*AccountMetering)(nil)).Index("idx_account_meterings_event_uuid").
+		Unique().Column("event_uuid").IfNotExists().Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestAccountMeteringStoreCreate_carriesSubCents(t *testing.T) {
+	ctx := context.Background()
+	db := initAccountingDB(t, "account_metering")
+	store := NewAccountMeteringStore()
+	price := AccountPrice{SkuPrice: 100, SkuUnit: 60}
+	recordedAt := time.Now()
+
+	// 60 one-minute events cost a cent and two thirds each, so nothing is lost to rounding
+	for i := 0; i < 60; i++ {
+		am := &AccountMetering{
+			EventUUID:   uuid.New(),
+			UserUUID:    "user",
+			Value:       1,
+			Scene:       types.SceneSpace,
+			ResourceID:  "sku",
+			CustomerID:  "space",
+			RecordedAt:  recordedAt,
+			SkuUnitType: "minute",
+			SubCentCost: price.SubCentCost(1),
+		}
+		charge := &AccountBalanceEntry{
+			OwnerType: types.BalanceOwnerUser,
+			OwnerID:   "user",
+			EntryType: types.BalanceEntryCharge,
+		}
+		if _, err := store.Create(ctx, am, charge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balance, err := NewAccountBalanceStore().FindByOwner(ctx, types.BalanceOwnerUser, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Balance != -100 {
+		t.Errorf("expect balance -100, got %d", balance.Balance)
+	}
+	if balance.SubCentRemainder < 0 || balance.SubCentRemainder >= SubCentsPerCent {
+		t.Errorf("expect remainder less than a cent, got %d", balance.SubCentRemainder)
+	}
+	var cost int64
+	err = db.Core.NewSelect().Model((*AccountMetering)(nil)).ColumnExpr("SUM(cost)").Scan(ctx, &cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != 100 {
+		t.Errorf("expect meterings to cost 100, got %d", cost)
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+type AccountPriceStore struct {
+	db *DB
+}
+
+func NewAccountPriceStore() *AccountPriceStore {
+	return &AccountPriceStore{
+		db: defaultDB,
+	}
+}
+
+// AccountPrice is the price of a sku in a scene during a time range, a price with empty SkuID
+// applies to all skus of the scene which have no price of their own
+type AccountPrice struct {
+	ID      int64           `bun:",pk,autoincrement" json:"id"`
+	SkuType types.SKUType   `bun:",notnull" json:"sku_type"`
+	Scene   types.SceneType `bun:",notnull" json:"scene"`
+	SkuID   string          `bun:",notnull" json:"sku_id"`
+	// SkuPrice in cents is charged for every SkuUnit units of SkuUnitType, like 100 cents per 60 minutes
+	SkuUnit     int64     `bun:",notnull" json:"sku_unit"`
+	SkuUnitType string    `bun:",notnull" json:"sku_unit_type"`
+	SkuPrice    int64     `bun:",notnull" json:"sku_price"`
+	StartTime   time.Time `bun:",notnull" json:"start_time"`
+	// zero end time means the price never expires
+	EndTime time.Time `bun:",nullzero" json:"end_time"`
+	times
+}
+
+// SubCentsPerCent is the precision of the cost of a metering event, the fractions of a cent are carried
+// on the balance and charged once they add up to whole cents
+const SubCentsPerCent = 1000000
+
+// SubCentCost returns the cost of the value in units of the price in sub-cents
+func (p *AccountPrice) SubCentCost(value float64) int64 {
+	return int64(math.Round(value * float64(p.SkuPrice) * SubCentsPerCent / float64(p.SkuUnit)))
+}
+
+func (s *AccountPriceStore) Create(ctx context.Context, price *AccountPrice) error {
+	res, err := s.db.Core.NewInsert().Model(price).Exec(ctx, price)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return fmt.Errorf("failed to create price, error: %w", err)
+	}
+	return nil
+}
+
+func (s *AccountPriceStore) Update(ctx context.Context, price *AccountPrice) error {
+	return assertAffectedOneRow(s.db.Core.NewUpdate().
+		Model(price).
+		WherePK().
+		Exec(ctx),
+	)
+}
+
+func (s *AccountPriceStore) Delete(ctx context.Context, id int64) error {
+	return assertAffectedOneRow(s.db.Core.NewDelete().
+		Model((*AccountPrice)(nil)).
+		Where("id = ?", id).
+		Exec(ctx),
+	)
+}
+
+func (s *AccountPriceStore) FindByID(ctx context.Context, id int64) (*AccountPrice, error) {
+	var price AccountPrice
+	err := s.db.Core.NewSelect().Model(&price).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (s *AccountPriceStore) List(ctx context.Context, req types.ACCT_PRICE_QUERY) ([]AccountPrice, int, error) {
+	var prices []AccountPrice
+	q := s.db.Core.NewSelect().Model(&prices)
+	if req.Scene > 0 {
+		q = q.Where("scene = ?", req.Scene)
+	}
+	if req.SkuID != "" {
+		q = q.Where("sku_id = ?", req.SkuID)
+	}
+	count, err := q.Count(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count prices, error: %w", err)
+	}
+	_, err = q.Order("scene ASC", "sku_id ASC", "start_time DESC").Limit(req.Per).Offset((req.Page-1)*req.Per).Exec(ctx, &prices)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list prices, error: %w", err)
+	}
+	return prices, count, nil
+}
+
+// FindEffective returns the price in the unit of the sku at the time, falls back to the default price of the scene,
+// returns nil if neither exists
+func (s *AccountPriceStore) FindEffective(ctx context.Context, scene types.SceneType, skuID, unitType string, at time.Time) (*AccountPrice, error) {
+	var price AccountPrice
+	err := s.db.Core.NewSelect().Model(&price).
+		Where("scene = ? and sku_id in (?, '') and sku_unit_type = ?", scene, skuID, unitType).
+		Where("start_time <= ?", at).
+		Where("end_time is null or end_time > ?", at).
+		Order("sku_id DESC", "start_time DESC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// CountOverlapping counts the other prices of the same sku, scene and unit whose time range overlaps the price
+func (s *AccountPriceStore) CountOverlapping(ctx context.Context, price *AccountPrice) (int, error) {
+	q := s.db.Core.NewSelect().Model((*AccountPrice)(nil)).
+		Where("id != ?", price.ID).
+		Where("scene = ? and sku_id = ? and sku_unit_type = ?", price.Scene, price.SkuID, price.SkuUnitType).
+		Where("end_time is null or end_time > ?", price.StartTime)
+	if !price.EndTime.IsZero() {
+		q = q.Where("start_time < ?", price.EndTime)
+	}
+	return q.Count(ctx)
+}
@@ -0,0 +1,24 @@
+package database
+
+import "testing"
+
+func TestAccountPriceSubCentCost(t *testing.T) {
+	testData := []struct {
+		name   string
+		price  AccountPrice
+		value  float64
+		expect int64
+	}{
+		{"whole units", AccountPrice{SkuPrice: 100, SkuUnit: 60}, 120, 200 * SubCentsPerCent},
+		{"keeps fractions of a cent", AccountPrice{SkuPrice: 100, SkuUnit: 60}, 0.2, 333333},
+		{"more than a cent", AccountPrice{SkuPrice: 100, SkuUnit: 60}, 1, 1666667},
+		{"per token", AccountPrice{SkuPrice: 3, SkuUnit: 1000}, 2500, 7500000},
+		{"free", AccountPrice{SkuPrice: 0, SkuUnit: 1}, 1000, 0},
+		{"no usage", AccountPrice{SkuPrice: 100, SkuUnit: 60}, 0, 0},
+	}
+	for _, data := range testData {
+		if cost := data.price.SubCentCost(data.value); cost != data.expect {
+			t.Errorf("%s: expect sub-cent cost %d, got %d", data.name, data.expect, cost)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type AccountUsageStore struct {
+	db *DB
+}
+
+func NewAccountUsageStore() *AccountUsageStore {
+	return &AccountUsageStore{
+		db: defaultDB,
+	}
+}
+
+// AccountUsageDaily is the daily rollup of the meterings of a resource, it is updated with each saved metering
+type AccountUsageDaily struct {
+	ID int64 `bun:",pk,autoincrement" json:"id"`
+	// start of the day in UTC
+	Day      time.Time `bun:",notnull,unique:idx_account_usage_dailies_key" json:"day"`
+	UserUUID string    `bun:",notnull,unique:idx_account_usage_dailies_key" json:"user_uuid"`
+	// organization the usage is attributed to, empty for personal usage
+	OrgName string          `bun:",notnull,default:'',unique:idx_account_usage_dailies_key" json:"org_name"`
+	Scene   types.SceneType `bun:",notnull,unique:idx_account_usage_dailies_key" json:"scene"`
+	SkuID   string          `bun:",notnull,unique:idx_account_usage_dailies_key" json:"sku_id"`
+	// resource is the customer id of metering, the instance of the sku
+	Resource     string `bun:",notnull,unique:idx_account_usage_dailies_key" json:"resource"`
+	ResourceName string `json:"resource_name"`
+	// a sku metered in different units, like minutes and tokens, has a rollup per unit
+	SkuUnitType string  `bun:",notnull,default:'',unique:idx_account_usage_dailies_key" json:"sku_unit_type"`
+	Value       float64 `bun:",notnull,default:0" json:"value"`
+	// cost in cents
+	Cost       int64 `bun:",notnull,default:0" json:"cost"`
+	EventCount int64 `bun:",notnull,default:0" json:"event_count"`
+	times
+}
+
+// UsageDay returns the day of the rollup a metering recorded at the time belongs to
+func UsageDay(recordedAt time.Time) time.Time {
+	return recordedAt.UTC().Truncate(24 * time.Hour)
+}
+
+// addUsage adds the metering to its daily rollup, it must run in the transaction saving the metering,
+// so the rollups always match the meterings. The metering consumer and the DLQ replay may add to the
+// same rollup concurrently, so it is created or updated in one upsert
+func addUsage(ctx context.Context, tx bun.Tx, am *AccountMetering) error {
+	_, err := tx.NewInsert().Model(&AccountUsageDaily{
+		Day:          UsageDay(am.RecordedAt),
+		UserUUID:     am.UserUUID,
+		OrgName:      am.OrgName,
+		Scene:        am.Scene,
+		SkuID:        am.ResourceID,
+		Resource:     am.CustomerID,
+		ResourceName: am.ResourceName,
+		SkuUnitType:  am.SkuUnitType,
+		Value:        am.Value,
+		Cost:         am.Cost,
+		EventCount:   1,
+	}).
+		On("CONFLICT (day, user_uuid, org_name, scene, sku_id, resource, sku_unit_type) DO UPDATE").
+		Set("value = account_usage_daily.value + EXCLUDED.value").
+		Set("cost = account_usage_daily.cost + EXCLUDED.cost").
+		Set("event_count = account_usage_daily.event_count + 1").
+		Set("resource_name = EXCLUDED.resource_name").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add daily usage, error: %w", err)
+	}
+	return nil
+}
+
+// List returns the daily rollups of the days in [start, end) matching the filters of the request
+func (s *AccountUsageStore) List(ctx context.Context, req types.ACCT_USAGE_REQ, start, end time.Time) ([]AccountUsageDaily, error) {
+	var usages []AccountUsageDaily
+	q := s.db.Core.NewSelect().Model(&usages).
+		Where("day >= ? and day < ?", start, end)
+	if req.UserUUID != "" {
+		q = q.Where("user_uuid = ?", req.UserUUID)
+	}
+	if req.OrgName != "" {
+		q = q.Where("org_name = ?", req.OrgName)
+	}
+	if req.Scene > 0 {
+		q = q.Where("scene = ?", req.Scene)
+	}
+	if req.SkuID != "" {
+		q = q.Where("sku_id = ?", req.SkuID)
+	}
+	err := q.Order("day ASC").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily usages, error: %w", err)
+	}
+	return usages, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestAddUsage_rollupPerUnitType(t *testing.T) {
+	ctx := context.Background()
+	initAccountingDB(t, "account_usage")
+	store := NewAccountMeteringStore()
+	recordedAt := time.Now()
+
+	for _, unitType := range []string{"minute", "token"} {
+		am := &AccountMetering{
+			EventUUID:   uuid.New(),
+			UserUUID:    "user",
+			Value:       10,
+			Scene:       types.SceneModelInference,
+			ResourceID:  "sku",
+			CustomerID:  "inference",
+			RecordedAt:  recordedAt,
+			SkuUnitType: unitType,
+		}
+		if _, err := store.Create(ctx, am, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	day := UsageDay(recordedAt)
+	usages, err := NewAccountUsageStore().List(ctx, types.ACCT_USAGE_REQ{UserUUID: "user"}, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 2 {
+		t.Fatalf("expect a rollup per unit type, got %d rollups", len(usages))
+	}
+	for _, usage := range usages {
+		if usage.Value != 10 || usage.EventCount != 1 {
+			t.Errorf("expect rollup of %s to have one event of 10, got %d events of %f", usage.SkuUnitType, usage.EventCount, usage.Value)
+		}
+	}
+}
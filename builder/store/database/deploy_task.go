@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/uptrace/bun"
 	"opencsg.com/csghub-server/builder/deploy/common"
 	"opencsg.com/csghub-server/common/types"
 )
@@ -46,6 +48,12 @@ type Deploy struct {
 	Type             int    `json:"type"`         // 0-space, 1-inference, 2-finetune, 3-serverless
 	UserUUID         string `bun:"," json:"user_uuid"`
 	SKU              string `bun:"," json:"sku"`
+	// why the cluster was chosen when the deploy did not pin one
+	PlacementReason string `bun:",nullzero" json:"placement_reason"`
+	// autoscaling policy of inference endpoints, knative defaults are used if nil
+	Autoscaling *types.AutoscalingPolicy `bun:"type:jsonb" json:"autoscaling,omitempty"`
+	// priority of the tasks created for the deploy
+	Priority int `bun:",notnull,default:0" json:"priority"`
 	times
 }
 
@@ -57,6 +65,11 @@ type DeployTask struct {
 	Message  string  `bun:",nullzero" json:"message"`
 	DeployID int64   `bun:",notnull" json:"deploy_id"`
 	Deploy   *Deploy `bun:"rel:belongs-to,join:deploy_id=id" json:"deploy"`
+	// tasks with higher priority run first in the priority scheduler
+	Priority int `bun:",notnull,default:0" json:"priority"`
+	// scheduler instance running the task, a claim expires at claimed_at plus the task timeout
+	ClaimedBy string    `bun:",nullzero" json:"-"`
+	ClaimedAt time.Time `bun:",nullzero" json:"-"`
 	times
 }
 
@@ -132,6 +145,47 @@ func (s *DeployTaskStore) GetNewTaskFirst(ctx context.Context) (*DeployTask, err
 	return deployTask, err
 }
 
+// GetNewTasks returns all tasks which have not end, higher priority and earlier tasks first
+func (s *DeployTaskStore) GetNewTasks(ctx context.Context) ([]*DeployTask, error) {
+	var deployTasks []*DeployTask
+	err := s.db.Core.NewSelect().Model(&deployTasks).Relation("Deploy").
+		Where("(task_type = 0 and deploy_task.status in (0,1)) or (task_type = 1 and deploy_task.status in (0,1,3))").
+		Order("deploy_task.priority DESC", "deploy_task.id ASC").
+		Scan(ctx)
+	return deployTasks, err
+}
+
+// ClaimTask marks the task as run by the scheduler instance, it fails if another instance
+// holds a claim newer than the lease
+func (s *DeployTaskStore) ClaimTask(ctx context.Context, id int64, owner string, lease time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := s.db.Core.NewUpdate().Model((*DeployTask)(nil)).
+		Set("claimed_by = ?", owner).
+		Set("claimed_at = ?", now).
+		Where("id = ?", id).
+		Where("claimed_at IS NULL OR claimed_at < ?", now.Add(-lease)).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// ReleaseTask removes the claim of the scheduler instance from the task
+func (s *DeployTaskStore) ReleaseTask(ctx context.Context, id int64, owner string) error {
+	_, err := s.db.Core.NewUpdate().Model((*DeployTask)(nil)).
+		Set("claimed_by = NULL").
+		Set("claimed_at = NULL").
+		Where("id = ?", id).
+		Where("claimed_by = ?", owner).
+		Exec(ctx)
+	return err
+}
+
 func (s *DeployTaskStore) UpdateInTx(ctx context.Context, deployColumns, deployTaskColumns []string, deploy *Deploy, deployTasks ...*DeployTask) error {
 	tx, err := s.db.Core.BeginTx(ctx, nil)
 	if err != nil {
@@ -168,6 +222,46 @@ func (s *DeployTaskStore) UpdateInTx(ctx context.Context, deployColumns, deployT
 	return tx.Commit()
 }
 
+// UpdateTaskUnlessStatusInTx updates the task and its deploy like UpdateInTx, unless the task has the skipped
+// status in the db, e.g. it was cancelled by another instance while running. It returns false if skipped
+func (s *DeployTaskStore) UpdateTaskUnlessStatusInTx(ctx context.Context, deployColumns, deployTaskColumns []string, deploy *Deploy, deployTask *DeployTask, skipped int) (bool, error) {
+	var updated bool
+	err := s.db.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		deployTask.UpdatedAt = time.Now()
+		res, err := tx.NewUpdate().Model(deployTask).
+			Column(append(deployTaskColumns, "updated_at")...).
+			WherePK().
+			Where("status != ?", skipped).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update deploy task,%w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return nil
+		}
+		updated = true
+		if deploy == nil {
+			return nil
+		}
+		deploy.UpdatedAt = time.Now()
+		_, err = tx.NewUpdate().Model(deploy).
+			Column(append(deployColumns, "updated_at")...).
+			WherePK().Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update deploy,%w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
 func (s *DeployTaskStore) ListDeploy(ctx context.Context, repoType types.RepositoryType, repoID, userID int64) ([]Deploy, error) {
 	var result []Deploy
 	query := s.db.Operator.Core.NewSelect().Model(&result).Where("user_id = ? and repo_id = ?", userID, repoID)
@@ -283,3 +377,80 @@ func (s *DeployTaskStore) ListServerless(ctx context.Context, req types.DeployRe
 	}
 	return result, total, nil
 }
+
+// ListActiveDeploysByRepoID returns the deploys of the repo which are deploying or running
+func (s *DeployTaskStore) ListActiveDeploysByRepoID(ctx context.Context, repoID int64) ([]Deploy, error) {
+	var result []Deploy
+	err := s.db.Operator.Core.NewSelect().Model(&result).
+		Where("repo_id = ?", repoID).
+		Where("status in (?)", bun.In([]int{common.Deploying, common.Startup, common.Running})).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListActiveDeploysByNamespace returns the deploys of all repos under the namespace which are deploying or running
+func (s *DeployTaskStore) ListActiveDeploysByNamespace(ctx context.Context, namespace string) ([]Deploy, error) {
+	return s.ListDeploysByNamespaceAndStatus(ctx, namespace, []int{common.Deploying, common.Startup, common.Running})
+}
+
+// ListDeploysByNamespaceAndStatus returns the deploys of all repos under the namespace in one of the statuses
+func (s *DeployTaskStore) ListDeploysByNamespaceAndStatus(ctx context.Context, namespace string, statuses []int) ([]Deploy, error) {
+	var result []Deploy
+	err := s.db.Operator.Core.NewSelect().Model(&result).
+		Join("JOIN repositories AS r ON r.id = deploy.repo_id").
+		Where("r.path LIKE ? ESCAPE '\\'", escapeLikePattern(namespace)+"/%").
+		Where("deploy.status in (?)", bun.In(statuses)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListActiveDeploysByUserUUID returns the deploys triggered by the user which are deploying or running
+func (s *DeployTaskStore) ListActiveDeploysByUserUUID(ctx context.Context, userUUID string) ([]Deploy, error) {
+	var result []Deploy
+	err := s.db.Operator.Core.NewSelect().Model(&result).
+		Where("user_uuid = ?", userUUID).
+		Where("status in (?)", bun.In([]int{common.Deploying, common.Startup, common.Running})).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListActiveDeploysByGitPathLike lists the active deploys whose git path matches the like pattern
+func (s *DeployTaskStore) ListActiveDeploysByGitPathLike(ctx context.Context, pattern string) ([]Deploy, error) {
+	var result []Deploy
+	err := s.db.Operator.Core.NewSelect().Model(&result).
+		Where("git_path like ?", pattern).
+		Where("status in (?)", bun.In([]int{common.Deploying, common.Startup, common.Running})).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *DeployTaskStore) ListDeploysByUserIDAndStatus(ctx context.Context, userID int64, statuses []int) ([]Deploy, error) {
+	var result []Deploy
+	err := s.db.Operator.Core.NewSelect().Model(&result).
+		Where("user_id = ?", userID).
+		Where("status in (?)", bun.In(statuses)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+var likePatternEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLikePattern escapes the wildcards of LIKE, so the text only matches itself
+func escapeLikePattern(text string) string {
+	return likePatternEscaper.Replace(text)
+}
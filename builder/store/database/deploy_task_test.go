@@ -0,0 +1,17 @@
+package database
+
+import "testing"
+
+func TestEscapeLikePattern(t *testing.T) {
+	testData := map[string]string{
+		"opencsg":    "opencsg",
+		"my_org":     `my\_org`,
+		"100%":       `100\%`,
+		`back\slash`: `back\\slash`,
+	}
+	for text, expect := range testData {
+		if got := escapeLikePattern(text); got != expect {
+			t.Errorf("expect %s, got %s", expect, got)
+		}
+	}
+}
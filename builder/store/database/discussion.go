@@ -24,8 +24,9 @@ type Comment struct {
 }
 
 const (
-	CommentableTypeDiscussion = "discussion"
-	CommentableTypeArticle    = "article"
+	CommentableTypeDiscussion  = "discussion"
+	CommentableTypeArticle     = "article"
+	CommentableTypePullRequest = "pull_request"
 )
 
 const (
@@ -103,6 +104,19 @@ func (s *DiscussionStore) FindDiscussionComments(ctx context.Context, discussion
 	return comments, nil
 }
 
+func (s *DiscussionStore) FindComments(ctx context.Context, commentableType string, commentableID int64) ([]Comment, error) {
+	comments := []Comment{}
+	err := s.db.Core.NewSelect().Model(&comments).
+		Relation("User").
+		Where("commentable_type = ? AND commentable_id = ?", commentableType, commentableID).
+		Order("comment.id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
 func (s *DiscussionStore) CreateComment(ctx context.Context, comment Comment) (*Comment, error) {
 	_, err := s.db.Core.NewInsert().Model(&comment).Exec(ctx)
 	if err != nil {
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type EndpointKeyStore struct {
+	db *DB
+}
+
+func NewEndpointKeyStore() *EndpointKeyStore {
+	return &EndpointKeyStore{
+		db: defaultDB,
+	}
+}
+
+// EndpointKey is an api key scoped to the endpoint of a deploy, calls with it are made on behalf of the user created it
+type EndpointKey struct {
+	ID       int64  `bun:",pk,autoincrement" json:"id"`
+	DeployID int64  `bun:",notnull" json:"deploy_id"`
+	UserID   int64  `bun:",notnull" json:"user_id"`
+	User     *User  `bun:"rel:belongs-to,join:user_id=id" json:"user"`
+	Name     string `bun:",notnull" json:"name"`
+	// sha256 of the key, the key itself is not saved
+	KeyHash   string `bun:",notnull,unique" json:"-"`
+	KeyPrefix string `bun:",notnull" json:"key_prefix"`
+	// 0 means unlimited
+	RequestsPerMinute int64 `bun:",notnull,default:0" json:"requests_per_minute"`
+	TokensPerMinute   int64 `bun:",notnull,default:0" json:"tokens_per_minute"`
+	times
+}
+
+func (s *EndpointKeyStore) Create(ctx context.Context, key *EndpointKey) error {
+	res, err := s.db.Core.NewInsert().Model(key).Exec(ctx, key)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return fmt.Errorf("failed to create endpoint key, error: %w", err)
+	}
+	return nil
+}
+
+func (s *EndpointKeyStore) ListByDeployID(ctx context.Context, deployID int64) ([]EndpointKey, error) {
+	var keys []EndpointKey
+	err := s.db.Core.NewSelect().Model(&keys).
+		Where("deploy_id = ?", deployID).
+		Order("id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoint keys, error: %w", err)
+	}
+	return keys, nil
+}
+
+// FindByHash returns nil if no key has the hash
+func (s *EndpointKeyStore) FindByHash(ctx context.Context, keyHash string) (*EndpointKey, error) {
+	var key EndpointKey
+	err := s.db.Core.NewSelect().Model(&key).
+		Relation("User").
+		Where("endpoint_key.key_hash = ?", keyHash).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *EndpointKeyStore) Delete(ctx context.Context, deployID, id int64) error {
+	return assertAffectedOneRow(s.db.Core.NewDelete().
+		Model((*EndpointKey)(nil)).
+		Where("deploy_id = ? and id = ?", deployID, id).
+		Exec(ctx),
+	)
+}
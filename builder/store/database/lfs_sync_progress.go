@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type LfsSyncProgressStore struct {
+	db *DB
+}
+
+func NewLfsSyncProgressStore() *LfsSyncProgressStore {
+	return &LfsSyncProgressStore{
+		db: defaultDB,
+	}
+}
+
+// LfsSyncProgress is the progress of mirroring an lfs object, the object is uploaded in parts so a failed
+// sync resumes from the last uploaded part. It is deleted once the object is uploaded
+type LfsSyncProgress struct {
+	ID       int64  `bun:",pk,autoincrement" json:"id"`
+	MirrorID int64  `bun:",notnull,unique:idx_lfs_sync_progresses_mirror_oid" json:"mirror_id"`
+	Oid      string `bun:",notnull,unique:idx_lfs_sync_progresses_mirror_oid" json:"oid"`
+	Size     int64  `bun:",notnull" json:"size"`
+	// multipart upload of the object
+	UploadID      string            `bun:",notnull" json:"upload_id"`
+	UploadedBytes int64             `bun:",notnull,default:0" json:"uploaded_bytes"`
+	Parts         []LfsSyncPartInfo `bun:"type:jsonb" json:"parts"`
+	// state of the sha256 of the uploaded bytes, so the object is verified without downloading them again
+	HashState []byte `json:"-"`
+	times
+}
+
+type LfsSyncPartInfo struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// Find returns nil if the object has no progress
+func (s *LfsSyncProgressStore) Find(ctx context.Context, mirrorID int64, oid string) (*LfsSyncProgress, error) {
+	var progress LfsSyncProgress
+	err := s.db.Core.NewSelect().Model(&progress).
+		Where("mirror_id = ? and oid = ?", mirrorID, oid).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func (s *LfsSyncProgressStore) ListByMirrorID(ctx context.Context, mirrorID int64) ([]LfsSyncProgress, error) {
+	var progresses []LfsSyncProgress
+	err := s.db.Core.NewSelect().Model(&progresses).
+		Column("id", "mirror_id", "oid", "size", "uploaded_bytes").
+		Where("mirror_id = ?", mirrorID).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lfs sync progresses, error: %w", err)
+	}
+	return progresses, nil
+}
+
+func (s *LfsSyncProgressStore) Create(ctx context.Context, progress *LfsSyncProgress) error {
+	res, err := s.db.Core.NewInsert().Model(progress).Exec(ctx, progress)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return fmt.Errorf("failed to create lfs sync progress, error: %w", err)
+	}
+	return nil
+}
+
+func (s *LfsSyncProgressStore) Update(ctx context.Context, progress *LfsSyncProgress) error {
+	return assertAffectedOneRow(s.db.Core.NewUpdate().
+		Model(progress).
+		WherePK().
+		Exec(ctx),
+	)
+}
+
+func (s *LfsSyncProgressStore) Delete(ctx context.Context, id int64) error {
+	_, err := s.db.Core.NewDelete().
+		Model((*LfsSyncProgress)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
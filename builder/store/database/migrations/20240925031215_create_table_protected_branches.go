@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, ProtectedBranch{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*ProtectedBranch)(nil)).
+			Index("idx_protected_branches_repository_id_pattern").
+			Column("repository_id", "pattern").
+			Unique().
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table protected_branches: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, ProtectedBranch{})
+	})
+}
+
+type ProtectedBranch struct {
+	ID             int64  `bun:",pk,autoincrement" json:"id"`
+	RepositoryID   int64  `bun:",notnull" json:"repository_id"`
+	Pattern        string `bun:",notnull" json:"pattern"`
+	AllowForcePush bool   `bun:",notnull,default:false" json:"allow_force_push"`
+	AllowDeletion  bool   `bun:",notnull,default:false" json:"allow_deletion"`
+	PushRole       string `bun:",notnull" json:"push_role"`
+	times
+}
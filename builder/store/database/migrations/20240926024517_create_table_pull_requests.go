@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, PullRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*PullRequest)(nil)).
+			Index("idx_pull_requests_repository_id_status").
+			Column("repository_id", "status").
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table pull_requests: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, PullRequest{})
+	})
+}
+
+type PullRequest struct {
+	ID                 int64     `bun:",pk,autoincrement" json:"id"`
+	RepositoryID       int64     `bun:",notnull" json:"repository_id"`
+	SourceRepositoryID int64     `bun:",notnull" json:"source_repository_id"`
+	SourceBranch       string    `bun:",notnull" json:"source_branch"`
+	TargetBranch       string    `bun:",notnull" json:"target_branch"`
+	Title              string    `bun:",notnull" json:"title"`
+	Description        string    `bun:",nullzero" json:"description"`
+	Status             string    `bun:",notnull" json:"status"`
+	UserID             int64     `bun:",notnull" json:"user_id"`
+	HeadCommitID       string    `bun:",nullzero" json:"head_commit_id"`
+	BaseCommitID       string    `bun:",nullzero" json:"base_commit_id"`
+	MergeCommitID      string    `bun:",nullzero" json:"merge_commit_id"`
+	MergedBy           int64     `bun:",nullzero" json:"merged_by"`
+	MergedAt           time.Time `bun:",nullzero" json:"merged_at"`
+	times
+}
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS idx_repo_relation_from_repo_id_type;
+
+--bun:split
+
+ALTER TABLE repo_relations DROP COLUMN IF EXISTS type;
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE repo_relations ADD COLUMN IF NOT EXISTS type VARCHAR(20);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_repo_relation_from_repo_id_type ON repo_relations (from_repo_id, type);
@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE deploys DROP COLUMN IF EXISTS placement_reason;
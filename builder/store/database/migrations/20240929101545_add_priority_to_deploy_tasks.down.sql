@@ -0,0 +1,17 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE deploy_tasks DROP COLUMN IF EXISTS priority;
+
+--bun:split
+
+ALTER TABLE deploy_tasks DROP COLUMN IF EXISTS claimed_by;
+
+--bun:split
+
+ALTER TABLE deploy_tasks DROP COLUMN IF EXISTS claimed_at;
+
+--bun:split
+
+ALTER TABLE deploys DROP COLUMN IF EXISTS priority;
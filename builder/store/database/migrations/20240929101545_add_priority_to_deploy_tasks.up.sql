@@ -0,0 +1,17 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE deploy_tasks ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0;
+
+--bun:split
+
+ALTER TABLE deploy_tasks ADD COLUMN IF NOT EXISTS claimed_by VARCHAR;
+
+--bun:split
+
+ALTER TABLE deploy_tasks ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP;
+
+--bun:split
+
+ALTER TABLE deploys ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE deploys ADD COLUMN IF NOT EXISTS autoscaling JSONB;
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, Secret{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*Secret)(nil)).
+			Index("idx_secrets_namespace_repository_id_name").
+			Column("namespace", "repository_id", "name").
+			Unique().
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table secrets: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, Secret{})
+	})
+}
+
+type Secret struct {
+	ID           int64  `bun:",pk,autoincrement" json:"id"`
+	Namespace    string `bun:",notnull" json:"namespace"`
+	RepositoryID int64  `bun:",notnull,default:0" json:"repository_id"`
+	Name         string `bun:",notnull" json:"name"`
+	Value        string `bun:",notnull" json:"-"`
+	times
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, Quota{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*Quota)(nil)).
+			Index("idx_quotas_owner_type_owner_name").
+			Column("owner_type", "owner_name").
+			Unique().
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table quotas: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, Quota{})
+	})
+}
+
+type Quota struct {
+	ID            int64  `bun:",pk,autoincrement" json:"id"`
+	OwnerType     string `bun:",notnull" json:"owner_type"`
+	OwnerName     string `bun:",notnull" json:"owner_name"`
+	MaxGPU        int    `bun:",notnull,default:0" json:"max_gpu"`
+	MaxMemory     int    `bun:",notnull,default:0" json:"max_memory"`
+	MaxInferences int    `bun:",notnull,default:0" json:"max_inferences"`
+	MaxFinetunes  int    `bun:",notnull,default:0" json:"max_finetunes"`
+	MaxSpaces     int    `bun:",notnull,default:0" json:"max_spaces"`
+	times
+}
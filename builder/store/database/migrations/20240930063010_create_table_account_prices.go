@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AccountPrice struct {
+	ID          int64     `bun:",pk,autoincrement" json:"id"`
+	SkuType     int       `bun:",notnull" json:"sku_type"`
+	Scene       int       `bun:",notnull" json:"scene"`
+	SkuID       string    `bun:",notnull" json:"sku_id"`
+	SkuUnit     int64     `bun:",notnull" json:"sku_unit"`
+	SkuUnitType string    `bun:",notnull" json:"sku_unit_type"`
+	SkuPrice    int64     `bun:",notnull" json:"sku_price"`
+	StartTime   time.Time `bun:",notnull" json:"start_time"`
+	EndTime     time.Time `bun:",nullzero" json:"end_time"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, AccountPrice{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountPrice)(nil)).
+			Index("idx_account_prices_scene_sku_id_start_time").
+			Column("scene", "sku_id", "start_time").
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_prices: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, AccountPrice{})
+	})
+}
@@ -0,0 +1,13 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE account_meterings DROP COLUMN IF EXISTS price_id;
+
+--bun:split
+
+ALTER TABLE account_meterings DROP COLUMN IF EXISTS cost;
+
+--bun:split
+
+ALTER TABLE account_meterings DROP COLUMN IF EXISTS sub_cent_cost;
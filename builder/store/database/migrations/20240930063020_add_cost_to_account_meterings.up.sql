@@ -0,0 +1,13 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE account_meterings ADD COLUMN IF NOT EXISTS price_id BIGINT NOT NULL DEFAULT 0;
+
+--bun:split
+
+ALTER TABLE account_meterings ADD COLUMN IF NOT EXISTS cost BIGINT NOT NULL DEFAULT 0;
+
+--bun:split
+
+ALTER TABLE account_meterings ADD COLUMN IF NOT EXISTS sub_cent_cost BIGINT NOT NULL DEFAULT 0;
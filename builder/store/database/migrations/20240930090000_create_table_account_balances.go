@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AccountBalance struct {
+	ID               int64     `bun:",pk,autoincrement" json:"id"`
+	OwnerType        string    `bun:",notnull,unique:idx_account_balances_owner" json:"owner_type"`
+	OwnerID          string    `bun:",notnull,unique:idx_account_balances_owner" json:"owner_id"`
+	Balance          int64     `bun:",notnull,default:0" json:"balance"`
+	SubCentRemainder int64     `bun:",notnull,default:0" json:"sub_cent_remainder"`
+	NegativeSince    time.Time `bun:",nullzero" json:"negative_since"`
+	times
+}
+
+type AccountBalanceEntry struct {
+	ID           int64     `bun:",pk,autoincrement" json:"id"`
+	OwnerType    string    `bun:",notnull" json:"owner_type"`
+	OwnerID      string    `bun:",notnull" json:"owner_id"`
+	EntryType    string    `bun:",notnull" json:"entry_type"`
+	Amount       int64     `bun:",notnull" json:"amount"`
+	BalanceAfter int64     `bun:",notnull" json:"balance_after"`
+	MeteringID   int64     `bun:",notnull,default:0" json:"metering_id"`
+	OpUser       string    `json:"op_user"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `bun:",notnull,default:current_timestamp" json:"created_at"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, AccountBalance{}, AccountBalanceEntry{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountBalanceEntry)(nil)).
+			Index("idx_account_balance_entries_owner").
+			Column("owner_type", "owner_id").
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_balance_entries: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, AccountBalance{}, AccountBalanceEntry{})
+	})
+}
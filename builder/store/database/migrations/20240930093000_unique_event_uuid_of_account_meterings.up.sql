@@ -0,0 +1,10 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+-- keep the first record of events which were saved more than once
+DELETE FROM account_meterings WHERE id NOT IN (SELECT MIN(id) FROM account_meterings GROUP BY event_uuid);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_meterings_event_uuid ON account_meterings (event_uuid);
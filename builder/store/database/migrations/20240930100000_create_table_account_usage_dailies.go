@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AccountUsageDaily struct {
+	ID           int64     `bun:",pk,autoincrement" json:"id"`
+	Day          time.Time `bun:",notnull,unique:idx_account_usage_dailies_key" json:"day"`
+	UserUUID     string    `bun:",notnull,unique:idx_account_usage_dailies_key" json:"user_uuid"`
+	OrgName      string    `bun:",notnull,default:'',unique:idx_account_usage_dailies_key" json:"org_name"`
+	Scene        int       `bun:",notnull,unique:idx_account_usage_dailies_key" json:"scene"`
+	SkuID        string    `bun:",notnull,unique:idx_account_usage_dailies_key" json:"sku_id"`
+	Resource     string    `bun:",notnull,unique:idx_account_usage_dailies_key" json:"resource"`
+	ResourceName string    `json:"resource_name"`
+	SkuUnitType  string    `bun:",notnull,default:'',unique:idx_account_usage_dailies_key" json:"sku_unit_type"`
+	Value        float64   `bun:",notnull,default:0" json:"value"`
+	Cost         int64     `bun:",notnull,default:0" json:"cost"`
+	EventCount   int64     `bun:",notnull,default:0" json:"event_count"`
+	times
+}
+
+// meteringForUsage is the part of account_meterings needed to backfill the daily usages
+type meteringForUsage struct {
+	bun.BaseModel `bun:"table:account_meterings"`
+
+	ID           int64     `bun:",pk"`
+	UserUUID     string    `bun:"user_uuid"`
+	Scene        int       `bun:"scene"`
+	ResourceID   string    `bun:"resource_id"`
+	CustomerID   string    `bun:"customer_id"`
+	ResourceName string    `bun:"resource_name"`
+	SkuUnitType  string    `bun:"sku_unit_type"`
+	Value        float64   `bun:"value"`
+	Cost         int64     `bun:"cost"`
+	RecordedAt   time.Time `bun:"recorded_at"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, AccountUsageDaily{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountUsageDaily)(nil)).
+			Index("idx_account_usage_dailies_day").
+			Column("day").
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_usage_dailies: %w", err)
+		}
+		return backfillUsageDailies(ctx, db)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, AccountUsageDaily{})
+	})
+}
+
+// backfillUsageDailies rolls up the meterings saved before the daily usages existed, page by page
+// so only one page of meterings and its rollups are held in memory at a time
+func backfillUsageDailies(ctx context.Context, db *bun.DB) error {
+	var lastID int64
+	for {
+		var meterings []meteringForUsage
+		err := db.NewSelect().Model(&meterings).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(1000).
+			Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list meterings to backfill daily usages: %w", err)
+		}
+		if len(meterings) == 0 {
+			return nil
+		}
+		if err := addUsageDailies(ctx, db, meterings); err != nil {
+			return err
+		}
+		lastID = meterings[len(meterings)-1].ID
+	}
+}
+
+// addUsageDailies adds a page of meterings to the daily usages, rollups already created by earlier
+// pages are added to
+func addUsageDailies(ctx context.Context, db *bun.DB, meterings []meteringForUsage) error {
+	type usageKey struct {
+		day                                    time.Time
+		userUUID, skuID, resource, skuUnitType string
+		scene                                  int
+	}
+	index := make(map[usageKey]int)
+	var usages []*AccountUsageDaily
+	for _, m := range meterings {
+		key := usageKey{
+			day:         m.RecordedAt.UTC().Truncate(24 * time.Hour),
+			userUUID:    m.UserUUID,
+			skuID:       m.ResourceID,
+			resource:    m.CustomerID,
+			skuUnitType: m.SkuUnitType,
+			scene:       m.Scene,
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(usages)
+			index[key] = i
+			usages = append(usages, &AccountUsageDaily{
+				Day:         key.day,
+				UserUUID:    key.userUUID,
+				Scene:       key.scene,
+				SkuID:       key.skuID,
+				Resource:    key.resource,
+				SkuUnitType: key.skuUnitType,
+			})
+		}
+		usages[i].ResourceName = m.ResourceName
+		usages[i].Value += m.Value
+		usages[i].Cost += m.Cost
+		usages[i].EventCount++
+	}
+	_, err := db.NewInsert().Model(&usages).
+		On("CONFLICT (day, user_uuid, org_name, scene, sku_id, resource, sku_unit_type) DO UPDATE").
+		Set("value = account_usage_daily.value + EXCLUDED.value").
+		Set("cost = account_usage_daily.cost + EXCLUDED.cost").
+		Set("event_count = account_usage_daily.event_count + EXCLUDED.event_count").
+		Set("resource_name = EXCLUDED.resource_name").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to backfill daily usages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS idx_account_meterings_org_name_recorded_at;
+
+--bun:split
+
+ALTER TABLE account_meterings DROP COLUMN IF EXISTS org_name;
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE account_meterings ADD COLUMN IF NOT EXISTS org_name VARCHAR NOT NULL DEFAULT '';
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_account_meterings_org_name_recorded_at ON account_meterings (org_name, recorded_at);
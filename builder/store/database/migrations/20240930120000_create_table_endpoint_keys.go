@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+type EndpointKey struct {
+	ID                int64  `bun:",pk,autoincrement" json:"id"`
+	DeployID          int64  `bun:",notnull" json:"deploy_id"`
+	UserID            int64  `bun:",notnull" json:"user_id"`
+	Name              string `bun:",notnull" json:"name"`
+	KeyHash           string `bun:",notnull,unique" json:"-"`
+	KeyPrefix         string `bun:",notnull" json:"key_prefix"`
+	RequestsPerMinute int64  `bun:",notnull,default:0" json:"requests_per_minute"`
+	TokensPerMinute   int64  `bun:",notnull,default:0" json:"tokens_per_minute"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, EndpointKey{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*EndpointKey)(nil)).
+			Index("idx_endpoint_keys_deploy_id").
+			Column("deploy_id").
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table endpoint_keys: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, EndpointKey{})
+	})
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type LfsSyncProgress struct {
+	ID            int64             `bun:",pk,autoincrement" json:"id"`
+	MirrorID      int64             `bun:",notnull,unique:idx_lfs_sync_progresses_mirror_oid" json:"mirror_id"`
+	Oid           string            `bun:",notnull,unique:idx_lfs_sync_progresses_mirror_oid" json:"oid"`
+	Size          int64             `bun:",notnull" json:"size"`
+	UploadID      string            `bun:",notnull" json:"upload_id"`
+	UploadedBytes int64             `bun:",notnull,default:0" json:"uploaded_bytes"`
+	Parts         []lfsSyncPartInfo `bun:"type:jsonb" json:"parts"`
+	HashState     []byte            `json:"-"`
+	times
+}
+
+type lfsSyncPartInfo struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return createTables(ctx, db, LfsSyncProgress{})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, LfsSyncProgress{})
+	})
+}
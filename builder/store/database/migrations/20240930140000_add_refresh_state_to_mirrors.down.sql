@@ -0,0 +1,13 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE mirrors DROP COLUMN IF EXISTS lfs_synced_at;
+
+--bun:split
+
+ALTER TABLE mirrors DROP COLUMN IF EXISTS synced_commits;
+
+--bun:split
+
+ALTER TABLE mirrors DROP COLUMN IF EXISTS remote_refs_hash;
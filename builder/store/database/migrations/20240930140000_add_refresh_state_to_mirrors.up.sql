@@ -0,0 +1,13 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE mirrors ADD COLUMN IF NOT EXISTS remote_refs_hash VARCHAR;
+
+--bun:split
+
+ALTER TABLE mirrors ADD COLUMN IF NOT EXISTS synced_commits JSONB;
+
+--bun:split
+
+ALTER TABLE mirrors ADD COLUMN IF NOT EXISTS lfs_synced_at TIMESTAMP;
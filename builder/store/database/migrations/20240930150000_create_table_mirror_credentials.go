@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type MirrorCredential struct {
+	ID             int64  `bun:",pk,autoincrement" json:"id"`
+	MirrorSourceID int64  `bun:",notnull,unique:idx_mirror_credentials_source_name" json:"mirror_source_id"`
+	Name           string `bun:",notnull,unique:idx_mirror_credentials_source_name" json:"name"`
+	Type           string `bun:",notnull" json:"type"`
+	Username       string `bun:",nullzero" json:"username"`
+	Secret         string `bun:",notnull" json:"-"`
+	IsDefault      bool   `bun:",notnull,default:false" json:"is_default"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return createTables(ctx, db, MirrorCredential{})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, MirrorCredential{})
+	})
+}
@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE mirrors DROP COLUMN IF EXISTS credential_id;
@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE mirrors ADD COLUMN IF NOT EXISTS credential_id BIGINT;
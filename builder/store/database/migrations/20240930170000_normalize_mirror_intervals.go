@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+// mirrorInterval is the part of mirrors needed to normalize the intervals
+type mirrorInterval struct {
+	bun.BaseModel `bun:"table:mirrors"`
+
+	ID       int64  `bun:",pk"`
+	Interval string `bun:"interval"`
+}
+
+// legacyMirrorIntervals maps the descriptors saved before intervals were validated to schedules
+var legacyMirrorIntervals = map[string]string{
+	"@hourly":  "1h",
+	"hourly":   "1h",
+	"@daily":   "0 0 * * *",
+	"daily":    "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"weekly":   "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"monthly":  "0 0 1 * *",
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		var mirrors []mirrorInterval
+		err := db.NewSelect().Model(&mirrors).Where("interval != ''").Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list mirror intervals: %w", err)
+		}
+		for _, m := range mirrors {
+			interval := normalizeMirrorInterval(m.Interval)
+			if interval == m.Interval {
+				continue
+			}
+			if interval == "" {
+				slog.Warn("clear invalid mirror interval", slog.Int64("mirrorId", m.ID), slog.String("interval", m.Interval))
+			}
+			_, err := db.NewUpdate().Model((*mirrorInterval)(nil)).
+				Set("interval = ?", interval).
+				Where("id = ?", m.ID).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to normalize interval of mirror %d: %w", m.ID, err)
+			}
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
+
+// normalizeMirrorInterval returns the schedule of a legacy interval, or an empty interval if it is invalid
+func normalizeMirrorInterval(interval string) string {
+	if _, err := common.ParseSchedule(interval); err == nil {
+		return interval
+	}
+	if schedule, ok := legacyMirrorIntervals[strings.ToLower(strings.TrimSpace(interval))]; ok {
+		return schedule
+	}
+	return ""
+}
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE mirror_credentials DROP COLUMN IF EXISTS allowed_users;
+
+--bun:split
+
+ALTER TABLE mirror_credentials DROP COLUMN IF EXISTS allowed_url_prefix;
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE mirror_credentials ADD COLUMN IF NOT EXISTS allowed_url_prefix VARCHAR NOT NULL DEFAULT '';
+
+--bun:split
+
+ALTER TABLE mirror_credentials ADD COLUMN IF NOT EXISTS allowed_users JSONB;
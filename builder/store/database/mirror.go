@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/uptrace/bun"
 	"opencsg.com/csghub-server/common/types"
 )
 
@@ -43,6 +44,15 @@ type Mirror struct {
 	Progress               int8                   `bun:",nullzero" json:"progress"`
 	NextExecutionTimestamp time.Time              `bun:",nullzero" json:"next_execution_timestamp"`
 	Priority               types.MirrorPriority   `bun:"mirror_priority,notnull,default:0" json:"priority"`
+	// credential profile of the mirror source to access the upstream, the mirror uses its own username and access token
+	// or the default profile of the source without it
+	CredentialID int64 `bun:",nullzero" json:"credential_id"`
+	// hash of the upstream branches and tags when the mirror was last refreshed, to skip unchanged upstreams
+	RemoteRefsHash string `bun:",nullzero" json:"-"`
+	// branch to commit id whose LFS pointers are synced, the next sync only scans the new commits
+	SyncedCommits map[string]string `bun:",type:jsonb,nullzero" json:"-"`
+	// time the LFS objects were all synced, objects updated after it are synced in the next LFS sync
+	LfsSyncedAt time.Time `bun:",nullzero" json:"lfs_synced_at"`
 
 	times
 }
@@ -249,6 +259,22 @@ func (s *MirrorStore) ToSyncLfs(ctx context.Context) ([]Mirror, error) {
 	return mirrors, nil
 }
 
+// ToRefresh returns the scheduled mirrors due to refresh, mirrors being synced are excluded
+func (s *MirrorStore) ToRefresh(ctx context.Context) ([]Mirror, error) {
+	var mirrors []Mirror
+	err := s.db.Operator.Core.NewSelect().
+		Model(&mirrors).
+		Relation("Repository").
+		Where("mirror.interval != ''").
+		Where("mirror.next_execution_timestamp IS NULL OR mirror.next_execution_timestamp <= ?", time.Now()).
+		Where("mirror.status IS NULL OR mirror.status NOT IN (?)", bun.In([]types.MirrorTaskStatus{types.MirrorWaiting, types.MirrorRunning, types.MirrorRepoSynced})).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mirrors, nil
+}
+
 func (s *MirrorStore) IndexWithPagination(ctx context.Context, per, page int) (mirrors []Mirror, count int, err error) {
 	q := s.db.Operator.Core.NewSelect().
 		Model(&mirrors).
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type MirrorCredentialStore struct {
+	db *DB
+}
+
+func NewMirrorCredentialStore() *MirrorCredentialStore {
+	return &MirrorCredentialStore{
+		db: defaultDB,
+	}
+}
+
+// MirrorCredential is a credential profile of a mirror source shared by its mirrors, the mirrors without a credential
+// of their own use the default profile of their source
+type MirrorCredential struct {
+	ID             int64                      `bun:",pk,autoincrement" json:"id"`
+	MirrorSourceID int64                      `bun:",notnull,unique:idx_mirror_credentials_source_name" json:"mirror_source_id"`
+	Name           string                     `bun:",notnull,unique:idx_mirror_credentials_source_name" json:"name"`
+	Type           types.MirrorCredentialType `bun:",notnull" json:"type"`
+	Username       string                     `bun:",nullzero" json:"username"`
+	// token or password encrypted by the server key, never returned to users
+	Secret    string `bun:",notnull" json:"-"`
+	IsDefault bool   `bun:",notnull,default:false" json:"is_default"`
+	// the credential is only sent to URLs under the prefix, like https://huggingface.co or https://github.com/org
+	AllowedURLPrefix string `bun:",notnull,default:''" json:"allowed_url_prefix"`
+	// users besides admins allowed to set the credential on their mirrors
+	AllowedUsers []string `bun:",type:jsonb,nullzero" json:"allowed_users"`
+	times
+}
+
+// Create creates the credential, it replaces the default credential of the source if it is the default
+func (s *MirrorCredentialStore) Create(ctx context.Context, credential *MirrorCredential) error {
+	return s.db.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if credential.IsDefault {
+			if err := clearDefaultMirrorCredential(ctx, tx, credential.MirrorSourceID); err != nil {
+				return err
+			}
+		}
+		return assertAffectedOneRow(tx.NewInsert().Model(credential).Exec(ctx))
+	})
+}
+
+// Update updates the credential, it replaces the default credential of the source if it is the default
+func (s *MirrorCredentialStore) Update(ctx context.Context, credential *MirrorCredential) error {
+	return s.db.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if credential.IsDefault {
+			if err := clearDefaultMirrorCredential(ctx, tx, credential.MirrorSourceID); err != nil {
+				return err
+			}
+		}
+		return assertAffectedOneRow(tx.NewUpdate().Model(credential).WherePK().Exec(ctx))
+	})
+}
+
+func clearDefaultMirrorCredential(ctx context.Context, tx bun.Tx, mirrorSourceID int64) error {
+	_, err := tx.NewUpdate().
+		Model((*MirrorCredential)(nil)).
+		Set("is_default = false").
+		Where("mirror_source_id = ? and is_default", mirrorSourceID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear default mirror credential, error: %w", err)
+	}
+	return nil
+}
+
+func (s *MirrorCredentialStore) ListBySourceID(ctx context.Context, mirrorSourceID int64) ([]MirrorCredential, error) {
+	var credentials []MirrorCredential
+	err := s.db.Core.NewSelect().
+		Model(&credentials).
+		Where("mirror_source_id = ?", mirrorSourceID).
+		Order("name ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// FindByID returns nil if the credential does not exist
+func (s *MirrorCredentialStore) FindByID(ctx context.Context, id int64) (*MirrorCredential, error) {
+	var credential MirrorCredential
+	err := s.db.Core.NewSelect().
+		Model(&credential).
+		Where("id = ?", id).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// FindDefault returns the default credential of the source, or nil if it has none
+func (s *MirrorCredentialStore) FindDefault(ctx context.Context, mirrorSourceID int64) (*MirrorCredential, error) {
+	var credential MirrorCredential
+	err := s.db.Core.NewSelect().
+		Model(&credential).
+		Where("mirror_source_id = ? and is_default", mirrorSourceID).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// Delete deletes the credential of the source, mirrors using it fall back to the default credential of the source
+func (s *MirrorCredentialStore) Delete(ctx context.Context, mirrorSourceID, id int64) error {
+	return s.db.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := assertAffectedOneRow(tx.NewDelete().
+			Model((*MirrorCredential)(nil)).
+			Where("id = ? and mirror_source_id = ?", id, mirrorSourceID).
+			Exec(ctx))
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model((*Mirror)(nil)).
+			Set("credential_id = NULL").
+			Where("credential_id = ?", id).
+			Exec(ctx)
+		return err
+	})
+}
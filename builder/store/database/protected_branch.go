@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+)
+
+type ProtectedBranchStore struct {
+	db *DB
+}
+
+func NewProtectedBranchStore() *ProtectedBranchStore {
+	return &ProtectedBranchStore{
+		db: defaultDB,
+	}
+}
+
+// ProtectedBranch is a push rule applied to the branches of a repository whose name matches Pattern
+type ProtectedBranch struct {
+	ID           int64       `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64       `bun:",notnull" json:"repository_id"`
+	Repository   *Repository `bun:"rel:belongs-to,join:repository_id=id" json:"-"`
+	// branch name or a glob pattern like release/*
+	Pattern        string `bun:",notnull" json:"pattern"`
+	AllowForcePush bool   `bun:",notnull,default:false" json:"allow_force_push"`
+	AllowDeletion  bool   `bun:",notnull,default:false" json:"allow_deletion"`
+	// the minimum org role required to push, one of read, write and admin
+	PushRole string `bun:",notnull" json:"push_role"`
+	times
+}
+
+func (s *ProtectedBranchStore) Create(ctx context.Context, pb *ProtectedBranch) (*ProtectedBranch, error) {
+	_, err := s.db.Operator.Core.NewInsert().
+		Model(pb).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+func (s *ProtectedBranchStore) FindByID(ctx context.Context, id int64) (*ProtectedBranch, error) {
+	var pb ProtectedBranch
+	err := s.db.Operator.Core.NewSelect().
+		Model(&pb).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb, nil
+}
+
+func (s *ProtectedBranchStore) FindByRepoID(ctx context.Context, repoID int64) ([]ProtectedBranch, error) {
+	var pbs []ProtectedBranch
+	err := s.db.Operator.Core.NewSelect().
+		Model(&pbs).
+		Where("repository_id = ?", repoID).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pbs, nil
+}
+
+func (s *ProtectedBranchStore) Update(ctx context.Context, pb *ProtectedBranch) error {
+	return assertAffectedOneRow(s.db.Operator.Core.NewUpdate().
+		Model(pb).
+		WherePK().
+		Exec(ctx),
+	)
+}
+
+func (s *ProtectedBranchStore) Delete(ctx context.Context, pb *ProtectedBranch) error {
+	_, err := s.db.Operator.Core.NewDelete().
+		Model(pb).
+		WherePK().
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	PullRequestStatusOpen = "open"
+	// the pull request is being merged, it is open again if the merge fails
+	PullRequestStatusMerging = "merging"
+	PullRequestStatusMerged  = "merged"
+	PullRequestStatusClosed  = "closed"
+)
+
+type PullRequestStore struct {
+	db *DB
+}
+
+func NewPullRequestStore() *PullRequestStore {
+	return &PullRequestStore{
+		db: defaultDB,
+	}
+}
+
+// PullRequest proposes to merge SourceBranch of the source repository into TargetBranch of the repository,
+// the source repository is the repository itself or one of its forks
+type PullRequest struct {
+	ID                 int64       `bun:",pk,autoincrement" json:"id"`
+	RepositoryID       int64       `bun:",notnull" json:"repository_id"`
+	Repository         *Repository `bun:"rel:belongs-to,join:repository_id=id" json:"-"`
+	SourceRepositoryID int64       `bun:",notnull" json:"source_repository_id"`
+	SourceRepository   *Repository `bun:"rel:belongs-to,join:source_repository_id=id" json:"-"`
+	SourceBranch       string      `bun:",notnull" json:"source_branch"`
+	TargetBranch       string      `bun:",notnull" json:"target_branch"`
+	Title              string      `bun:",notnull" json:"title"`
+	Description        string      `bun:",nullzero" json:"description"`
+	Status             string      `bun:",notnull" json:"status"`
+	UserID             int64       `bun:",notnull" json:"user_id"`
+	User               *User       `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+	// commits of source and target branch when the pull request was merged
+	HeadCommitID  string    `bun:",nullzero" json:"head_commit_id"`
+	BaseCommitID  string    `bun:",nullzero" json:"base_commit_id"`
+	MergeCommitID string    `bun:",nullzero" json:"merge_commit_id"`
+	MergedBy      int64     `bun:",nullzero" json:"merged_by"`
+	MergedAt      time.Time `bun:",nullzero" json:"merged_at"`
+	times
+}
+
+func (s *PullRequestStore) Create(ctx context.Context, pr *PullRequest) (*PullRequest, error) {
+	_, err := s.db.Operator.Core.NewInsert().
+		Model(pr).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+func (s *PullRequestStore) FindByID(ctx context.Context, id int64) (*PullRequest, error) {
+	var pr PullRequest
+	err := s.db.Operator.Core.NewSelect().
+		Model(&pr).
+		Relation("SourceRepository").
+		Relation("User").
+		Where("pull_request.id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// FindByRepoID lists the pull requests targeting the repository, status is ignored when empty
+func (s *PullRequestStore) FindByRepoID(ctx context.Context, repoID int64, status string, per, page int) ([]PullRequest, int, error) {
+	var prs []PullRequest
+	q := s.db.Operator.Core.NewSelect().
+		Model(&prs).
+		Relation("SourceRepository").
+		Relation("User").
+		Where("pull_request.repository_id = ?", repoID)
+	if status != "" {
+		q = q.Where("pull_request.status = ?", status)
+	}
+	count, err := q.Count(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count pull requests, error: %w", err)
+	}
+	err = q.Order("pull_request.id DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		Scan(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list pull requests, error: %w", err)
+	}
+	return prs, count, nil
+}
+
+func (s *PullRequestStore) Update(ctx context.Context, pr *PullRequest) error {
+	return assertAffectedOneRow(s.db.Operator.Core.NewUpdate().
+		Model(pr).
+		WherePK().
+		Exec(ctx),
+	)
+}
+
+// UpdateStatus changes the status of the pull request only if it is still in the from status, it returns false
+// if another request changed the status first
+func (s *PullRequestStore) UpdateStatus(ctx context.Context, id int64, from, to string) (bool, error) {
+	res, err := s.db.Operator.Core.NewUpdate().
+		Model((*PullRequest)(nil)).
+		Set("status = ?", to).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ? AND status = ?", id, from).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// UpdateContent updates the title and description of the pull request if it is still open
+func (s *PullRequestStore) UpdateContent(ctx context.Context, pr *PullRequest) error {
+	pr.UpdatedAt = time.Now()
+	return assertAffectedOneRow(s.db.Operator.Core.NewUpdate().
+		Model(pr).
+		Column("title", "description", "updated_at").
+		Where("id = ? AND status = ?", pr.ID, PullRequestStatusOpen).
+		Exec(ctx),
+	)
+}
+
+func (s *PullRequestStore) Delete(ctx context.Context, id int64) error {
+	return assertAffectedOneRow(s.db.Operator.Core.NewDelete().
+		Model((*PullRequest)(nil)).
+		Where("id = ?", id).
+		Exec(ctx),
+	)
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/uptrace/bun"
+)
+
+type QuotaStore struct {
+	db *DB
+}
+
+func NewQuotaStore() *QuotaStore {
+	return &QuotaStore{
+		db: defaultDB,
+	}
+}
+
+// Quota limits the compute resources used by the deploys of a user or an organization,
+// a limit of 0 means no limit
+type Quota struct {
+	ID int64 `bun:",pk,autoincrement" json:"id"`
+	// user or organization
+	OwnerType string `bun:",notnull" json:"owner_type"`
+	OwnerName string `bun:",notnull" json:"owner_name"`
+	MaxGPU    int    `bun:",notnull,default:0" json:"max_gpu"`
+	// total memory in GiB
+	MaxMemory int `bun:",notnull,default:0" json:"max_memory"`
+	// max concurrent deploys per type
+	MaxInferences int `bun:",notnull,default:0" json:"max_inferences"`
+	MaxFinetunes  int `bun:",notnull,default:0" json:"max_finetunes"`
+	MaxSpaces     int `bun:",notnull,default:0" json:"max_spaces"`
+	times
+}
+
+// FindByOwner returns nil if the owner has no quota
+func (s *QuotaStore) FindByOwner(ctx context.Context, ownerType, ownerName string) (*Quota, error) {
+	var quota Quota
+	err := s.db.Operator.Core.NewSelect().
+		Model(&quota).
+		Where("owner_type = ? and owner_name = ?", ownerType, ownerName).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// RunLocked runs fn in a transaction holding the row lock of the owner's quota, so that concurrent
+// deploys of the owner are checked and created one after another. The quota passed to fn is nil if
+// the owner has no quota
+func (s *QuotaStore) RunLocked(ctx context.Context, ownerType, ownerName string, fn func(ctx context.Context, quota *Quota) error) error {
+	return s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var quota Quota
+		err := tx.NewSelect().
+			Model(&quota).
+			Where("owner_type = ? and owner_name = ?", ownerType, ownerName).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fn(ctx, nil)
+		}
+		if err != nil {
+			return err
+		}
+		return fn(ctx, &quota)
+	})
+}
+
+func (s *QuotaStore) List(ctx context.Context, per, page int) ([]Quota, int, error) {
+	var quotas []Quota
+	query := s.db.Operator.Core.NewSelect().
+		Model(&quotas).
+		Order("id ASC").
+		Limit(per).
+		Offset((page - 1) * per)
+	err := query.Scan(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := query.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return quotas, total, nil
+}
+
+// Upsert creates the quota of the owner or replaces its limits
+func (s *QuotaStore) Upsert(ctx context.Context, quota *Quota) error {
+	_, err := s.db.Operator.Core.NewInsert().
+		Model(quota).
+		On("CONFLICT (owner_type, owner_name) DO UPDATE").
+		Set("max_gpu = EXCLUDED.max_gpu").
+		Set("max_memory = EXCLUDED.max_memory").
+		Set("max_inferences = EXCLUDED.max_inferences").
+		Set("max_finetunes = EXCLUDED.max_finetunes").
+		Set("max_spaces = EXCLUDED.max_spaces").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+	return err
+}
+
+func (s *QuotaStore) Delete(ctx context.Context, ownerType, ownerName string) error {
+	return assertAffectedOneRow(s.db.Operator.Core.NewDelete().
+		Model((*Quota)(nil)).
+		Where("owner_type = ? and owner_name = ?", ownerType, ownerName).
+		Exec(ctx),
+	)
+}
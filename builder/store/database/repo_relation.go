@@ -15,26 +15,54 @@ func NewRepoRelationsStore() *RepoRelationsStore {
 	}
 }
 
+// RepoRelationTypeFork marks a relation from a fork to the repository it was forked from
+const RepoRelationTypeFork = "fork"
+
 type RepoRelation struct {
 	ID         int64 `bun:",pk,autoincrement" json:"id"`
 	FromRepoID int64 `bun:",notnull" json:"from_repo_id"`
 	ToRepoID   int64 `bun:",notnull" json:"to_repo_id"`
+	// empty for relations declared in the repository metadata
+	Type string `bun:",nullzero" json:"type,omitempty"`
 }
 
 // From gets the relationships from a repository
 func (r *RepoRelationsStore) From(ctx context.Context, repoID int64) ([]*RepoRelation, error) {
 	var rrs []*RepoRelation
-	err := r.db.Core.NewSelect().Model(&rrs).Where("from_repo_id = ?", repoID).Scan(ctx)
+	err := r.db.Core.NewSelect().Model(&rrs).Where("from_repo_id = ? and type is null", repoID).Scan(ctx)
 	return rrs, err
 }
 
 // To gets the relationships to a repository
 func (r *RepoRelationsStore) To(ctx context.Context, repoID int64) ([]*RepoRelation, error) {
 	var rrs []*RepoRelation
-	err := r.db.Core.NewSelect().Model(&rrs).Where("to_repo_id = ?", repoID).Scan(ctx)
+	err := r.db.Core.NewSelect().Model(&rrs).Where("to_repo_id = ? and type is null", repoID).Scan(ctx)
 	return rrs, err
 }
 
+// CreateFork records that repository fork was forked from repository parent
+func (r *RepoRelationsStore) CreateFork(ctx context.Context, parent, fork int64) error {
+	_, err := r.db.Core.NewInsert().Model(&RepoRelation{
+		FromRepoID: fork,
+		ToRepoID:   parent,
+		Type:       RepoRelationTypeFork,
+	}).Exec(ctx)
+	return err
+}
+
+// ForkParent gets the relationship from a fork to the repository it was forked from
+func (r *RepoRelationsStore) ForkParent(ctx context.Context, repoID int64) (*RepoRelation, error) {
+	var rr RepoRelation
+	err := r.db.Core.NewSelect().Model(&rr).
+		Where("from_repo_id = ? and type = ?", repoID, RepoRelationTypeFork).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rr, nil
+}
+
 // Override replaces all existing relationships from a repository to others
 //
 // `to` can be empty, in which case all existing relationships will be deleted
@@ -51,7 +79,7 @@ func (r *RepoRelationsStore) Override(ctx context.Context, from int64, to ...int
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	_, err = tx.NewDelete().Model((*RepoRelation)(nil)).Where("from_repo_id = ?", from).Exec(ctx)
+	_, err = tx.NewDelete().Model((*RepoRelation)(nil)).Where("from_repo_id = ? and type is null", from).Exec(ctx)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to delete existing relations: %w", err)
@@ -73,7 +101,7 @@ func (r *RepoRelationsStore) Override(ctx context.Context, from int64, to ...int
 func (r *RepoRelationsStore) Delete(ctx context.Context, from, to int64) error {
 	result, err := r.db.Core.NewDelete().
 		Model((*RepoRelation)(nil)).
-		Where("from_repo_id = ? and to_repo_id = ?", from, to).
+		Where("from_repo_id = ? and to_repo_id = ? and type is null", from, to).
 		Exec(ctx)
 	return assertAffectedOneRow(result, err)
 }
@@ -504,6 +504,24 @@ func (s *RepoStore) CleanRelationsByRepoID(ctx context.Context, repoId int64) er
 	return err
 }
 
+// DeleteForkedRepo deletes a repository left by a failed fork together with its model, dataset, code or space
+// record, lfs meta objects and relations
+func (s *RepoStore) DeleteForkedRepo(ctx context.Context, repoID int64) error {
+	return s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, model := range []interface{}{(*Model)(nil), (*Dataset)(nil), (*Code)(nil), (*Space)(nil), (*LfsMetaObject)(nil)} {
+			if _, err := tx.NewDelete().Model(model).Where("repository_id = ?", repoID).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		_, err := tx.NewDelete().Model((*RepoRelation)(nil)).Where("from_repo_id = ? or to_repo_id = ?", repoID, repoID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewDelete().Model((*Repository)(nil)).Where("id = ?", repoID).Exec(ctx)
+		return err
+	})
+}
+
 func (s *RepoStore) BatchCreateRepoTags(ctx context.Context, repoTags []RepositoryTag) error {
 	result, err := s.db.Operator.Core.NewInsert().
 		Model(&repoTags).
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+)
+
+type SecretStore struct {
+	db *DB
+}
+
+func NewSecretStore() *SecretStore {
+	return &SecretStore{
+		db: defaultDB,
+	}
+}
+
+// Secret is an encrypted variable injected into the deploys of a repository, or of all repositories
+// under a namespace when RepositoryID is 0
+type Secret struct {
+	ID           int64  `bun:",pk,autoincrement" json:"id"`
+	Namespace    string `bun:",notnull" json:"namespace"`
+	RepositoryID int64  `bun:",notnull,default:0" json:"repository_id"`
+	Name         string `bun:",notnull" json:"name"`
+	// value encrypted by the server key, never returned to users
+	Value string `bun:",notnull" json:"-"`
+	times
+}
+
+// Upsert creates the secret or replaces the value of the secret with the same name
+func (s *SecretStore) Upsert(ctx context.Context, secret *Secret) error {
+	_, err := s.db.Operator.Core.NewInsert().
+		Model(secret).
+		On("CONFLICT (namespace, repository_id, name) DO UPDATE").
+		Set("value = EXCLUDED.value").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
+
+func (s *SecretStore) FindByRepoID(ctx context.Context, repoID int64) ([]Secret, error) {
+	var secrets []Secret
+	err := s.db.Operator.Core.NewSelect().
+		Model(&secrets).
+		Where("repository_id = ?", repoID).
+		Order("name ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// FindByNamespace returns the namespace level secrets, which are shared by all repositories of the namespace
+func (s *SecretStore) FindByNamespace(ctx context.Context, namespace string) ([]Secret, error) {
+	var secrets []Secret
+	err := s.db.Operator.Core.NewSelect().
+		Model(&secrets).
+		Where("namespace = ? and repository_id = 0", namespace).
+		Order("name ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (s *SecretStore) Delete(ctx context.Context, namespace string, repoID int64, name string) error {
+	return assertAffectedOneRow(s.db.Operator.Core.NewDelete().
+		Model((*Secret)(nil)).
+		Where("namespace = ? and repository_id = ? and name = ?", namespace, repoID, name).
+		Exec(ctx),
+	)
+}
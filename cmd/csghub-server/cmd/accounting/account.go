@@ -7,6 +7,7 @@ import (
 func init() {
 	// add subcommands here
 	Cmd.AddCommand(launchCmd)
+	Cmd.AddCommand(replayDLQCmd)
 }
 
 var Cmd = &cobra.Command{
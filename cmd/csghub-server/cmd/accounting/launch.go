@@ -42,7 +42,7 @@ var launchCmd = &cobra.Command{
 		meter := consumer.NewMetering(mqHandler, cfg)
 		meter.Run()
 
-		r, err := router.NewAccountRouter(cfg)
+		r, err := router.NewAccountRouter(cfg, mqHandler)
 		if err != nil {
 			return fmt.Errorf("failed to init router: %w", err)
 		}
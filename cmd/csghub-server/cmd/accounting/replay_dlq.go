@@ -0,0 +1,73 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mq"
+)
+
+var (
+	dlqListOnly  bool
+	dlqSequences []uint
+	dlqFromSeq   uint64
+	dlqLimit     int
+)
+
+func init() {
+	replayDLQCmd.Flags().BoolVar(&dlqListOnly, "list", false, "only list the messages parked in DLQ without replaying them")
+	replayDLQCmd.Flags().UintSliceVar(&dlqSequences, "seq", nil, "sequences of the messages to replay, for example '12,15', all messages are replayed if not set")
+	replayDLQCmd.Flags().Uint64Var(&dlqFromSeq, "from", 0, "sequence to start listing messages from")
+	replayDLQCmd.Flags().IntVar(&dlqLimit, "limit", 100, "max number of messages to list or replay")
+}
+
+var replayDLQCmd = &cobra.Command{
+	Use:   "replay-dlq",
+	Short: "Inspect and replay metering messages parked in DLQ",
+	Example: `
+# list the first 100 messages parked in DLQ
+csghub-server accounting replay-dlq --list
+# replay the messages with sequence 12 and 15
+csghub-server accounting replay-dlq --seq 12,15
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		database.InitDB(database.DBConfig{
+			Dialect: database.DatabaseDialect(cfg.Database.Driver),
+			DSN:     cfg.Database.DSN,
+		})
+		mqHandler, err := mq.Init(cfg)
+		if err != nil {
+			return fmt.Errorf("fail to build message queue handler: %w", err)
+		}
+
+		ctx := context.Background()
+		dc := component.NewDLQComponent(mqHandler)
+		var result interface{}
+		if dlqListOnly {
+			result, err = dc.ListMessages(ctx, dlqFromSeq, dlqLimit)
+		} else {
+			req := types.ACCT_DLQ_REPLAY_REQ{Limit: dlqLimit}
+			for _, seq := range dlqSequences {
+				req.Sequences = append(req.Sequences, uint64(seq))
+			}
+			result, err = dc.Replay(ctx, req)
+		}
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	},
+}
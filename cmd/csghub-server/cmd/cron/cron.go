@@ -9,6 +9,7 @@ func init() {
 	Cmd.AddCommand(cmdCalcRecomScore)
 	Cmd.AddCommand(cmdCreatePushMirror)
 	Cmd.AddCommand(cmdGenTelemetry)
+	Cmd.AddCommand(cmdRefreshMirrors)
 }
 
 var Cmd = &cobra.Command{
@@ -0,0 +1,70 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/builder/store/cache"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/component"
+)
+
+var cmdRefreshMirrors = &cobra.Command{
+	Use:   "refresh-mirrors",
+	Short: "the cmd to enqueue the scheduled mirrors whose upstream changed",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		config, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config,%w", err)
+		}
+
+		dbConfig := database.DBConfig{
+			Dialect: database.DatabaseDialect(config.Database.Driver),
+			DSN:     config.Database.DSN,
+		}
+
+		database.InitDB(dbConfig)
+		ctx := context.WithValue(cmd.Context(), "config", config)
+		cmd.SetContext(ctx)
+		return
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		config, ok := ctx.Value("config").(*config.Config)
+		if !ok {
+			slog.Error("config not found in context")
+			return
+		}
+
+		if config.GitServer.Type != types.GitServerTypeGitaly {
+			return
+		}
+
+		locker, err := cache.NewCache(ctx, cache.RedisConfig{
+			Addr:     config.Redis.Endpoint,
+			Username: config.Redis.User,
+			Password: config.Redis.Password,
+		})
+		if err != nil {
+			slog.Error("failed to initialize redis", "err", err)
+			return
+		}
+
+		// runs of the cron job must not overlap, or a mirror may be enqueued twice
+		err = locker.RunWhileLocked(ctx, "refresh-mirrors", 30*time.Minute, func(ctx context.Context) error {
+			c, err := component.NewMirrorComponent(config)
+			if err != nil {
+				return fmt.Errorf("failed to create mirror component: %w", err)
+			}
+			return c.RefreshMirrors(ctx)
+		})
+		if err != nil {
+			slog.Error("failed to refresh mirrors", "err", err)
+		}
+	},
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"opencsg.com/csghub-server/api/httpbase"
 	"opencsg.com/csghub-server/api/router"
+	"opencsg.com/csghub-server/builder/accounting"
 	"opencsg.com/csghub-server/builder/deploy"
 	"opencsg.com/csghub-server/builder/event"
 	"opencsg.com/csghub-server/builder/store/database"
@@ -60,16 +61,30 @@ var serverCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("fail to initialize message queue, %w", err)
 		}
-		deploy.Init(deploy.DeployConfig{
-			ImageBuilderURL:         cfg.Space.BuilderEndpoint,
-			ImageRunnerURL:          cfg.Space.RunnerEndpoint,
-			MonitorInterval:         10 * time.Second,
-			InternalRootDomain:      cfg.Space.InternalRootDomain,
-			SpaceDeployTimeoutInMin: cfg.Space.DeployTimeoutInMin,
-			ModelDeployTimeoutInMin: cfg.Model.DeployTimeoutInMin,
-			ModelDownloadEndpoint:   cfg.Model.DownloadEndpoint,
-			PublicRootDomain:        cfg.Space.PublicRootDomain,
-		})
+		deployConfig := deploy.DeployConfig{
+			ImageBuilderURL:           cfg.Space.BuilderEndpoint,
+			ImageRunnerURL:            cfg.Space.RunnerEndpoint,
+			MonitorInterval:           10 * time.Second,
+			InternalRootDomain:        cfg.Space.InternalRootDomain,
+			SpaceDeployTimeoutInMin:   cfg.Space.DeployTimeoutInMin,
+			ModelDeployTimeoutInMin:   cfg.Model.DeployTimeoutInMin,
+			ModelDownloadEndpoint:     cfg.Model.DownloadEndpoint,
+			PublicRootDomain:          cfg.Space.PublicRootDomain,
+			Scheduler:                 cfg.Space.DeployScheduler,
+			SchedulerMaxRunning:       cfg.Space.SchedulerMaxRunning,
+			SchedulerMaxRunningOfUser: cfg.Space.SchedulerMaxRunningOfUser,
+			SchedulerMaxRunningOfOrg:  cfg.Space.SchedulerMaxRunningOfOrg,
+			BuildTaskTimeoutInMin:     cfg.Space.BuildTaskTimeoutInMin,
+			DeployTaskTimeoutInMin:    cfg.Space.DeployTaskTimeoutInMin,
+		}
+		if cfg.Accounting.CreditEnforce {
+			deployConfig.AcctClient, err = accounting.NewAccountingClient(cfg)
+			if err != nil {
+				return fmt.Errorf("fail to create accounting client, %w", err)
+			}
+			deployConfig.CreditGracePeriodInMin = cfg.Accounting.CreditGracePeriodInMin
+		}
+		deploy.Init(deployConfig)
 		r, err := router.NewRouter(cfg, enableSwagger)
 		if err != nil {
 			return fmt.Errorf("failed to init router: %w", err)
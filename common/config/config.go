@@ -22,6 +22,8 @@ type Config struct {
 		Port             int    `envconfig:"STARHUB_SERVER_MIRROR_PORT" default:"8085"`
 		SessionSecretKey string `envconfig:"STARHUB_SERVER_MIRROR_SESSION_SECRET_KEY" default:"mirror"`
 		WorkerNumber     int    `envconfig:"STARHUB_SERVER_MIRROR_WORKER_NUMBER" default:"5"`
+		// a task waiting longer than this outranks the tasks of the next higher priority enqueued after it
+		QueueAgingInMin int `envconfig:"STARHUB_SERVER_MIRROR_QUEUE_AGING_IN_MIN" default:"60"`
 	}
 
 	DocsHost string `envconfig:"STARHUB_SERVER_SERVER_DOCS_HOST" default:"http://localhost:6636"`
@@ -58,6 +60,8 @@ type Config struct {
 		Storge    string `envconfig:"STARHUB_SERVER_GITALY_STORGE" default:"default"`
 		Token     string `envconfig:"STARHUB_SERVER_GITALY_TOKEN" default:"abc123secret"`
 		JWTSecret string `envconfig:"STARHUB_SERVER_GITALY_JWT_SECRET" default:"signing-key"`
+		// a multi-file commit streams the content of every file, 0 means no limit
+		CommitFilesTimeoutInSec int `envconfig:"STARHUB_SERVER_GITALY_COMMIT_FILES_TIMEOUT_IN_SECONDS" default:"300"`
 	}
 
 	MirrorServer struct {
@@ -124,6 +128,23 @@ type Config struct {
 		ReadnessDelaySeconds     int    `envconfig:"STARHUB_SERVER_READNESS_DELAY_SECONDS" default:"120"`
 		ReadnessPeriodSeconds    int    `envconfig:"STARHUB_SERVER_READNESS_PERIOD_SECONDS" default:"10"`
 		ReadnessFailureThreshold int    `envconfig:"STARHUB_SERVER_READNESS_FAILURE_THRESHOLD" default:"3"`
+		// policy to choose a cluster for deploys without one: least_loaded, bin_packing or random
+		PlacementPolicy string `envconfig:"STARHUB_SERVER_SPACE_PLACEMENT_POLICY" default:"least_loaded"`
+		// clusters in this zone are preferred when they have enough resources
+		PlacementZone string `envconfig:"STARHUB_SERVER_SPACE_PLACEMENT_ZONE"`
+		// only nodes with all these labels can run deploys, like key1:value1,key2:value2
+		PlacementNodeLabels map[string]string `envconfig:"STARHUB_SERVER_SPACE_PLACEMENT_NODE_LABELS"`
+		// seconds the node resources of a cluster are reused between placements
+		PlacementCacheSeconds int `envconfig:"STARHUB_SERVER_SPACE_PLACEMENT_CACHE_SECONDS" default:"30"`
+		// scheduler of deploy tasks, fifo or priority
+		DeployScheduler string `envconfig:"STARHUB_SERVER_SPACE_DEPLOY_SCHEDULER" default:"fifo"`
+		// running task limits of the priority scheduler, 0 means no limit
+		SchedulerMaxRunning       int `envconfig:"STARHUB_SERVER_SPACE_SCHEDULER_MAX_RUNNING" default:"100"`
+		SchedulerMaxRunningOfUser int `envconfig:"STARHUB_SERVER_SPACE_SCHEDULER_MAX_RUNNING_OF_USER" default:"3"`
+		SchedulerMaxRunningOfOrg  int `envconfig:"STARHUB_SERVER_SPACE_SCHEDULER_MAX_RUNNING_OF_ORG" default:"10"`
+		// timeouts of image build and image run tasks in the priority scheduler
+		BuildTaskTimeoutInMin  int `envconfig:"STARHUB_SERVER_SPACE_BUILD_TASK_TIMEOUT_IN_MINUTES" default:"30"`
+		DeployTaskTimeoutInMin int `envconfig:"STARHUB_SERVER_SPACE_DEPLOY_TASK_TIMEOUT_IN_MINUTES" default:"90"`
 	}
 
 	Model struct {
@@ -132,6 +153,8 @@ type Config struct {
 		DockerRegBase       string `envconfig:"STARHUB_SERVER_MODEL_DOCKER_REG_BASE" default:"opencsg-registry.cn-beijing.cr.aliyuncs.com/public/"`
 		NimDockerSecretName string `envconfig:"STARHUB_SERVER_MODEL_NIM_DOCKER_SECRET_NAME" default:"ngc-secret"`
 		NimNGCSecretName    string `envconfig:"STARHUB_SERVER_MODEL_NIM_NGC_SECRET_NAME" default:"nvidia-nim-secrets"`
+		// custom metric of gpu utilization served to HPA, for the gpu autoscaling metric
+		GPUUtilizationMetric string `envconfig:"STARHUB_SERVER_MODEL_GPU_UTILIZATION_METRIC" default:"DCGM_FI_DEV_GPU_UTIL"`
 	}
 	// send events
 	Event struct {
@@ -159,6 +182,10 @@ type Config struct {
 	Accounting struct {
 		Host string `envconfig:"OPENCSG_ACCOUNTING_SERVER_HOST" default:"http://localhost"`
 		Port int    `envconfig:"OPENCSG_ACCOUNTING_SERVER_PORT" default:"8086"`
+		// require a positive balance to start billable deploys, and stop them once the balance
+		// has been negative for longer than the grace period
+		CreditEnforce          bool `envconfig:"OPENCSG_ACCOUNTING_CREDIT_ENFORCE" default:"false"`
+		CreditGracePeriodInMin int  `envconfig:"OPENCSG_ACCOUNTING_CREDIT_GRACE_PERIOD_IN_MIN" default:"60"`
 	}
 
 	User struct {
@@ -177,6 +204,11 @@ type Config struct {
 		Enable    bool   `envconfig:"STARHUB_SERVER_TELEMETRY_ENABLE" default:"true"`
 		ReportURL string `envconfig:"STARHUB_SERVER_TELEMETRY_URL" default:"http://hub.opencsg.com/api/v1/telemetry"`
 	}
+
+	Secret struct {
+		// key to encrypt the deploy secrets and mirror credentials at rest, neither can be set without it
+		EncryptionKey string `envconfig:"STARHUB_SERVER_SECRET_ENCRYPTION_KEY" default:""`
+	}
 }
 
 func LoadConfig() (cfg *Config, err error) {
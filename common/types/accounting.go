@@ -44,6 +44,7 @@ var (
 type ACCT_STATEMENTS_REQ struct {
 	CurrentUser  string `json:"current_user"`
 	UserUUID     string `json:"user_uuid"`
+	OrgName      string `json:"org_name"`
 	Scene        int    `json:"scene"`
 	InstanceName string `json:"instance_name"`
 	StartTime    string `json:"start_time"`
@@ -64,4 +65,157 @@ type METERING_EVENT struct {
 	CustomerID   string    `json:"customer_id"`   // customer_id will be shown in bill
 	CreatedAt    time.Time `json:"created_at"`    // time of event happen
 	Extra        string    `json:"extra"`
+	// organization owning the metered resource, the usage is billed to it rather than the user if set
+	OrgName string `json:"org_name,omitempty"`
+}
+
+type ACCT_PRICE_REQ struct {
+	SkuType SKUType   `json:"sku_type"`
+	Scene   SceneType `json:"scene" binding:"required"`
+	// empty sku id is the default price of the scene
+	SkuID       string    `json:"sku_id"`
+	SkuUnit     int64     `json:"sku_unit" binding:"required,min=1"`
+	SkuUnitType string    `json:"sku_unit_type" binding:"required"`
+	SkuPrice    int64     `json:"sku_price" binding:"min=0"` // price in cents per sku_unit
+	StartTime   time.Time `json:"start_time" binding:"required"`
+	EndTime     time.Time `json:"end_time"` // zero means never expires
+}
+
+type ACCT_PRICE_QUERY struct {
+	Scene int    `json:"scene"`
+	SkuID string `json:"sku_id"`
+	Per   int    `json:"per"`
+	Page  int    `json:"page"`
+}
+
+type ACCT_MONTHLY_REQ struct {
+	CurrentUser string `json:"current_user"`
+	UserUUID    string `json:"user_uuid"`
+	OrgName     string `json:"org_name"`
+	Scene       int    `json:"scene"`       // 0 for all scenes
+	StartMonth  string `json:"start_month"` // format: '2024-06'
+	EndMonth    string `json:"end_month"`   // format: '2024-09', included
+}
+
+type ACCT_MONTHLY_TOTAL struct {
+	Month       string    `json:"month"`
+	Scene       SceneType `json:"scene"`
+	SkuUnitType string    `json:"sku_unit_type"`
+	Value       float64   `json:"value"`
+	Cost        int64     `json:"cost"` // cost in cents
+}
+
+type BalanceOwnerType string
+
+const (
+	// the owner id of a user balance is the user uuid
+	BalanceOwnerUser BalanceOwnerType = "user"
+	// the owner id of an organization balance is the organization name
+	BalanceOwnerOrg BalanceOwnerType = "organization"
+)
+
+type BalanceEntryType string
+
+const (
+	BalanceEntryTopUp      BalanceEntryType = "topup"
+	BalanceEntryCharge     BalanceEntryType = "charge"
+	BalanceEntryAdjustment BalanceEntryType = "adjustment"
+)
+
+type ACCT_BALANCE struct {
+	OwnerType BalanceOwnerType `json:"owner_type"`
+	OwnerID   string           `json:"owner_id"`
+	Balance   int64            `json:"balance"` // balance in cents
+	// time the balance went negative, nil if it is not negative
+	NegativeSince *time.Time `json:"negative_since,omitempty"`
+}
+
+type ACCT_BALANCE_REQ struct {
+	OpUser string `json:"op_user"`
+	// amount in cents, must be positive for top-ups, adjustments can be negative
+	Amount int64  `json:"amount" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type ACCT_BALANCE_ENTRIES_REQ struct {
+	CurrentUser string           `json:"current_user"`
+	OwnerType   BalanceOwnerType `json:"owner_type"`
+	OwnerID     string           `json:"owner_id"`
+	Per         int              `json:"per"`
+	Page        int              `json:"page"`
+}
+
+// ACCT_DLQ_MESSAGE is a metering message parked in DLQ after it failed to be saved
+type ACCT_DLQ_MESSAGE struct {
+	Sequence uint64    `json:"sequence"`
+	Subject  string    `json:"subject"`
+	Data     string    `json:"data"`
+	Time     time.Time `json:"time"`
+}
+
+type ACCT_DLQ_REPLAY_REQ struct {
+	// sequences of the messages to replay, the first `limit` messages are replayed if empty
+	Sequences []uint64 `json:"sequences"`
+	Limit     int      `json:"limit"`
+}
+
+type ACCT_DLQ_REPLAY_FAILURE struct {
+	Sequence uint64 `json:"sequence"`
+	Error    string `json:"error"`
+}
+
+type ACCT_DLQ_REPLAY_RESULT struct {
+	Replayed []uint64                  `json:"replayed"`
+	Failed   []ACCT_DLQ_REPLAY_FAILURE `json:"failed"`
+}
+
+type UsageBucket string
+
+const (
+	UsageBucketDay   UsageBucket = "day"
+	UsageBucketWeek  UsageBucket = "week"
+	UsageBucketMonth UsageBucket = "month"
+	// one bucket for the whole time range
+	UsageBucketTotal UsageBucket = "total"
+)
+
+var UsageBuckets = []UsageBucket{UsageBucketDay, UsageBucketWeek, UsageBucketMonth, UsageBucketTotal}
+
+// dimensions usage can be grouped by
+const (
+	UsageGroupByUser     = "user"
+	UsageGroupByOrg      = "org"
+	UsageGroupByScene    = "scene"
+	UsageGroupBySku      = "sku"
+	UsageGroupByResource = "resource"
+)
+
+var UsageGroupBys = []string{UsageGroupByUser, UsageGroupByOrg, UsageGroupByScene, UsageGroupBySku, UsageGroupByResource}
+
+type ACCT_USAGE_REQ struct {
+	CurrentUser string      `json:"current_user"`
+	StartDate   string      `json:"start_date"` // format: '2024-06-01'
+	EndDate     string      `json:"end_date"`   // format: '2024-06-30', included
+	Bucket      UsageBucket `json:"bucket"`
+	GroupBy     []string    `json:"group_by"`
+	// filters, empty or 0 matches all
+	UserUUID string `json:"user_uuid"`
+	OrgName  string `json:"org_name"`
+	Scene    int    `json:"scene"`
+	SkuID    string `json:"sku_id"`
+}
+
+// ACCT_USAGE is the usage of a time bucket, the dimensions not grouped by are empty
+type ACCT_USAGE struct {
+	Bucket       string    `json:"bucket"`
+	UserUUID     string    `json:"user_uuid,omitempty"`
+	OrgName      string    `json:"org_name,omitempty"`
+	Scene        SceneType `json:"scene,omitempty"`
+	SkuID        string    `json:"sku_id,omitempty"`
+	ResourceName string    `json:"resource_name,omitempty"`
+	Resource     string    `json:"resource,omitempty"`
+	SkuUnitType  string    `json:"sku_unit_type"`
+	Value        float64   `json:"value"`
+	Cost         int64     `json:"cost"` // cost in cents
+	EventCount   int64     `json:"event_count"`
 }
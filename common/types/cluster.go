@@ -37,6 +37,8 @@ type NodeResourceInfo struct {
 	TotalMem         float32 `json:"total_mem"`     //in GB
 	AvailableMem     float32 `json:"available_mem"` //in GB
 	XPUCapacityLabel string  `json:"xpu_capacity_label"`
+	// node labels, used to match placement label constraints
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 type UpdateClusterResponse struct {
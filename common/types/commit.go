@@ -29,3 +29,44 @@ type CommitResponse struct {
 	Diff    []byte        `json:"diff"`
 	Stats   *CommitStats  `json:"stats"`
 }
+
+// BlameRange is a range of consecutive lines last changed by the same commit, line numbers start at 1
+type BlameRange struct {
+	Commit    Commit   `json:"commit"`
+	StartLine int      `json:"start_line"`
+	EndLine   int      `json:"end_line"`
+	Lines     []string `json:"lines"`
+}
+
+type CompareFile struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// LFSPointerChange describes an LFS file whose pointer differs between two refs,
+// old fields are empty for an added file and new fields are empty for a deleted one
+type LFSPointerChange struct {
+	Path    string `json:"path"`
+	OldOid  string `json:"old_oid"`
+	OldSize int64  `json:"old_size"`
+	NewOid  string `json:"new_oid"`
+	NewSize int64  `json:"new_size"`
+}
+
+type CompareResponse struct {
+	BaseCommitID string `json:"base_commit_id"`
+	HeadCommitID string `json:"head_commit_id"`
+	// on gitea it is the first parent of the oldest compared commit, which is exact for linear histories only
+	MergeBaseCommitID string `json:"merge_base_commit_id"`
+	// a page of the commits, newest first
+	Commits      []Commit           `json:"commits"`
+	TotalCommits int                `json:"total_commits"`
+	Files        []CompareFile      `json:"files"`
+	LFSFiles     []LFSPointerChange `json:"lfs_files"`
+	Stats        *CommitStats       `json:"stats"`
+	Diff         []byte             `json:"diff"`
+	// the diff is cut at the size limit, files and stats may miss the changes after it
+	DiffTruncated bool `json:"diff_truncated"`
+}
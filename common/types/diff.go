@@ -20,3 +20,18 @@ type PostReceiveReq struct {
 	Identifier   string `json:"identifier"`
 }
 
+type PreReceiveReq struct {
+	Changes      string `json:"changes"`
+	GlRepository string `json:"gl_repository"`
+	// user-<id> or key-<id> of the pusher
+	GlID     string `json:"gl_id"`
+	Protocol string `json:"protocol"`
+	// json encoded GitObjectDirs of the push quarantine
+	Env string `json:"env"`
+}
+
+// GitObjectDirs are the quarantine object directories gitaly passes to the hooks of a push
+type GitObjectDirs struct {
+	GitObjectDirectory            string   `json:"GIT_OBJECT_DIRECTORY_RELATIVE"`
+	GitAlternateObjectDirectories []string `json:"GIT_ALTERNATE_OBJECT_DIRECTORIES_RELATIVE"`
+}
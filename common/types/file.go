@@ -39,6 +39,44 @@ type CreateFileReq struct {
 
 type CreateFileResp struct{}
 
+const (
+	CommitActionCreate = "create"
+	CommitActionUpdate = "update"
+	CommitActionDelete = "delete"
+	CommitActionMove   = "move"
+)
+
+type CommitAction struct {
+	Action string `json:"action" binding:"required,oneof=create update delete move"`
+	Path   string `json:"path" binding:"required"`
+	// source path of a move
+	PreviousPath string `json:"previous_path"`
+	// base64 encoded file content, a move without content keeps the content of the previous path
+	Content string `json:"content"`
+
+	// Use for lfs file
+	OriginalContent []byte   `json:"-"`
+	Pointer         *Pointer `json:"-"`
+}
+
+type CommitFilesReq struct {
+	//will use login username, ignore username from http request body
+	Username string         `json:"-"`
+	Email    string         `json:"-"`
+	Message  string         `json:"message" binding:"required"`
+	Branch   string         `json:"branch"`
+	Actions  []CommitAction `json:"actions" binding:"required,min=1,dive"`
+
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type CommitFilesResp struct {
+	CommitID string `json:"commit_id"`
+}
+
 type UpdateFileReq struct {
 	//will use login username, ignore username from http request body
 	Username   string `json:"-"`
@@ -68,6 +106,18 @@ type GetCommitsReq struct {
 	Per         int    `json:"per"`
 	Page        int    `json:"page"`
 	Ref         string `json:"ref"`
+	Path        string `json:"path"`
+	RepoType    RepositoryType
+	CurrentUser string `json:"current_user"`
+}
+
+type CompareRefsReq struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Base        string `json:"base"`
+	Head        string `json:"head"`
+	Per         int    `json:"per"`
+	Page        int    `json:"page"`
 	RepoType    RepositoryType
 	CurrentUser string `json:"current_user"`
 }
@@ -99,6 +149,56 @@ type GetTagsReq struct {
 	CurrentUser string `json:"current_user"`
 }
 
+type CreateBranchReq struct {
+	Branch string `json:"branch" binding:"required"`
+	// branch, tag or commit id the new branch starts from, default branch if empty
+	StartPoint string `json:"start_point"`
+
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type DeleteBranchReq struct {
+	Branch      string         `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type RenameBranchReq struct {
+	NewBranch string `json:"new_branch" binding:"required"`
+
+	Branch      string         `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type CreateTagReq struct {
+	Tag string `json:"tag" binding:"required"`
+	// branch or commit id the tag points to, default branch if empty
+	Target string `json:"target"`
+	// an annotated tag is created if message is not empty
+	Message string `json:"message"`
+
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type DeleteTagReq struct {
+	Tag         string         `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
 // currently update and create fiel share the same response
 type UpdateFileResp CreateFileResp
 
@@ -85,6 +85,8 @@ type Pointer struct {
 	Oid         string `json:"oid"`
 	Size        int64  `json:"size"`
 	DownloadURL string `json:"download_url"`
+	// headers the LFS server requires to download the object
+	DownloadHeader map[string]string `json:"download_header,omitempty"`
 }
 
 type BatchResponse struct {
@@ -83,7 +83,8 @@ type LFSBatchResponse struct {
 		Size    int64  `json:"size"`
 		Actions struct {
 			Download struct {
-				Href string `json:"href"`
+				Href   string            `json:"href"`
+				Header map[string]string `json:"header"`
 			} `json:"download"`
 		} `json:"actions"`
 	} `json:"objects"`
@@ -18,6 +18,8 @@ type CreateMirrorReq struct {
 	CurrentUser     string         `json:"current_user"`
 	RepoType        RepositoryType `json:"repo_type"`
 	SyncLfs         bool           `json:"sync_lfs"`
+	// credential profile of the mirror source to access the source, instead of username and password
+	CredentialID int64 `json:"credential_id"`
 }
 
 type CreateMirrorParams struct {
@@ -25,6 +27,7 @@ type CreateMirrorParams struct {
 	MirrorSourceID int64  `json:"mirror_source_id"`
 	Username       string `json:"username"`
 	AccessToken    string `json:"password"`
+	CredentialID   int64  `json:"credential_id"`
 }
 
 type GetMirrorReq struct {
@@ -96,6 +99,90 @@ const (
 	MirrorIncomplete MirrorTaskStatus = "incomplete"
 )
 
+type MirrorCredentialType string
+
+const (
+	HFTokenCredential   MirrorCredentialType = "hf_token"
+	GitHubPATCredential MirrorCredentialType = "github_pat"
+	BasicAuthCredential MirrorCredentialType = "basic"
+)
+
+type CreateMirrorCredentialReq struct {
+	MirrorSourceID int64                `json:"-"`
+	Name           string               `json:"name" binding:"required"`
+	Type           MirrorCredentialType `json:"type" binding:"required"`
+	// username of basic auth, optional for tokens
+	Username string `json:"username"`
+	// token or password
+	Secret    string `json:"secret" binding:"required"`
+	IsDefault bool   `json:"is_default"`
+	// the credential is only sent to URLs under the prefix, like https://huggingface.co or https://github.com/org
+	AllowedURLPrefix string `json:"allowed_url_prefix" binding:"required"`
+	// users besides admins allowed to set the credential on their mirrors
+	AllowedUsers []string `json:"allowed_users"`
+	CurrentUser  string   `json:"-"`
+}
+
+type UpdateMirrorCredentialReq struct {
+	ID             int64                `json:"-"`
+	MirrorSourceID int64                `json:"-"`
+	Name           string               `json:"name" binding:"required"`
+	Type           MirrorCredentialType `json:"type" binding:"required"`
+	Username       string               `json:"username"`
+	// the secret is kept if empty
+	Secret           string   `json:"secret"`
+	IsDefault        bool     `json:"is_default"`
+	AllowedURLPrefix string   `json:"allowed_url_prefix" binding:"required"`
+	AllowedUsers     []string `json:"allowed_users"`
+	CurrentUser      string   `json:"-"`
+}
+
+type MirrorTaskType string
+
+const (
+	RepoMirrorTask MirrorTaskType = "repo"
+	LfsMirrorTask  MirrorTaskType = "lfs"
+)
+
+// MirrorDeadLetter is a mirror task which failed more than its retries
+type MirrorDeadLetter struct {
+	MirrorID  int64          `json:"mirror_id"`
+	Type      MirrorTaskType `json:"type"`
+	RepoPath  string         `json:"repo_path"`
+	RepoType  RepositoryType `json:"repo_type"`
+	Priority  int            `json:"priority"`
+	Retries   int            `json:"retries"`
+	LastError string         `json:"last_error"`
+	FailedAt  time.Time      `json:"failed_at"`
+}
+
+type MirrorQueueBand struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	Depth    int64  `json:"depth"`
+}
+
+type MirrorQueueStats struct {
+	Type  MirrorTaskType    `json:"type"`
+	Bands []MirrorQueueBand `json:"bands"`
+	// tasks in all bands
+	Depth int64 `json:"depth"`
+	// failed tasks waiting for their backoff to end
+	Delayed     int64 `json:"delayed"`
+	DeadLetters int64 `json:"dead_letters"`
+}
+
+type MirrorQueuePosition struct {
+	MirrorID int64          `json:"mirror_id"`
+	Type     MirrorTaskType `json:"type"`
+	Band     string         `json:"band"`
+	Priority int            `json:"priority"`
+	// position in the pop order of all bands, 1 is the next task to run
+	Position   int       `json:"position"`
+	Depth      int       `json:"depth"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
 type Mapping string
 
 const (
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -114,11 +115,47 @@ type DeleteRepoReq struct {
 	RepoType  RepositoryType `json:"-"`
 }
 
+type ForkRepoReq struct {
+	// user or org namespace the fork is created in
+	TargetNamespace string `json:"target_namespace" binding:"required" example:"user_or_org_name"`
+	// defaults to the name of the forked repo
+	TargetName  string `json:"target_name" example:"model_name_1"`
+	Nickname    string `json:"nickname" example:"model display name"`
+	Description string `json:"description"`
+	// forks of private repos are always private
+	Private     bool           `json:"private"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+// make sure ForkRepoReq implements SensitiveRequest
+var _ SensitiveRequest = (*ForkRepoReq)(nil)
+
+func (c *ForkRepoReq) SensName() string {
+	return c.TargetName
+}
+
+func (c *ForkRepoReq) SensNickName() string {
+	return c.Nickname
+}
+
+func (c *ForkRepoReq) SensDescription() string {
+	return c.Description
+}
+
+func (c *ForkRepoReq) SensHomepage() string {
+	return ""
+}
+
 type Relations struct {
-	Models   []*Model   `json:"models,omitempty"`
-	Datasets []*Dataset `json:"datasets,omitempty"`
-	Codes    []*Code    `json:"codes,omitempty"`
-	Spaces   []*Space   `json:"spaces,omitempty"`
+	// path of the repo this repo was forked from
+	ForkedFrom string     `json:"forked_from,omitempty"`
+	Models     []*Model   `json:"models,omitempty"`
+	Datasets   []*Dataset `json:"datasets,omitempty"`
+	Codes      []*Code    `json:"codes,omitempty"`
+	Spaces     []*Space   `json:"spaces,omitempty"`
 }
 
 type Model struct {
@@ -197,6 +234,10 @@ type ModelRunReq struct {
 	MaxReplica         int    `json:"max_replica"`
 	Revision           string `json:"revision"`
 	SecureLevel        int    `json:"secure_level"`
+	// knative defaults are used if not set
+	Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"`
+	// deploys with higher priority are scheduled first, only admins can set it
+	Priority int `json:"priority"`
 }
 
 func (c *ModelRunReq) SensName() string {
@@ -258,6 +299,8 @@ type ModelUpdateResponse struct {
 type ModelStatusEventData struct {
 	Status  string     `json:"status"`
 	Details []Instance `json:"details"`
+	// position in the deploy queue while waiting to be scheduled
+	QueuePosition int `json:"queue_position,omitempty"`
 }
 
 const (
@@ -267,6 +310,28 @@ const (
 	ServerlessType = 3    // serverless
 )
 
+// EndpointKeyPrefix marks the api keys of endpoints, so they are told apart from user access tokens
+const EndpointKeyPrefix = "csgek-"
+
+type CreateEndpointKeyReq struct {
+	Name string `json:"name" binding:"required"`
+	// requests and tokens allowed per minute, 0 means unlimited
+	RequestsPerMinute int64 `json:"requests_per_minute" binding:"min=0"`
+	TokensPerMinute   int64 `json:"tokens_per_minute" binding:"min=0"`
+}
+
+type EndpointKey struct {
+	ID       int64  `json:"id"`
+	DeployID int64  `json:"deploy_id"`
+	Name     string `json:"name"`
+	// the key is returned only once when it is created
+	Key               string    `json:"key,omitempty"`
+	KeyPrefix         string    `json:"key_prefix"`
+	RequestsPerMinute int64     `json:"requests_per_minute"`
+	TokensPerMinute   int64     `json:"tokens_per_minute"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
 type DeployActReq struct {
 	RepoType     RepositoryType `json:"repo_type"`
 	Namespace    string         `json:"namespace"`
@@ -287,4 +352,55 @@ type DeployUpdateReq struct {
 	MaxReplica         *int    `json:"max_replica" validate:"min=1,gtefield=MinReplica"`
 	Revision           *string `json:"revision"`
 	SecureLevel        *int    `json:"secure_level"`
+	// replaces the whole autoscaling policy when set
+	Autoscaling *AutoscalingPolicy `json:"autoscaling"`
+}
+
+const (
+	AutoscalingMetricConcurrency = "concurrency"
+	AutoscalingMetricRPS         = "rps"
+	AutoscalingMetricGPU         = "gpu"
+)
+
+// AutoscalingPolicy decides how the replicas of an inference endpoint scale between min and max replica
+type AutoscalingPolicy struct {
+	// concurrency or rps scaled by knative KPA, gpu utilization scaled by HPA
+	Metric string `json:"metric"`
+	// concurrent requests or requests per second of each replica, or gpu utilization percentage
+	Target int `json:"target"`
+	// seconds to wait before scaling down once the load dropped, KPA only
+	ScaleDownDelay int `json:"scale_down_delay"`
+	// seconds to keep the last replica before scaling to zero when min replica is 0, KPA only
+	ScaleToZeroWindow int `json:"scale_to_zero_window"`
+}
+
+// Validate checks the policy of a deploy running at least minReplica replicas
+func (p *AutoscalingPolicy) Validate(minReplica int) error {
+	switch p.Metric {
+	case AutoscalingMetricConcurrency, AutoscalingMetricRPS:
+	case AutoscalingMetricGPU:
+		// HPA can not scale from zero, there is no gpu utilization without a replica
+		if minReplica < 1 {
+			return fmt.Errorf("metric %s requires min replica of at least 1", p.Metric)
+		}
+		if p.Target > 100 {
+			return fmt.Errorf("gpu utilization target %d is greater than 100", p.Target)
+		}
+		if p.ScaleDownDelay > 0 || p.ScaleToZeroWindow > 0 {
+			return fmt.Errorf("scale down delay and scale to zero window are not supported by metric %s", p.Metric)
+		}
+	default:
+		return fmt.Errorf("unsupported autoscaling metric %s", p.Metric)
+	}
+	if p.Target <= 0 {
+		return fmt.Errorf("autoscaling target must be greater than 0")
+	}
+	// knative limits the scale down delay to one hour
+	if p.ScaleDownDelay < 0 || p.ScaleDownDelay > 3600 {
+		return fmt.Errorf("scale down delay must be between 0 and 3600 seconds")
+	}
+	if p.ScaleToZeroWindow < 0 {
+		return fmt.Errorf("scale to zero window must not be negative")
+	}
+	return nil
 }
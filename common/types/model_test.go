@@ -0,0 +1,27 @@
+package types
+
+import "testing"
+
+func TestAutoscalingPolicy_Validate(t *testing.T) {
+	testData := []struct {
+		name       string
+		policy     AutoscalingPolicy
+		minReplica int
+		valid      bool
+	}{
+		{"concurrency scales to zero", AutoscalingPolicy{Metric: AutoscalingMetricConcurrency, Target: 5, ScaleToZeroWindow: 60}, 0, true},
+		{"gpu with a replica", AutoscalingPolicy{Metric: AutoscalingMetricGPU, Target: 80}, 1, true},
+		{"gpu without a replica", AutoscalingPolicy{Metric: AutoscalingMetricGPU, Target: 80}, 0, false},
+		{"gpu over 100 percent", AutoscalingPolicy{Metric: AutoscalingMetricGPU, Target: 120}, 1, false},
+		{"gpu with scale down delay", AutoscalingPolicy{Metric: AutoscalingMetricGPU, Target: 80, ScaleDownDelay: 30}, 1, false},
+		{"unknown metric", AutoscalingPolicy{Metric: "memory", Target: 5}, 1, false},
+		{"zero target", AutoscalingPolicy{Metric: AutoscalingMetricRPS}, 1, false},
+		{"scale down delay over an hour", AutoscalingPolicy{Metric: AutoscalingMetricRPS, Target: 5, ScaleDownDelay: 3601}, 1, false},
+	}
+	for _, data := range testData {
+		err := data.policy.Validate(data.minReplica)
+		if (err == nil) != data.valid {
+			t.Errorf("%s: expect valid %v, got error %v", data.name, data.valid, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package types
+
+type CreateProtectedBranchReq struct {
+	// branch name or a glob pattern like release/*
+	Pattern        string `json:"pattern" binding:"required"`
+	AllowForcePush bool   `json:"allow_force_push"`
+	AllowDeletion  bool   `json:"allow_deletion"`
+	// the minimum org role required to push, defaults to write
+	PushRole string `json:"push_role" binding:"omitempty,oneof=read write admin"`
+
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type UpdateProtectedBranchReq struct {
+	Pattern        *string `json:"pattern"`
+	AllowForcePush *bool   `json:"allow_force_push"`
+	AllowDeletion  *bool   `json:"allow_deletion"`
+	PushRole       *string `json:"push_role" binding:"omitempty,oneof=read write admin"`
+
+	ID          int64          `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type ProtectedBranchActReq struct {
+	ID          int64          `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
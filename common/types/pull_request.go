@@ -0,0 +1,101 @@
+package types
+
+import "time"
+
+type CreatePullRequestReq struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	// namespace and name of the fork the changes come from, empty for a branch of the repository itself
+	SourceNamespace string `json:"source_namespace"`
+	SourceName      string `json:"source_name"`
+	SourceBranch    string `json:"source_branch" binding:"required"`
+	// defaults to the default branch of the repository
+	TargetBranch string `json:"target_branch"`
+
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type ListPullRequestsReq struct {
+	// open, merged or closed, all pull requests are listed when empty
+	Status      string         `json:"status"`
+	Per         int            `json:"per"`
+	Page        int            `json:"page"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type PullRequestActReq struct {
+	ID          int64          `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type UpdatePullRequestReq struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+
+	ID          int64          `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type MergePullRequestReq struct {
+	// message of the merge commit
+	Message string `json:"message"`
+
+	ID          int64          `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type CreatePullRequestCommentReq struct {
+	Content string `json:"content" binding:"required"`
+
+	ID          int64          `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type PullRequest struct {
+	ID            int64     `json:"id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	Status        string    `json:"status"`
+	SourceRepo    string    `json:"source_repo"`
+	SourceBranch  string    `json:"source_branch"`
+	TargetBranch  string    `json:"target_branch"`
+	Author        *User     `json:"author,omitempty"`
+	MergeCommitID string    `json:"merge_commit_id,omitempty"`
+	MergedAt      time.Time `json:"merged_at,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type PullRequestDetail struct {
+	PullRequest
+	HeadCommitID string   `json:"head_commit_id"`
+	BaseCommitID string   `json:"base_commit_id"`
+	Added        []string `json:"added"`
+	Removed      []string `json:"removed"`
+	Modified     []string `json:"modified"`
+}
+
+type PullRequestComment struct {
+	ID        int64     `json:"id"`
+	Content   string    `json:"content"`
+	User      *User     `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,39 @@
+package types
+
+const (
+	QuotaOwnerUser         = "user"
+	QuotaOwnerOrganization = "organization"
+)
+
+// QuotaLimits are the compute limits of a user or an organization, 0 means no limit
+type QuotaLimits struct {
+	MaxGPU int `json:"max_gpu" binding:"min=0"`
+	// total memory in GiB
+	MaxMemory     int `json:"max_memory" binding:"min=0"`
+	MaxInferences int `json:"max_inferences" binding:"min=0"`
+	MaxFinetunes  int `json:"max_finetunes" binding:"min=0"`
+	MaxSpaces     int `json:"max_spaces" binding:"min=0"`
+}
+
+// QuotaUsage is the resources held by the deploys which are not stopped or failed
+type QuotaUsage struct {
+	GPU        int `json:"gpu"`
+	Memory     int `json:"memory"`
+	Inferences int `json:"inferences"`
+	Finetunes  int `json:"finetunes"`
+	Spaces     int `json:"spaces"`
+}
+
+type Quota struct {
+	OwnerType string `json:"owner_type"`
+	OwnerName string `json:"owner_name"`
+	// nil if the owner has no quota
+	Limits *QuotaLimits `json:"limits"`
+	Usage  QuotaUsage   `json:"usage"`
+}
+
+type SetQuotaReq struct {
+	QuotaLimits
+	OwnerType string `json:"-"`
+	OwnerName string `json:"-"`
+}
@@ -112,6 +112,9 @@ type DeployRepo struct {
 	SKU              string     `json:"sku,omitempty"`
 	ResourceType     string     `json:"resource_type,omitempty"`
 	RepoTag          string     `json:"repo_tag,omitempty"`
+	// tasks of deploys with higher priority run first in the priority scheduler
+	Priority    int                `json:"priority,omitempty"`
+	Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"`
 }
 
 type RuntimeFrameworkReq struct {
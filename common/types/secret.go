@@ -0,0 +1,109 @@
+package types
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	SecretScopeRepo      = "repo"
+	SecretScopeNamespace = "namespace"
+)
+
+type SetSecretReq struct {
+	// name of the env variable the secret is injected as
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"required"`
+
+	Namespace string `json:"-"`
+	// empty for namespace secrets
+	RepoName    string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type SecretActReq struct {
+	Name        string         `json:"-"`
+	Namespace   string         `json:"-"`
+	RepoName    string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+// Secret is the metadata of a secret, the value is write only
+type Secret struct {
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// reservedSecretNames are the env variables the deploy sets itself, a secret must not replace them
+var reservedSecretNames = []string{
+	"ACCESS_TOKEN", "HTTPCloneURL", "REPO_ID", "REVISION", "GPU_NUM", "port",
+	"HF_ENDPOINT", "HF_HUB_OFFLINE", "HF_TOKEN",
+	"CONTEXT_PATH", "GRADIO_ROOT_PATH", "STREAMLIT_SERVER_BASE_URL_PATH",
+	"NVIDIA_VISIBLE_DEVICES", "NGC_API_KEY", "NIM_CACHE_PATH",
+}
+
+// tokenEnvNames are the env variables holding the access token of the deploy
+var tokenEnvNames = []string{"ACCESS_TOKEN", "HF_TOKEN", "HTTPCloneURL"}
+
+// IsReservedSecretName reports whether the name is an env variable set by the deploy, ignoring case
+func IsReservedSecretName(name string) bool {
+	for _, reserved := range reservedSecretNames {
+		if strings.EqualFold(name, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+// runRequest and svcRequest drop the LogValue method, so the masked copies are logged as is
+type (
+	runRequest RunRequest
+	svcRequest SVCRequest
+)
+
+// LogValue masks the secret values and the access token, the requests are logged as a whole in many places
+func (r RunRequest) LogValue() slog.Value {
+	r.Secrets = maskSecrets(r.Secrets)
+	r.Env = maskTokenEnv(r.Env)
+	if r.Accesstoken != "" {
+		r.Accesstoken = "******"
+	}
+	return slog.AnyValue(runRequest(r))
+}
+
+// LogValue masks the secret values received by the service runner
+func (r SVCRequest) LogValue() slog.Value {
+	r.Secrets = maskSecrets(r.Secrets)
+	return slog.AnyValue(svcRequest(r))
+}
+
+func maskSecrets(secrets map[string]string) map[string]string {
+	if secrets == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(secrets))
+	for key := range secrets {
+		masked[key] = "******"
+	}
+	return masked
+}
+
+func maskTokenEnv(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(env))
+	for key, value := range env {
+		masked[key] = value
+	}
+	for _, key := range tokenEnvNames {
+		if _, ok := masked[key]; ok {
+			masked[key] = "******"
+		}
+	}
+	return masked
+}
@@ -34,12 +34,19 @@ type (
 		DeployType       int    `json:"deploy_type"`
 		UserID           string `json:"user_id"`
 		Sku              string `json:"sku"`
+		// autoscaling policy of inference endpoints
+		Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"`
+		// decrypted repo and namespace secrets, stored as a k8s secret and injected as env variables
+		Secrets map[string]string `json:"secrets,omitempty"`
 	}
 
 	RunResponse struct {
 		DeployID int64  `json:"deploy_id"`
 		Code     int    `json:"code"`
 		Message  string `json:"message"`
+		// cluster chosen by the runner when the request does not pin one
+		ClusterID       string `json:"cluster_id"`
+		PlacementReason string `json:"placement_reason"`
 	}
 
 	StopRequest struct {
@@ -181,5 +188,9 @@ type (
 		DeployType int               `json:"deploy_type"`
 		UserID     string            `json:"user_id"`
 		Sku        string            `json:"sku"`
+		// autoscaling policy of inference endpoints
+		Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"`
+		// decrypted repo and namespace secrets, stored as a k8s secret and injected as env variables
+		Secrets map[string]string `json:"secrets,omitempty"`
 	}
 )
@@ -53,6 +53,8 @@ type Space struct {
 	CanWrite     bool                 `json:"can_write"`
 	CanManage    bool                 `json:"can_manage"`
 	Namespace    *Namespace           `json:"namespace"`
+	// path of the space this space was forked from
+	ForkedFrom string `json:"forked_from,omitempty"`
 }
 
 type UpdateSpaceReq struct {
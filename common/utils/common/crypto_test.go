@@ -0,0 +1,61 @@
+package common
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestEncryptString(t *testing.T) {
+	encrypted, err := EncryptString("key", "hello secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := EncryptString("key", "hello secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted == again {
+		t.Error("expect a random nonce for each encryption")
+	}
+
+	for _, e := range []string{encrypted, again} {
+		text, err := DecryptString("key", e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "hello secret" {
+			t.Errorf("expect hello secret, got %s", text)
+		}
+	}
+}
+
+func TestDecryptString_invalid(t *testing.T) {
+	encrypted, err := EncryptString("key", "hello secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(encrypted)
+	data[len(data)-1] ^= 0xff
+	tampered := base64.StdEncoding.EncodeToString(data)
+
+	testData := map[string]struct {
+		key       string
+		encrypted string
+	}{
+		"wrong key":     {"other", encrypted},
+		"tampered":      {"key", tampered},
+		"not base64":    {"key", "%%%"},
+		"too short":     {"key", base64.StdEncoding.EncodeToString([]byte("short"))},
+		"key not set":   {"", encrypted},
+		"empty payload": {"key", ""},
+	}
+	for name, data := range testData {
+		if _, err := DecryptString(data.key, data.encrypted); err == nil {
+			t.Errorf("%s: expect an error", name)
+		}
+	}
+
+	if _, err := EncryptString("", "text"); err == nil {
+		t.Error("expect an error without a key")
+	}
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_next(t *testing.T) {
+	// a wednesday
+	from := time.Date(2024, 10, 2, 10, 7, 30, 0, time.UTC)
+	testData := []struct {
+		spec   string
+		expect time.Time
+	}{
+		{"6h", time.Date(2024, 10, 2, 16, 7, 30, 0, time.UTC)},
+		{"* * * * *", time.Date(2024, 10, 2, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 10, 2, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2024, 10, 2, 10, 25, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 10, 2, 13, 0, 0, 0, time.UTC)},
+		{"30 8 1,15 * *", time.Date(2024, 10, 15, 8, 30, 0, 0, time.UTC)},
+		{"0 3 * * 1-5", time.Date(2024, 10, 3, 3, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 0 and 7 are both sunday
+		{"0 0 * * 0", time.Date(2024, 10, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 10, 6, 0, 0, 0, 0, time.UTC)},
+		// a day matching either restricted day field runs
+		{"0 0 15 * 5", time.Date(2024, 10, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 3 * 1", time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC)},
+		// a day field with * restricts the days to the other field only
+		{"0 0 15 * *", time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1", time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, data := range testData {
+		schedule, err := ParseSchedule(data.spec)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", data.spec, err)
+			continue
+		}
+		if next := schedule.Next(from); !next.Equal(data.expect) {
+			t.Errorf("%s: expect next run at %s, got %s", data.spec, data.expect, next)
+		}
+	}
+}
+
+func TestParseSchedule_invalid(t *testing.T) {
+	testData := []string{
+		"",
+		"30s",
+		"every day",
+		"daily",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a-5 * * * *",
+		// never runs
+		"0 0 31 2 *",
+		"0 0 30 2 *",
+	}
+	for _, spec := range testData {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("%q: expect an error", spec)
+		}
+	}
+}
@@ -6,15 +6,17 @@ import (
 	"fmt"
 
 	"opencsg.com/csghub-server/builder/accounting"
+	"opencsg.com/csghub-server/builder/rpc"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 )
 
 type AccountingComponent struct {
-	acctClient *accounting.AccountingClient
-	user       *database.UserStore
-	deploy     *database.DeployTaskStore
+	acctClient    *accounting.AccountingClient
+	user          *database.UserStore
+	deploy        *database.DeployTaskStore
+	userSvcClient rpc.UserSvcClient
 }
 
 func NewAccountingComponent(config *config.Config) (*AccountingComponent, error) {
@@ -26,6 +28,8 @@ func NewAccountingComponent(config *config.Config) (*AccountingComponent, error)
 		acctClient: c,
 		user:       database.NewUserStore(),
 		deploy:     database.NewDeployTaskStore(),
+		userSvcClient: rpc.NewUserSvcHttpClient(fmt.Sprintf("%s:%d", config.User.Host, config.User.Port),
+			rpc.AuthWithApiKey(config.APIToken)),
 	}, nil
 }
 
@@ -39,3 +43,118 @@ func (ac *AccountingComponent) ListMeteringsByUserIDAndTime(ctx context.Context,
 	}
 	return ac.acctClient.ListMeteringsByUserIDAndTime(req)
 }
+
+func (ac *AccountingComponent) ListMonthlyTotalsByUserID(ctx context.Context, req types.ACCT_MONTHLY_REQ) (interface{}, error) {
+	user, err := ac.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("user does not exist, %w", err)
+	}
+	if user.UUID != req.UserUUID {
+		return nil, errors.New("invalid user")
+	}
+	return ac.acctClient.ListMonthlyTotalsByUserID(req)
+}
+
+func (ac *AccountingComponent) ListMeteringsByOrgNameAndTime(ctx context.Context, req types.ACCT_STATEMENTS_REQ) (interface{}, error) {
+	if err := ac.checkOrgAdmin(ctx, req.CurrentUser, req.OrgName); err != nil {
+		return nil, err
+	}
+	return ac.acctClient.ListMeteringsByOrgNameAndTime(req)
+}
+
+func (ac *AccountingComponent) ListMonthlyTotalsByOrgName(ctx context.Context, req types.ACCT_MONTHLY_REQ) (interface{}, error) {
+	if err := ac.checkOrgAdmin(ctx, req.CurrentUser, req.OrgName); err != nil {
+		return nil, err
+	}
+	return ac.acctClient.ListMonthlyTotalsByOrgName(req)
+}
+
+func (ac *AccountingComponent) ListPrices(ctx context.Context, req types.ACCT_PRICE_QUERY) (interface{}, error) {
+	return ac.acctClient.ListPrices(req)
+}
+
+func (ac *AccountingComponent) GetPrice(ctx context.Context, id int64) (interface{}, error) {
+	return ac.acctClient.GetPrice(id)
+}
+
+func (ac *AccountingComponent) CreatePrice(ctx context.Context, req types.ACCT_PRICE_REQ) (interface{}, error) {
+	return ac.acctClient.CreatePrice(req)
+}
+
+func (ac *AccountingComponent) UpdatePrice(ctx context.Context, id int64, req types.ACCT_PRICE_REQ) (interface{}, error) {
+	return ac.acctClient.UpdatePrice(id, req)
+}
+
+func (ac *AccountingComponent) DeletePrice(ctx context.Context, id int64) error {
+	_, err := ac.acctClient.DeletePrice(id)
+	return err
+}
+
+func (ac *AccountingComponent) GetBalance(ctx context.Context, currentUser string, ownerType types.BalanceOwnerType, ownerID string) (*types.ACCT_BALANCE, error) {
+	if err := ac.checkBalanceOwner(ctx, currentUser, ownerType, ownerID); err != nil {
+		return nil, err
+	}
+	return ac.acctClient.GetBalance(ownerType, ownerID)
+}
+
+func (ac *AccountingComponent) ListBalanceEntries(ctx context.Context, req types.ACCT_BALANCE_ENTRIES_REQ) (interface{}, error) {
+	if err := ac.checkBalanceOwner(ctx, req.CurrentUser, req.OwnerType, req.OwnerID); err != nil {
+		return nil, err
+	}
+	return ac.acctClient.ListBalanceEntries(req)
+}
+
+// ListUsage returns the usage of the current user, or of the organization for its admins
+func (ac *AccountingComponent) ListUsage(ctx context.Context, req types.ACCT_USAGE_REQ) (interface{}, error) {
+	if req.OrgName != "" {
+		if err := ac.checkOrgAdmin(ctx, req.CurrentUser, req.OrgName); err != nil {
+			return nil, err
+		}
+	} else {
+		user, err := ac.user.FindByUsername(ctx, req.CurrentUser)
+		if err != nil {
+			return nil, fmt.Errorf("user does not exist, %w", err)
+		}
+		req.UserUUID = user.UUID
+	}
+	return ac.acctClient.ListUsage(req)
+}
+
+func (ac *AccountingComponent) TopUpBalance(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string, req types.ACCT_BALANCE_REQ) (interface{}, error) {
+	return ac.acctClient.TopUpBalance(ownerType, ownerID, req)
+}
+
+func (ac *AccountingComponent) AdjustBalance(ctx context.Context, ownerType types.BalanceOwnerType, ownerID string, req types.ACCT_BALANCE_REQ) (interface{}, error) {
+	return ac.acctClient.AdjustBalance(ownerType, ownerID, req)
+}
+
+// checkBalanceOwner allows users to see their own balance, and organization admins the balance of the organization
+func (ac *AccountingComponent) checkBalanceOwner(ctx context.Context, currentUser string, ownerType types.BalanceOwnerType, ownerID string) error {
+	switch ownerType {
+	case types.BalanceOwnerUser:
+		user, err := ac.user.FindByUsername(ctx, currentUser)
+		if err != nil {
+			return fmt.Errorf("user does not exist, %w", err)
+		}
+		if user.UUID != ownerID {
+			return ErrUnauthorized
+		}
+	case types.BalanceOwnerOrg:
+		return ac.checkOrgAdmin(ctx, currentUser, ownerID)
+	default:
+		return fmt.Errorf("invalid balance owner type %s", ownerType)
+	}
+	return nil
+}
+
+// checkOrgAdmin allows only the admins of the organization to see its billing
+func (ac *AccountingComponent) checkOrgAdmin(ctx context.Context, currentUser, orgName string) error {
+	role, err := ac.userSvcClient.GetMemberRole(ctx, orgName, currentUser)
+	if err != nil {
+		return fmt.Errorf("failed to get member role of organization %s, %w", orgName, err)
+	}
+	if !role.CanAdmin() {
+		return ErrUnauthorized
+	}
+	return nil
+}
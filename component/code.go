@@ -345,6 +345,7 @@ func (c *CodeComponent) getRelations(ctx context.Context, repoID int64, currentU
 		return nil, err
 	}
 	rels := new(types.Relations)
+	rels.ForkedFrom = c.forkedFrom(ctx, repoID, currentUser)
 	modelRepos := res[types.ModelRepo]
 	for _, repo := range modelRepos {
 		rels.Models = append(rels.Models, &types.Model{
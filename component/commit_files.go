@@ -0,0 +1,172 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// CommitFiles applies a list of create/update/delete/move actions to a branch as a single commit
+func (c *RepoComponent) CommitFiles(ctx context.Context, req *types.CommitFilesReq) (*types.CommitFilesResp, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanWrite {
+		return nil, ErrUnauthorized
+	}
+
+	user, err := c.user.FindByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, fmt.Errorf("fail to check user, cause: %w", err)
+	}
+	req.Email = user.Email
+
+	if req.Branch == "" {
+		req.Branch = repo.DefaultBranch
+	}
+	// a commit always fast-forwards the branch, only the push role matters
+	err = c.checkProtectedBranches(ctx, repo, req.CurrentUser, []refChange{{
+		Ref: branchRefPrefix + req.Branch,
+	}}, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = decodeCommitActions(req.Actions)
+	if err != nil {
+		return nil, err
+	}
+	for i := range req.Actions {
+		action := &req.Actions[i]
+		if action.OriginalContent == nil || c.config.GitServer.Type != types.GitServerTypeGitaly {
+			continue
+		}
+		useLfs, fileReq := c.checkIfShouldUseLfs(ctx, &types.CreateFileReq{
+			Namespace:       req.Namespace,
+			Name:            req.Name,
+			RepoType:        req.RepoType,
+			Branch:          req.Branch,
+			FilePath:        action.Path,
+			OriginalContent: action.OriginalContent,
+		})
+		if useLfs {
+			action.Content = fileReq.Content
+			action.Pointer = fileReq.Pointer
+		}
+	}
+
+	// upload lfs objects first, so the pointers never reference a missing object
+	for _, action := range req.Actions {
+		if action.Pointer == nil {
+			continue
+		}
+		err = c.uploadLfsObject(ctx, repo.ID, action.Pointer, action.OriginalContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload lfs object of file %s, error: %w", action.Path, err)
+		}
+	}
+
+	commitID, err := c.git.CommitFiles(ctx, gitserver.CommitFilesReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Branch:    req.Branch,
+		Message:   req.Message,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Actions:   req.Actions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit %s files, error: %w", req.RepoType, err)
+	}
+
+	c.updateCommittedFileTags(ctx, req)
+
+	err = c.repo.SetUpdateTimeByPath(ctx, req.RepoType, req.Namespace, req.Name, time.Now())
+	if err != nil {
+		slog.Error("failed to set repo update time", slog.Any("error", err), slog.String("repo_type", string(req.RepoType)), slog.String("namespace", req.Namespace), slog.String("name", req.Name))
+	}
+
+	return &types.CommitFilesResp{CommitID: commitID}, nil
+}
+
+// decodeCommitActions checks every action is complete and decodes the base64 content into OriginalContent
+func decodeCommitActions(actions []types.CommitAction) error {
+	for i := range actions {
+		action := &actions[i]
+		if action.Action == types.CommitActionMove && action.PreviousPath == "" {
+			return fmt.Errorf("previous path is required to move file %s", action.Path)
+		}
+		if action.Action == types.CommitActionDelete || action.Content == "" {
+			continue
+		}
+		content, err := base64.StdEncoding.DecodeString(action.Content)
+		if err != nil {
+			return fmt.Errorf("content of file %s is not base64 encoded, error: %w", action.Path, err)
+		}
+		action.OriginalContent = content
+	}
+	return nil
+}
+
+func (c *RepoComponent) uploadLfsObject(ctx context.Context, repoID int64, pointer *types.Pointer, content []byte) error {
+	objectKey := filepath.Join("lfs", pointer.RelativePath())
+	uploadInfo, err := c.s3Client.PutObject(ctx, c.config.S3.Bucket, objectKey, bytes.NewReader(content), pointer.Size, minio.PutObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to upload to Minio: %w", err)
+	}
+	if uploadInfo.Size != pointer.Size {
+		return fmt.Errorf("uploaded file size does not match expected size: %d != %d", uploadInfo.Size, pointer.Size)
+	}
+	_, err = c.lfsMetaObjectStore.UpdateOrCreate(ctx, database.LfsMetaObject{
+		Oid:          pointer.Oid,
+		Size:         pointer.Size,
+		RepositoryID: repoID,
+		Existing:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create LFS meta object: %w", err)
+	}
+	return nil
+}
+
+// updateCommittedFileTags keeps the meta and library tags in sync like CreateFile and UpdateFile do
+func (c *RepoComponent) updateCommittedFileTags(ctx context.Context, req *types.CommitFilesReq) {
+	scope := getTagScopeByRepoType(req.RepoType)
+	for _, action := range req.Actions {
+		var err error
+		switch {
+		case action.Action == types.CommitActionDelete:
+			continue
+		case filepath.Base(action.Path) == "README.md":
+			if action.OriginalContent == nil {
+				continue
+			}
+			_, err = c.tc.UpdateMetaTags(ctx, scope, req.Namespace, req.Name, string(action.OriginalContent))
+		case action.Action == types.CommitActionCreate:
+			err = c.tc.UpdateLibraryTags(ctx, scope, req.Namespace, req.Name, "", action.Path)
+		case action.Action == types.CommitActionMove:
+			err = c.tc.UpdateLibraryTags(ctx, scope, req.Namespace, req.Name, action.PreviousPath, action.Path)
+		}
+		if err != nil {
+			slog.Error("failed to update tags of committed file", slog.String("file", action.Path), slog.Any("error", err),
+				slog.String("namespace", req.Namespace), slog.String("name", req.Name))
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package component
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/git/membership"
+	"opencsg.com/csghub-server/builder/rpc"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestDecodeCommitActions(t *testing.T) {
+	actions := []types.CommitAction{
+		{Action: types.CommitActionCreate, Path: "a.txt", Content: base64.StdEncoding.EncodeToString([]byte("hello"))},
+		{Action: types.CommitActionDelete, Path: "b.txt", Content: "not decoded"},
+		{Action: types.CommitActionMove, Path: "d.txt", PreviousPath: "c.txt"},
+	}
+	if err := decodeCommitActions(actions); err != nil {
+		t.Fatal(err)
+	}
+	if string(actions[0].OriginalContent) != "hello" {
+		t.Errorf("expect decoded content hello, got %q", actions[0].OriginalContent)
+	}
+	if actions[1].OriginalContent != nil || actions[2].OriginalContent != nil {
+		t.Error("deletes and moves without content should have no original content")
+	}
+}
+
+func TestDecodeCommitActions_invalid(t *testing.T) {
+	testData := map[string]types.CommitAction{
+		"move without previous path": {Action: types.CommitActionMove, Path: "a.txt"},
+		"content not base64":         {Action: types.CommitActionUpdate, Path: "a.txt", Content: "%%%"},
+	}
+	for name, action := range testData {
+		if err := decodeCommitActions([]types.CommitAction{action}); err == nil {
+			t.Errorf("%s: expect an error", name)
+		}
+	}
+}
+
+// fakeCommitGitServer serves the .gitattributes of the repo and records the committed files
+type fakeCommitGitServer struct {
+	gitserver.GitServer
+	gitattributes string
+	committed     *gitserver.CommitFilesReq
+}
+
+func (g *fakeCommitGitServer) GetRepoFileContents(ctx context.Context, req gitserver.GetRepoInfoByPathReq) (*types.File, error) {
+	if req.Path != GitAttributesFileName || g.gitattributes == "" {
+		return nil, errors.New("file not found")
+	}
+	return &types.File{Content: base64.StdEncoding.EncodeToString([]byte(g.gitattributes))}, nil
+}
+
+func (g *fakeCommitGitServer) CommitFiles(ctx context.Context, req gitserver.CommitFilesReq) (string, error) {
+	g.committed = &req
+	return "0123456789abcdef0123456789abcdef01234567", nil
+}
+
+type fakeMemberRole struct {
+	rpc.UserSvcClient
+	role membership.Role
+}
+
+func (f *fakeMemberRole) GetMemberRole(ctx context.Context, orgName, userName string) (membership.Role, error) {
+	return f.role, nil
+}
+
+var commitFilesDBOnce sync.Once
+
+// newCommitFilesComponent creates the tables CommitFiles uses in an in-memory database, and a repo
+// owned by namespace which username can write to
+func newCommitFilesComponent(t *testing.T, gitServerType, namespace string, nsType database.NamespaceType, username string) (*RepoComponent, *database.Repository) {
+	ctx := context.Background()
+	dbConfig := database.DBConfig{Dialect: database.DialectSQLite, DSN: "file:commit_files?mode=memory&cache=shared"}
+	commitFilesDBOnce.Do(func() {
+		database.InitDB(dbConfig)
+	})
+	db, err := database.NewDB(ctx, dbConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []any{
+		(*database.User)(nil),
+		(*database.Namespace)(nil),
+		(*database.Repository)(nil),
+		(*database.ProtectedBranch)(nil),
+		(*database.LfsMetaObject)(nil),
+	} {
+		if _, err := db.Core.NewCreateTable().Model(model).IfNotExists().Exec(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user := &database.User{Username: username, Email: username + "@example.com"}
+	if _, err := db.Core.NewInsert().Model(user).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ns := &database.Namespace{Path: namespace, UserID: user.ID, NamespaceType: nsType}
+	if _, err := db.Core.NewInsert().Model(ns).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	repo := &database.Repository{
+		UserID:         user.ID,
+		Path:           namespace + "/model",
+		GitPath:        "models_" + namespace + "/model",
+		Name:           "model",
+		RepositoryType: types.ModelRepo,
+		DefaultBranch:  "main",
+	}
+	if _, err := db.Core.NewInsert().Model(repo).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.GitServer.Type = gitServerType
+	cfg.S3.Bucket = "lfs"
+	c := &RepoComponent{
+		config:             cfg,
+		user:               database.NewUserStore(),
+		namespace:          database.NewNamespaceStore(),
+		repo:               database.NewRepoStore(),
+		protectedBranch:    database.NewProtectedBranchStore(),
+		lfsMetaObjectStore: database.NewLfsMetaObjectStore(),
+		userSvcClient:      &fakeMemberRole{role: membership.RoleWrite},
+	}
+	return c, repo
+}
+
+// newFakeS3 stores the objects put to it by path
+func newFakeS3(t *testing.T) (*minio.Client, map[string]string) {
+	objects := make(map[string]string)
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		objects[r.URL.Path] = string(body)
+		mu.Unlock()
+		w.Header().Set("ETag", `"etag"`)
+	}))
+	t.Cleanup(server.Close)
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:        credentials.NewStaticV4("key", "secret", ""),
+		BucketLookup: minio.BucketLookupPath,
+		Region:       "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, objects
+}
+
+func commitFilesReq(namespace, username string) *types.CommitFilesReq {
+	return &types.CommitFilesReq{
+		Namespace:   namespace,
+		Name:        "model",
+		RepoType:    types.ModelRepo,
+		Message:     "upload weights",
+		Username:    username,
+		CurrentUser: username,
+		Actions: []types.CommitAction{
+			{Action: types.CommitActionUpdate, Path: "weights/model.bin", Content: base64.StdEncoding.EncodeToString([]byte("weights"))},
+			{Action: types.CommitActionUpdate, Path: "config.json", Content: base64.StdEncoding.EncodeToString([]byte("{}"))},
+		},
+	}
+}
+
+func TestCommitFiles_lfs(t *testing.T) {
+	c, repo := newCommitFilesComponent(t, types.GitServerTypeGitaly, "lfs-owner", "user", "lfs-owner")
+	git := &fakeCommitGitServer{gitattributes: "*.bin filter=lfs diff=lfs merge=lfs -text\n"}
+	c.git = git
+	var objects map[string]string
+	c.s3Client, objects = newFakeS3(t)
+
+	resp, err := c.CommitFiles(context.Background(), commitFilesReq("lfs-owner", "lfs-owner"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.CommitID == "" || git.committed == nil {
+		t.Fatal("expect the files to be committed")
+	}
+	if git.committed.Branch != "main" {
+		t.Errorf("expect the default branch to be committed to, got %s", git.committed.Branch)
+	}
+
+	oid := fmt.Sprintf("%x", sha256.Sum256([]byte("weights")))
+	actions := git.committed.Actions
+	lfsAction := actions[0]
+	if lfsAction.Pointer == nil || lfsAction.Pointer.Oid != oid {
+		t.Fatalf("expect the file matching .gitattributes to be committed as a pointer to %s, got %+v", oid, lfsAction.Pointer)
+	}
+	pointer, err := base64.StdEncoding.DecodeString(lfsAction.Content)
+	if err != nil {
+		t.Fatalf("expect the pointer content to be base64 encoded, error: %v", err)
+	}
+	expectPointer := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize 7\n", oid)
+	if string(pointer) != expectPointer {
+		t.Errorf("expect pointer %q, got %q", expectPointer, pointer)
+	}
+	if lfsAction.Pointer.Size != 7 {
+		t.Errorf("expect pointer size 7, got %d", lfsAction.Pointer.Size)
+	}
+	// the body is signed in chunks over plain http
+	if !strings.Contains(objects["/lfs/lfs/"+lfsAction.Pointer.RelativePath()], "weights") {
+		t.Errorf("expect the original content to be uploaded before the commit, got %v", objects)
+	}
+	meta, err := c.lfsMetaObjectStore.FindByOID(context.Background(), repo.ID, oid)
+	if err != nil || !meta.Existing || meta.Size != 7 {
+		t.Errorf("expect the lfs object to be recorded, got %+v, error: %v", meta, err)
+	}
+
+	if actions[1].Pointer != nil || actions[1].Content != base64.StdEncoding.EncodeToString([]byte("{}")) {
+		t.Errorf("expect other files to be committed as they are, got %+v", actions[1])
+	}
+}
+
+func TestCommitFiles_noLfsOnGitea(t *testing.T) {
+	c, _ := newCommitFilesComponent(t, types.GitServerTypeGitea, "gitea-owner", "user", "gitea-owner")
+	git := &fakeCommitGitServer{gitattributes: "*.bin filter=lfs diff=lfs merge=lfs -text\n"}
+	c.git = git
+
+	if _, err := c.CommitFiles(context.Background(), commitFilesReq("gitea-owner", "gitea-owner")); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range git.committed.Actions {
+		if action.Pointer != nil {
+			t.Errorf("expect no lfs pointer on gitea, got %+v", action)
+		}
+	}
+}
+
+func TestCommitFiles_protectedBranch(t *testing.T) {
+	c, repo := newCommitFilesComponent(t, types.GitServerTypeGitaly, "protected-org", "organization", "protected-writer")
+	git := &fakeCommitGitServer{}
+	c.git = git
+	_, err := c.protectedBranch.Create(context.Background(), &database.ProtectedBranch{
+		RepositoryID: repo.ID,
+		Pattern:      "main",
+		PushRole:     string(membership.RoleAdmin),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.CommitFiles(context.Background(), commitFilesReq("protected-org", "protected-writer"))
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expect a writer to be forbidden to commit to a branch requiring admin, got %v", err)
+	}
+	if git.committed != nil {
+		t.Error("expect nothing to be committed to the protected branch")
+	}
+
+	req := commitFilesReq("protected-org", "protected-writer")
+	req.Branch = "feature"
+	if _, err := c.CommitFiles(context.Background(), req); err != nil {
+		t.Fatalf("expect a writer to commit to an unprotected branch, got %v", err)
+	}
+	if git.committed == nil || git.committed.Branch != "feature" {
+		t.Errorf("expect the files to be committed to feature, got %+v", git.committed)
+	}
+}
@@ -449,6 +449,7 @@ func (c *DatasetComponent) getRelations(ctx context.Context, repoID int64, curre
 		return nil, err
 	}
 	rels := new(types.Relations)
+	rels.ForkedFrom = c.forkedFrom(ctx, repoID, currentUser)
 	modelRepos := res[types.ModelRepo]
 	for _, repo := range modelRepos {
 		rels.Models = append(rels.Models, &types.Model{
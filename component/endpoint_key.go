@@ -0,0 +1,115 @@
+package component
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// DeployKeyCreate creates an api key of the inference endpoint, the key can not be seen again once returned
+func (c *RepoComponent) DeployKeyCreate(ctx context.Context, actReq types.DeployActReq, req types.CreateEndpointKeyReq) (*types.EndpointKey, error) {
+	user, deploy, err := c.checkDeployPermissionForUser(ctx, actReq)
+	if err != nil {
+		return nil, err
+	}
+	if deploy.Type != types.InferenceType {
+		return nil, errors.New("api keys are only available for inference endpoints")
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate endpoint key, error: %w", err)
+	}
+	value := types.EndpointKeyPrefix + hex.EncodeToString(secret)
+	key := &database.EndpointKey{
+		DeployID:          deploy.ID,
+		UserID:            user.ID,
+		Name:              req.Name,
+		KeyHash:           hashEndpointKey(value),
+		KeyPrefix:         value[:len(types.EndpointKeyPrefix)+6],
+		RequestsPerMinute: req.RequestsPerMinute,
+		TokensPerMinute:   req.TokensPerMinute,
+	}
+	err = c.endpointKey.Create(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	resp := endpointKeyResp(key)
+	resp.Key = value
+	return &resp, nil
+}
+
+func (c *RepoComponent) DeployKeyList(ctx context.Context, actReq types.DeployActReq) ([]types.EndpointKey, error) {
+	_, deploy, err := c.checkDeployPermissionForUser(ctx, actReq)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.endpointKey.ListByDeployID(ctx, deploy.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]types.EndpointKey, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, endpointKeyResp(&keys[i]))
+	}
+	return resp, nil
+}
+
+// DeployKeyRevoke deletes the key, calls with it are rejected right away
+func (c *RepoComponent) DeployKeyRevoke(ctx context.Context, actReq types.DeployActReq, keyID int64) error {
+	_, deploy, err := c.checkDeployPermissionForUser(ctx, actReq)
+	if err != nil {
+		return err
+	}
+	err = c.endpointKey.Delete(ctx, deploy.ID, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke endpoint key %d, error: %w", keyID, err)
+	}
+	return nil
+}
+
+// FindEndpointKey returns the key of the deploy, or nil if the key does not exist, belongs to another deploy
+// or its creator can no longer access the deploy
+func (c *RepoComponent) FindEndpointKey(ctx context.Context, deploy *database.Deploy, value string) (*database.EndpointKey, error) {
+	key, err := c.endpointKey.FindByHash(ctx, hashEndpointKey(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find endpoint key, error: %w", err)
+	}
+	if key == nil || key.DeployID != deploy.ID || key.User == nil {
+		return nil, nil
+	}
+	// the creator may have lost the deploy or the read access to its repo since the key was created
+	if key.UserID != deploy.UserID {
+		return nil, nil
+	}
+	allow, err := c.AllowAccessByRepoID(ctx, deploy.RepoID, key.User.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repo access of endpoint key creator, error: %w", err)
+	}
+	if !allow {
+		return nil, nil
+	}
+	return key, nil
+}
+
+func hashEndpointKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func endpointKeyResp(key *database.EndpointKey) types.EndpointKey {
+	return types.EndpointKey{
+		ID:                key.ID,
+		DeployID:          key.DeployID,
+		Name:              key.Name,
+		KeyPrefix:         key.KeyPrefix,
+		RequestsPerMinute: key.RequestsPerMinute,
+		TokensPerMinute:   key.TokensPerMinute,
+		CreatedAt:         key.CreatedAt,
+	}
+}
@@ -0,0 +1,68 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"opencsg.com/csghub-server/builder/store/cache"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+)
+
+// EndpointLimitComponent enforces the requests and tokens per minute limits of endpoint keys,
+// the limits are counted in redis and not enforced while redis is not reachable
+type EndpointLimitComponent struct {
+	keyCache *cache.EndpointKeyCache
+}
+
+func NewEndpointLimitComponent(config *config.Config) (*EndpointLimitComponent, error) {
+	c := &EndpointLimitComponent{}
+	redis, err := cache.NewCache(context.Background(), cache.RedisConfig{
+		Addr:     config.Redis.Endpoint,
+		Username: config.Redis.User,
+		Password: config.Redis.Password,
+	})
+	if err != nil {
+		// the proxy must serve without redis, the client connects once redis is back
+		slog.Warn("redis is not reachable, limits of endpoint keys are not enforced until it is", slog.Any("error", err))
+	}
+	if redis != nil {
+		c.keyCache = cache.NewEndpointKeyCache(redis)
+	}
+	return c, nil
+}
+
+// Allow counts a request of the key, and rejects it if the key has used up its requests or tokens of the current minute
+func (c *EndpointLimitComponent) Allow(ctx context.Context, key *database.EndpointKey) error {
+	if c.keyCache == nil {
+		return nil
+	}
+	if key.RequestsPerMinute > 0 {
+		requests, err := c.keyCache.IncrRequests(ctx, key.ID)
+		if err != nil {
+			return fmt.Errorf("failed to count requests of endpoint key %d, error: %w", key.ID, err)
+		}
+		if requests > key.RequestsPerMinute {
+			return fmt.Errorf("%w, the key allows %d requests per minute", ErrTooManyRequests, key.RequestsPerMinute)
+		}
+	}
+	if key.TokensPerMinute > 0 {
+		tokens, err := c.keyCache.Tokens(ctx, key.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get tokens of endpoint key %d, error: %w", key.ID, err)
+		}
+		if tokens >= key.TokensPerMinute {
+			return fmt.Errorf("%w, the key allows %d tokens per minute", ErrTooManyRequests, key.TokensPerMinute)
+		}
+	}
+	return nil
+}
+
+// AddTokens counts the tokens used by a request of the key, tokens are counted only if the key limits them
+func (c *EndpointLimitComponent) AddTokens(ctx context.Context, key *database.EndpointKey, tokens int64) error {
+	if key.TokensPerMinute <= 0 || c.keyCache == nil {
+		return nil
+	}
+	return c.keyCache.AddTokens(ctx, key.ID, tokens)
+}
@@ -1,12 +1,21 @@
 package component
 
-import "errors"
+import (
+	"errors"
+
+	"opencsg.com/csghub-server/builder/deploy"
+)
 
 var (
-	ErrUnauthorized     = errors.New("unauthorized")
-	ErrNotFound         = errors.New("not found")
-	ErrForbidden        = errors.New("forbidden")
-	ErrUserNotFound     = errors.New("user not found, please login first")
-	ErrAlreadyExists    = errors.New("the record already exists")
-	ErrPermissionDenied = errors.New("permission denied")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrNotFound            = errors.New("not found")
+	ErrForbidden           = errors.New("forbidden")
+	ErrUserNotFound        = errors.New("user not found, please login first")
+	ErrAlreadyExists       = errors.New("the record already exists")
+	ErrPermissionDenied    = errors.New("permission denied")
+	ErrQuotaExceeded       = errors.New("quota exceeded")
+	ErrTooManyRequests     = errors.New("too many requests")
+	ErrNotSupported        = errors.New("not supported")
+	ErrInsufficientBalance = deploy.ErrInsufficientBalance
+	ErrNoTaskToCancel      = deploy.ErrNoTaskToCancel
 )
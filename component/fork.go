@@ -0,0 +1,230 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// ForkRepo creates a copy of a repo in the target namespace. Git objects are cloned by the git server
+// and lfs objects are shared with the forked repo, only their meta records are copied.
+func (c *RepoComponent) ForkRepo(ctx context.Context, req *types.ForkRepoReq) (_ *database.Repository, err error) {
+	parent, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	allow, err := c.AllowReadAccessRepo(ctx, parent, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repo permission, error: %w", err)
+	}
+	if !allow {
+		return nil, ErrUnauthorized
+	}
+
+	namespace, err := c.namespace.FindByPath(ctx, req.TargetNamespace)
+	if err != nil {
+		return nil, errors.New("namespace does not exist")
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, errors.New("user does not exist")
+	}
+	err = c.checkCreateRepoPermission(ctx, &user, &namespace, req.RepoType)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TargetName == "" {
+		req.TargetName = parent.Name
+	}
+	_, err = c.repo.FindByPath(ctx, req.RepoType, req.TargetNamespace, req.TargetName)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %s %s/%s", ErrAlreadyExists, req.RepoType, req.TargetNamespace, req.TargetName)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check target repo, error: %w", err)
+	}
+	if req.Nickname == "" {
+		req.Nickname = parent.Nickname
+	}
+	if req.Description == "" {
+		req.Description = parent.Description
+	}
+	private := req.Private || parent.Private
+
+	gitRepo, err := c.git.ForkRepo(ctx, gitserver.ForkRepoReq{
+		Namespace:       req.Namespace,
+		Name:            req.Name,
+		RepoType:        req.RepoType,
+		TargetNamespace: req.TargetNamespace,
+		TargetName:      req.TargetName,
+		Username:        user.Username,
+		Nickname:        req.Nickname,
+		Description:     req.Description,
+		License:         parent.License,
+		DefaultBranch:   parent.DefaultBranch,
+		Private:         private,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to fork repo in git, error: %w", err)
+	}
+	var forkID int64
+	defer func() {
+		if err != nil {
+			c.cleanupFork(req, forkID)
+		}
+	}()
+
+	fork, err := c.repo.CreateRepo(ctx, database.Repository{
+		UserID:         user.ID,
+		Path:           path.Join(req.TargetNamespace, req.TargetName),
+		GitPath:        gitRepo.GitPath,
+		Name:           req.TargetName,
+		Nickname:       req.Nickname,
+		Description:    req.Description,
+		Private:        private,
+		License:        parent.License,
+		DefaultBranch:  gitRepo.DefaultBranch,
+		RepositoryType: req.RepoType,
+		HTTPCloneURL:   gitRepo.HttpCloneURL,
+		SSHCloneURL:    gitRepo.SshCloneURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to create database repo, error: %w", err)
+	}
+	forkID = fork.ID
+	fork.User = user
+
+	if err := c.createForkedRepoRecord(ctx, parent, fork); err != nil {
+		return nil, err
+	}
+
+	lfsObjects, err := c.lfsMetaObjectStore.FindByRepoID(ctx, parent.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lfs objects of repo, error: %w", err)
+	}
+	if len(lfsObjects) > 0 {
+		forkLfsObjects := make([]database.LfsMetaObject, 0, len(lfsObjects))
+		for _, obj := range lfsObjects {
+			forkLfsObjects = append(forkLfsObjects, database.LfsMetaObject{
+				Oid:          obj.Oid,
+				Size:         obj.Size,
+				RepositoryID: fork.ID,
+				Existing:     obj.Existing,
+			})
+		}
+		err = c.lfsMetaObjectStore.BulkUpdateOrCreate(ctx, forkLfsObjects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link lfs objects to forked repo, error: %w", err)
+		}
+	}
+
+	err = c.rel.CreateFork(ctx, parent.ID, fork.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fork relation, error: %w", err)
+	}
+	return fork, nil
+}
+
+// cleanupFork removes the git repo and database records of a fork that failed halfway, forkID is zero if the
+// database repo was not created
+func (c *RepoComponent) cleanupFork(req *types.ForkRepoReq, forkID int64) {
+	// the request context may be cancelled already
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if forkID != 0 {
+		if err := c.repo.DeleteForkedRepo(ctx, forkID); err != nil {
+			slog.Error("failed to delete database records of failed fork", slog.Int64("repo_id", forkID), slog.Any("error", err))
+		}
+	}
+	err := c.git.DeleteRepo(ctx, gitserver.DeleteRepoReq{
+		Namespace: req.TargetNamespace,
+		Name:      req.TargetName,
+		RepoType:  req.RepoType,
+	})
+	if err != nil {
+		slog.Error("failed to delete git repo of failed fork", slog.String("repo_type", string(req.RepoType)),
+			slog.String("namespace", req.TargetNamespace), slog.String("name", req.TargetName), slog.Any("error", err))
+	}
+}
+
+// createForkedRepoRecord creates the model, dataset, code or space of a forked repo
+func (c *RepoComponent) createForkedRepoRecord(ctx context.Context, parent, fork *database.Repository) error {
+	var err error
+	switch fork.RepositoryType {
+	case types.ModelRepo:
+		var model *database.Model
+		model, err = database.NewModelStore().ByRepoID(ctx, parent.ID)
+		if err != nil {
+			return fmt.Errorf("failed to find model of repo, error: %w", err)
+		}
+		_, err = database.NewModelStore().Create(ctx, database.Model{
+			RepositoryID:  fork.ID,
+			LastUpdatedAt: time.Now(),
+			BaseModel:     model.BaseModel,
+		})
+	case types.DatasetRepo:
+		_, err = database.NewDatasetStore().Create(ctx, database.Dataset{
+			RepositoryID:  fork.ID,
+			LastUpdatedAt: time.Now(),
+		})
+	case types.CodeRepo:
+		_, err = database.NewCodeStore().Create(ctx, database.Code{
+			RepositoryID:  fork.ID,
+			LastUpdatedAt: time.Now(),
+		})
+	case types.SpaceRepo:
+		var space *database.Space
+		space, err = database.NewSpaceStore().ByRepoID(ctx, parent.ID)
+		if err != nil {
+			return err
+		}
+		// secrets are not copied to the fork
+		_, err = database.NewSpaceStore().Create(ctx, database.Space{
+			RepositoryID:  fork.ID,
+			Sdk:           space.Sdk,
+			SdkVersion:    space.SdkVersion,
+			Template:      space.Template,
+			CoverImageUrl: space.CoverImageUrl,
+			Env:           space.Env,
+			Hardware:      space.Hardware,
+			HasAppFile:    space.HasAppFile,
+			SKU:           space.SKU,
+		})
+	default:
+		return fmt.Errorf("repo type %s can not be forked", fork.RepositoryType)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s of forked repo, error: %w", fork.RepositoryType, err)
+	}
+	return nil
+}
+
+// forkedFrom returns the path of the repo the given repo was forked from if the user can see it
+func (c *RepoComponent) forkedFrom(ctx context.Context, repoID int64, currentUser string) string {
+	rel, err := c.rel.ForkParent(ctx, repoID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to get fork parent of repo", slog.Int64("repo_id", repoID), slog.Any("error", err))
+		}
+		return ""
+	}
+	parent, err := c.repo.FindById(ctx, rel.ToRepoID)
+	if err != nil {
+		slog.Error("failed to find fork parent repo", slog.Int64("repo_id", rel.ToRepoID), slog.Any("error", err))
+		return ""
+	}
+	allow, _ := c.AllowReadAccessRepo(ctx, parent, currentUser)
+	if !allow {
+		return ""
+	}
+	return parent.Path
+}
@@ -1,6 +1,8 @@
 package component
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/sha256"
 	"database/sql"
@@ -14,6 +16,7 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -126,7 +129,7 @@ func (c *GitHTTPComponent) GitUploadPack(ctx context.Context, req types.GitUploa
 }
 
 func (c *GitHTTPComponent) GitReceivePack(ctx context.Context, req types.GitReceivePackReq) error {
-	_, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
 	if err != nil {
 		return fmt.Errorf("failed to find repo, error: %w", err)
 	}
@@ -141,8 +144,28 @@ func (c *GitHTTPComponent) GitReceivePack(ctx context.Context, req types.GitRece
 		return ErrUnauthorized
 	}
 	if !allowed {
-		return ErrForbidden
+		return fmt.Errorf("you do not have permission to access this repository: %w", ErrForbidden)
+	}
+
+	req.Request.Body, err = receivePackBody(req.Request)
+	if err != nil {
+		return err
+	}
+	// reject pushes to protected branches before the pack is streamed to the git server,
+	// force pushes are checked later by the pre receive hook once the objects are received
+	changes, consumed, err := readReceivePackCommands(req.Request.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read receive pack commands, error: %w", err)
 	}
+	req.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(consumed), req.Request.Body), req.Request.Body}
+	err = c.checkProtectedBranches(ctx, repo, req.CurrentUser, changes, nil, false)
+	if err != nil {
+		return err
+	}
+
 	err = c.git.ReceivePack(ctx, gitserver.ReceivePackReq{
 		Namespace:   req.Namespace,
 		Name:        req.Name,
@@ -720,3 +743,64 @@ func buildLFSLockList(lfsLocks []database.LfsLock) *types.LFSLockList {
 	}
 }
 
+// receivePackBody returns the decompressed body of a receive-pack request, git compresses large requests with gzip.
+// The git server gets the decompressed body as well
+func receivePackBody(r *http.Request) (io.ReadCloser, error) {
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		return r.Body, nil
+	}
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzip receive pack request, error: %w", err)
+	}
+	r.Header.Del("Content-Encoding")
+	return struct {
+		io.Reader
+		io.Closer
+	}{zr, r.Body}, nil
+}
+
+// readReceivePackCommands reads the ref update commands at the beginning of a receive-pack request,
+// it returns the bytes consumed from r so the request can be replayed to the git server.
+func readReceivePackCommands(r io.Reader) ([]refChange, []byte, error) {
+	var (
+		changes  []refChange
+		consumed bytes.Buffer
+		lenBuf   = make([]byte, 4)
+	)
+	tr := io.TeeReader(r, &consumed)
+	for {
+		if _, err := io.ReadFull(tr, lenBuf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return changes, consumed.Bytes(), nil
+			}
+			return nil, consumed.Bytes(), err
+		}
+		pktLen, err := strconv.ParseUint(string(lenBuf), 16, 16)
+		if err != nil {
+			return nil, consumed.Bytes(), fmt.Errorf("invalid pkt-line length %q", lenBuf)
+		}
+		// flush packet ends the command list
+		if pktLen == 0 {
+			return changes, consumed.Bytes(), nil
+		}
+		if pktLen < 4 {
+			return nil, consumed.Bytes(), fmt.Errorf("invalid pkt-line length %q", lenBuf)
+		}
+		line := make([]byte, pktLen-4)
+		if _, err := io.ReadFull(tr, line); err != nil {
+			return nil, consumed.Bytes(), err
+		}
+		// strip capabilities sent with the first command
+		cmd, _, _ := strings.Cut(string(line), "\x00")
+		cmd = strings.TrimSuffix(cmd, "\n")
+		if strings.HasPrefix(cmd, "shallow ") {
+			continue
+		}
+		// signed pushes carry the commands in the certificate, leave them to the pre receive hook
+		if strings.HasPrefix(cmd, "push-cert") {
+			return nil, consumed.Bytes(), nil
+		}
+		changes = append(changes, parseRefChanges(cmd)...)
+	}
+}
@@ -0,0 +1,62 @@
+package component
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+func TestReadReceivePackCommands_gzip(t *testing.T) {
+	oldID := "1111111111111111111111111111111111111111"
+	newID := "2222222222222222222222222222222222222222"
+	body := pktLine(oldID+" "+newID+" refs/heads/main\x00report-status\n") + "0000PACK"
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(body))
+	zw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/git-receive-pack", &compressed)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	decoded, err := receivePackBody(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("Content-Encoding") != "" {
+		t.Error("expect the git server to get a decompressed body")
+	}
+	changes, consumed, err := readReceivePackCommands(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("expect the ref command of a gzip body to be read, got %+v", changes)
+	}
+	rest, _ := io.ReadAll(decoded)
+	if string(consumed)+string(rest) != body {
+		t.Errorf("expect the decompressed body to be replayed, got %q", string(consumed)+string(rest))
+	}
+
+	plain := httptest.NewRequest(http.MethodPost, "/git-receive-pack", bytes.NewBufferString(body))
+	decoded, err = receivePackBody(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes, _, _ := readReceivePackCommands(decoded); len(changes) != 1 {
+		t.Errorf("expect the ref command of a plain body to be read, got %+v", changes)
+	}
+
+	broken := httptest.NewRequest(http.MethodPost, "/git-receive-pack", bytes.NewBufferString(body))
+	broken.Header.Set("Content-Encoding", "gzip")
+	if _, err := receivePackBody(broken); err == nil {
+		t.Error("expect an error for a body which is not gzip")
+	}
+}
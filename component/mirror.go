@@ -2,12 +2,16 @@ package component
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"opencsg.com/csghub-server/builder/git"
@@ -18,6 +22,7 @@ import (
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/mirror/credential"
 	"opencsg.com/csghub-server/mirror/queue"
 )
 
@@ -37,7 +42,9 @@ type MirrorComponent struct {
 	mirrorSourceStore  *database.MirrorSourceStore
 	namespaceStore     *database.NamespaceStore
 	lfsMetaObjectStore *database.LfsMetaObjectStore
+	lfsSyncProgress    *database.LfsSyncProgressStore
 	userStore          *database.UserStore
+	credentials        *credential.Resolver
 	config             *config.Config
 	mq                 *queue.PriorityQueue
 }
@@ -81,7 +88,9 @@ func NewMirrorComponent(config *config.Config) (*MirrorComponent, error) {
 	c.mirrorSourceStore = database.NewMirrorSourceStore()
 	c.namespaceStore = database.NewNamespaceStore()
 	c.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
+	c.lfsSyncProgress = database.NewLfsSyncProgressStore()
 	c.userStore = database.NewUserStore()
+	c.credentials = credential.NewResolver(config)
 	c.saas = config.Saas
 	c.config = config
 	return c, nil
@@ -286,6 +295,108 @@ func (m *MirrorComponent) mapNamespaceAndName(sourceNamespace string) string {
 	return namespace
 }
 
+// listUpstreamRefs lists the branches and tags of the upstream with the credential of the mirror
+func (c *MirrorComponent) listUpstreamRefs(ctx context.Context, mirror *database.Mirror) (map[string]string, error) {
+	username, accessToken, err := c.credentials.Resolve(ctx, mirror, mirror.SourceUrl)
+	if err != nil {
+		return nil, err
+	}
+	return git.LsRemote(ctx, mirror.SourceUrl, username, accessToken)
+}
+
+// RefreshMirrors enqueues the scheduled mirrors due to refresh, mirrors synced successfully last time are only enqueued if
+// the branches or tags of the upstream changed. Dead-lettered mirrors are not enqueued until an admin retries or drops
+// their dead letter
+func (c *MirrorComponent) RefreshMirrors(ctx context.Context) error {
+	mirrors, err := c.mirrorStore.ToRefresh(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get mirrors to refresh: %w", err)
+	}
+	now := time.Now()
+	for _, mirror := range mirrors {
+		next, err := nextMirrorRefresh(mirror.Interval, now)
+		if err != nil {
+			// clear an interval saved before schedules were validated, so the mirror is not selected again on every run
+			slog.Error("clear invalid mirror interval", slog.Int64("mirrorId", mirror.ID), slog.String("interval", mirror.Interval), slog.String("error", err.Error()))
+			mirror.Interval = ""
+			mirror.NextExecutionTimestamp = time.Time{}
+			if err := c.mirrorStore.Update(ctx, &mirror); err != nil {
+				slog.Error("fail to update mirror", slog.Int64("mirrorId", mirror.ID), slog.String("error", err.Error()))
+			}
+			continue
+		}
+		deadLettered, err := c.mq.RepoMirrorQueue.IsDeadLettered(ctx, mirror.ID)
+		if err != nil {
+			// the next refresh time is kept, so the mirror is checked again in the next run
+			slog.Error("failed to check dead letter of mirror", slog.Int64("mirrorId", mirror.ID), slog.String("error", err.Error()))
+			continue
+		}
+
+		changed := !deadLettered
+		if changed && mirror.Status == types.MirrorFinished {
+			refs, err := c.listUpstreamRefs(ctx, &mirror)
+			if err != nil {
+				// the next refresh time is kept, so the upstream is checked again in the next run
+				slog.Error("failed to list upstream refs of mirror", slog.Int64("mirrorId", mirror.ID), slog.String("error", err.Error()))
+				continue
+			}
+			hash := hashRefs(refs)
+			changed = hash != mirror.RemoteRefsHash
+			mirror.RemoteRefsHash = hash
+		}
+		mirror.NextExecutionTimestamp = next
+		if changed {
+			mirror.Status = types.MirrorWaiting
+		}
+		err = c.mirrorStore.Update(ctx, &mirror)
+		if err != nil {
+			slog.Error("fail to update mirror", slog.Int64("mirrorId", mirror.ID), slog.String("error", err.Error()))
+			continue
+		}
+		if deadLettered {
+			slog.Info("skip refreshing dead-lettered mirror", slog.Int64("mirrorId", mirror.ID), slog.Time("next", next))
+			continue
+		}
+		if !changed {
+			slog.Info("skip refreshing unchanged mirror", slog.Int64("mirrorId", mirror.ID), slog.Time("next", next))
+			continue
+		}
+		c.mq.PushRepoMirror(&queue.MirrorTask{
+			MirrorID:  mirror.ID,
+			Priority:  queue.Priority(mirror.Priority),
+			CreatedAt: mirror.CreatedAt.Unix(),
+		})
+		slog.Info("mirror enqueued to refresh", slog.Int64("mirrorId", mirror.ID), slog.Time("next", next))
+	}
+	return nil
+}
+
+// nextMirrorRefresh returns the next refresh time of the mirror interval, which is a duration or a cron expression,
+// mirrors without an interval are not refreshed
+func nextMirrorRefresh(interval string, from time.Time) (time.Time, error) {
+	if interval == "" {
+		return time.Time{}, nil
+	}
+	schedule, err := common.ParseSchedule(interval)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid mirror interval, error: %w", err)
+	}
+	return schedule.Next(from), nil
+}
+
+func hashRefs(refs map[string]string) string {
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s %s\n", refs[name], name)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (c *MirrorComponent) CheckMirrorProgress(ctx context.Context) error {
 	mirrors, err := c.mirrorStore.Unfinished(ctx)
 	if err != nil {
@@ -541,10 +652,12 @@ func (c *MirrorComponent) getMirrorStatusAndProgressSaas(ctx context.Context, mi
 	}
 }
 
+// countMirrorProgress counts the share of bytes of the LFS files uploaded, including the uploaded parts of the files being synced
 func (c *MirrorComponent) countMirrorProgress(ctx context.Context, mirror database.Mirror) (int8, error) {
 	var (
-		lfsFiles          []*types.File
-		finishedFileCount int
+		lfsFiles      []*types.File
+		totalBytes    int64
+		finishedBytes int64
 	)
 	namespaceAndName := strings.Split(mirror.Repository.Path, "/")
 	namespace := namespaceAndName[0]
@@ -561,11 +674,21 @@ func (c *MirrorComponent) countMirrorProgress(ctx context.Context, mirror databa
 	for _, f := range allFiles {
 		if f.Lfs {
 			lfsFiles = append(lfsFiles, f)
+			totalBytes += f.Size
 		}
 	}
-	if len(lfsFiles) == 0 {
+	if len(lfsFiles) == 0 || totalBytes == 0 {
 		return 100, nil
 	}
+	syncProgresses, err := c.lfsSyncProgress.ListByMirrorID(ctx, mirror.ID)
+	if err != nil {
+		slog.Error("fail to get lfs sync progresses", slog.Int64("mirrorId", mirror.ID), slog.String("error", err.Error()))
+		return 0, err
+	}
+	uploadedBytes := make(map[string]int64, len(syncProgresses))
+	for _, p := range syncProgresses {
+		uploadedBytes[p.Oid] = p.UploadedBytes
+	}
 	for _, f := range lfsFiles {
 		objectKey := f.LfsRelativePath
 		objectKey = path.Join("lfs", objectKey)
@@ -575,12 +698,13 @@ func (c *MirrorComponent) countMirrorProgress(ctx context.Context, mirror databa
 				slog.Error("fail to check lfs file", slog.Int64("mirrorId", mirror.ID), slog.String("namespace", namespace), slog.String("name", name), slog.String("filename", f.Path), slog.String("error", err.Error()))
 				return 0, err
 			}
+			finishedBytes += uploadedBytes[path.Base(f.LfsRelativePath)]
 		} else {
-			finishedFileCount += 1
+			finishedBytes += f.Size
 		}
 	}
 
-	progress := (finishedFileCount * 100) / len(lfsFiles)
+	progress := (finishedBytes * 100) / totalBytes
 	return int8(progress), nil
 }
 
@@ -642,3 +766,146 @@ func (c *MirrorComponent) Index(ctx context.Context, currentUser string, per, pa
 	}
 	return mirrorsResp, total, nil
 }
+
+func (c *MirrorComponent) checkAdmin(ctx context.Context, currentUser string) error {
+	user, err := c.userStore.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return fmt.Errorf("user does not exist: %w", ErrUnauthorized)
+	}
+	if !user.CanAdmin() {
+		return fmt.Errorf("user does not have admin permission: %w", ErrUnauthorized)
+	}
+	return nil
+}
+
+// DeadLetters lists the mirror tasks which failed more than their retries, latest failed first
+func (c *MirrorComponent) DeadLetters(ctx context.Context, currentUser string) ([]types.MirrorDeadLetter, error) {
+	if err := c.checkAdmin(ctx, currentUser); err != nil {
+		return nil, err
+	}
+	var deadLetters []types.MirrorDeadLetter
+	for _, taskType := range []types.MirrorTaskType{types.RepoMirrorTask, types.LfsMirrorTask} {
+		mq, err := c.mq.TaskQueue(taskType)
+		if err != nil {
+			return nil, err
+		}
+		tasks, err := mq.DeadLetters(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead-lettered %s mirror tasks: %w", taskType, err)
+		}
+		for _, task := range tasks {
+			deadLetter := types.MirrorDeadLetter{
+				MirrorID:  task.MirrorID,
+				Type:      taskType,
+				Priority:  task.Priority.Int(),
+				Retries:   task.Retries,
+				LastError: task.LastError,
+				FailedAt:  time.Unix(task.FailedAt, 0),
+			}
+			mirror, err := c.mirrorStore.FindByID(ctx, task.MirrorID)
+			if err != nil {
+				// the mirror may be deleted, the task can only be dropped then
+				slog.Warn("fail to get mirror of dead-lettered task", slog.Int64("mirrorId", task.MirrorID), slog.String("error", err.Error()))
+			} else if mirror.Repository != nil {
+				deadLetter.RepoPath = mirror.Repository.Path
+				deadLetter.RepoType = mirror.Repository.RepositoryType
+			}
+			deadLetters = append(deadLetters, deadLetter)
+		}
+	}
+	sort.SliceStable(deadLetters, func(i, j int) bool {
+		return deadLetters[i].FailedAt.After(deadLetters[j].FailedAt)
+	})
+	return deadLetters, nil
+}
+
+// RetryDeadLetter pushes the dead-lettered task of the mirror back to its queue with a new retry budget
+func (c *MirrorComponent) RetryDeadLetter(ctx context.Context, currentUser string, taskType types.MirrorTaskType, mirrorID int64) error {
+	if err := c.checkAdmin(ctx, currentUser); err != nil {
+		return err
+	}
+	mq, err := c.mq.TaskQueue(taskType)
+	if err != nil {
+		return err
+	}
+	mirror, err := c.mirrorStore.FindByID(ctx, mirrorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("mirror %d does not exist, drop the task instead: %w", mirrorID, ErrNotFound)
+		}
+		return fmt.Errorf("failed to get mirror %d: %w", mirrorID, err)
+	}
+	_, err = mq.RetryDeadLetter(ctx, mirrorID)
+	if errors.Is(err, queue.ErrTaskNotFound) {
+		return fmt.Errorf("no dead-lettered %s task of mirror %d: %w", taskType, mirrorID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retry dead-lettered %s task of mirror %d: %w", taskType, mirrorID, err)
+	}
+	mirror.Status = types.MirrorWaiting
+	err = c.mirrorStore.Update(ctx, mirror)
+	if err != nil {
+		return fmt.Errorf("failed to update mirror status: %w", err)
+	}
+	return nil
+}
+
+// DropDeadLetter removes the dead-lettered task of the mirror, the mirror stays failed
+func (c *MirrorComponent) DropDeadLetter(ctx context.Context, currentUser string, taskType types.MirrorTaskType, mirrorID int64) error {
+	if err := c.checkAdmin(ctx, currentUser); err != nil {
+		return err
+	}
+	mq, err := c.mq.TaskQueue(taskType)
+	if err != nil {
+		return err
+	}
+	err = mq.DropDeadLetter(ctx, mirrorID)
+	if errors.Is(err, queue.ErrTaskNotFound) {
+		return fmt.Errorf("no dead-lettered %s task of mirror %d: %w", taskType, mirrorID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to drop dead-lettered %s task of mirror %d: %w", taskType, mirrorID, err)
+	}
+	return nil
+}
+
+// QueueStats returns the number of tasks in each band of the repo and LFS mirror queues
+func (c *MirrorComponent) QueueStats(ctx context.Context, currentUser string) ([]types.MirrorQueueStats, error) {
+	if err := c.checkAdmin(ctx, currentUser); err != nil {
+		return nil, err
+	}
+	var stats []types.MirrorQueueStats
+	for _, taskType := range []types.MirrorTaskType{types.RepoMirrorTask, types.LfsMirrorTask} {
+		mq, err := c.mq.TaskQueue(taskType)
+		if err != nil {
+			return nil, err
+		}
+		s, err := mq.Stats(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats of %s mirror queue: %w", taskType, err)
+		}
+		s.Type = taskType
+		stats = append(stats, *s)
+	}
+	return stats, nil
+}
+
+// QueuePosition returns the position of the queued task of the mirror in the pop order of the queue
+func (c *MirrorComponent) QueuePosition(ctx context.Context, currentUser string, taskType types.MirrorTaskType, mirrorID int64) (*types.MirrorQueuePosition, error) {
+	if err := c.checkAdmin(ctx, currentUser); err != nil {
+		return nil, err
+	}
+	mq, err := c.mq.TaskQueue(taskType)
+	if err != nil {
+		return nil, err
+	}
+	position, err := mq.Position(ctx, mirrorID)
+	if errors.Is(err, queue.ErrTaskNotFound) {
+		return nil, fmt.Errorf("no queued %s task of mirror %d: %w", taskType, mirrorID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position of %s task of mirror %d: %w", taskType, mirrorID, err)
+	}
+	position.Type = taskType
+	return position, nil
+}
@@ -2,23 +2,30 @@ package component
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/mirror/credential"
 )
 
 type MirrorSourceComponent struct {
-	msStore   *database.MirrorSourceStore
-	userStore *database.UserStore
+	msStore         *database.MirrorSourceStore
+	credentialStore *database.MirrorCredentialStore
+	userStore       *database.UserStore
+	config          *config.Config
 }
 
 func NewMirrorSourceComponent(config *config.Config) (*MirrorSourceComponent, error) {
 	return &MirrorSourceComponent{
-		msStore:   database.NewMirrorSourceStore(),
-		userStore: database.NewUserStore(),
+		msStore:         database.NewMirrorSourceStore(),
+		credentialStore: database.NewMirrorCredentialStore(),
+		userStore:       database.NewUserStore(),
+		config:          config,
 	}, nil
 }
 
@@ -106,3 +113,145 @@ func (c *MirrorSourceComponent) Delete(ctx context.Context, id int64, currentUse
 	}
 	return nil
 }
+
+func (c *MirrorSourceComponent) checkAdmin(ctx context.Context, currentUser string) error {
+	user, err := c.userStore.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return fmt.Errorf("user does not exist: %w", ErrUnauthorized)
+	}
+	if !user.CanAdmin() {
+		return fmt.Errorf("user does not have admin permission: %w", ErrUnauthorized)
+	}
+	return nil
+}
+
+func (c *MirrorSourceComponent) findSource(ctx context.Context, id int64) (*database.MirrorSource, error) {
+	ms, err := c.msStore.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("mirror source %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find mirror source, error: %w", err)
+	}
+	return ms, nil
+}
+
+// validateCredential checks the fields of a credential profile before its secret is encrypted
+func (c *MirrorSourceComponent) validateCredential(credentialType types.MirrorCredentialType, username, allowedURLPrefix string) error {
+	switch credentialType {
+	case types.HFTokenCredential, types.GitHubPATCredential:
+	case types.BasicAuthCredential:
+		if username == "" {
+			return errors.New("username is required for basic auth credential")
+		}
+	default:
+		return fmt.Errorf("invalid credential type %s", credentialType)
+	}
+	if err := credential.ValidateURLPrefix(allowedURLPrefix); err != nil {
+		return err
+	}
+	if c.config.Secret.EncryptionKey == "" {
+		return errors.New("mirror credentials are not enabled as the encryption key is not configured")
+	}
+	return nil
+}
+
+// CreateCredential creates a credential profile of the mirror source, the secret is stored encrypted
+func (c *MirrorSourceComponent) CreateCredential(ctx context.Context, req types.CreateMirrorCredentialReq) (*database.MirrorCredential, error) {
+	if err := c.checkAdmin(ctx, req.CurrentUser); err != nil {
+		return nil, err
+	}
+	if err := c.validateCredential(req.Type, req.Username, req.AllowedURLPrefix); err != nil {
+		return nil, err
+	}
+	if _, err := c.findSource(ctx, req.MirrorSourceID); err != nil {
+		return nil, err
+	}
+	secret, err := common.EncryptString(c.config.Secret.EncryptionKey, req.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt mirror credential, error: %w", err)
+	}
+	mc := &database.MirrorCredential{
+		MirrorSourceID:   req.MirrorSourceID,
+		Name:             req.Name,
+		Type:             req.Type,
+		Username:         req.Username,
+		Secret:           secret,
+		IsDefault:        req.IsDefault,
+		AllowedURLPrefix: req.AllowedURLPrefix,
+		AllowedUsers:     req.AllowedUsers,
+	}
+	err = c.credentialStore.Create(ctx, mc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mirror credential, error: %w", err)
+	}
+	return mc, nil
+}
+
+// ListCredentials lists the credential profiles of the mirror source without their secrets
+func (c *MirrorSourceComponent) ListCredentials(ctx context.Context, mirrorSourceID int64, currentUser string) ([]database.MirrorCredential, error) {
+	if err := c.checkAdmin(ctx, currentUser); err != nil {
+		return nil, err
+	}
+	if _, err := c.findSource(ctx, mirrorSourceID); err != nil {
+		return nil, err
+	}
+	credentials, err := c.credentialStore.ListBySourceID(ctx, mirrorSourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mirror credentials, error: %w", err)
+	}
+	return credentials, nil
+}
+
+// UpdateCredential updates the credential profile, the secret is kept if the request has none
+func (c *MirrorSourceComponent) UpdateCredential(ctx context.Context, req types.UpdateMirrorCredentialReq) (*database.MirrorCredential, error) {
+	if err := c.checkAdmin(ctx, req.CurrentUser); err != nil {
+		return nil, err
+	}
+	if err := c.validateCredential(req.Type, req.Username, req.AllowedURLPrefix); err != nil {
+		return nil, err
+	}
+	mc, err := c.credentialStore.FindByID(ctx, req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find mirror credential, error: %w", err)
+	}
+	if mc == nil || mc.MirrorSourceID != req.MirrorSourceID {
+		return nil, fmt.Errorf("mirror credential %d: %w", req.ID, ErrNotFound)
+	}
+	if req.Secret != "" {
+		mc.Secret, err = common.EncryptString(c.config.Secret.EncryptionKey, req.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt mirror credential, error: %w", err)
+		}
+	}
+	mc.Name = req.Name
+	mc.Type = req.Type
+	mc.Username = req.Username
+	mc.IsDefault = req.IsDefault
+	mc.AllowedURLPrefix = req.AllowedURLPrefix
+	mc.AllowedUsers = req.AllowedUsers
+	err = c.credentialStore.Update(ctx, mc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update mirror credential, error: %w", err)
+	}
+	return mc, nil
+}
+
+// DeleteCredential deletes the credential profile, the mirrors using it fall back to the default profile of the source
+func (c *MirrorSourceComponent) DeleteCredential(ctx context.Context, mirrorSourceID, id int64, currentUser string) error {
+	if err := c.checkAdmin(ctx, currentUser); err != nil {
+		return err
+	}
+	mc, err := c.credentialStore.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find mirror credential, error: %w", err)
+	}
+	if mc == nil || mc.MirrorSourceID != mirrorSourceID {
+		return fmt.Errorf("mirror credential %d: %w", id, ErrNotFound)
+	}
+	err = c.credentialStore.Delete(ctx, mirrorSourceID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete mirror credential, error: %w", err)
+	}
+	return nil
+}
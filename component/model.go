@@ -574,6 +574,7 @@ func (c *ModelComponent) getRelations(ctx context.Context, fromRepoID int64, cur
 		return nil, err
 	}
 	rels := new(types.Relations)
+	rels.ForkedFrom = c.forkedFrom(ctx, fromRepoID, currentUser)
 	datasetRepos := res[types.DatasetRepo]
 	for _, repo := range datasetRepos {
 		rels.Datasets = append(rels.Datasets, &types.Dataset{
@@ -674,6 +675,16 @@ func (c *ModelComponent) Deploy(ctx context.Context, deployReq types.DeployActRe
 		}
 	}
 
+	if req.Priority != 0 && !c.isAdminRole(user) {
+		return -1, fmt.Errorf("need admin permission to set deploy priority")
+	}
+
+	if req.Autoscaling != nil {
+		if err = req.Autoscaling.Validate(req.MinReplica); err != nil {
+			return -1, fmt.Errorf("invalid autoscaling policy, %w", err)
+		}
+	}
+
 	frame, err := c.rtfm.FindEnabledByID(ctx, req.RuntimeFrameworkID)
 	if err != nil {
 		return -1, fmt.Errorf("cannot find available runtime framework, %w", err)
@@ -712,29 +723,45 @@ func (c *ModelComponent) Deploy(ctx context.Context, deployReq types.DeployActRe
 	}
 
 	// create deploy for model
-	return c.deployer.Deploy(ctx, types.DeployRepo{
-		DeployName:       req.DeployName,
-		SpaceID:          0,
-		Path:             m.Repository.Path,
-		GitPath:          m.Repository.GitPath,
-		GitBranch:        req.Revision,
-		Env:              req.Env,
-		Hardware:         resource.Resources,
-		UserID:           user.ID,
-		ModelID:          m.ID,
-		RepoID:           m.Repository.ID,
-		RuntimeFramework: frame.FrameName,
-		ContainerPort:    frame.ContainerPort, // default container port
-		ImageID:          containerImg,        // do not need build pod image for model
-		MinReplica:       req.MinReplica,
-		MaxReplica:       req.MaxReplica,
-		Annotation:       string(annoStr),
-		ClusterID:        req.ClusterID,
-		SecureLevel:      req.SecureLevel,
-		Type:             deployReq.DeployType,
-		UserUUID:         user.UUID,
-		SKU:              strconv.FormatInt(resource.ID, 10),
+	var deployID int64
+	err = c.quota.DeployWithinQuota(ctx, deployQuotaReq{
+		User:       &user,
+		Namespace:  deployReq.Namespace,
+		DeployType: deployReq.DeployType,
+		Hardware:   resource.Resources,
+		Replicas:   req.MaxReplica,
+	}, func() error {
+		deployID, err = c.deployer.Deploy(ctx, types.DeployRepo{
+			DeployName:       req.DeployName,
+			SpaceID:          0,
+			Path:             m.Repository.Path,
+			GitPath:          m.Repository.GitPath,
+			GitBranch:        req.Revision,
+			Env:              req.Env,
+			Hardware:         resource.Resources,
+			UserID:           user.ID,
+			ModelID:          m.ID,
+			RepoID:           m.Repository.ID,
+			RuntimeFramework: frame.FrameName,
+			ContainerPort:    frame.ContainerPort, // default container port
+			ImageID:          containerImg,        // do not need build pod image for model
+			MinReplica:       req.MinReplica,
+			MaxReplica:       req.MaxReplica,
+			Annotation:       string(annoStr),
+			ClusterID:        req.ClusterID,
+			SecureLevel:      req.SecureLevel,
+			Type:             deployReq.DeployType,
+			UserUUID:         user.UUID,
+			SKU:              strconv.FormatInt(resource.ID, 10),
+			Autoscaling:      req.Autoscaling,
+			Priority:         req.Priority,
+		})
+		return err
 	})
+	if err != nil {
+		return -1, err
+	}
+	return deployID, nil
 }
 
 func (c *ModelComponent) ListModelsByRuntimeFrameworkID(ctx context.Context, currentUser string, per, page int, id int64, deployType int) ([]types.Model, int, error) {
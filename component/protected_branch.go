@@ -0,0 +1,327 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/git/membership"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const (
+	zeroCommitID    = "0000000000000000000000000000000000000000"
+	branchRefPrefix = "refs/heads/"
+)
+
+// refChange is a single ref update of a git push, as "<old> <new> <ref>"
+type refChange struct {
+	OldRev string
+	NewRev string
+	Ref    string
+}
+
+func parseRefChanges(changes string) []refChange {
+	var refChanges []refChange
+	for _, line := range strings.Split(changes, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		refChanges = append(refChanges, refChange{
+			OldRev: fields[0],
+			NewRev: fields[1],
+			Ref:    fields[2],
+		})
+	}
+	return refChanges
+}
+
+func matchProtectedBranch(rules []database.ProtectedBranch, branch string) *database.ProtectedBranch {
+	for i, rule := range rules {
+		if rule.Pattern == branch {
+			return &rules[i]
+		}
+		if matched, _ := path.Match(rule.Pattern, branch); matched {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func hasRole(permission *types.UserRepoPermission, role membership.Role) bool {
+	switch role {
+	case membership.RoleAdmin:
+		return permission.CanAdmin
+	case membership.RoleRead:
+		return permission.CanRead
+	default:
+		return permission.CanWrite
+	}
+}
+
+func (c *RepoComponent) ListProtectedBranches(ctx context.Context, req types.ProtectedBranchActReq) ([]database.ProtectedBranch, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, ErrUnauthorized
+	}
+	return c.protectedBranch.FindByRepoID(ctx, repo.ID)
+}
+
+func (c *RepoComponent) CreateProtectedBranch(ctx context.Context, req *types.CreateProtectedBranchReq) (*database.ProtectedBranch, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanAdmin {
+		return nil, ErrUnauthorized
+	}
+	if _, err := path.Match(req.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid branch pattern %s, error: %w", req.Pattern, err)
+	}
+	if req.PushRole == "" {
+		req.PushRole = string(membership.RoleWrite)
+	}
+	pb := &database.ProtectedBranch{
+		RepositoryID:   repo.ID,
+		Pattern:        req.Pattern,
+		AllowForcePush: req.AllowForcePush,
+		AllowDeletion:  req.AllowDeletion,
+		PushRole:       req.PushRole,
+	}
+	pb, err = c.protectedBranch.Create(ctx, pb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create protected branch, error: %w", err)
+	}
+	return pb, nil
+}
+
+func (c *RepoComponent) UpdateProtectedBranch(ctx context.Context, req *types.UpdateProtectedBranchReq) (*database.ProtectedBranch, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanAdmin {
+		return nil, ErrUnauthorized
+	}
+	pb, err := c.protectedBranch.FindByID(ctx, req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find protected branch, error: %w", err)
+	}
+	if pb.RepositoryID != repo.ID {
+		return nil, ErrNotFound
+	}
+	if req.Pattern != nil {
+		if _, err := path.Match(*req.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid branch pattern %s, error: %w", *req.Pattern, err)
+		}
+		pb.Pattern = *req.Pattern
+	}
+	if req.AllowForcePush != nil {
+		pb.AllowForcePush = *req.AllowForcePush
+	}
+	if req.AllowDeletion != nil {
+		pb.AllowDeletion = *req.AllowDeletion
+	}
+	if req.PushRole != nil {
+		pb.PushRole = *req.PushRole
+	}
+	err = c.protectedBranch.Update(ctx, pb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update protected branch, error: %w", err)
+	}
+	return pb, nil
+}
+
+func (c *RepoComponent) DeleteProtectedBranch(ctx context.Context, req types.ProtectedBranchActReq) error {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanAdmin {
+		return ErrUnauthorized
+	}
+	pb, err := c.protectedBranch.FindByID(ctx, req.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find protected branch, error: %w", err)
+	}
+	if pb.RepositoryID != repo.ID {
+		return ErrNotFound
+	}
+	return c.protectedBranch.Delete(ctx, pb)
+}
+
+// checkProtectedBranches validates the ref changes of a push against the protected branch rules of the repo.
+// Force pushes can only be detected once the pushed objects are available, so checkForcePush should be
+// false before the pack is received; dirs points to the quarantine objects of the push if any.
+func (c *RepoComponent) checkProtectedBranches(ctx context.Context, repo *database.Repository, username string, changes []refChange, dirs *types.GitObjectDirs, checkForcePush bool) error {
+	rules, err := c.protectedBranch.FindByRepoID(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find protected branches, error: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var permission *types.UserRepoPermission
+	namespace, name := repo.NamespaceAndName()
+	for _, change := range changes {
+		if !strings.HasPrefix(change.Ref, branchRefPrefix) {
+			continue
+		}
+		branch := strings.TrimPrefix(change.Ref, branchRefPrefix)
+		rule := matchProtectedBranch(rules, branch)
+		if rule == nil {
+			continue
+		}
+
+		if permission == nil {
+			permission, err = c.getUserRepoPermission(ctx, username, repo)
+			if err != nil {
+				return fmt.Errorf("failed to get user repo permission, error: %w", err)
+			}
+		}
+		if !hasRole(permission, membership.Role(rule.PushRole)) {
+			return fmt.Errorf("%w: pushing to protected branch %s requires %s role", ErrForbidden, branch, rule.PushRole)
+		}
+
+		if change.NewRev == zeroCommitID {
+			if !rule.AllowDeletion {
+				return fmt.Errorf("%w: protected branch %s can not be deleted", ErrForbidden, branch)
+			}
+			continue
+		}
+
+		if !checkForcePush || rule.AllowForcePush || change.OldRev == zeroCommitID {
+			continue
+		}
+		ancestorReq := gitserver.CommitIsAncestorReq{
+			Namespace:  namespace,
+			Name:       name,
+			RepoType:   repo.RepositoryType,
+			AncestorID: change.OldRev,
+			ChildID:    change.NewRev,
+		}
+		if dirs != nil {
+			ancestorReq.GitObjectDirectory = dirs.GitObjectDirectory
+			ancestorReq.GitAlternateObjectDirectories = dirs.GitAlternateObjectDirectories
+		}
+		fastForward, err := c.git.CommitIsAncestor(ctx, ancestorReq)
+		if err != nil {
+			return fmt.Errorf("failed to check commit ancestry of branch %s, error: %w", branch, err)
+		}
+		if !fastForward {
+			return fmt.Errorf("%w: force push to protected branch %s is not allowed", ErrForbidden, branch)
+		}
+	}
+	return nil
+}
+
+// checkBranchCommit checks a commit made through the api to the branch, or to newBranch created from it, against the
+// protected branch rules. Api commits always fast-forward the branch, so only the push role matters
+func (c *RepoComponent) checkBranchCommit(ctx context.Context, repo *database.Repository, username, branch, newBranch string) error {
+	if newBranch != "" {
+		branch = newBranch
+	}
+	if branch == "" {
+		branch = repo.DefaultBranch
+	}
+	return c.checkProtectedBranches(ctx, repo, username, []refChange{{
+		Ref: branchRefPrefix + branch,
+	}}, nil, false)
+}
+
+// PreReceive checks the ref changes of a push against the protected branch rules, it is called from the
+// pre-receive hook of gitaly where the pushed objects are available in the push quarantine.
+func (c *InternalComponent) PreReceive(ctx context.Context, req types.PreReceiveReq) error {
+	changes := parseRefChanges(req.Changes)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	repoType, namespace, name, err := parseGlRepository(req.GlRepository)
+	if err != nil {
+		return err
+	}
+	repo, err := c.repoStore.FindByPath(ctx, repoType, namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, err: %v", err)
+	}
+
+	username, err := c.usernameByGlID(ctx, req.GlID)
+	if err != nil {
+		return err
+	}
+
+	var dirs *types.GitObjectDirs
+	if req.Env != "" {
+		dirs = &types.GitObjectDirs{}
+		if err := json.Unmarshal([]byte(req.Env), dirs); err != nil {
+			slog.Warn("failed to parse git object directories of pre receive", slog.String("env", req.Env), slog.Any("error", err))
+			dirs = nil
+		}
+	}
+
+	return c.checkProtectedBranches(ctx, repo, username, changes, dirs, true)
+}
+
+func (c *InternalComponent) usernameByGlID(ctx context.Context, glID string) (string, error) {
+	kind, idStr, found := strings.Cut(glID, "-")
+	if !found {
+		return "", fmt.Errorf("invalid gl_id %s", glID)
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid gl_id %s, error: %w", glID, err)
+	}
+	switch kind {
+	case "key":
+		sshKey, err := c.sshKeyStore.FindByID(ctx, id)
+		if err != nil {
+			return "", fmt.Errorf("failed to find ssh key by id, err: %v", err)
+		}
+		return sshKey.User.Username, nil
+	case "user":
+		user, err := c.user.FindByID(ctx, int(id))
+		if err != nil {
+			return "", fmt.Errorf("failed to find user by id, err: %v", err)
+		}
+		return user.Username, nil
+	default:
+		return "", fmt.Errorf("invalid gl_id %s", glID)
+	}
+}
+
+// parseGlRepository parses gl_repository like models/namespace/name
+func parseGlRepository(glRepository string) (types.RepositoryType, string, string, error) {
+	paths := strings.Split(strings.TrimSuffix(glRepository, ".git"), "/")
+	if len(paths) != 3 {
+		return "", "", "", fmt.Errorf("invalid gl_repository %s", glRepository)
+	}
+	return types.RepositoryType(strings.TrimSuffix(paths[0], "s")), paths[1], paths[2], nil
+}
@@ -0,0 +1,516 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type PullRequestComponent struct {
+	pullRequest *database.PullRequestStore
+	discussion  *database.DiscussionStore
+	*RepoComponent
+}
+
+func NewPullRequestComponent(config *config.Config) (*PullRequestComponent, error) {
+	var err error
+	c := &PullRequestComponent{}
+	c.pullRequest = database.NewPullRequestStore()
+	c.discussion = database.NewDiscussionStore()
+	c.RepoComponent, err = NewRepoComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// pullRequestHeadRef is the ref of the target repository the source branch is fetched into
+func pullRequestHeadRef(id int64) string {
+	return fmt.Sprintf("refs/pull/%d/head", id)
+}
+
+func (c *PullRequestComponent) CreatePullRequest(ctx context.Context, req *types.CreatePullRequestReq) (*types.PullRequest, error) {
+	// gitea can not fetch the source branch or merge it, so pull requests could never be merged
+	if c.config.GitServer.Type == types.GitServerTypeGitea {
+		return nil, fmt.Errorf("pull requests are not supported by gitea: %w", ErrNotSupported)
+	}
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, ErrUnauthorized
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user, error: %w", err)
+	}
+
+	sourceRepo := repo
+	if req.SourceNamespace != "" && req.SourceName != "" &&
+		(req.SourceNamespace != req.Namespace || req.SourceName != req.Name) {
+		sourceRepo, err = c.repo.FindByPath(ctx, req.RepoType, req.SourceNamespace, req.SourceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find source repo, error: %w", err)
+		}
+		allow, err := c.AllowReadAccessRepo(ctx, sourceRepo, req.CurrentUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check source repo permission, error: %w", err)
+		}
+		if !allow {
+			return nil, ErrUnauthorized
+		}
+	}
+	if req.TargetBranch == "" {
+		req.TargetBranch = repo.DefaultBranch
+	}
+	if sourceRepo.ID == repo.ID && req.SourceBranch == req.TargetBranch {
+		return nil, errors.New("source branch and target branch are the same")
+	}
+
+	sourceNamespace, sourceName := sourceRepo.NamespaceAndName()
+	sourceHead, err := c.git.GetRepoLastCommit(ctx, gitserver.GetRepoLastCommitReq{
+		Namespace: sourceNamespace,
+		Name:      sourceName,
+		Ref:       req.SourceBranch,
+		RepoType:  req.RepoType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source branch, error: %w", err)
+	}
+	if sourceHead.ID == "" {
+		return nil, fmt.Errorf("source branch %s not found", req.SourceBranch)
+	}
+
+	pr, err := c.pullRequest.Create(ctx, &database.PullRequest{
+		RepositoryID:       repo.ID,
+		SourceRepositoryID: sourceRepo.ID,
+		SourceBranch:       req.SourceBranch,
+		TargetBranch:       req.TargetBranch,
+		Title:              req.Title,
+		Description:        req.Description,
+		Status:             database.PullRequestStatusOpen,
+		UserID:             user.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request, error: %w", err)
+	}
+	pr.SourceRepository = sourceRepo
+	pr.User = &user
+	if err := c.fetchPullRequestHead(ctx, repo, pr); err != nil {
+		// the pull request has no head to compare without the fetched ref
+		if delErr := c.pullRequest.Delete(ctx, pr.ID); delErr != nil {
+			slog.Error("failed to delete pull request after fetch failure", slog.Int64("id", pr.ID), slog.Any("error", delErr))
+		}
+		return nil, err
+	}
+	return pullRequestFromDB(pr), nil
+}
+
+func (c *PullRequestComponent) ListPullRequests(ctx context.Context, req *types.ListPullRequestsReq) ([]types.PullRequest, int, error) {
+	repo, err := c.findReadableRepo(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, 0, err
+	}
+	prs, total, err := c.pullRequest.FindByRepoID(ctx, repo.ID, req.Status, req.Per, req.Page)
+	if err != nil {
+		return nil, 0, err
+	}
+	resPRs := make([]types.PullRequest, 0, len(prs))
+	for i := range prs {
+		resPRs = append(resPRs, *pullRequestFromDB(&prs[i]))
+	}
+	return resPRs, total, nil
+}
+
+func (c *PullRequestComponent) GetPullRequest(ctx context.Context, req types.PullRequestActReq) (*types.PullRequestDetail, error) {
+	repo, err := c.findReadableRepo(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := c.findPullRequest(ctx, repo, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &types.PullRequestDetail{
+		PullRequest:  *pullRequestFromDB(pr),
+		HeadCommitID: pr.HeadCommitID,
+		BaseCommitID: pr.BaseCommitID,
+	}
+	// open and closed pull requests are compared with the current target branch, the head ref is refreshed when
+	// the pull request is created, updated or merged
+	if pr.Status != database.PullRequestStatusMerged {
+		detail.HeadCommitID, detail.BaseCommitID, err = c.pullRequestCommits(ctx, repo, pr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	namespace, name := repo.NamespaceAndName()
+	diff, err := c.git.GetDiffBetweenTwoCommits(ctx, gitserver.GetDiffBetweenTwoCommitsReq{
+		Namespace:     namespace,
+		Name:          name,
+		RepoType:      req.RepoType,
+		LeftCommitId:  detail.BaseCommitID,
+		RightCommitId: detail.HeadCommitID,
+		Private:       repo.Private,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request diff, error: %w", err)
+	}
+	for _, commit := range diff.Commits {
+		detail.Added = append(detail.Added, commit.Added...)
+		detail.Removed = append(detail.Removed, commit.Removed...)
+		detail.Modified = append(detail.Modified, commit.Modified...)
+	}
+	return detail, nil
+}
+
+func (c *PullRequestComponent) MergePullRequest(ctx context.Context, req *types.MergePullRequestReq) (*types.PullRequest, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanWrite {
+		return nil, ErrUnauthorized
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user, error: %w", err)
+	}
+	pr, err := c.findPullRequest(ctx, repo, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	// claim the pull request so concurrent merges or closes of it fail
+	claimed, err := c.pullRequest.UpdateStatus(ctx, pr.ID, database.PullRequestStatusOpen, database.PullRequestStatusMerging)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update pull request status, error: %w", err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("pull request %d is not open", pr.ID)
+	}
+	merged := false
+	defer func() {
+		if merged {
+			return
+		}
+		_, err := c.pullRequest.UpdateStatus(context.Background(), pr.ID, database.PullRequestStatusMerging, database.PullRequestStatusOpen)
+		if err != nil {
+			slog.Error("failed to reopen pull request after merge failure", slog.Int64("id", pr.ID), slog.Any("error", err))
+		}
+	}()
+
+	if err := c.fetchPullRequestHead(ctx, repo, pr); err != nil {
+		return nil, err
+	}
+	headCommitID, baseCommitID, err := c.pullRequestCommits(ctx, repo, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name := repo.NamespaceAndName()
+	targetHead, err := c.git.GetRepoLastCommit(ctx, gitserver.GetRepoLastCommitReq{
+		Namespace: namespace,
+		Name:      name,
+		Ref:       pr.TargetBranch,
+		RepoType:  req.RepoType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target branch, error: %w", err)
+	}
+	err = c.checkProtectedBranches(ctx, repo, req.CurrentUser, []refChange{{
+		OldRev: targetHead.ID,
+		NewRev: headCommitID,
+		Ref:    branchRefPrefix + pr.TargetBranch,
+	}}, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Message == "" {
+		sourceNamespace, sourceName := pr.SourceRepository.NamespaceAndName()
+		req.Message = fmt.Sprintf("Merge pull request #%d from %s/%s:%s\n\n%s", pr.ID, sourceNamespace, sourceName, pr.SourceBranch, pr.Title)
+	}
+	mergeCommitID, err := c.git.MergeBranch(ctx, gitserver.MergeBranchReq{
+		Namespace: namespace,
+		Name:      name,
+		RepoType:  req.RepoType,
+		CommitID:  headCommitID,
+		Branch:    pr.TargetBranch,
+		Message:   req.Message,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge pull request, error: %w", err)
+	}
+	// the target branch has the merge commit now, the pull request is merged even if saving it fails
+	merged = true
+
+	pr.Status = database.PullRequestStatusMerged
+	pr.HeadCommitID = headCommitID
+	pr.BaseCommitID = baseCommitID
+	pr.MergeCommitID = mergeCommitID
+	pr.MergedBy = user.ID
+	pr.MergedAt = time.Now()
+	if err := c.pullRequest.Update(ctx, pr); err != nil {
+		// at least record the merge, otherwise the pull request stays merging forever
+		_, statusErr := c.pullRequest.UpdateStatus(context.Background(), pr.ID, database.PullRequestStatusMerging, database.PullRequestStatusMerged)
+		if statusErr != nil {
+			slog.Error("failed to mark pull request merged", slog.Int64("id", pr.ID), slog.Any("error", statusErr))
+		}
+		return nil, fmt.Errorf("failed to update pull request, error: %w", err)
+	}
+	return pullRequestFromDB(pr), nil
+}
+
+func (c *PullRequestComponent) ClosePullRequest(ctx context.Context, req types.PullRequestActReq) error {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	pr, err := c.findPullRequest(ctx, repo, req.ID)
+	if err != nil {
+		return err
+	}
+	// the author can close its own pull request
+	if pr.User == nil || pr.User.Username != req.CurrentUser {
+		permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+		if err != nil {
+			return fmt.Errorf("failed to get user repo permission, error: %w", err)
+		}
+		if !permission.CanWrite {
+			return ErrUnauthorized
+		}
+	}
+	closed, err := c.pullRequest.UpdateStatus(ctx, pr.ID, database.PullRequestStatusOpen, database.PullRequestStatusClosed)
+	if err != nil {
+		return fmt.Errorf("failed to close pull request, error: %w", err)
+	}
+	if !closed {
+		return fmt.Errorf("pull request %d is not open", pr.ID)
+	}
+	return nil
+}
+
+// UpdatePullRequest updates the title and description of an open pull request and refreshes its head to the
+// latest commit of the source branch
+func (c *PullRequestComponent) UpdatePullRequest(ctx context.Context, req *types.UpdatePullRequestReq) (*types.PullRequest, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	pr, err := c.findPullRequest(ctx, repo, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	// the author can update its own pull request
+	if pr.User == nil || pr.User.Username != req.CurrentUser {
+		permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+		}
+		if !permission.CanWrite {
+			return nil, ErrUnauthorized
+		}
+	}
+	if pr.Status != database.PullRequestStatusOpen {
+		return nil, fmt.Errorf("pull request %d is %s", pr.ID, pr.Status)
+	}
+	if req.Title != nil {
+		if *req.Title == "" {
+			return nil, errors.New("title of pull request can not be empty")
+		}
+		pr.Title = *req.Title
+	}
+	if req.Description != nil {
+		pr.Description = *req.Description
+	}
+	if err := c.fetchPullRequestHead(ctx, repo, pr); err != nil {
+		return nil, err
+	}
+	if err := c.pullRequest.UpdateContent(ctx, pr); err != nil {
+		return nil, fmt.Errorf("failed to update pull request, error: %w", err)
+	}
+	return pullRequestFromDB(pr), nil
+}
+
+func (c *PullRequestComponent) CreatePullRequestComment(ctx context.Context, req *types.CreatePullRequestCommentReq) (*types.PullRequestComment, error) {
+	repo, err := c.findReadableRepo(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := c.findPullRequest(ctx, repo, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user, error: %w", err)
+	}
+	comment, err := c.discussion.CreateComment(ctx, database.Comment{
+		Content:         req.Content,
+		CommentableType: database.CommentableTypePullRequest,
+		CommentableID:   pr.ID,
+		UserID:          user.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request comment, error: %w", err)
+	}
+	comment.User = &user
+	return pullRequestCommentFromDB(comment), nil
+}
+
+func (c *PullRequestComponent) ListPullRequestComments(ctx context.Context, req types.PullRequestActReq) ([]types.PullRequestComment, error) {
+	repo, err := c.findReadableRepo(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := c.findPullRequest(ctx, repo, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := c.discussion.FindComments(ctx, database.CommentableTypePullRequest, pr.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pull request comments, error: %w", err)
+	}
+	resComments := make([]types.PullRequestComment, 0, len(comments))
+	for i := range comments {
+		resComments = append(resComments, *pullRequestCommentFromDB(&comments[i]))
+	}
+	return resComments, nil
+}
+
+func (c *PullRequestComponent) findReadableRepo(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string) (*database.Repository, error) {
+	repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	allow, err := c.AllowReadAccessRepo(ctx, repo, currentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repo permission, error: %w", err)
+	}
+	if !allow {
+		return nil, ErrUnauthorized
+	}
+	return repo, nil
+}
+
+func (c *PullRequestComponent) findPullRequest(ctx context.Context, repo *database.Repository, id int64) (*database.PullRequest, error) {
+	pr, err := c.pullRequest.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pull request, error: %w", err)
+	}
+	if pr.RepositoryID != repo.ID {
+		return nil, ErrNotFound
+	}
+	return pr, nil
+}
+
+// fetchPullRequestHead updates the head ref of the pull request to the latest commit of the source branch
+func (c *PullRequestComponent) fetchPullRequestHead(ctx context.Context, repo *database.Repository, pr *database.PullRequest) error {
+	namespace, name := repo.NamespaceAndName()
+	sourceNamespace, sourceName := pr.SourceRepository.NamespaceAndName()
+	err := c.git.FetchSourceBranch(ctx, gitserver.FetchSourceBranchReq{
+		Namespace:       namespace,
+		Name:            name,
+		RepoType:        repo.RepositoryType,
+		SourceNamespace: sourceNamespace,
+		SourceName:      sourceName,
+		SourceBranch:    pr.SourceBranch,
+		TargetRef:       pullRequestHeadRef(pr.ID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch source branch of pull request %d, error: %w", pr.ID, err)
+	}
+	return nil
+}
+
+// pullRequestCommits returns the head commit of the pull request and its merge base with the target branch
+func (c *PullRequestComponent) pullRequestCommits(ctx context.Context, repo *database.Repository, pr *database.PullRequest) (string, string, error) {
+	namespace, name := repo.NamespaceAndName()
+	head, err := c.git.GetRepoLastCommit(ctx, gitserver.GetRepoLastCommitReq{
+		Namespace: namespace,
+		Name:      name,
+		Ref:       pullRequestHeadRef(pr.ID),
+		RepoType:  repo.RepositoryType,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get head of pull request %d, error: %w", pr.ID, err)
+	}
+	if head.ID == "" {
+		return "", "", fmt.Errorf("head of pull request %d not found", pr.ID)
+	}
+	base, err := c.git.GetMergeBase(ctx, gitserver.GetMergeBaseReq{
+		Namespace: namespace,
+		Name:      name,
+		RepoType:  repo.RepositoryType,
+		Revisions: []string{branchRefPrefix + pr.TargetBranch, head.ID},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get merge base of pull request %d, error: %w", pr.ID, err)
+	}
+	return head.ID, base, nil
+}
+
+func pullRequestFromDB(pr *database.PullRequest) *types.PullRequest {
+	resPR := &types.PullRequest{
+		ID:            pr.ID,
+		Title:         pr.Title,
+		Description:   pr.Description,
+		Status:        pr.Status,
+		SourceBranch:  pr.SourceBranch,
+		TargetBranch:  pr.TargetBranch,
+		MergeCommitID: pr.MergeCommitID,
+		MergedAt:      pr.MergedAt,
+		CreatedAt:     pr.CreatedAt,
+		UpdatedAt:     pr.UpdatedAt,
+	}
+	if pr.SourceRepository != nil {
+		resPR.SourceRepo = pr.SourceRepository.Path
+	}
+	if pr.User != nil {
+		resPR.Author = &types.User{
+			ID:       pr.User.ID,
+			Username: pr.User.Username,
+			Nickname: pr.User.NickName,
+			Avatar:   pr.User.Avatar,
+		}
+	}
+	return resPR
+}
+
+func pullRequestCommentFromDB(comment *database.Comment) *types.PullRequestComment {
+	resComment := &types.PullRequestComment{
+		ID:        comment.ID,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+	}
+	if comment.User != nil {
+		resComment.User = &types.User{
+			ID:       comment.User.ID,
+			Username: comment.User.Username,
+			Nickname: comment.User.NickName,
+			Avatar:   comment.User.Avatar,
+		}
+	}
+	return resComment
+}
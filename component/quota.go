@@ -0,0 +1,295 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	deployStatus "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// deploys in these statuses hold cluster resources and count against the quota
+var quotaHoldingStatuses = []int{
+	deployStatus.Pending,
+	deployStatus.Building,
+	deployStatus.BuildSuccess,
+	deployStatus.BuildSkip,
+	deployStatus.Deploying,
+	deployStatus.Startup,
+	deployStatus.Running,
+}
+
+type QuotaComponent struct {
+	quota     *database.QuotaStore
+	deploy    *database.DeployTaskStore
+	user      *database.UserStore
+	namespace *database.NamespaceStore
+}
+
+func NewQuotaComponent(config *config.Config) (*QuotaComponent, error) {
+	c := &QuotaComponent{}
+	c.quota = database.NewQuotaStore()
+	c.deploy = database.NewDeployTaskStore()
+	c.user = database.NewUserStore()
+	c.namespace = database.NewNamespaceStore()
+	return c, nil
+}
+
+// deployQuotaReq is a deploy to be started by the user in the namespace
+type deployQuotaReq struct {
+	User       *database.User
+	Namespace  string
+	DeployType int
+	Hardware   string
+	Replicas   int
+	// deploys replaced by the new one, they do not count against the quota
+	Replaces func(deploy *database.Deploy) bool
+}
+
+// UserQuota returns the quota and the current usage of a user, visible to the user and admins
+func (c *QuotaComponent) UserQuota(ctx context.Context, username, currentUser string) (*types.Quota, error) {
+	if currentUser == "" {
+		return nil, ErrUserNotFound
+	}
+	if currentUser != username {
+		operator, err := c.user.FindByUsername(ctx, currentUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find current user, error: %w", err)
+		}
+		if !operator.CanAdmin() {
+			return nil, ErrUnauthorized
+		}
+	}
+	user, err := c.user.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user %s, error: %w", username, err)
+	}
+	return c.quotaOf(ctx, types.QuotaOwnerUser, username, user.ID)
+}
+
+func (c *QuotaComponent) ListQuotas(ctx context.Context, per, page int) ([]types.Quota, int, error) {
+	quotas, total, err := c.quota.List(ctx, per, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list quotas, error: %w", err)
+	}
+	var result []types.Quota
+	for _, q := range quotas {
+		var userID int64
+		if q.OwnerType == types.QuotaOwnerUser {
+			user, err := c.user.FindByUsername(ctx, q.OwnerName)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to find user %s, error: %w", q.OwnerName, err)
+			}
+			userID = user.ID
+		}
+		quota, err := c.quotaOf(ctx, q.OwnerType, q.OwnerName, userID)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, *quota)
+	}
+	return result, total, nil
+}
+
+func (c *QuotaComponent) SetQuota(ctx context.Context, req *types.SetQuotaReq) error {
+	if err := c.checkQuotaOwner(ctx, req.OwnerType, req.OwnerName); err != nil {
+		return err
+	}
+	return c.quota.Upsert(ctx, &database.Quota{
+		OwnerType:     req.OwnerType,
+		OwnerName:     req.OwnerName,
+		MaxGPU:        req.MaxGPU,
+		MaxMemory:     req.MaxMemory,
+		MaxInferences: req.MaxInferences,
+		MaxFinetunes:  req.MaxFinetunes,
+		MaxSpaces:     req.MaxSpaces,
+	})
+}
+
+func (c *QuotaComponent) DeleteQuota(ctx context.Context, ownerType, ownerName string) error {
+	return c.quota.Delete(ctx, ownerType, ownerName)
+}
+
+func (c *QuotaComponent) checkQuotaOwner(ctx context.Context, ownerType, ownerName string) error {
+	var nsType database.NamespaceType
+	switch ownerType {
+	case types.QuotaOwnerUser:
+		nsType = database.UserNamespace
+	case types.QuotaOwnerOrganization:
+		nsType = database.OrgNamespace
+	default:
+		return fmt.Errorf("invalid quota owner type %s", ownerType)
+	}
+	ns, err := c.namespace.FindByPath(ctx, ownerName)
+	if err != nil {
+		return fmt.Errorf("failed to find %s %s, error: %w", ownerType, ownerName, err)
+	}
+	if ns.NamespaceType != nsType {
+		return fmt.Errorf("%s is not a %s", ownerName, ownerType)
+	}
+	return nil
+}
+
+// DeployWithinQuota checks the new deploy fits in the quota of the user, and of the organization
+// when the repo belongs to one, then runs deploy. The quotas stay locked until deploy returns so
+// concurrent deploys of the same owner can not exceed the quota together
+func (c *QuotaComponent) DeployWithinQuota(ctx context.Context, req deployQuotaReq, deploy func() error) error {
+	// serverless deploys are created by admins for everyone
+	if req.DeployType == types.ServerlessType {
+		return deploy()
+	}
+	owners := []quotaOwner{{Type: types.QuotaOwnerUser, Name: req.User.Username, UserID: req.User.ID}}
+	if req.Namespace != req.User.Username {
+		ns, err := c.namespace.FindByPath(ctx, req.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to find namespace %s, error: %w", req.Namespace, err)
+		}
+		if ns.NamespaceType == database.OrgNamespace {
+			owners = append(owners, quotaOwner{Type: types.QuotaOwnerOrganization, Name: req.Namespace})
+		}
+	}
+	return c.deployLocked(ctx, owners, req, deploy)
+}
+
+type quotaOwner struct {
+	Type string
+	Name string
+	// only set for users
+	UserID int64
+}
+
+// deployLocked locks the quotas of the owners one by one, always the user before the organization,
+// and checks the deploy against each of them before running it
+func (c *QuotaComponent) deployLocked(ctx context.Context, owners []quotaOwner, req deployQuotaReq, deploy func() error) error {
+	if len(owners) == 0 {
+		return deploy()
+	}
+	owner := owners[0]
+	return c.quota.RunLocked(ctx, owner.Type, owner.Name, func(ctx context.Context, quota *database.Quota) error {
+		if quota != nil {
+			if err := c.checkOwnerQuota(ctx, owner, quota, req); err != nil {
+				return err
+			}
+		}
+		return c.deployLocked(ctx, owners[1:], req, deploy)
+	})
+}
+
+func (c *QuotaComponent) checkOwnerQuota(ctx context.Context, owner quotaOwner, quota *database.Quota, req deployQuotaReq) error {
+	usage, err := c.usageOf(ctx, owner.Type, owner.Name, owner.UserID, req.Replaces)
+	if err != nil {
+		return err
+	}
+	addDeployUsage(&usage, req.DeployType, req.Hardware, req.Replicas)
+
+	checks := []struct {
+		name        string
+		used, limit int
+		unit        string
+	}{
+		{"gpus", usage.GPU, quota.MaxGPU, ""},
+		{"memory", usage.Memory, quota.MaxMemory, "Gi"},
+		{"inference endpoints", usage.Inferences, quota.MaxInferences, ""},
+		{"finetunes", usage.Finetunes, quota.MaxFinetunes, ""},
+		{"spaces", usage.Spaces, quota.MaxSpaces, ""},
+	}
+	for _, check := range checks {
+		if check.limit > 0 && check.used > check.limit {
+			return fmt.Errorf("%w: %s %s would use %d%s %s, the limit is %d%s", ErrQuotaExceeded,
+				owner.Type, owner.Name, check.used, check.unit, check.name, check.limit, check.unit)
+		}
+	}
+	return nil
+}
+
+// quotaOf returns the limits and usage of the owner
+func (c *QuotaComponent) quotaOf(ctx context.Context, ownerType, ownerName string, userID int64) (*types.Quota, error) {
+	quota, err := c.quota.FindByOwner(ctx, ownerType, ownerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find quota of %s %s, error: %w", ownerType, ownerName, err)
+	}
+	usage, err := c.usageOf(ctx, ownerType, ownerName, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.Quota{
+		OwnerType: ownerType,
+		OwnerName: ownerName,
+		Usage:     usage,
+	}
+	if quota != nil {
+		result.Limits = &types.QuotaLimits{
+			MaxGPU:        quota.MaxGPU,
+			MaxMemory:     quota.MaxMemory,
+			MaxInferences: quota.MaxInferences,
+			MaxFinetunes:  quota.MaxFinetunes,
+			MaxSpaces:     quota.MaxSpaces,
+		}
+	}
+	return result, nil
+}
+
+// usageOf counts the resources held by the deploys of the owner, deploys matching skip are not counted
+func (c *QuotaComponent) usageOf(ctx context.Context, ownerType, ownerName string, userID int64, skip func(deploy *database.Deploy) bool) (types.QuotaUsage, error) {
+	var (
+		usage   types.QuotaUsage
+		deploys []database.Deploy
+		err     error
+	)
+	if ownerType == types.QuotaOwnerUser {
+		deploys, err = c.deploy.ListDeploysByUserIDAndStatus(ctx, userID, quotaHoldingStatuses)
+	} else {
+		deploys, err = c.deploy.ListDeploysByNamespaceAndStatus(ctx, ownerName, quotaHoldingStatuses)
+	}
+	if err != nil {
+		return usage, fmt.Errorf("failed to list deploys of %s %s, error: %w", ownerType, ownerName, err)
+	}
+	for i := range deploys {
+		if skip != nil && skip(&deploys[i]) {
+			continue
+		}
+		addDeployUsage(&usage, deploys[i].Type, deploys[i].Hardware, deploys[i].MaxReplica)
+	}
+	return usage, nil
+}
+
+// addDeployUsage counts the resources of a deploy, which may scale up to its max replicas
+func addDeployUsage(usage *types.QuotaUsage, deployType int, hardware string, replicas int) {
+	switch deployType {
+	case types.SpaceType:
+		usage.Spaces++
+	case types.InferenceType:
+		usage.Inferences++
+	case types.FinetuneType:
+		usage.Finetunes++
+	case types.ServerlessType:
+		return
+	}
+
+	var hw types.HardWare
+	if err := json.Unmarshal([]byte(hardware), &hw); err != nil {
+		return
+	}
+	replicas = max(replicas, 1)
+	gpu, _ := strconv.Atoi(hw.Gpu.Num)
+	usage.GPU += gpu * replicas
+	usage.Memory += memoryGiB(hw.Memory) * replicas
+}
+
+// memoryGiB converts a kubernetes memory quantity like 512Mi or 16Gi to GiB, rounded up
+func memoryGiB(memory string) int {
+	if memory == "" {
+		return 0
+	}
+	q, err := resource.ParseQuantity(memory)
+	if err != nil {
+		return 0
+	}
+	return int((q.Value() + 1<<30 - 1) >> 30)
+}
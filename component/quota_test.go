@@ -0,0 +1,30 @@
+package component
+
+import (
+	"testing"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestAddDeployUsage(t *testing.T) {
+	testData := []struct {
+		name       string
+		deployType int
+		hardware   string
+		replicas   int
+		expect     types.QuotaUsage
+	}{
+		{"gpu inference", types.InferenceType, `{"gpu":{"num":"2"},"memory":"16Gi"}`, 2, types.QuotaUsage{GPU: 4, Memory: 32, Inferences: 1}},
+		{"memory in Mi rounds up", types.SpaceType, `{"memory":"1536Mi"}`, 0, types.QuotaUsage{Memory: 2, Spaces: 1}},
+		{"memory in bytes", types.FinetuneType, `{"memory":"2147483648"}`, 1, types.QuotaUsage{Memory: 2, Finetunes: 1}},
+		{"invalid memory", types.SpaceType, `{"memory":"lots"}`, 1, types.QuotaUsage{Spaces: 1}},
+		{"serverless", types.ServerlessType, `{"gpu":{"num":"1"},"memory":"16Gi"}`, 1, types.QuotaUsage{}},
+	}
+	for _, data := range testData {
+		var usage types.QuotaUsage
+		addDeployUsage(&usage, data.deployType, data.hardware, data.replicas)
+		if usage != data.expect {
+			t.Errorf("%s: expect %+v, got %+v", data.name, data.expect, usage)
+		}
+	}
+}
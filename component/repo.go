@@ -31,6 +31,7 @@ import (
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/mirror/credential"
 	"opencsg.com/csghub-server/mirror/queue"
 )
 
@@ -62,6 +63,8 @@ type RepoComponent struct {
 	serverBaseUrl      string
 	cluster            *database.ClusterInfoStore
 	mirrorSource       *database.MirrorSourceStore
+	mirrorCredential   *database.MirrorCredentialStore
+	credentials        *credential.Resolver
 	tokenStore         *database.AccessTokenStore
 	rtfm               *database.RuntimeFrameworksStore
 	rrtfms             *database.RepositoriesRuntimeFrameworkStore
@@ -74,6 +77,10 @@ type RepoComponent struct {
 	lfsMetaObjectStore *database.LfsMetaObjectStore
 	recom              *database.RecomStore
 	mq                 *queue.PriorityQueue
+	endpointKey        *database.EndpointKeyStore
+	protectedBranch    *database.ProtectedBranchStore
+	secret             *database.SecretStore
+	quota              *QuotaComponent
 }
 
 func NewRepoComponent(config *config.Config) (*RepoComponent, error) {
@@ -86,6 +93,8 @@ func NewRepoComponent(config *config.Config) (*RepoComponent, error) {
 	c.uls = database.NewUserLikesStore()
 	c.mirror = database.NewMirrorStore()
 	c.mirrorSource = database.NewMirrorSourceStore()
+	c.mirrorCredential = database.NewMirrorCredentialStore()
+	c.credentials = credential.NewResolver(config)
 	c.tokenStore = database.NewAccessTokenStore()
 	c.syncVersion = database.NewSyncVersionStore()
 	c.syncClientSetting = database.NewSyncClientSettingStore()
@@ -125,6 +134,7 @@ func NewRepoComponent(config *config.Config) (*RepoComponent, error) {
 		rpc.AuthWithApiKey(config.APIToken))
 	c.runFrame = database.NewRuntimeFrameworksStore()
 	c.deploy = database.NewDeployTaskStore()
+	c.endpointKey = database.NewEndpointKeyStore()
 	c.deployer = deploy.NewDeployer()
 	c.publicRootDomain = config.Space.PublicRootDomain
 	c.serverBaseUrl = config.APIServer.PublicDomain
@@ -138,6 +148,12 @@ func NewRepoComponent(config *config.Config) (*RepoComponent, error) {
 	c.srs = database.NewSpaceResourceStore()
 	c.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
 	c.recom = database.NewRecomStore()
+	c.protectedBranch = database.NewProtectedBranchStore()
+	c.secret = database.NewSecretStore()
+	c.quota, err = NewQuotaComponent(config)
+	if err != nil {
+		return nil, err
+	}
 	c.config = config
 	return c, nil
 }
@@ -157,20 +173,9 @@ func (c *RepoComponent) CreateRepo(ctx context.Context, req types.CreateRepoReq)
 		return nil, nil, fmt.Errorf("please set your email first")
 	}
 
-	if !user.CanAdmin() {
-		if namespace.NamespaceType == database.OrgNamespace {
-			canWrite, err := c.checkCurrentUserPermission(ctx, req.Username, req.Namespace, membership.RoleWrite)
-			if err != nil {
-				return nil, nil, err
-			}
-			if !canWrite {
-				return nil, nil, fmt.Errorf("users do not have permission to create %s in this organization", req.RepoType)
-			}
-		} else {
-			if namespace.Path != user.Username {
-				return nil, nil, fmt.Errorf("users do not have permission to create %s in this namespace", req.RepoType)
-			}
-		}
+	err = c.checkCreateRepoPermission(ctx, &user, &namespace, req.RepoType)
+	if err != nil {
+		return nil, nil, err
 	}
 	if req.DefaultBranch == "" {
 		req.DefaultBranch = "main"
@@ -217,6 +222,27 @@ func (c *RepoComponent) CreateRepo(ctx context.Context, req types.CreateRepoReq)
 	return gitRepo, newDBRepo, nil
 }
 
+// checkCreateRepoPermission checks whether the user can create repos in the namespace
+func (c *RepoComponent) checkCreateRepoPermission(ctx context.Context, user *database.User, namespace *database.Namespace, repoType types.RepositoryType) error {
+	if user.CanAdmin() {
+		return nil
+	}
+	if namespace.NamespaceType == database.OrgNamespace {
+		canWrite, err := c.checkCurrentUserPermission(ctx, user.Username, namespace.Path, membership.RoleWrite)
+		if err != nil {
+			return err
+		}
+		if !canWrite {
+			return fmt.Errorf("users do not have permission to create %s in this organization", repoType)
+		}
+	} else {
+		if namespace.Path != user.Username {
+			return fmt.Errorf("users do not have permission to create %s in this namespace", repoType)
+		}
+	}
+	return nil
+}
+
 func (c *RepoComponent) UpdateRepo(ctx context.Context, req types.UpdateRepoReq) (*database.Repository, error) {
 	repo, err := c.repo.Find(ctx, req.Namespace, string(req.RepoType), req.Name)
 	if err != nil {
@@ -450,6 +476,10 @@ func (c *RepoComponent) CreateFile(ctx context.Context, req *types.CreateFileReq
 	if !permission.CanWrite {
 		return nil, ErrUnauthorized
 	}
+	err = c.checkBranchCommit(ctx, repo, req.CurrentUser, req.Branch, req.NewBranch)
+	if err != nil {
+		return nil, err
+	}
 
 	user, err = c.user.FindByUsername(ctx, req.Username)
 	if err != nil {
@@ -564,6 +594,10 @@ func (c *RepoComponent) UpdateFile(ctx context.Context, req *types.UpdateFileReq
 	if !permission.CanWrite {
 		return nil, ErrUnauthorized
 	}
+	err = c.checkBranchCommit(ctx, repo, req.CurrentUser, req.Branch, req.NewBranch)
+	if err != nil {
+		return nil, err
+	}
 
 	user, err = c.user.FindByUsername(ctx, req.Username)
 	if err != nil {
@@ -684,6 +718,7 @@ func (c *RepoComponent) Commits(ctx context.Context, req *types.GetCommitsReq) (
 		Per:       req.Per,
 		Page:      req.Page,
 		RepoType:  req.RepoType,
+		Path:      req.Path,
 	}
 	commits, pageOpt, err := c.git.GetRepoCommits(ctx, getCommitsReq)
 	if err != nil {
@@ -722,6 +757,37 @@ func (c *RepoComponent) LastCommit(ctx context.Context, req *types.GetCommitsReq
 	return commit, nil
 }
 
+func (c *RepoComponent) FileBlame(ctx context.Context, req *types.GetFileReq) ([]types.BlameRange, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, ErrUnauthorized
+	}
+
+	if req.Ref == "" {
+		req.Ref = repo.DefaultBranch
+	}
+	getFileBlameReq := gitserver.GetRepoInfoByPathReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		Ref:       req.Ref,
+		Path:      req.Path,
+		RepoType:  req.RepoType,
+	}
+	ranges, err := c.git.GetRepoFileBlame(ctx, getFileBlameReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git %s repository file blame, error: %w", req.RepoType, err)
+	}
+	return ranges, nil
+}
+
 func (c *RepoComponent) FileRaw(ctx context.Context, req *types.GetFileReq) (string, error) {
 	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
 	if err != nil || repo == nil {
@@ -868,6 +934,167 @@ func (c *RepoComponent) Tags(ctx context.Context, req *types.GetTagsReq) ([]data
 	return tags, nil
 }
 
+func (c *RepoComponent) CreateBranch(ctx context.Context, req *types.CreateBranchReq) (*types.Branch, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user, error: %w", err)
+	}
+	if req.StartPoint == "" {
+		req.StartPoint = repo.DefaultBranch
+	}
+	err = c.checkProtectedBranches(ctx, repo, req.CurrentUser, []refChange{{
+		OldRev: zeroCommitID,
+		Ref:    branchRefPrefix + req.Branch,
+	}}, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	branch, err := c.git.CreateBranch(ctx, gitserver.CreateBranchReq{
+		Namespace:  req.Namespace,
+		Name:       req.Name,
+		RepoType:   req.RepoType,
+		Branch:     req.Branch,
+		StartPoint: req.StartPoint,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create git %s repository branch, error: %w", req.RepoType, err)
+	}
+	return branch, nil
+}
+
+func (c *RepoComponent) DeleteBranch(ctx context.Context, req *types.DeleteBranchReq) error {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	if req.Branch == repo.DefaultBranch {
+		return fmt.Errorf("can not delete the default branch %s", req.Branch)
+	}
+	err = c.checkProtectedBranches(ctx, repo, req.CurrentUser, []refChange{{
+		NewRev: zeroCommitID,
+		Ref:    branchRefPrefix + req.Branch,
+	}}, nil, false)
+	if err != nil {
+		return err
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return fmt.Errorf("failed to find user, error: %w", err)
+	}
+	err = c.git.DeleteBranch(ctx, gitserver.DeleteBranchReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Branch:    req.Branch,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete git %s repository branch, error: %w", req.RepoType, err)
+	}
+	return nil
+}
+
+func (c *RepoComponent) RenameBranch(ctx context.Context, req *types.RenameBranchReq) error {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	if req.Branch == repo.DefaultBranch {
+		return fmt.Errorf("can not rename the default branch %s", req.Branch)
+	}
+	// renaming deletes the old branch and creates the new one
+	err = c.checkProtectedBranches(ctx, repo, req.CurrentUser, []refChange{{
+		NewRev: zeroCommitID,
+		Ref:    branchRefPrefix + req.Branch,
+	}, {
+		OldRev: zeroCommitID,
+		Ref:    branchRefPrefix + req.NewBranch,
+	}}, nil, false)
+	if err != nil {
+		return err
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return fmt.Errorf("failed to find user, error: %w", err)
+	}
+	err = c.git.RenameBranch(ctx, gitserver.RenameBranchReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Branch:    req.Branch,
+		NewBranch: req.NewBranch,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename git %s repository branch, error: %w", req.RepoType, err)
+	}
+	return nil
+}
+
+func (c *RepoComponent) CreateTag(ctx context.Context, req *types.CreateTagReq) (*types.Tag, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user, error: %w", err)
+	}
+	if req.Target == "" {
+		req.Target = repo.DefaultBranch
+	}
+	tag, err := c.git.CreateTag(ctx, gitserver.CreateTagReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Tag:       req.Tag,
+		Target:    req.Target,
+		Message:   req.Message,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create git %s repository tag, error: %w", req.RepoType, err)
+	}
+	return tag, nil
+}
+
+func (c *RepoComponent) DeleteTag(ctx context.Context, req *types.DeleteTagReq) error {
+	_, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return fmt.Errorf("failed to find user, error: %w", err)
+	}
+	err = c.git.DeleteTag(ctx, gitserver.DeleteTagReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Tag:       req.Tag,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete git %s repository tag, error: %w", req.RepoType, err)
+	}
+	return nil
+}
+
 func (c *RepoComponent) UpdateTags(ctx context.Context, namespace, name string, repoType types.RepositoryType, category, currentUser string, tags []string) error {
 	repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
 	if err != nil {
@@ -1383,6 +1610,45 @@ func (c *RepoComponent) GetCommitWithDiff(ctx context.Context, req *types.GetCom
 	return resp, nil
 }
 
+// CompareRefs returns the commits, file changes and diff of Head since its merge base with Base
+func (c *RepoComponent) CompareRefs(ctx context.Context, req *types.CompareRefsReq) (*types.CompareResponse, error) {
+	if req.Base == "" || req.Head == "" {
+		return nil, fmt.Errorf("base and head refs are required")
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Per < 1 || req.Per > gitserver.MaxCompareCommitsPerPage {
+		req.Per = gitserver.MaxCompareCommitsPerPage
+	}
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, ErrUnauthorized
+	}
+	resp, err := c.git.CompareRefs(ctx, gitserver.CompareRefsReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Base:      req.Base,
+		Head:      req.Head,
+		Per:       req.Per,
+		Page:      req.Page,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare git %s repository %s refs '%s...%s', error: %w", req.RepoType, req.Name, req.Base, req.Head, err)
+	}
+
+	return resp, nil
+}
+
 func (c *RepoComponent) CreateMirror(ctx context.Context, req types.CreateMirrorReq) (*database.Mirror, error) {
 	var (
 		mirror database.Mirror
@@ -1412,19 +1678,32 @@ func (c *RepoComponent) CreateMirror(ctx context.Context, req types.CreateMirror
 	if err != nil {
 		return nil, fmt.Errorf("failed to get mirror source, err: %w, id: %d", err, req.MirrorSourceID)
 	}
+	err = c.checkMirrorCredential(ctx, req.CurrentUser, req.CredentialID, 0, req.MirrorSourceID, req.SourceUrl)
+	if err != nil {
+		return nil, err
+	}
 	pushAccessToken, err := c.tokenStore.GetUserGitToken(ctx, req.CurrentUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find access token, error: %w", err)
 	}
+	mirror.NextExecutionTimestamp, err = nextMirrorRefresh(req.Interval, time.Now())
+	if err != nil {
+		return nil, err
+	}
 	mirror.Interval = req.Interval
 	mirror.SourceUrl = req.SourceUrl
 	mirror.MirrorSourceID = req.MirrorSourceID
 	mirror.Username = req.Username
 	mirror.PushUrl = repo.HTTPCloneURL
 	mirror.AccessToken = req.AccessToken
+	mirror.CredentialID = req.CredentialID
 	mirror.SourceRepoPath = req.SourceRepoPath
 	mirror.LocalRepoPath = fmt.Sprintf("%s_%s_%s_%s", mirrorSource.SourceName, req.RepoType, req.Namespace, req.Name)
 	mirror.RepositoryID = repo.ID
+	username, accessToken, err := c.credentials.Resolve(ctx, &mirror, mirror.SourceUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mirror credential, error: %w", err)
+	}
 
 	if c.config.Saas {
 		if c.config.GitServer.Type == types.GitServerTypeGitea {
@@ -1434,8 +1713,8 @@ func (c *RepoComponent) CreateMirror(ctx context.Context, req types.CreateMirror
 				Namespace:   "root",
 				Name:        mirror.LocalRepoPath,
 				CloneUrl:    mirror.SourceUrl,
-				Username:    mirror.Username,
-				AccessToken: mirror.AccessToken,
+				Username:    username,
+				AccessToken: accessToken,
 				Private:     false,
 			})
 			if err != nil {
@@ -1448,8 +1727,8 @@ func (c *RepoComponent) CreateMirror(ctx context.Context, req types.CreateMirror
 				Namespace:   req.Namespace,
 				Name:        req.Name,
 				CloneUrl:    mirror.SourceUrl,
-				Username:    mirror.Username,
-				AccessToken: mirror.AccessToken,
+				Username:    username,
+				AccessToken: accessToken,
 				RepoType:    req.RepoType,
 			})
 			if err != nil {
@@ -1631,18 +1910,29 @@ func (c *RepoComponent) UpdateMirror(ctx context.Context, req types.UpdateMirror
 	if err != nil {
 		return nil, fmt.Errorf("failed to get mirror source, err: %w, id: %d", err, req.MirrorSourceID)
 	}
+	err = c.checkMirrorCredential(ctx, req.CurrentUser, req.CredentialID, mirror.CredentialID, req.MirrorSourceID, req.SourceUrl)
+	if err != nil {
+		return nil, err
+	}
 
 	pushAccessToken, err := c.tokenStore.GetUserGitToken(ctx, req.CurrentUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find access token, error: %w", err)
 	}
 
+	if req.Interval != mirror.Interval {
+		mirror.NextExecutionTimestamp, err = nextMirrorRefresh(req.Interval, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
 	mirror.Interval = req.Interval
 	mirror.SourceUrl = req.SourceUrl
 	mirror.MirrorSourceID = req.MirrorSourceID
 	mirror.Username = req.Username
 	mirror.PushUrl = req.PushUrl
 	mirror.AccessToken = req.AccessToken
+	mirror.CredentialID = req.CredentialID
 	mirror.PushUsername = req.CurrentUser
 	mirror.PushAccessToken = pushAccessToken.Token
 	mirror.SourceRepoPath = req.SourceRepoPath
@@ -1654,6 +1944,36 @@ func (c *RepoComponent) UpdateMirror(ctx context.Context, req types.UpdateMirror
 	return mirror, nil
 }
 
+// checkMirrorCredential checks the credential profile of a mirror belongs to the mirror source of the mirror and is
+// allowed for its source URL. Only admins and the allowed users of the profile may set it, the profile the mirror
+// has already is kept for other users
+func (c *RepoComponent) checkMirrorCredential(ctx context.Context, currentUser string, credentialID, currentCredentialID, mirrorSourceID int64, sourceURL string) error {
+	if credentialID == 0 {
+		return nil
+	}
+	mc, err := c.mirrorCredential.FindByID(ctx, credentialID)
+	if err != nil {
+		return fmt.Errorf("failed to find mirror credential, error: %w", err)
+	}
+	if mc == nil || mc.MirrorSourceID != mirrorSourceID {
+		return fmt.Errorf("mirror credential %d does not belong to mirror source %d", credentialID, mirrorSourceID)
+	}
+	if !credential.AllowsURL(mc, sourceURL) {
+		return fmt.Errorf("mirror credential %d is scoped to %s, not the source URL of the mirror: %w", credentialID, mc.AllowedURLPrefix, credential.ErrURLNotAllowed)
+	}
+	if credentialID == currentCredentialID || slices.Contains(mc.AllowedUsers, currentUser) {
+		return nil
+	}
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return fmt.Errorf("failed to find user, error: %w", err)
+	}
+	if !user.CanAdmin() {
+		return fmt.Errorf("user %s is not allowed to use mirror credential %d: %w", currentUser, credentialID, ErrUnauthorized)
+	}
+	return nil
+}
+
 func (c *RepoComponent) DeleteMirror(ctx context.Context, req types.DeleteMirrorReq) error {
 	admin, err := c.checkCurrentUserPermission(ctx, req.CurrentUser, req.Namespace, membership.RoleAdmin)
 	if err != nil {
@@ -1984,6 +2304,7 @@ func deployStatusCodeToString(code int) string {
 	// DeployRunTimeError = 23
 	// DeployStopped      = 26
 	// DeployRunDeleted   = 27 // end user trigger delete action for deploy
+	// DeployCancelled    = 28 // end user cancel the build or deploy task
 
 	// simplified status for frontend show
 	var txt string
@@ -2012,6 +2333,8 @@ func deployStatusCodeToString(code int) string {
 		txt = SpaceStatusStopped
 	case 27:
 		txt = RepoStatusDeleted
+	case 28:
+		txt = SpaceStatusCancelled
 	default:
 		txt = SpaceStatusStopped
 	}
@@ -2172,6 +2495,30 @@ func (c *RepoComponent) DeployStop(ctx context.Context, stopReq types.DeployActR
 	return err
 }
 
+// DeployCancel cancels the unfinished build or deploy task of a deploy, the repo admin permission is checked by caller
+func (c *RepoComponent) DeployCancel(ctx context.Context, cancelReq types.DeployActReq) error {
+	repo, err := c.repo.FindByPath(ctx, cancelReq.RepoType, cancelReq.Namespace, cancelReq.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	deploy, err := c.deploy.GetDeployByID(ctx, cancelReq.DeployID)
+	if err != nil {
+		return fmt.Errorf("fail to get deploy %v, %w", cancelReq.DeployID, err)
+	}
+	if deploy.RepoID != repo.ID {
+		return errors.New("found incorrect repo")
+	}
+	return c.deployer.Cancel(ctx, types.DeployRepo{
+		DeployID:  deploy.ID,
+		SpaceID:   deploy.SpaceID,
+		ModelID:   deploy.ModelID,
+		Namespace: cancelReq.Namespace,
+		Name:      cancelReq.Name,
+		SvcName:   deploy.SvcName,
+		ClusterID: deploy.ClusterID,
+	})
+}
+
 func (c *RepoComponent) AllowReadAccessByDeployID(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string, deployID int64) (bool, error) {
 	user, err := c.user.FindByUsername(ctx, currentUser)
 	if err != nil {
@@ -2219,6 +2566,11 @@ func (c *RepoComponent) DeployStatus(ctx context.Context, repoType types.Reposit
 	return srvName, deployStatusCodeToString(code), instances, nil
 }
 
+// DeployQueuePosition returns the position of the deploy in the deploy queue, 0 if it is not waiting
+func (c *RepoComponent) DeployQueuePosition(deployID int64) int {
+	return c.deployer.QueuePosition(deployID)
+}
+
 func (c *RepoComponent) GetDeployBySvcName(ctx context.Context, svcName string) (*database.Deploy, error) {
 	d, err := c.deploy.GetDeployBySvcName(ctx, svcName)
 	if err != nil {
@@ -2339,6 +2691,25 @@ func (c *RepoComponent) DeployUpdate(ctx context.Context, updateReq types.Deploy
 		}
 	}
 
+	if req.Autoscaling != nil && deploy.Type == types.SpaceType {
+		return errors.New("autoscaling policy is only supported by inference endpoints")
+	}
+	// a new min replica has to fit the current policy too
+	policy := req.Autoscaling
+	if policy == nil {
+		policy = deploy.Autoscaling
+	}
+	minReplica := deploy.MinReplica
+	if req.MinReplica != nil {
+		minReplica = *req.MinReplica
+	}
+	if policy != nil {
+		err = policy.Validate(minReplica)
+		if err != nil {
+			return fmt.Errorf("invalid autoscaling policy, %w", err)
+		}
+	}
+
 	// check service
 	deployRepo := types.DeployRepo{
 		DeployID:  updateReq.DeployID,
@@ -2366,13 +2737,14 @@ func (c *RepoComponent) DeployUpdate(ctx context.Context, updateReq types.Deploy
 
 func (c *RepoComponent) DeployStart(ctx context.Context, startReq types.DeployActReq) error {
 	var (
+		user   *database.User   = nil
 		deploy *database.Deploy = nil
 		err    error            = nil
 	)
 	if startReq.DeployType == types.ServerlessType {
-		_, deploy, err = c.checkDeployPermissionForServerless(ctx, startReq)
+		user, deploy, err = c.checkDeployPermissionForServerless(ctx, startReq)
 	} else {
-		_, deploy, err = c.checkDeployPermissionForUser(ctx, startReq)
+		user, deploy, err = c.checkDeployPermissionForUser(ctx, startReq)
 	}
 
 	if err != nil {
@@ -2399,12 +2771,22 @@ func (c *RepoComponent) DeployStart(ctx context.Context, startReq types.DeployAc
 	}
 
 	// start deploy
-	err = c.deployer.StartDeploy(ctx, deploy)
-	if err != nil {
-		return fmt.Errorf("fail to start deploy, %w", err)
-	}
-
-	return err
+	return c.quota.DeployWithinQuota(ctx, deployQuotaReq{
+		User:       user,
+		Namespace:  startReq.Namespace,
+		DeployType: deploy.Type,
+		Hardware:   deploy.Hardware,
+		Replicas:   deploy.MaxReplica,
+		Replaces: func(d *database.Deploy) bool {
+			return d.ID == deploy.ID
+		},
+	}, func() error {
+		err := c.deployer.StartDeploy(ctx, deploy)
+		if err != nil {
+			return fmt.Errorf("fail to start deploy, %w", err)
+		}
+		return nil
+	})
 }
 
 func (c *RepoComponent) AllFiles(ctx context.Context, req types.GetAllFilesReq) ([]*types.File, error) {
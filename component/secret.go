@@ -0,0 +1,172 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"opencsg.com/csghub-server/builder/git/membership"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+// secrets are injected as env variables, so the name must be a valid variable name
+var secretNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (c *RepoComponent) ListSecrets(ctx context.Context, req types.SecretActReq) ([]types.Secret, error) {
+	if req.RepoName == "" {
+		if err := c.checkNamespaceSecretPermission(ctx, req.CurrentUser, req.Namespace); err != nil {
+			return nil, err
+		}
+		secrets, err := c.secret.FindByNamespace(ctx, req.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find namespace secrets, error: %w", err)
+		}
+		return toSecretResponses(secrets, types.SecretScopeNamespace), nil
+	}
+
+	repo, err := c.findRepoForSecret(ctx, req.CurrentUser, req.RepoType, req.Namespace, req.RepoName)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := c.secret.FindByRepoID(ctx, repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo secrets, error: %w", err)
+	}
+	return toSecretResponses(secrets, types.SecretScopeRepo), nil
+}
+
+// SetSecret creates or rotates a secret, the running deploys using it are redeployed with the new value
+func (c *RepoComponent) SetSecret(ctx context.Context, req *types.SetSecretReq) error {
+	if !secretNameRegexp.MatchString(req.Name) {
+		return fmt.Errorf("invalid secret name %s, only letters, digits and underscores are allowed", req.Name)
+	}
+	if types.IsReservedSecretName(req.Name) {
+		return fmt.Errorf("invalid secret name %s, it is set by the deploy itself", req.Name)
+	}
+	if c.config.Secret.EncryptionKey == "" {
+		return errors.New("secrets are not enabled as the encryption key is not configured")
+	}
+
+	secret := &database.Secret{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+	}
+	if req.RepoName == "" {
+		if err := c.checkNamespaceSecretPermission(ctx, req.CurrentUser, req.Namespace); err != nil {
+			return err
+		}
+	} else {
+		repo, err := c.findRepoForSecret(ctx, req.CurrentUser, req.RepoType, req.Namespace, req.RepoName)
+		if err != nil {
+			return err
+		}
+		secret.RepositoryID = repo.ID
+	}
+
+	encrypted, err := common.EncryptString(c.config.Secret.EncryptionKey, req.Value)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret, error: %w", err)
+	}
+	secret.Value = encrypted
+	if err := c.secret.Upsert(ctx, secret); err != nil {
+		return fmt.Errorf("failed to save secret, error: %w", err)
+	}
+
+	c.redeployWithSecrets(ctx, req.Namespace, secret.RepositoryID)
+	return nil
+}
+
+func (c *RepoComponent) DeleteSecret(ctx context.Context, req types.SecretActReq) error {
+	var repoID int64
+	if req.RepoName == "" {
+		if err := c.checkNamespaceSecretPermission(ctx, req.CurrentUser, req.Namespace); err != nil {
+			return err
+		}
+	} else {
+		repo, err := c.findRepoForSecret(ctx, req.CurrentUser, req.RepoType, req.Namespace, req.RepoName)
+		if err != nil {
+			return err
+		}
+		repoID = repo.ID
+	}
+
+	if err := c.secret.Delete(ctx, req.Namespace, repoID, req.Name); err != nil {
+		return fmt.Errorf("failed to delete secret %s, error: %w", req.Name, err)
+	}
+
+	c.redeployWithSecrets(ctx, req.Namespace, repoID)
+	return nil
+}
+
+// findRepoForSecret returns the repo if the user is admin of it, secrets are managed by admins only
+func (c *RepoComponent) findRepoForSecret(ctx context.Context, currentUser string, repoType types.RepositoryType, namespace, name string) (*database.Repository, error) {
+	repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, currentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanAdmin {
+		return nil, ErrUnauthorized
+	}
+	return repo, nil
+}
+
+func (c *RepoComponent) checkNamespaceSecretPermission(ctx context.Context, currentUser, namespace string) error {
+	if currentUser == "" {
+		return ErrUnauthorized
+	}
+	canAdmin, err := c.checkCurrentUserPermission(ctx, currentUser, namespace, membership.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to check namespace permission, error: %w", err)
+	}
+	if !canAdmin {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// redeployWithSecrets restarts the active deploys of the repo, or of all repos of the namespace
+// for a namespace secret, so they pick up the changed secrets
+func (c *RepoComponent) redeployWithSecrets(ctx context.Context, namespace string, repoID int64) {
+	var (
+		deploys []database.Deploy
+		err     error
+	)
+	if repoID > 0 {
+		deploys, err = c.deploy.ListActiveDeploysByRepoID(ctx, repoID)
+	} else {
+		deploys, err = c.deploy.ListActiveDeploysByNamespace(ctx, namespace)
+	}
+	if err != nil {
+		slog.Error("failed to list deploys to redeploy with secrets", slog.String("namespace", namespace), slog.Int64("repo_id", repoID), slog.Any("error", err))
+		return
+	}
+	// the deploys are running already, so they are restarted even if the balance is used up
+	for i := range deploys {
+		err = c.deployer.RestartDeploy(ctx, &deploys[i])
+		if err != nil {
+			slog.Error("failed to redeploy with secrets", slog.Int64("deploy_id", deploys[i].ID), slog.Any("error", err))
+			continue
+		}
+		slog.Info("redeploy with changed secrets", slog.Int64("deploy_id", deploys[i].ID), slog.String("namespace", namespace))
+	}
+}
+
+func toSecretResponses(secrets []database.Secret, scope string) []types.Secret {
+	resp := make([]types.Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		resp = append(resp, types.Secret{
+			Name:      secret.Name,
+			Scope:     scope,
+			UpdatedAt: secret.UpdatedAt,
+		})
+	}
+	return resp
+}
@@ -260,6 +260,7 @@ func (c *SpaceComponent) Show(ctx context.Context, namespace, name, currentUser
 		CanWrite:      permission.CanWrite,
 		CanManage:     permission.CanAdmin,
 		Namespace:     ns,
+		ForkedFrom:    c.forkedFrom(ctx, space.Repository.ID, currentUser),
 	}
 
 	return resModel, nil
@@ -557,27 +558,43 @@ func (c *SpaceComponent) Deploy(ctx context.Context, namespace, name, currentUse
 	}
 	slog.Info("run space with container image", slog.Any("namespace", namespace), slog.Any("name", name), slog.Any("containerImg", containerImg))
 
-	// create deploy for space
-	return c.deployer.Deploy(ctx, types.DeployRepo{
-		SpaceID:    s.ID,
-		Path:       s.Repository.Path,
-		GitPath:    s.Repository.GitPath,
-		GitBranch:  s.Repository.DefaultBranch,
-		Sdk:        s.Sdk,
-		SdkVersion: s.SdkVersion,
-		Template:   s.Template,
-		Env:        s.Env,
+	// create deploy for space, a new deploy of the space replaces the old ones
+	var deployID int64
+	err = c.quota.DeployWithinQuota(ctx, deployQuotaReq{
+		User:       &user,
+		Namespace:  namespace,
+		DeployType: types.SpaceType,
 		Hardware:   s.Hardware,
-		Secret:     s.Secrets,
-		RepoID:     s.Repository.ID,
-		ModelID:    0,
-		UserID:     user.ID,
-		Annotation: string(annoStr),
-		ImageID:    containerImg,
-		Type:       types.SpaceType,
-		UserUUID:   user.UUID,
-		SKU:        s.SKU,
+		Replaces: func(d *database.Deploy) bool {
+			return d.SpaceID == s.ID
+		},
+	}, func() error {
+		deployID, err = c.deployer.Deploy(ctx, types.DeployRepo{
+			SpaceID:    s.ID,
+			Path:       s.Repository.Path,
+			GitPath:    s.Repository.GitPath,
+			GitBranch:  s.Repository.DefaultBranch,
+			Sdk:        s.Sdk,
+			SdkVersion: s.SdkVersion,
+			Template:   s.Template,
+			Env:        s.Env,
+			Hardware:   s.Hardware,
+			Secret:     s.Secrets,
+			RepoID:     s.Repository.ID,
+			ModelID:    0,
+			UserID:     user.ID,
+			Annotation: string(annoStr),
+			ImageID:    containerImg,
+			Type:       types.SpaceType,
+			UserUUID:   user.UUID,
+			SKU:        s.SKU,
+		})
+		return err
 	})
+	if err != nil {
+		return -1, err
+	}
+	return deployID, nil
 }
 
 func (c *SpaceComponent) Wakeup(ctx context.Context, namespace, name string) error {
@@ -771,6 +788,7 @@ const (
 	SpaceStatusRuntimeError = "RuntimeError"
 	SpaceStatusStopped      = "Stopped"
 	SpaceStatusSleeping     = "Sleeping"
+	SpaceStatusCancelled    = "Cancelled"
 
 	SpaceStatusNoAppFile   = "NoAppFile"
 	RepoStatusDeleted      = "Deleted"
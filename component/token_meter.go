@@ -0,0 +1,66 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"opencsg.com/csghub-server/builder/event"
+	"opencsg.com/csghub-server/builder/proxy"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type TokenMeterComponent struct {
+	user     *database.UserStore
+	eventPub *event.EventPublisher
+}
+
+func NewTokenMeterComponent(config *config.Config) (*TokenMeterComponent, error) {
+	c := &TokenMeterComponent{}
+	c.user = database.NewUserStore()
+	c.eventPub = &event.DefaultEventPublisher
+	return c, nil
+}
+
+// tokenUsageExtra is the extra of token metering events, the value of the event is the total tokens
+type tokenUsageExtra struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+// PublishTokenUsage publishes a metering event of the tokens used by the user calling the endpoint of the deploy
+func (c *TokenMeterComponent) PublishTokenUsage(ctx context.Context, currentUser string, deploy *database.Deploy, usage proxy.TokenUsage) error {
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return fmt.Errorf("failed to find user %s, error: %w", currentUser, err)
+	}
+	extra, err := json.Marshal(tokenUsageExtra{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal token usage, error: %w", err)
+	}
+	meterEvent := types.METERING_EVENT{
+		Uuid:         uuid.New(),
+		UserUUID:     user.UUID,
+		Value:        usage.TotalTokens,
+		ValueType:    types.TokenNumberType,
+		Scene:        int(types.SceneModelInference),
+		OpUID:        "",
+		ResourceID:   deploy.SKU,
+		ResourceName: deploy.DeployName,
+		CustomerID:   deploy.SvcName,
+		CreatedAt:    time.Now(),
+		Extra:        string(extra),
+	}
+	str, err := json.Marshal(meterEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metering event, error: %w", err)
+	}
+	return c.eventPub.PublishTokenMeteringEvent(str)
+}
@@ -0,0 +1,109 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+// usernames of basic auth with tokens, the hosts only check the token
+var tokenUsernames = map[types.MirrorCredentialType]string{
+	types.HFTokenCredential:   "hf_user",
+	types.GitHubPATCredential: "x-access-token",
+}
+
+// ErrURLNotAllowed is returned if a credential profile would be sent to a URL out of its allowed URL prefix
+var ErrURLNotAllowed = errors.New("mirror credential is not allowed for the URL")
+
+// ValidateURLPrefix checks the allowed URL prefix of a credential profile is an http(s) URL with a host,
+// like https://huggingface.co or https://github.com/org
+func ValidateURLPrefix(prefix string) error {
+	u, err := url.Parse(prefix)
+	if err != nil {
+		return fmt.Errorf("invalid allowed URL prefix %s: %w", prefix, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid allowed URL prefix %s, expected an http(s) URL with a host and an optional path", prefix)
+	}
+	return nil
+}
+
+// AllowsURL reports whether the credential profile may be sent to the URL, which must have the scheme and host of the
+// allowed URL prefix of the profile and a path under its path. Profiles without an allowed URL prefix are never sent
+func AllowsURL(credential *database.MirrorCredential, rawURL string) bool {
+	if ValidateURLPrefix(credential.AllowedURLPrefix) != nil {
+		return false
+	}
+	prefix, _ := url.Parse(credential.AllowedURLPrefix)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User != nil {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, prefix.Scheme) || !strings.EqualFold(u.Host, prefix.Host) {
+		return false
+	}
+	prefixPath := strings.TrimSuffix(prefix.Path, "/")
+	return prefixPath == "" || u.Path == prefixPath || strings.HasPrefix(u.Path, prefixPath+"/")
+}
+
+// Resolver finds the credential to access the upstream of mirrors
+type Resolver struct {
+	store         *database.MirrorCredentialStore
+	encryptionKey string
+}
+
+func NewResolver(config *config.Config) *Resolver {
+	return &Resolver{
+		store:         database.NewMirrorCredentialStore(),
+		encryptionKey: config.Secret.EncryptionKey,
+	}
+}
+
+// Resolve returns the basic auth to send to the target URL of the upstream of the mirror, which is empty for public
+// upstreams. The credential profile of the mirror comes first, then the username and access token of the mirror, then
+// the default credential profile of the mirror source. A profile is only returned if it allows the target URL
+func (r *Resolver) Resolve(ctx context.Context, mirror *database.Mirror, targetURL string) (username, token string, err error) {
+	var credential *database.MirrorCredential
+	if mirror.CredentialID != 0 {
+		credential, err = r.store.FindByID(ctx, mirror.CredentialID)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to find mirror credential %d: %w", mirror.CredentialID, err)
+		}
+	}
+	if credential == nil && mirror.AccessToken != "" {
+		return mirror.Username, mirror.AccessToken, nil
+	}
+	if credential == nil {
+		credential, err = r.store.FindDefault(ctx, mirror.MirrorSourceID)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to find default credential of mirror source %d: %w", mirror.MirrorSourceID, err)
+		}
+	}
+	if credential == nil {
+		return "", "", nil
+	}
+	if !AllowsURL(credential, targetURL) {
+		return "", "", fmt.Errorf("mirror credential %s is scoped to %s: %w", credential.Name, credential.AllowedURLPrefix, ErrURLNotAllowed)
+	}
+	return r.Decrypt(credential)
+}
+
+// Decrypt returns the basic auth of the credential profile
+func (r *Resolver) Decrypt(credential *database.MirrorCredential) (username, token string, err error) {
+	token, err = common.DecryptString(r.encryptionKey, credential.Secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt mirror credential %s: %w", credential.Name, err)
+	}
+	username = credential.Username
+	if username == "" {
+		username = tokenUsernames[credential.Type]
+	}
+	return username, token, nil
+}
@@ -3,31 +3,51 @@ package lfssyncer
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/builder/store/s3"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mirror/credential"
 	"opencsg.com/csghub-server/mirror/queue"
 )
 
+// lfsPartSize is the size of the parts LFS files are uploaded in, a failed upload resumes from the last uploaded part.
+// Objects up to 640 GB fit in the 10000 parts allowed by S3
+const lfsPartSize = 64 << 20
+
+// lfsOidMetadata is the user metadata of the uploaded objects recording their oid
+const lfsOidMetadata = "Lfs-Oid"
+
+// errLFSUploadGone is returned if the multipart upload of an object was aborted or expired in the bucket
+var errLFSUploadGone = errors.New("multipart upload of LFS file is gone")
+
 type MinioLFSSyncWorker struct {
 	mq                 *queue.PriorityQueue
 	tasks              chan queue.MirrorTask
 	wg                 sync.WaitGroup
 	mirrorStore        *database.MirrorStore
 	lfsMetaObjectStore *database.LfsMetaObjectStore
-	s3Client           *minio.Client
-	config             *config.Config
-	numWorkers         int
+	// per object progress of LFS files being uploaded
+	lfsSyncProgressStore *database.LfsSyncProgressStore
+	credentials          *credential.Resolver
+	s3Client             *minio.Client
+	config               *config.Config
+	numWorkers           int
 }
 
 func NewMinioLFSSyncWorker(config *config.Config, numWorkers int) (*MinioLFSSyncWorker, error) {
@@ -42,6 +62,8 @@ func NewMinioLFSSyncWorker(config *config.Config, numWorkers int) (*MinioLFSSync
 	}
 	w.mirrorStore = database.NewMirrorStore()
 	w.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
+	w.lfsSyncProgressStore = database.NewLfsSyncProgressStore()
+	w.credentials = credential.NewResolver(config)
 	w.config = config
 	mq, err := queue.GetPriorityQueueInstance()
 	if err != nil {
@@ -86,11 +108,37 @@ func (w *MinioLFSSyncWorker) worker(id int) {
 		err := w.SyncLfs(ctx, id, task.MirrorID)
 		if err != nil {
 			slog.Error("fail to sync lfs", slog.Int("workerId", id), slog.String("error", err.Error()))
+			w.retry(ctx, task, err)
 			continue
 		}
 	}
 }
 
+// retry pushes the failed task back to the queue after a backoff, the mirror fails once the task runs out of retries
+func (w *MinioLFSSyncWorker) retry(ctx context.Context, task queue.MirrorTask, taskErr error) {
+	status := types.MirrorWaiting
+	deadLettered, err := w.mq.LfsMirrorQueue.Retry(ctx, &task, taskErr)
+	if err != nil {
+		slog.Error("fail to retry lfs sync", slog.Int64("mirrorId", task.MirrorID), slog.String("error", err.Error()))
+		status = types.MirrorFailed
+	}
+	if deadLettered {
+		slog.Warn("lfs sync task is dead-lettered", slog.Int64("mirrorId", task.MirrorID), slog.Int("retries", task.Retries))
+		status = types.MirrorFailed
+	}
+	mirror, err := w.mirrorStore.FindByID(ctx, task.MirrorID)
+	if err != nil {
+		slog.Error("fail to get mirror", slog.Int64("mirrorId", task.MirrorID), slog.String("error", err.Error()))
+		return
+	}
+	mirror.Status = status
+	mirror.LastMessage = taskErr.Error()
+	err = w.mirrorStore.Update(ctx, mirror)
+	if err != nil {
+		slog.Error("fail to update mirror status", slog.Int64("mirrorId", task.MirrorID), slog.String("error", err.Error()))
+	}
+}
+
 func (w *MinioLFSSyncWorker) SyncLfs(ctx context.Context, workerId int, mirrorID int64) error {
 	var pointers []*types.Pointer
 	mirror, err := w.mirrorStore.FindByID(ctx, mirrorID)
@@ -104,15 +152,21 @@ func (w *MinioLFSSyncWorker) SyncLfs(ctx context.Context, workerId int, mirrorID
 		return fmt.Errorf("fail to get lfs meta objects: %w", err)
 	}
 	for _, lfsMetaObject := range lfsMetaObjects {
+		// objects recorded before the last complete LFS sync are in the bucket already
+		if lfsMetaObject.UpdatedAt.Before(mirror.LfsSyncedAt) {
+			continue
+		}
 		pointers = append(pointers, &types.Pointer{
 			Oid:  lfsMetaObject.Oid,
 			Size: lfsMetaObject.Size,
 		})
 	}
 
-	pointers, err = w.GetLFSDownloadURLs(ctx, mirror, pointers)
-	if err != nil {
-		return fmt.Errorf("fail to get LFS download URL: %w", err)
+	if len(pointers) > 0 {
+		pointers, err = w.GetLFSDownloadURLs(ctx, mirror, pointers)
+		if err != nil {
+			return fmt.Errorf("fail to get LFS download URL: %w", err)
+		}
 	}
 	err = w.DownloadAndUploadLFSFiles(ctx, mirror, pointers)
 	if err != nil {
@@ -158,6 +212,17 @@ func (w *MinioLFSSyncWorker) GetLFSDownloadURLs(ctx context.Context, mirror *dat
 	req.Header.Set("Content-Type", "application/vnd.git-lfs+json; charset=utf-8")
 	req.Header.Set("User-Agent", "git-lfs/3.5.1")
 
+	username, accessToken, err := w.credentials.Resolve(ctx, mirror, lfsAPIURL)
+	if errors.Is(err, credential.ErrURLNotAllowed) {
+		return resPointers, queue.NonRetryable(fmt.Errorf("failed to resolve mirror credential: %w", err))
+	}
+	if err != nil {
+		return resPointers, fmt.Errorf("failed to resolve mirror credential: %w", err)
+	}
+	if accessToken != "" {
+		req.SetBasicAuth(username, accessToken)
+	}
+
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -166,7 +231,7 @@ func (w *MinioLFSSyncWorker) GetLFSDownloadURLs(ctx context.Context, mirror *dat
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return resPointers, fmt.Errorf("failed to get LFS download URL, status code: %d", resp.StatusCode)
+		return resPointers, queue.UpstreamStatusError(resp.StatusCode, fmt.Errorf("failed to get LFS download URL, status code: %d", resp.StatusCode))
 	}
 
 	var batchResponse types.LFSBatchResponse
@@ -183,6 +248,8 @@ func (w *MinioLFSSyncWorker) GetLFSDownloadURLs(ctx context.Context, mirror *dat
 			Oid:         object.Oid,
 			Size:        object.Size,
 			DownloadURL: object.Actions.Download.Href,
+			// private hosts authorize the download with these headers instead of a signed url
+			DownloadHeader: object.Actions.Download.Header,
 		})
 	}
 
@@ -190,19 +257,44 @@ func (w *MinioLFSSyncWorker) GetLFSDownloadURLs(ctx context.Context, mirror *dat
 }
 
 func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFiles(ctx context.Context, mirror *database.Mirror, pointers []*types.Pointer) error {
-	var finishedLFSFileCount int
-	lfsFilesCount := len(pointers)
+	var totalBytes, finishedBytes int64
+	for _, pointer := range pointers {
+		totalBytes += pointer.Size
+	}
+	// progress of the mirror is the share of bytes uploaded
+	updateProgress := func(uploadedBytes int64) error {
+		if totalBytes == 0 {
+			mirror.Progress = 100
+		} else {
+			mirror.Progress = int8((finishedBytes + uploadedBytes) * 100 / totalBytes)
+		}
+		err := w.mirrorStore.Update(ctx, mirror)
+		if err != nil {
+			return fmt.Errorf("failed to update mirror progress: %w", err)
+		}
+		return nil
+	}
+
+	if len(pointers) == 0 {
+		mirror.Progress = 100
+	}
+	var failedCount int
 	for _, pointer := range pointers {
 		objectKey := filepath.Join("lfs", pointer.RelativePath())
-		fileInfo, err := w.s3Client.StatObject(ctx, w.config.S3.Bucket, objectKey, minio.StatObjectOptions{})
-		if err != nil && err.Error() != "The specified key does not exist." {
-			slog.Error("failed to check if LFS file exists", slog.Any("error", err))
+		exists, err := w.lfsObjectExists(ctx, objectKey, pointer)
+		if err != nil {
+			slog.Error("failed to check if LFS file exists", slog.Any("objectKey", objectKey), slog.Any("error", err))
+			failedCount++
 			continue
 		}
-		if (err != nil && err.Error() != "The specified key does not exist.") || fileInfo.Size != pointer.Size {
-			err = w.DownloadAndUploadLFSFile(ctx, mirror, pointer)
+		if exists {
+			slog.Info("skip LFS file uploaded before", slog.Any("objectKey", objectKey))
+		} else {
+			err = w.DownloadAndUploadLFSFile(ctx, mirror, pointer, updateProgress)
 			if err != nil {
-				slog.Error("failed to download and upload LFS file", slog.Any("error", err))
+				slog.Error("failed to download and upload LFS file", slog.Any("objectKey", objectKey), slog.Any("error", err))
+				failedCount++
+				continue
 			}
 		}
 
@@ -218,59 +310,237 @@ func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFiles(ctx context.Context, mirr
 			return fmt.Errorf("failed to update or create LFS meta object: %w", err)
 		}
 		slog.Info("finish to download and upload LFS file", slog.Any("objectKey", objectKey))
-		finishedLFSFileCount += 1
-		mirror.Progress = int8(finishedLFSFileCount * 100 / lfsFilesCount)
-		err = w.mirrorStore.Update(ctx, mirror)
+		finishedBytes += pointer.Size
+		err = updateProgress(0)
 		if err != nil {
-			return fmt.Errorf("failed to update mirror progress: %w", err)
+			return err
 		}
 	}
+	// objects failed to sync are resumed in the next sync
 	mirror.Status = types.MirrorFinished
+	if failedCount > 0 {
+		mirror.Status = types.MirrorIncomplete
+	} else {
+		mirror.LfsSyncedAt = time.Now()
+	}
 	err := w.mirrorStore.Update(ctx, mirror)
 	if err != nil {
 		return fmt.Errorf("failed to update mirror status: %w", err)
 	}
+	if failedCount > 0 {
+		return fmt.Errorf("failed to sync %d of %d LFS files", failedCount, len(pointers))
+	}
 	return nil
 }
 
-func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFile(ctx context.Context, mirror *database.Mirror, pointer *types.Pointer) error {
+// lfsObjectExists checks if the object is already in the bucket with the size and oid of the pointer. The oid is
+// recorded in the metadata of the objects uploaded by the syncer, other objects are hashed to verify it
+func (w *MinioLFSSyncWorker) lfsObjectExists(ctx context.Context, objectKey string, pointer *types.Pointer) (bool, error) {
+	fileInfo, err := w.s3Client.StatObject(ctx, w.config.S3.Bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, err
+	}
+	if fileInfo.Size != pointer.Size {
+		return false, nil
+	}
+	if oid, ok := fileInfo.UserMetadata[lfsOidMetadata]; ok {
+		return oid == pointer.Oid, nil
+	}
+	object, err := w.s3Client.GetObject(ctx, w.config.S3.Bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer object.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, object); err != nil {
+		return false, fmt.Errorf("failed to hash LFS file in bucket: %w", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)) == pointer.Oid, nil
+}
+
+// DownloadAndUploadLFSFile uploads the object in parts while downloading it, the uploaded parts are recorded so a failed
+// upload resumes from the last uploaded part with a range request. The object is committed only if its sha256 matches the oid.
+// If the recorded multipart upload is gone from the bucket, the object is uploaded again from the start
+func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFile(ctx context.Context, mirror *database.Mirror, pointer *types.Pointer, onProgress func(uploadedBytes int64) error) error {
+	if pointer.Size == 0 {
+		return w.uploadEmptyLFSFile(ctx, filepath.Join("lfs", pointer.RelativePath()), pointer)
+	}
+	err := w.uploadLFSFile(ctx, mirror, pointer, onProgress)
+	if errors.Is(err, errLFSUploadGone) {
+		slog.Warn("restart LFS file upload", slog.String("oid", pointer.Oid), slog.Any("error", err))
+		err = w.uploadLFSFile(ctx, mirror, pointer, onProgress)
+	}
+	return err
+}
+
+func (w *MinioLFSSyncWorker) uploadLFSFile(ctx context.Context, mirror *database.Mirror, pointer *types.Pointer, onProgress func(uploadedBytes int64) error) error {
 	objectKey := filepath.Join("lfs", pointer.RelativePath())
-	slog.Info("downloading LFS file from", slog.Any("url", pointer.DownloadURL))
+	core := minio.Core{Client: w.s3Client}
+
+	progress, err := w.lfsSyncProgressStore.Find(ctx, mirror.ID, pointer.Oid)
+	if err != nil {
+		return fmt.Errorf("failed to get sync progress of LFS file: %w", err)
+	}
+	hasher := sha256.New()
+	if progress != nil && progress.Size == pointer.Size {
+		if err := hasher.(encoding.BinaryUnmarshaler).UnmarshalBinary(progress.HashState); err != nil {
+			slog.Warn("restart LFS file upload with invalid hash state", slog.Any("objectKey", objectKey), slog.Any("error", err))
+			hasher.Reset()
+			progress.UploadedBytes = 0
+			progress.Parts = nil
+		}
+	} else {
+		if progress != nil {
+			// the object changed, start over
+			_ = core.AbortMultipartUpload(ctx, w.config.S3.Bucket, objectKey, progress.UploadID)
+			err = w.lfsSyncProgressStore.Delete(ctx, progress.ID)
+			if err != nil {
+				return fmt.Errorf("failed to delete outdated sync progress of LFS file: %w", err)
+			}
+		}
+		uploadID, err := core.NewMultipartUpload(ctx, w.config.S3.Bucket, objectKey, minio.PutObjectOptions{
+			UserMetadata: map[string]string{lfsOidMetadata: pointer.Oid},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start multipart upload: %w", err)
+		}
+		progress = &database.LfsSyncProgress{
+			MirrorID: mirror.ID,
+			Oid:      pointer.Oid,
+			Size:     pointer.Size,
+			UploadID: uploadID,
+		}
+		err = w.lfsSyncProgressStore.Create(ctx, progress)
+		if err != nil {
+			return err
+		}
+	}
+
+	if progress.UploadedBytes < progress.Size {
+		body, err := w.downloadLFSFile(ctx, pointer, progress.UploadedBytes)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		slog.Info("uploading LFS file", slog.Any("object_key", objectKey), slog.Int64("offset", progress.UploadedBytes))
+		for progress.UploadedBytes < progress.Size {
+			size := min(lfsPartSize, progress.Size-progress.UploadedBytes)
+			partNumber := len(progress.Parts) + 1
+			part, err := core.PutObjectPart(ctx, w.config.S3.Bucket, objectKey, progress.UploadID, partNumber,
+				io.TeeReader(io.LimitReader(body, size), hasher), size, minio.PutObjectPartOptions{})
+			if err != nil {
+				if minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+					return w.dropLFSUpload(ctx, progress, err)
+				}
+				return fmt.Errorf("failed to upload part %d to Minio: %w", partNumber, err)
+			}
+			state, err := hasher.(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("failed to save hash state: %w", err)
+			}
+			progress.Parts = append(progress.Parts, database.LfsSyncPartInfo{PartNumber: part.PartNumber, ETag: part.ETag})
+			progress.UploadedBytes += size
+			progress.HashState = state
+			err = w.lfsSyncProgressStore.Update(ctx, progress)
+			if err != nil {
+				return fmt.Errorf("failed to update sync progress of LFS file: %w", err)
+			}
+			if err := onProgress(progress.UploadedBytes); err != nil {
+				return err
+			}
+		}
+	}
+
+	oid := hex.EncodeToString(hasher.Sum(nil))
+	if oid != pointer.Oid {
+		// the uploaded parts are corrupted, start over in the next sync
+		_ = core.AbortMultipartUpload(ctx, w.config.S3.Bucket, objectKey, progress.UploadID)
+		if err := w.lfsSyncProgressStore.Delete(ctx, progress.ID); err != nil {
+			slog.Error("failed to delete sync progress of corrupted LFS file", slog.Any("objectKey", objectKey), slog.Any("error", err))
+		}
+		return fmt.Errorf("sha256 of downloaded LFS file %s does not match its oid %s", oid, pointer.Oid)
+	}
+	parts := make([]minio.CompletePart, 0, len(progress.Parts))
+	for _, part := range progress.Parts {
+		parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	_, err = core.CompleteMultipartUpload(ctx, w.config.S3.Bucket, objectKey, progress.UploadID, parts, minio.PutObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+			return w.dropLFSUpload(ctx, progress, err)
+		}
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return w.lfsSyncProgressStore.Delete(ctx, progress.ID)
+}
+
+// dropLFSUpload deletes the progress of a multipart upload which is gone from the bucket, like aborted by
+// a lifecycle rule, so the next upload of the object starts a new one
+func (w *MinioLFSSyncWorker) dropLFSUpload(ctx context.Context, progress *database.LfsSyncProgress, uploadErr error) error {
+	if err := w.lfsSyncProgressStore.Delete(ctx, progress.ID); err != nil {
+		return fmt.Errorf("failed to delete sync progress of gone multipart upload: %w", err)
+	}
+	return fmt.Errorf("%w: %w", errLFSUploadGone, uploadErr)
+}
 
-	req, err := http.NewRequest("GET", pointer.DownloadURL, nil)
+// downloadLFSFile downloads the object from the offset
+func (w *MinioLFSSyncWorker) downloadLFSFile(ctx context.Context, pointer *types.Pointer, offset int64) (io.ReadCloser, error) {
+	slog.Info("downloading LFS file from", slog.Any("url", pointer.DownloadURL), slog.Int64("offset", offset))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pointer.DownloadURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create downlaod request: %w", err)
+		return nil, fmt.Errorf("failed to create downlaod request: %w", err)
 	}
 
 	parsedURL, err := url.Parse(pointer.DownloadURL)
 	if err != nil {
-		return fmt.Errorf("failed to parse LFS API URL: %v", err)
+		return nil, fmt.Errorf("failed to parse LFS API URL: %v", err)
 	}
 
 	req.Header.Set("Host", parsedURL.Host)
 	req.Header.Set("Accept", "application/vnd.git-lfs+json")
 	req.Header.Set("Content-Type", "application/vnd.git-lfs+json; charset=utf-8")
 	req.Header.Set("User-Agent", "git-lfs/3.5.1")
+	for k, v := range pointer.DownloadHeader {
+		req.Header.Set(k, v)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to download LFS file: %w", err)
+		return nil, fmt.Errorf("failed to download LFS file: %w", err)
 	}
-	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		return resp.Body, nil
+	case resp.StatusCode == http.StatusOK:
+		// the source ignores the range, skip the bytes uploaded before
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to skip uploaded bytes of LFS file: %w", err)
+		}
+		return resp.Body, nil
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download LFS file: %s", resp.Status)
+	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download LFS file: %s", resp.Status)
+func (w *MinioLFSSyncWorker) uploadEmptyLFSFile(ctx context.Context, objectKey string, pointer *types.Pointer) error {
+	sum := sha256.Sum256(nil)
+	if oid := hex.EncodeToString(sum[:]); oid != pointer.Oid {
+		return fmt.Errorf("sha256 of empty LFS file %s does not match its oid %s", oid, pointer.Oid)
 	}
-	slog.Info("uploading LFS file", slog.Any("object_key", objectKey))
-	uploadInfo, err := w.s3Client.PutObject(ctx, w.config.S3.Bucket, objectKey, resp.Body, resp.ContentLength, minio.PutObjectOptions{})
+	_, err := w.s3Client.PutObject(ctx, w.config.S3.Bucket, objectKey, bytes.NewReader(nil), 0, minio.PutObjectOptions{
+		UserMetadata: map[string]string{lfsOidMetadata: pointer.Oid},
+	})
 	if err != nil {
 		return fmt.Errorf("failed to upload to Minio: %w", err)
 	}
-
-	if uploadInfo.Size != pointer.Size {
-		return fmt.Errorf("uploaded file size does not match expected size: %d != %d", uploadInfo.Size, pointer.Size)
-	}
-
 	return nil
 }
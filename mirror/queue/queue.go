@@ -2,8 +2,14 @@ package queue
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -32,20 +38,127 @@ var PriorityMap = map[types.MirrorPriority]Priority{
 const (
 	repoQueueName = "repo_mirror_queue"
 	lfsQueueName  = "lfs_mirror_queue"
+	// failed tasks wait in the delayed set of the queue until their backoff ends
+	delayedSuffix = "_delayed"
+	// tasks out of retries are parked in the dead-letter hash of the queue, keyed by mirror id
+	deadLetterSuffix = "_dead_letter"
+	// the bands hold the mirror ids scored by the enqueue time, the tasks are in the task hash keyed by mirror id
+	tasksSuffix = "_tasks"
 )
 
+const (
+	// MaxRetries is the retry budget of a mirror task
+	MaxRetries     = 5
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = time.Hour
+	// wait time of Pop when the queue is empty
+	popInterval = time.Second
+)
+
+var ErrTaskNotFound = errors.New("mirror task not found")
+
+// ErrNonRetryable marks the errors a mirror task fails with on every retry, like the upstream rejecting the
+// credential or missing the repository, such tasks are dead-lettered at once
+var ErrNonRetryable = errors.New("mirror task can not succeed on retry")
+
+// NonRetryable marks the error of a task as not retryable
+func NonRetryable(err error) error {
+	return fmt.Errorf("%w: %w", ErrNonRetryable, err)
+}
+
+// UpstreamStatusError returns the error of a failed upstream response, rejected credentials and missing
+// repositories are not retryable
+func UpstreamStatusError(statusCode int, err error) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return NonRetryable(err)
+	}
+	return err
+}
+
+// retryable reports whether a retry of the failed task may succeed, tasks of deleted mirrors are not retried
+func retryable(err error) bool {
+	return !errors.Is(err, ErrNonRetryable) && !errors.Is(err, sql.ErrNoRows)
+}
+
 type MirrorTask struct {
 	MirrorID    int64    `json:"mirror_id"`
 	Priority    Priority `json:"priority"`
 	CreatedAt   int64    `json:"created_at"`
 	MirrorToken string   `json:"mirror_token"`
+	// unix milli time the task was pushed to the queue, kept as the score of the task in its band
+	EnqueuedAt int64 `json:"-"`
+	// times the task failed
+	Retries   int    `json:"retries,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+	// unix time the task failed last
+	FailedAt int64 `json:"failed_at,omitempty"`
 }
 
+// MirrorQueue is a multi-level queue, each priority has a band of tasks in FIFO order. A task is popped from the
+// highest band unless a task of a lower band waited longer than the aging per band difference, so low priority
+// tasks are not starved
 type MirrorQueue struct {
 	redis     *cache.Cache
 	QueueName string
+	// waiting time making up a priority band
+	aging time.Duration
+}
+
+// bands from the highest priority
+var bands = []Priority{HighPriority, MediumPriority, LowPriority}
+
+var bandNames = map[Priority]string{
+	HighPriority:   "high",
+	MediumPriority: "medium",
+	LowPriority:    "low",
 }
 
+// pushScript queues the task of a mirror once, a task pushed again keeps its earliest enqueue time and moves to the
+// band of its latest priority. KEYS are the bands from the highest priority and the task hash, ARGV[1] is the band
+// of the task, ARGV[2] the mirror id, ARGV[3] the enqueue time and ARGV[4] the task
+var pushScript = redis.NewScript(`
+local band = tonumber(ARGV[1])
+local score = tonumber(ARGV[3])
+for i = 1, #KEYS - 1 do
+	local queued = redis.call("ZSCORE", KEYS[i], ARGV[2])
+	if queued then
+		score = math.min(score, tonumber(queued))
+		if i ~= band then
+			redis.call("ZREM", KEYS[i], ARGV[2])
+		end
+	end
+end
+redis.call("ZADD", KEYS[band], score, ARGV[2])
+redis.call("HSET", KEYS[#KEYS], ARGV[2], ARGV[4])
+return tostring(score)`)
+
+// popScript pops the task with the lowest effective score, which is the enqueue time minus the aging of its band.
+// KEYS are the bands from the highest priority and the task hash, ARGV[1] is the aging per band in milliseconds.
+// Returns the task and its enqueue time
+var popScript = redis.NewScript(`
+local best, bestScore
+for i = 1, #KEYS - 1 do
+	local head = redis.call("ZRANGE", KEYS[i], 0, 0, "WITHSCORES")
+	if head[1] then
+		local score = tonumber(head[2]) - (#KEYS - 1 - i) * tonumber(ARGV[1])
+		if not best or score < bestScore then
+			best = i
+			bestScore = score
+		end
+	end
+end
+if not best then
+	return false
+end
+local head = redis.call("ZPOPMIN", KEYS[best])
+local task = redis.call("HGET", KEYS[#KEYS], head[1])
+redis.call("HDEL", KEYS[#KEYS], head[1])
+if not task then
+	return false
+end
+return {task, head[2]}`)
+
 func (m *MirrorTask) MarshalBinary() ([]byte, error) {
 	return json.Marshal(m)
 }
@@ -54,26 +167,338 @@ func (m *MirrorTask) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, m)
 }
 
+// band returns the band of the priority, out of range priorities go to the closest band
+func band(p Priority) Priority {
+	return min(max(p, LowPriority), HighPriority)
+}
+
+func (mq *MirrorQueue) bandKey(p Priority) string {
+	return mq.QueueName + "_" + bandNames[band(p)]
+}
+
+// bandIndex returns the position of the band of the priority in the keys of the scripts, counting from 1
+func bandIndex(p Priority) int {
+	return int(HighPriority-band(p)) + 1
+}
+
+// scriptKeys returns the bands from the highest priority and the task hash
+func (mq *MirrorQueue) scriptKeys() []string {
+	keys := make([]string, 0, len(bands)+1)
+	for _, p := range bands {
+		keys = append(keys, mq.bandKey(p))
+	}
+	return append(keys, mq.QueueName+tasksSuffix)
+}
+
+// Push queues the task, a mirror has one task in the queue which keeps the place of the first push
 func (mq *MirrorQueue) Push(t *MirrorTask) {
+	mq.push(context.Background(), t, time.Now())
+}
+
+func (mq *MirrorQueue) push(ctx context.Context, t *MirrorTask, enqueuedAt time.Time) {
 	if t.CreatedAt == 0 {
 		t.CreatedAt = time.Now().Unix()
 	}
-	mq.redis.ZAdd(context.Background(), mq.QueueName, redis.Z{
-		Score:  float64(t.CreatedAt) * float64(t.Priority),
-		Member: t,
-	})
+	data, err := t.MarshalBinary()
+	if err != nil {
+		slog.Error("failed to marshal mirror task", slog.Int64("mirrorId", t.MirrorID), slog.Any("error", err))
+		return
+	}
+	r, err := mq.redis.RunScript(ctx, pushScript, mq.scriptKeys(),
+		bandIndex(t.Priority), strconv.FormatInt(t.MirrorID, 10), enqueuedAt.UnixMilli(), data)
+	if err != nil {
+		slog.Error("failed to push mirror task", slog.String("queue", mq.QueueName), slog.Int64("mirrorId", t.MirrorID), slog.Any("error", err))
+		return
+	}
+	score, _ := r.(string)
+	if ms, err := strconv.ParseFloat(score, 64); err == nil {
+		t.EnqueuedAt = int64(ms)
+	}
 }
 
+// Pop returns the next task, or nil if there is no task for a while
 func (mq *MirrorQueue) Pop() *MirrorTask {
-	r, err := mq.redis.BZPopMax(context.Background(), mq.QueueName)
+	ctx := context.Background()
+	mq.pushDelayed(ctx)
+	r, err := mq.redis.RunScript(ctx, popScript, mq.scriptKeys(), mq.aging.Milliseconds())
 	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to pop mirror task", slog.String("queue", mq.QueueName), slog.Any("error", err))
+		}
+		time.Sleep(popInterval)
+		return nil
+	}
+	values, ok := r.([]interface{})
+	if !ok || len(values) != 2 {
 		return nil
 	}
+	member, _ := values[0].(string)
 	var task MirrorTask
-	json.Unmarshal([]byte(r.Member.(string)), &task)
+	if err := task.UnmarshalBinary([]byte(member)); err != nil {
+		slog.Error("invalid mirror task", slog.String("task", member), slog.Any("error", err))
+		return nil
+	}
+	score, _ := values[1].(string)
+	if ms, err := strconv.ParseFloat(score, 64); err == nil {
+		task.EnqueuedAt = int64(ms)
+	}
 	return &task
 }
 
+// queuedTask is the place of a task in its band
+type queuedTask struct {
+	mirrorID int64
+	band     Priority
+	// score of the task, the unix milli time it was enqueued
+	enqueuedAt float64
+}
+
+// effectiveScore is the score the pop script compares tasks of different bands by
+func effectiveScore(t queuedTask, aging time.Duration) float64 {
+	return t.enqueuedAt - float64(band(t.band)-LowPriority)*float64(aging.Milliseconds())
+}
+
+// sortByPopOrder sorts the tasks of the bands in the order the pop script pops them, the tasks of a band are
+// in the order of the band, and higher bands go first on ties
+func sortByPopOrder(tasks []queuedTask, aging time.Duration) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		si, sj := effectiveScore(tasks[i], aging), effectiveScore(tasks[j], aging)
+		if si != sj {
+			return si < sj
+		}
+		return band(tasks[i].band) > band(tasks[j].band)
+	})
+}
+
+// Stats returns the number of tasks in each band, the delayed set and the dead-letter hash
+func (mq *MirrorQueue) Stats(ctx context.Context) (*types.MirrorQueueStats, error) {
+	stats := &types.MirrorQueueStats{}
+	for _, p := range bands {
+		depth, err := mq.redis.ZCard(ctx, mq.bandKey(p))
+		if err != nil {
+			return nil, err
+		}
+		stats.Bands = append(stats.Bands, types.MirrorQueueBand{
+			Name:     bandNames[p],
+			Priority: p.Int(),
+			Depth:    depth,
+		})
+		stats.Depth += depth
+	}
+	var err error
+	stats.Delayed, err = mq.redis.ZCard(ctx, mq.QueueName+delayedSuffix)
+	if err != nil {
+		return nil, err
+	}
+	stats.DeadLetters, err = mq.redis.HLen(ctx, mq.QueueName+deadLetterSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Position returns the position of the task of the mirror in the pop order, counting from 1,
+// returns ErrTaskNotFound if the mirror has no task queued
+func (mq *MirrorQueue) Position(ctx context.Context, mirrorID int64) (*types.MirrorQueuePosition, error) {
+	var tasks []queuedTask
+	for _, p := range bands {
+		zs, err := mq.redis.ZRangeWithScores(ctx, mq.bandKey(p))
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range zs {
+			member, _ := z.Member.(string)
+			id, err := strconv.ParseInt(member, 10, 64)
+			if err != nil {
+				continue
+			}
+			tasks = append(tasks, queuedTask{mirrorID: id, band: p, enqueuedAt: z.Score})
+		}
+	}
+	sortByPopOrder(tasks, mq.aging)
+	for i, q := range tasks {
+		if q.mirrorID != mirrorID {
+			continue
+		}
+		priority := q.band
+		value, err := mq.redis.HGet(ctx, mq.QueueName+tasksSuffix, strconv.FormatInt(mirrorID, 10))
+		if err == nil {
+			var task MirrorTask
+			if task.UnmarshalBinary([]byte(value)) == nil {
+				priority = task.Priority
+			}
+		}
+		return &types.MirrorQueuePosition{
+			MirrorID:   mirrorID,
+			Band:       bandNames[q.band],
+			Priority:   priority.Int(),
+			Position:   i + 1,
+			Depth:      len(tasks),
+			EnqueuedAt: time.UnixMilli(int64(q.enqueuedAt)),
+		}, nil
+	}
+	return nil, ErrTaskNotFound
+}
+
+// legacyEnqueuedAt is the enqueue time of a task of the single sorted set queue, which is the time the task was created
+func legacyEnqueuedAt(t *MirrorTask) time.Time {
+	if t.CreatedAt == 0 {
+		return time.Now()
+	}
+	return time.Unix(t.CreatedAt, 0)
+}
+
+// moveLegacyTasks moves the tasks of the single sorted set queue used before the bands into the bands, in the
+// place of the time they were created
+func (mq *MirrorQueue) moveLegacyTasks(ctx context.Context) error {
+	for {
+		z, err := mq.redis.ZPopMin(ctx, mq.QueueName)
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		member, _ := z.Member.(string)
+		var task MirrorTask
+		if err := task.UnmarshalBinary([]byte(member)); err != nil {
+			slog.Error("drop invalid legacy mirror task", slog.String("task", member), slog.Any("error", err))
+			continue
+		}
+		mq.push(ctx, &task, legacyEnqueuedAt(&task))
+	}
+}
+
+// Retry pushes the failed task back to the queue after an exponential backoff, the task is moved to the dead-letter
+// hash once it runs out of retries or failed with a non-retryable error
+func (mq *MirrorQueue) Retry(ctx context.Context, t *MirrorTask, taskErr error) (deadLettered bool, err error) {
+	if t.fail(taskErr) {
+		data, err := t.MarshalBinary()
+		if err != nil {
+			return false, err
+		}
+		err = mq.redis.HSet(ctx, mq.QueueName+deadLetterSuffix, strconv.FormatInt(t.MirrorID, 10), data)
+		if err != nil {
+			return false, fmt.Errorf("failed to add task to dead-letter hash: %w", err)
+		}
+		return true, nil
+	}
+	err = mq.redis.ZAdd(ctx, mq.QueueName+delayedSuffix, redis.Z{
+		Score:  float64(time.Now().Add(retryDelay(t.Retries)).Unix()),
+		Member: t,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to add task to delayed set: %w", err)
+	}
+	return false, nil
+}
+
+// fail records the failure of the task, and reports whether the task is out of retries or can not succeed on retry
+func (t *MirrorTask) fail(taskErr error) bool {
+	t.Retries++
+	t.LastError = taskErr.Error()
+	t.FailedAt = time.Now().Unix()
+	return t.Retries > MaxRetries || !retryable(taskErr)
+}
+
+// retryDelay doubles the delay of each retry
+func retryDelay(retries int) time.Duration {
+	delay := retryBaseDelay << (retries - 1)
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// pushDelayed moves the tasks whose backoff ended to the queue
+func (mq *MirrorQueue) pushDelayed(ctx context.Context) {
+	delayedName := mq.QueueName + delayedSuffix
+	members, err := mq.redis.ZRangeByScore(ctx, delayedName, float64(time.Now().Unix()))
+	if err != nil {
+		slog.Error("failed to get delayed mirror tasks", slog.String("queue", mq.QueueName), slog.Any("error", err))
+		return
+	}
+	for _, member := range members {
+		// only the dispatcher removing the task pushes it
+		removed, err := mq.redis.ZRem(ctx, delayedName, member)
+		if err != nil || !removed {
+			continue
+		}
+		var task MirrorTask
+		if err := task.UnmarshalBinary([]byte(member)); err != nil {
+			slog.Error("invalid delayed mirror task", slog.String("task", member), slog.Any("error", err))
+			continue
+		}
+		mq.Push(&task)
+	}
+}
+
+// DeadLetters returns the tasks out of retries
+func (mq *MirrorQueue) DeadLetters(ctx context.Context) ([]MirrorTask, error) {
+	values, err := mq.redis.HGetAll(ctx, mq.QueueName+deadLetterSuffix)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]MirrorTask, 0, len(values))
+	for _, value := range values {
+		var task MirrorTask
+		if err := task.UnmarshalBinary([]byte(value)); err != nil {
+			return nil, fmt.Errorf("invalid dead-lettered mirror task %s: %w", value, err)
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].FailedAt > tasks[j].FailedAt
+	})
+	return tasks, nil
+}
+
+// IsDeadLettered returns if the task of the mirror is in the dead-letter hash
+func (mq *MirrorQueue) IsDeadLettered(ctx context.Context, mirrorID int64) (bool, error) {
+	return mq.redis.HExists(ctx, mq.QueueName+deadLetterSuffix, strconv.FormatInt(mirrorID, 10))
+}
+
+// RetryDeadLetter pushes the dead-lettered task of the mirror back to the queue with a new retry budget
+func (mq *MirrorQueue) RetryDeadLetter(ctx context.Context, mirrorID int64) (*MirrorTask, error) {
+	deadLetterName := mq.QueueName + deadLetterSuffix
+	field := strconv.FormatInt(mirrorID, 10)
+	value, err := mq.redis.HGet(ctx, deadLetterName, field)
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	removed, err := mq.redis.HDel(ctx, deadLetterName, field)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrTaskNotFound
+	}
+	var task MirrorTask
+	if err := task.UnmarshalBinary([]byte(value)); err != nil {
+		return nil, fmt.Errorf("invalid dead-lettered mirror task %s: %w", value, err)
+	}
+	task.Retries = 0
+	task.LastError = ""
+	task.FailedAt = 0
+	mq.Push(&task)
+	return &task, nil
+}
+
+// DropDeadLetter removes the dead-lettered task of the mirror
+func (mq *MirrorQueue) DropDeadLetter(ctx context.Context, mirrorID int64) error {
+	removed, err := mq.redis.HDel(ctx, mq.QueueName+deadLetterSuffix, strconv.FormatInt(mirrorID, 10))
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
 type PriorityQueue struct {
 	RepoMirrorQueue MirrorQueue
 	LfsMirrorQueue  MirrorQueue
@@ -87,6 +512,9 @@ var (
 )
 
 func NewPriorityQueue(ctx context.Context, config *config.Config) (*PriorityQueue, error) {
+	if config.Mirror.QueueAgingInMin <= 0 {
+		return nil, fmt.Errorf("invalid mirror queue aging %d, it must be a positive number of minutes", config.Mirror.QueueAgingInMin)
+	}
 	redis, err := cache.NewCache(ctx, cache.RedisConfig{
 		Addr:     config.Redis.Endpoint,
 		Username: config.Redis.User,
@@ -95,16 +523,24 @@ func NewPriorityQueue(ctx context.Context, config *config.Config) (*PriorityQueu
 	if err != nil {
 		return nil, fmt.Errorf("initializing redis: %w", err)
 	}
+	aging := time.Duration(config.Mirror.QueueAgingInMin) * time.Minute
 	mq := &PriorityQueue{
 		RepoMirrorQueue: MirrorQueue{
 			redis:     redis,
 			QueueName: repoQueueName,
+			aging:     aging,
 		},
 		LfsMirrorQueue: MirrorQueue{
 			redis:     redis,
 			QueueName: lfsQueueName,
+			aging:     aging,
 		},
 	}
+	for _, q := range []*MirrorQueue{&mq.RepoMirrorQueue, &mq.LfsMirrorQueue} {
+		if err := q.moveLegacyTasks(ctx); err != nil {
+			return nil, fmt.Errorf("failed to move legacy tasks of %s: %w", q.QueueName, err)
+		}
+	}
 	return mq, nil
 }
 
@@ -124,6 +560,18 @@ func (pq *PriorityQueue) PopLfsMirror() *MirrorTask {
 	return pq.LfsMirrorQueue.Pop()
 }
 
+// TaskQueue returns the queue of the task type
+func (pq *PriorityQueue) TaskQueue(taskType types.MirrorTaskType) (*MirrorQueue, error) {
+	switch taskType {
+	case types.RepoMirrorTask:
+		return &pq.RepoMirrorQueue, nil
+	case types.LfsMirrorTask:
+		return &pq.LfsMirrorQueue, nil
+	default:
+		return nil, fmt.Errorf("invalid mirror task type %s", taskType)
+	}
+}
+
 func GetPriorityQueueInstance() (*PriorityQueue, error) {
 	once.Do(func() {
 		c, err = config.LoadConfig()
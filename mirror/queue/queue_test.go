@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"opencsg.com/csghub-server/common/config"
+)
+
+func TestRetryDelay(t *testing.T) {
+	testData := []struct {
+		retries int
+		expect  time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		// shifts overflowing the duration are capped too
+		{100, time.Hour},
+	}
+	for _, data := range testData {
+		if delay := retryDelay(data.retries); delay != data.expect {
+			t.Errorf("retry %d: expect delay %s, got %s", data.retries, data.expect, delay)
+		}
+	}
+}
+
+func TestMirrorTaskFail(t *testing.T) {
+	task := &MirrorTask{MirrorID: 1}
+	for i := 1; i <= MaxRetries; i++ {
+		if task.fail(errors.New("connection reset")) {
+			t.Fatalf("expect retry %d to be within the budget", i)
+		}
+	}
+	if task.Retries != MaxRetries || task.LastError != "connection reset" || task.FailedAt == 0 {
+		t.Errorf("expect the failures to be recorded, got %+v", task)
+	}
+	if !task.fail(errors.New("connection reset")) {
+		t.Error("expect a task out of retries to be dead-lettered")
+	}
+}
+
+func TestMirrorTaskFail_nonRetryable(t *testing.T) {
+	testData := map[string]error{
+		"unauthorized":   UpstreamStatusError(http.StatusUnauthorized, errors.New("status code: 401")),
+		"forbidden":      UpstreamStatusError(http.StatusForbidden, errors.New("status code: 403")),
+		"not found":      UpstreamStatusError(http.StatusNotFound, errors.New("status code: 404")),
+		"marked":         fmt.Errorf("failed to sync: %w", NonRetryable(errors.New("authentication failed"))),
+		"deleted mirror": fmt.Errorf("failed to get mirror: %w", sql.ErrNoRows),
+	}
+	for name, err := range testData {
+		task := &MirrorTask{MirrorID: 1}
+		if !task.fail(err) {
+			t.Errorf("%s: expect the task to be dead-lettered at once", name)
+		}
+	}
+
+	for _, code := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway} {
+		task := &MirrorTask{MirrorID: 1}
+		if task.fail(UpstreamStatusError(code, fmt.Errorf("status code: %d", code))) {
+			t.Errorf("status %d: expect the task to be retried", code)
+		}
+	}
+}
+
+func TestSortByPopOrder(t *testing.T) {
+	aging := time.Hour
+	base := float64(time.Date(2024, 10, 2, 10, 0, 0, 0, time.UTC).UnixMilli())
+	minute := float64(time.Minute.Milliseconds())
+	tasks := []queuedTask{
+		// bands are collected from the highest priority, each in its own order
+		{mirrorID: 1, band: HighPriority, enqueuedAt: base + 30*minute},
+		{mirrorID: 2, band: HighPriority, enqueuedAt: base + 50*minute},
+		{mirrorID: 3, band: MediumPriority, enqueuedAt: base + 10*minute},
+		{mirrorID: 4, band: MediumPriority, enqueuedAt: base - 40*minute},
+		{mirrorID: 5, band: LowPriority, enqueuedAt: base - 150*minute},
+		{mirrorID: 6, band: LowPriority, enqueuedAt: base - 90*minute},
+	}
+	sortByPopOrder(tasks, aging)
+	// effective scores in minutes: 1: -90, 2: -70, 3: -50, 4: -100, 5: -150, 6: -90
+	expect := []int64{5, 4, 1, 6, 2, 3}
+	for i, id := range expect {
+		if tasks[i].mirrorID != id {
+			t.Fatalf("expect pop order %v, got %+v", expect, tasks)
+		}
+	}
+}
+
+func TestSortByPopOrder_aging(t *testing.T) {
+	base := float64(time.Date(2024, 10, 2, 10, 0, 0, 0, time.UTC).UnixMilli())
+	hour := float64(time.Hour.Milliseconds())
+	testData := []struct {
+		name string
+		// enqueue time of the low priority task relative to the high priority one
+		lowWaited float64
+		aging     time.Duration
+		lowFirst  bool
+	}{
+		{"fresh low task waits", 0, time.Hour, false},
+		{"low task waited less than two bands", 1.5 * hour, time.Hour, false},
+		{"low task waited two bands ties and loses", 2 * hour, time.Hour, false},
+		{"low task waited more than two bands", 2*hour + 1, time.Hour, true},
+		{"longer aging keeps the low task waiting", 3 * hour, 2 * time.Hour, false},
+	}
+	for _, data := range testData {
+		tasks := []queuedTask{
+			{mirrorID: 1, band: HighPriority, enqueuedAt: base},
+			{mirrorID: 2, band: LowPriority, enqueuedAt: base - data.lowWaited},
+		}
+		sortByPopOrder(tasks, data.aging)
+		if lowFirst := tasks[0].mirrorID == 2; lowFirst != data.lowFirst {
+			t.Errorf("%s: expect low priority task first %v, got %v", data.name, data.lowFirst, lowFirst)
+		}
+	}
+}
+
+func TestBandIndex(t *testing.T) {
+	testData := map[Priority]int{
+		HighPriority:   1,
+		MediumPriority: 2,
+		LowPriority:    3,
+		// out of range priorities go to the closest band
+		Priority(9): 1,
+		Priority(0): 3,
+	}
+	for p, expect := range testData {
+		if i := bandIndex(p); i != expect {
+			t.Errorf("priority %d: expect band index %d, got %d", p, expect, i)
+		}
+	}
+}
+
+func TestLegacyEnqueuedAt(t *testing.T) {
+	task := &MirrorTask{MirrorID: 1, CreatedAt: 1727863200}
+	if at := legacyEnqueuedAt(task); at.Unix() != task.CreatedAt {
+		t.Errorf("expect a legacy task to keep its created time, got %s", at)
+	}
+	if at := legacyEnqueuedAt(&MirrorTask{MirrorID: 1}); time.Since(at) > time.Minute {
+		t.Errorf("expect a legacy task without created time to be enqueued now, got %s", at)
+	}
+}
+
+func TestNewPriorityQueue_invalidAging(t *testing.T) {
+	for _, aging := range []int{0, -1} {
+		cfg := &config.Config{}
+		cfg.Mirror.QueueAgingInMin = aging
+		if _, err := NewPriorityQueue(context.Background(), cfg); err == nil {
+			t.Errorf("aging %d: expect an error", aging)
+		}
+	}
+}
@@ -2,17 +2,20 @@ package reposyncer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"opencsg.com/csghub-server/builder/git"
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mirror/credential"
 	"opencsg.com/csghub-server/mirror/queue"
 )
 
@@ -26,6 +29,7 @@ type LocalMirrorWoker struct {
 	lfsMetaObjectStore *database.LfsMetaObjectStore
 	repoStore          *database.RepoStore
 	git                gitserver.GitServer
+	credentials        *credential.Resolver
 	config             *config.Config
 }
 
@@ -42,6 +46,7 @@ func NewLocalMirrorWoker(config *config.Config, numWorkers int) (*LocalMirrorWok
 	w.mirrorStore = database.NewMirrorStore()
 	w.repoStore = database.NewRepoStore()
 	w.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
+	w.credentials = credential.NewResolver(config)
 	w.saas = config.Saas
 	w.config = config
 	mq, err := queue.GetPriorityQueueInstance()
@@ -88,15 +93,66 @@ func (w *LocalMirrorWoker) worker(id int) {
 		err := w.SyncRepo(context.Background(), task)
 		if err != nil {
 			slog.Info("fail to mirror", slog.Int64("mirrorId", task.MirrorID), slog.Int("priority", task.Priority.Int()), slog.Int("workerId", id), slog.String("error", err.Error()))
+			w.retry(context.Background(), task, err)
 		}
 		slog.Info("finish to mirror", slog.Int64("mirrorId", task.MirrorID), slog.Int("priority", task.Priority.Int()), slog.Int("workerId", id))
 	}
 }
 
+// retry pushes the failed task back to the queue after a backoff, the mirror fails once the task runs out of retries
+func (w *LocalMirrorWoker) retry(ctx context.Context, task queue.MirrorTask, taskErr error) {
+	status := types.MirrorWaiting
+	deadLettered, err := w.mq.RepoMirrorQueue.Retry(ctx, &task, taskErr)
+	if err != nil {
+		slog.Error("fail to retry mirror", slog.Int64("mirrorId", task.MirrorID), slog.String("error", err.Error()))
+		status = types.MirrorFailed
+	}
+	if deadLettered {
+		slog.Warn("mirror task is dead-lettered", slog.Int64("mirrorId", task.MirrorID), slog.Int("retries", task.Retries))
+		status = types.MirrorFailed
+	}
+	mirror, err := w.mirrorStore.FindByID(ctx, task.MirrorID)
+	if err != nil {
+		slog.Error("fail to get mirror", slog.Int64("mirrorId", task.MirrorID), slog.String("error", err.Error()))
+		return
+	}
+	mirror.Status = status
+	mirror.LastMessage = taskErr.Error()
+	err = w.mirrorStore.Update(ctx, mirror)
+	if err != nil {
+		slog.Error("fail to update mirror status", slog.Int64("mirrorId", task.MirrorID), slog.String("error", err.Error()))
+	}
+}
+
+// upstreamFetchFailures are the messages of git fetches the upstream rejected for the credential or
+// the repository, a retry fails the same way
+var upstreamFetchFailures = []string{
+	"authentication failed",
+	"could not read username",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+	"the requested url returned error: 404",
+	"repository not found",
+}
+
+// fetchError marks the error of a fetch as not retryable if the upstream rejected it
+func fetchError(err error) error {
+	msg := strings.ToLower(err.Error())
+	for _, failure := range upstreamFetchFailures {
+		if strings.Contains(msg, failure) {
+			return queue.NonRetryable(err)
+		}
+	}
+	if strings.Contains(msg, "repository '") && strings.Contains(msg, "' not found") {
+		return queue.NonRetryable(err)
+	}
+	return err
+}
+
 func (w *LocalMirrorWoker) SyncRepo(ctx context.Context, task queue.MirrorTask) error {
 	mirror, err := w.mirrorStore.FindByID(ctx, task.MirrorID)
 	if err != nil {
-		return fmt.Errorf("failed to get mirror: %v", err)
+		return fmt.Errorf("failed to get mirror: %w", err)
 	}
 	mirror.Status = types.MirrorRunning
 	mirror.Priority = types.LowMirrorPriority
@@ -110,13 +166,21 @@ func (w *LocalMirrorWoker) SyncRepo(ctx context.Context, task queue.MirrorTask)
 	namespace := strings.Split(mirror.Repository.Path, "/")[0]
 	name := strings.Split(mirror.Repository.Path, "/")[1]
 
+	username, accessToken, err := w.credentials.Resolve(ctx, mirror, mirror.SourceUrl)
+	if errors.Is(err, credential.ErrURLNotAllowed) {
+		return queue.NonRetryable(fmt.Errorf("failed to resolve mirror credential: %w", err))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve mirror credential: %w", err)
+	}
+
 	slog.Info("Start to sync mirror repo", "repo_type", mirror.Repository.RepositoryType, "namespace", namespace, "name", name)
 	req := gitserver.MirrorSyncReq{
 		Namespace:   namespace,
 		Name:        name,
 		CloneUrl:    mirror.SourceUrl,
-		Username:    mirror.Username,
-		AccessToken: mirror.AccessToken,
+		Username:    username,
+		AccessToken: accessToken,
 		RepoType:    mirror.Repository.RepositoryType,
 	}
 	if task.MirrorToken != "" {
@@ -125,7 +189,7 @@ func (w *LocalMirrorWoker) SyncRepo(ctx context.Context, task queue.MirrorTask)
 	err = w.git.MirrorSync(ctx, req)
 
 	if err != nil {
-		return fmt.Errorf("failed mirror remote repo in git server: %v", err)
+		return fetchError(fmt.Errorf("failed mirror remote repo in git server: %v", err))
 	}
 	slog.Info("Mirror remote repo in git server successfully", "repo_type", mirror.Repository.RepositoryType, "namespace", namespace, "name", name)
 
@@ -158,6 +222,7 @@ func (w *LocalMirrorWoker) SyncRepo(ctx context.Context, task queue.MirrorTask)
 		return fmt.Errorf("failed to sync lfs files: %v", err)
 	}
 	mirror.Status = types.MirrorRepoSynced
+	mirror.LastUpdatedAt = time.Now()
 	err = w.mirrorStore.Update(ctx, mirror)
 	if err != nil {
 		return fmt.Errorf("failed to update mirror: %w", err)
@@ -172,8 +237,13 @@ func (w *LocalMirrorWoker) SyncRepo(ctx context.Context, task queue.MirrorTask)
 	return nil
 }
 
+// generateLfsMetaObjects records the LFS objects of the mirror, only the pointers introduced since the commits synced last time
+// are scanned if there are any
 func (c *LocalMirrorWoker) generateLfsMetaObjects(ctx context.Context, mirror *database.Mirror) error {
-	var lfsMetaObjects []database.LfsMetaObject
+	var (
+		lfsMetaObjects []database.LfsMetaObject
+		lfsPointers    []*types.LFSPointer
+	)
 	namespace := strings.Split(mirror.Repository.Path, "/")[0]
 	name := strings.Split(mirror.Repository.Path, "/")[1]
 	branches, err := c.git.GetRepoBranches(ctx, gitserver.GetBranchesReq{
@@ -184,30 +254,68 @@ func (c *LocalMirrorWoker) generateLfsMetaObjects(ctx context.Context, mirror *d
 	if err != nil {
 		return fmt.Errorf("failed to get repo branches: %v", err)
 	}
+	syncedCommits := make(map[string]string, len(branches))
 	for _, branch := range branches {
-		lfsPointers, err := c.getAllLfsPointersByRef(ctx, mirror.Repository.RepositoryType, namespace, name, branch.Name)
+		syncedCommits[branch.Name] = branch.Commit.ID
+	}
+
+	if len(mirror.SyncedCommits) > 0 {
+		lfsPointers, err = c.getNewLfsPointers(ctx, mirror, namespace, name, syncedCommits)
 		if err != nil {
-			return fmt.Errorf("failed to get all lfs pointers: %v", err)
+			// the commits synced last time may be gone after a force push
+			slog.Warn("failed to get new lfs pointers, fallback to scan all lfs pointers", slog.Int64("mirrorId", mirror.ID), slog.String("error", err.Error()))
 		}
-		for _, lfsPointer := range lfsPointers {
-			lfsMetaObjects = append(lfsMetaObjects, database.LfsMetaObject{
-				Size:         lfsPointer.FileSize,
-				Oid:          lfsPointer.FileOid,
-				RepositoryID: mirror.Repository.ID,
-				Existing:     true,
-			})
+	}
+	if len(mirror.SyncedCommits) == 0 || err != nil {
+		for _, branch := range branches {
+			branchPointers, err := c.getAllLfsPointersByRef(ctx, mirror.Repository.RepositoryType, namespace, name, branch.Name)
+			if err != nil {
+				return fmt.Errorf("failed to get all lfs pointers: %v", err)
+			}
+			lfsPointers = append(lfsPointers, branchPointers...)
 		}
 	}
+	for _, lfsPointer := range lfsPointers {
+		lfsMetaObjects = append(lfsMetaObjects, database.LfsMetaObject{
+			Size:         lfsPointer.FileSize,
+			Oid:          lfsPointer.FileOid,
+			RepositoryID: mirror.Repository.ID,
+			Existing:     true,
+		})
+	}
 	lfsMetaObjects = removeDuplicateLfsMetaObject(lfsMetaObjects)
 
-	err = c.lfsMetaObjectStore.BulkUpdateOrCreate(ctx, lfsMetaObjects)
-	if err != nil {
-		return fmt.Errorf("failed to bulk update or create lfs meta objects: %v", err)
+	if len(lfsMetaObjects) > 0 {
+		err = c.lfsMetaObjectStore.BulkUpdateOrCreate(ctx, lfsMetaObjects)
+		if err != nil {
+			return fmt.Errorf("failed to bulk update or create lfs meta objects: %v", err)
+		}
 	}
+	mirror.SyncedCommits = syncedCommits
 
 	return nil
 }
 
+// getNewLfsPointers returns the LFS pointers reachable from the branches but not from the commits synced last time
+func (c *LocalMirrorWoker) getNewLfsPointers(ctx context.Context, mirror *database.Mirror, namespace, name string, commits map[string]string) ([]*types.LFSPointer, error) {
+	var revisions []string
+	for _, commit := range commits {
+		revisions = append(revisions, commit)
+	}
+	if len(revisions) == 0 {
+		return nil, nil
+	}
+	for _, commit := range mirror.SyncedCommits {
+		revisions = append(revisions, "^"+commit)
+	}
+	return c.git.GetRepoLfsPointersByRevisions(ctx, gitserver.GetRepoLfsPointersByRevisionsReq{
+		Namespace: namespace,
+		Name:      name,
+		RepoType:  mirror.Repository.RepositoryType,
+		Revisions: revisions,
+	})
+}
+
 func (c *LocalMirrorWoker) getAllLfsPointersByRef(ctx context.Context, RepoType types.RepositoryType, namespace, name, ref string) ([]*types.LFSPointer, error) {
 	return c.git.GetRepoAllLfsPointers(ctx, gitserver.GetRepoAllFilesReq{
 		Namespace: namespace,
@@ -5,6 +5,7 @@ import (
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
+	"opencsg.com/csghub-server/common/types"
 )
 
 type MessageQueue interface {
@@ -20,4 +21,8 @@ type MessageQueue interface {
 	PublishData(subject string, data []byte) error
 	PublishMeterDataToDLQ(data []byte) error
 	PublishMeterDurationData(data []byte) error
+	PublishMeterTokenData(data []byte) error
+	ListDLQMessages(ctx context.Context, fromSeq uint64, limit int) ([]types.ACCT_DLQ_MESSAGE, error)
+	GetDLQMessage(ctx context.Context, seq uint64) (*types.ACCT_DLQ_MESSAGE, error)
+	DeleteDLQMessage(ctx context.Context, seq uint64) error
 }
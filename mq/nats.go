@@ -8,6 +8,7 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
 )
 
 var _ MessageQueue = (*NatsHandler)(nil)
@@ -200,3 +201,57 @@ func (nh *NatsHandler) PublishMeterDataToDLQ(data []byte) error {
 func (nh *NatsHandler) PublishMeterDurationData(data []byte) error {
 	return nh.PublishData(nh.meterReqSub.duration, data)
 }
+
+func (nh *NatsHandler) PublishMeterTokenData(data []byte) error {
+	return nh.PublishData(nh.meterReqSub.token, data)
+}
+
+// ListDLQMessages returns at most limit messages parked in DLQ, starting at the sequence
+func (nh *NatsHandler) ListDLQMessages(ctx context.Context, fromSeq uint64, limit int) ([]types.ACCT_DLQ_MESSAGE, error) {
+	stream, err := nh.js.Stream(ctx, dlqCfg.StreamName)
+	if err != nil {
+		return nil, err
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []types.ACCT_DLQ_MESSAGE
+	for seq := max(fromSeq, info.State.FirstSeq); seq <= info.State.LastSeq && len(msgs) < limit; seq++ {
+		msg, err := nh.GetDLQMessage(ctx, seq)
+		// replayed messages are deleted from the stream
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, *msg)
+	}
+	return msgs, nil
+}
+
+func (nh *NatsHandler) GetDLQMessage(ctx context.Context, seq uint64) (*types.ACCT_DLQ_MESSAGE, error) {
+	stream, err := nh.js.Stream(ctx, dlqCfg.StreamName)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := stream.GetMsg(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ACCT_DLQ_MESSAGE{
+		Sequence: msg.Sequence,
+		Subject:  msg.Subject,
+		Data:     string(msg.Data),
+		Time:     msg.Time,
+	}, nil
+}
+
+func (nh *NatsHandler) DeleteDLQMessage(ctx context.Context, seq uint64) error {
+	stream, err := nh.js.Stream(ctx, dlqCfg.StreamName)
+	if err != nil {
+		return err
+	}
+	return stream.DeleteMsg(ctx, seq)
+}
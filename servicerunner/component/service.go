@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log/slog"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -55,6 +57,10 @@ func (s *ServiceComponent) GenerateService(ctx context.Context, cluster cluster.
 	if request.Env != nil {
 		// generate env
 		for key, value := range request.Env {
+			// secrets take the place of plain env variables with the same name
+			if _, ok := request.Secrets[key]; ok {
+				continue
+			}
 			environments = append(environments, corev1.EnvVar{Name: key, Value: value})
 		}
 
@@ -75,6 +81,24 @@ func (s *ServiceComponent) GenerateService(ctx context.Context, cluster cluster.
 		environments = append(environments, corev1.EnvVar{Name: "NVIDIA_VISIBLE_DEVICES", Value: "none"})
 	}
 
+	// secrets are referenced from the k8s secret created by ApplySecret, so their values never appear in the service spec
+	secretKeys := make([]string, 0, len(request.Secrets))
+	for key := range request.Secrets {
+		secretKeys = append(secretKeys, key)
+	}
+	sort.Strings(secretKeys)
+	for _, key := range secretKeys {
+		environments = append(environments, corev1.EnvVar{
+			Name: key,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: SecretName(srvName)},
+					Key:                  key,
+				},
+			},
+		})
+	}
+
 	if appPort == 0 {
 		return nil, fmt.Errorf("app export port is not defined")
 	}
@@ -109,11 +133,7 @@ func (s *ServiceComponent) GenerateService(ctx context.Context, cluster cluster.
 	templateAnnotations := make(map[string]string)
 	if request.RepoType == string(types.ModelRepo) {
 		// auto scaling
-		templateAnnotations["autoscaling.knative.dev/class"] = "kpa.autoscaling.knative.dev"
-		templateAnnotations["enable-scale-to-zero"] = "false"
-		templateAnnotations["autoscaling.knative.dev/metric"] = "concurrency"
-		templateAnnotations["autoscaling.knative.dev/target"] = "5"
-		templateAnnotations["autoscaling.knative.dev/target-utilization-percentage"] = "90"
+		s.setAutoscalingAnnotations(templateAnnotations, request.Autoscaling)
 		templateAnnotations["autoscaling.knative.dev/min-scale"] = strconv.Itoa(request.MinReplica)
 		templateAnnotations["autoscaling.knative.dev/max-scale"] = strconv.Itoa(request.MaxReplica)
 		templateAnnotations["serving.knative.dev/progress-deadline"] = fmt.Sprintf("%dm", s.env.Model.DeployTimeoutInMin)
@@ -184,6 +204,47 @@ func (s *ServiceComponent) GenerateService(ctx context.Context, cluster cluster.
 	return service, nil
 }
 
+// SecretName is the name of the k8s secret holding the repo and namespace secrets of a service
+func SecretName(srvName string) string {
+	return srvName + "-secrets"
+}
+
+// ApplySecret creates or updates the k8s secret of the service, or deletes it when there are no secrets
+func (s *ServiceComponent) ApplySecret(ctx context.Context, cluster cluster.Cluster, srvName string, data map[string]string) error {
+	secrets := cluster.Client.CoreV1().Secrets(s.k8sNameSpace)
+	if len(data) == 0 {
+		return s.DeleteSecret(ctx, cluster, srvName)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName(srvName),
+			Namespace: s.k8sNameSpace,
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: data,
+	}
+	existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	// update replaces all the keys, removed secrets are dropped
+	secret.ResourceVersion = existing.ResourceVersion
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+func (s *ServiceComponent) DeleteSecret(ctx context.Context, cluster cluster.Cluster, srvName string) error {
+	err := cluster.Client.CoreV1().Secrets(s.k8sNameSpace).Delete(ctx, SecretName(srvName), metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 // get secret from k8s
 // notes: admin should create nim secret "ngc-secret" and "nvidia-nim-secrets" in related namespace before deploy
 func (s *ServiceComponent) GetNimSecret(ctx context.Context, cluster cluster.Cluster) (string, error) {
@@ -287,3 +348,35 @@ func (s *ServiceComponent) NewPersistentVolumeClaim(name string, ctx context.Con
 	_, err = cluster.Client.CoreV1().PersistentVolumeClaims(s.k8sNameSpace).Create(ctx, &pvc, metav1.CreateOptions{})
 	return err
 }
+
+// setAutoscalingAnnotations renders the autoscaling policy of the deploy into revision annotations,
+// the policy is validated when the deploy is created or updated. Scaling to zero is decided by the
+// min-scale annotation of the min replica
+func (s *ServiceComponent) setAutoscalingAnnotations(annotations map[string]string, policy *types.AutoscalingPolicy) {
+	if policy == nil {
+		annotations["autoscaling.knative.dev/class"] = "kpa.autoscaling.knative.dev"
+		annotations["autoscaling.knative.dev/metric"] = "concurrency"
+		annotations["autoscaling.knative.dev/target"] = "5"
+		annotations["autoscaling.knative.dev/target-utilization-percentage"] = "90"
+		return
+	}
+
+	if policy.Metric == types.AutoscalingMetricGPU {
+		// KPA only scales on requests, gpu utilization comes from a custom metric of HPA
+		annotations["autoscaling.knative.dev/class"] = "hpa.autoscaling.knative.dev"
+		annotations["autoscaling.knative.dev/metric"] = s.env.Model.GPUUtilizationMetric
+		annotations["autoscaling.knative.dev/target"] = strconv.Itoa(policy.Target)
+		return
+	}
+
+	annotations["autoscaling.knative.dev/class"] = "kpa.autoscaling.knative.dev"
+	annotations["autoscaling.knative.dev/metric"] = policy.Metric
+	annotations["autoscaling.knative.dev/target"] = strconv.Itoa(policy.Target)
+	annotations["autoscaling.knative.dev/target-utilization-percentage"] = "90"
+	if policy.ScaleDownDelay > 0 {
+		annotations["autoscaling.knative.dev/scale-down-delay"] = fmt.Sprintf("%ds", policy.ScaleDownDelay)
+	}
+	if policy.ScaleToZeroWindow > 0 {
+		annotations["autoscaling.knative.dev/scale-to-zero-pod-retention-period"] = fmt.Sprintf("%ds", policy.ScaleToZeroWindow)
+	}
+}
@@ -38,6 +38,7 @@ func NewK8sHander(config *config.Config) (*K8sHander, error) {
 		slog.Error("falied to build kubeconfig", "error", err)
 		return nil, fmt.Errorf("failed to build kubeconfig,%w", err)
 	}
+	clusterPool.Placement = cluster.NewPlacementPolicy(config.Space.PlacementPolicy)
 	domainParts := strings.SplitN(config.Space.InternalRootDomain, ".", 2)
 	serviceComponent := component.NewServiceComponent(config, domainParts[0])
 	return &K8sHander{
@@ -59,6 +60,22 @@ func (s *K8sHander) RunService(c *gin.Context) {
 	}
 	slog.Debug("Recv request", slog.Any("body", request))
 
+	var placement *cluster.PlacementDecision
+	if request.ClusterID == "" {
+		placement, err = s.clusterPool.PlaceService(c, s.env, cluster.PlacementRequest{
+			Hardware:   request.Hardware,
+			Zone:       s.env.Space.PlacementZone,
+			NodeLabels: s.env.Space.PlacementNodeLabels,
+		})
+		if err != nil {
+			slog.Error("fail to place service to a cluster", slog.Any("error", err), slog.Any("req", request))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Info("placed service to cluster", slog.Int64("deploy_id", request.DeployID), slog.String("cluster_id", placement.ClusterID), slog.String("reason", placement.Reason))
+		request.ClusterID = placement.ClusterID
+	}
+
 	cluster, err := s.clusterPool.GetClusterByID(c, request.ClusterID)
 	if err != nil {
 		slog.Error("fail to get cluster ", slog.Any("error", err), slog.Any("req", request))
@@ -79,6 +96,13 @@ func (s *K8sHander) RunService(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// the service references its secrets by name, so they must exist before it starts
+	err = s.s.ApplySecret(c, *cluster, srvName, request.Secrets)
+	if err != nil {
+		slog.Error("fail to apply service secrets", slog.Any("error", err), slog.Int64("deploy_id", request.DeployID), slog.String("srv_name", srvName))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply service secrets"})
+		return
+	}
 	volumes := []corev1.Volume{}
 	volumeMounts := []corev1.VolumeMount{}
 	if request.DeployType != types.SpaceType {
@@ -141,7 +165,12 @@ func (s *K8sHander) RunService(c *gin.Context) {
 	}
 
 	slog.Info("service created successfully", slog.String("srv_name", srvName), slog.Int64("deploy_id", request.DeployID))
-	c.JSON(http.StatusOK, gin.H{"message": "Service created successfully"})
+	resp := types.RunResponse{DeployID: request.DeployID, Message: "Service created successfully"}
+	if placement != nil {
+		resp.ClusterID = placement.ClusterID
+		resp.PlacementReason = placement.Reason
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (s *K8sHander) StopService(c *gin.Context) {
@@ -863,7 +892,15 @@ func (s *K8sHander) PurgeService(c *gin.Context) {
 		}
 	}
 
-	// 2 clean up pvc
+	// 2 clean up secrets
+	err = s.s.DeleteSecret(c, *cluster, srvName)
+	if err != nil {
+		slog.Error("fail to delete service secrets", slog.String("srv_name", srvName), slog.Any("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fail to delete service secrets"})
+		return
+	}
+
+	// 3 clean up pvc
 	if cluster.StorageClass != "" && request.DeployType == types.FinetuneType {
 		err = cluster.Client.CoreV1().PersistentVolumeClaims(s.k8sNameSpace).Delete(c, srvName, metav1.DeleteOptions{})
 		if err != nil {